@@ -1,9 +1,11 @@
 package app
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/containerd/containerd"
 	"github.com/mokrz/clamor/log"
@@ -33,20 +35,88 @@ func Execute() {
 		}
 	}
 
-	ctr, ctrErr := containerd.New("/run/containerd/containerd.sock")
+	var backend node.Backend
 
-	if ctrErr != nil {
-		fmt.Printf("containerd.New failed with error: %s\n", ctrErr.Error())
+	switch cfg.Backend {
+	case node.MemoryBackendName:
+		var remotes []node.RemoteImage
+
+		for _, ref := range cfg.MemoryImages {
+			remotes = append(remotes, node.RemoteImage{Ref: ref})
+		}
+
+		backend = node.NewMemoryBackend(remotes...)
+	case node.ContainerdBackendName, "":
+		ctr, ctrErr := containerd.New("/run/containerd/containerd.sock")
+
+		if ctrErr != nil {
+			fmt.Printf("containerd.New failed with error: %s\n", ctrErr.Error())
+			return
+		}
+
+		defer ctr.Close()
+
+		root := cfg.ContainerdRoot
+
+		if root == "" {
+			root = node.DefaultContainerdRoot
+		}
+
+		backend = node.NewContainerdBackend(ctr, root, cfg.Registries)
+	default:
+		fmt.Printf("unknown backend %s\n", cfg.Backend)
 		return
 	}
 
-	defer ctr.Close()
-
 	logger, _ := zap.NewProduction()
 	defer logger.Sync()
 
-	nodeSvc := node.NewNode(ctr)
+	logDir := cfg.LogDir
+
+	if logDir == "" {
+		logDir = node.DefaultLogDir
+	}
+
+	if policyErr := cfg.ImageGC.Validate(); policyErr != nil {
+		fmt.Printf("invalid image gc policy: %s\n", policyErr.Error())
+		return
+	}
+
+	nodeOpts := []node.NodeOpt{node.WithImageGC(cfg.ImageGC)}
+
+	if cfg.ReconcileInterval > 0 {
+		nodeOpts = append(nodeOpts, node.WithReconcileInterval(time.Duration(cfg.ReconcileInterval)))
+	}
+
+	if cfg.SandboxImage != "" {
+		nodeOpts = append(nodeOpts, node.WithSandboxImage(cfg.SandboxImage))
+	}
+
+	nodeSvc := node.NewNode(backend, node.NewLogStore(logDir), nodeOpts...)
 	nodeSvc = log.NewLoggingNode(logger, nodeSvc)
+	defer nodeSvc.Close()
+
+	for _, path := range cfg.Manifests {
+		manifest, manifestErr := node.LoadManifest(path)
+
+		if manifestErr != nil {
+			fmt.Printf("node.LoadManifest failed with error: %s\n", manifestErr.Error())
+			return
+		}
+
+		if _, applyErr := node.ApplyManifest(nodeSvc, manifest); applyErr != nil {
+			fmt.Printf("node.ApplyManifest failed with error: %s\n", applyErr.Error())
+			return
+		}
+	}
+
+	if cfg.ManifestDir != "" {
+		watcher := node.NewManifestWatcher(nodeSvc, cfg.ManifestDir, time.Duration(cfg.ManifestPollInterval))
+
+		go watcher.Run(context.Background(), func(err error) {
+			logger.Error("manifest sync failed", zap.String("error", err.Error()))
+		})
+	}
 
 	resolverSet := api.NewResolverSet(nodeSvc)
 	resolverSet = log.NewLoggingResolverSet(logger, resolverSet)
@@ -58,7 +128,7 @@ func Execute() {
 		return
 	}
 
-	apiServer := node_api.NewServer(gqlSchema, cfg.APIHost+":"+strconv.Itoa(cfg.APIPort))
+	apiServer := node_api.NewServer(gqlSchema, nodeSvc, cfg.APIHost+":"+strconv.Itoa(cfg.APIPort))
 
 	if serveErr := apiServer.Serve(); serveErr != nil {
 		fmt.Printf("node.Serve() failed with error: %s\n", serveErr.Error())
@@ -6,18 +6,23 @@ replace github.com/docker/distribution v2.7.1+incompatible => github.com/docker/
 
 require (
 	github.com/Microsoft/hcsshim v0.8.9 // indirect
+	github.com/containerd/cgroups v1.0.1
 	github.com/containerd/containerd v1.3.2
-	github.com/docker/distribution v2.7.1+incompatible // indirect
+	github.com/containerd/typeurl v0.0.0-20180627222232-a93fcdb778cd
+	github.com/docker/distribution v2.7.1+incompatible
 	github.com/docker/go-events v0.0.0-20190806004212-e31b211e4f1c // indirect
 	github.com/gogo/googleapis v1.4.0 // indirect
+	github.com/gogo/protobuf v1.3.2
+	github.com/gorilla/websocket v1.4.2
 	github.com/graphql-go/graphql v0.7.9
 	github.com/imdario/mergo v0.3.9 // indirect
-	github.com/opencontainers/go-digest v1.0.0 // indirect
-	github.com/opencontainers/image-spec v1.0.1 // indirect
+	github.com/opencontainers/go-digest v1.0.0
+	github.com/opencontainers/image-spec v1.0.1
 	github.com/opencontainers/runc v0.1.1 // indirect
-	github.com/sirupsen/logrus v1.6.0 // indirect
+	github.com/opencontainers/runtime-spec v1.0.2
 	github.com/syndtr/gocapability v0.0.0-20180916011248-d98352740cb2 // indirect
 	go.etcd.io/bbolt v1.3.5 // indirect
 	go.uber.org/zap v1.15.0
+	golang.org/x/sys v0.0.0-20210124154548-22da62e12c0c
 	google.golang.org/grpc v1.29.1 // indirect
 )
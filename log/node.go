@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 	"errors"
+	"io"
 
 	"github.com/containerd/containerd/namespaces"
 	"github.com/mokrz/clamor/node"
@@ -37,16 +38,16 @@ func baseFields(ctx context.Context) []zap.Field {
 	return logFields
 }
 
-func (ln *loggingNode) PullImage(ctx context.Context, name string) (image node.Image, err error) {
+func (ln *loggingNode) PullImage(ctx context.Context, name string, platforms ...string) (image node.Image, err error) {
 	var dne node.ErrNotFound
 	logFields := baseFields(ctx)
-	logFields = append(logFields, zap.String("image", name))
+	logFields = append(logFields, zap.String("image", name), zap.Strings("platforms", platforms))
 	msg := "PullImage"
 
 	defer func(took time.Time) {
 		logFields = append(logFields, zap.String("took", time.Since(took).String()))
 
-		if image, err = ln.next.PullImage(ctx, name); err == nil {
+		if image, err = ln.next.PullImage(ctx, name, platforms...); err == nil {
 			ln.logger.Info(msg, logFields...)
 		} else if errors.As(err, &dne) {
 			logFields = append(logFields, zap.String("error", dne.Error()))
@@ -124,16 +125,292 @@ func (ln *loggingNode) DeleteImage(ctx context.Context, name string) (err error)
 	return err
 }
 
-func (ln *loggingNode) CreateContainer(ctx context.Context, imageName string, id string) (container node.Container, err error) {
+func (ln *loggingNode) TagImage(ctx context.Context, source, target string) (image node.Image, err error) {
+	var dne node.ErrNotFound
+	logFields := baseFields(ctx)
+	logFields = append(logFields, zap.String("image", source), zap.String("target", target))
+	msg := "TagImage"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if image, err = ln.next.TagImage(ctx, source, target); err == nil {
+			ln.logger.Info(msg, logFields...)
+		} else if errors.As(err, &dne) {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Warn(msg, logFields...)
+		} else {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		}
+
+	}(time.Now())
+
+	return image, err
+}
+
+func (ln *loggingNode) UntagImage(ctx context.Context, name string) (err error) {
+	var dne node.ErrNotFound
+	logFields := baseFields(ctx)
+	logFields = append(logFields, zap.String("image", name))
+	msg := "UntagImage"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if err = ln.next.UntagImage(ctx, name); err == nil {
+			ln.logger.Info(msg, logFields...)
+		} else if errors.As(err, &dne) {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Warn(msg, logFields...)
+		} else {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		}
+
+	}(time.Now())
+
+	return err
+}
+
+func (ln *loggingNode) ImportImages(ctx context.Context, r io.Reader) (images []node.Image, err error) {
+	logFields := baseFields(ctx)
+	msg := "ImportImages"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if images, err = ln.next.ImportImages(ctx, r); err != nil {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		} else {
+			logFields = append(logFields, zap.Int("images", len(images)))
+			ln.logger.Info(msg, logFields...)
+		}
+	}(time.Now())
+
+	return images, err
+}
+
+func (ln *loggingNode) ExportImages(ctx context.Context, w io.Writer, names ...string) (err error) {
+	var dne node.ErrNotFound
+	logFields := baseFields(ctx)
+	logFields = append(logFields, zap.Strings("images", names))
+	msg := "ExportImages"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if err = ln.next.ExportImages(ctx, w, names...); err == nil {
+			ln.logger.Info(msg, logFields...)
+		} else if errors.As(err, &dne) {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Warn(msg, logFields...)
+		} else {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		}
+	}(time.Now())
+
+	return err
+}
+
+func (ln *loggingNode) PruneImages(ctx context.Context, dryRun bool) (report node.ImagePruneReport, err error) {
+	logFields := baseFields(ctx)
+	logFields = append(logFields, zap.Bool("dry_run", dryRun))
+	msg := "PruneImages"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if report, err = ln.next.PruneImages(ctx, dryRun); err != nil {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		} else {
+			logFields = append(logFields, zap.Int("images", len(report.Images)), zap.Int64("freed", report.Freed))
+			ln.logger.Info(msg, logFields...)
+		}
+	}(time.Now())
+
+	return report, err
+}
+
+func (ln *loggingNode) GetPullJob(ctx context.Context, ref string) (job node.PullJob, err error) {
+	var dne node.ErrNotFound
+	logFields := baseFields(ctx)
+	logFields = append(logFields, zap.String("image", ref))
+	msg := "GetPullJob"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if job, err = ln.next.GetPullJob(ctx, ref); err == nil {
+			ln.logger.Info(msg, logFields...)
+		} else if errors.As(err, &dne) {
+			logFields = append(logFields, zap.String("error", dne.Error()))
+			ln.logger.Warn(msg, logFields...)
+		} else {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		}
+	}(time.Now())
+
+	return job, err
+}
+
+func (ln *loggingNode) GetPullJobs(ctx context.Context) (jobs []node.PullJob, err error) {
+	logFields := baseFields(ctx)
+	msg := "GetPullJobs"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if jobs, err = ln.next.GetPullJobs(ctx); err != nil {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		} else {
+			ln.logger.Info(msg, logFields...)
+		}
+	}(time.Now())
+
+	return jobs, err
+}
+
+func (ln *loggingNode) FollowPullJob(ctx context.Context, ref string) (jobs <-chan node.PullJob, err error) {
+	logFields := baseFields(ctx)
+	logFields = append(logFields, zap.String("image", ref))
+	msg := "FollowPullJob"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if jobs, err = ln.next.FollowPullJob(ctx, ref); err != nil {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		} else {
+			ln.logger.Info(msg, logFields...)
+		}
+	}(time.Now())
+
+	return jobs, err
+}
+
+func (ln *loggingNode) PullImageAsync(ctx context.Context, ref string, platforms ...string) (op node.Operation, err error) {
+	logFields := baseFields(ctx)
+	logFields = append(logFields, zap.String("image", ref), zap.Strings("platforms", platforms))
+	msg := "PullImageAsync"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if op, err = ln.next.PullImageAsync(ctx, ref, platforms...); err != nil {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		} else {
+			logFields = append(logFields, zap.String("operation", op.ID))
+			ln.logger.Info(msg, logFields...)
+		}
+	}(time.Now())
+
+	return op, err
+}
+
+func (ln *loggingNode) KillTaskAsync(ctx context.Context, containerID, signal string, timeout time.Duration) (op node.Operation, err error) {
+	logFields := baseFields(ctx)
+	logFields = append(logFields, zap.String("container_id", containerID), zap.String("signal", signal), zap.Duration("timeout", timeout))
+	msg := "KillTaskAsync"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if op, err = ln.next.KillTaskAsync(ctx, containerID, signal, timeout); err != nil {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		} else {
+			logFields = append(logFields, zap.String("operation", op.ID))
+			ln.logger.Info(msg, logFields...)
+		}
+	}(time.Now())
+
+	return op, err
+}
+
+func (ln *loggingNode) GetOperation(ctx context.Context, id string) (op node.Operation, err error) {
+	var dne node.ErrNotFound
+	logFields := baseFields(ctx)
+	logFields = append(logFields, zap.String("operation", id))
+	msg := "GetOperation"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if op, err = ln.next.GetOperation(ctx, id); err == nil {
+			ln.logger.Info(msg, logFields...)
+		} else if errors.As(err, &dne) {
+			logFields = append(logFields, zap.String("error", dne.Error()))
+			ln.logger.Warn(msg, logFields...)
+		} else {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		}
+	}(time.Now())
+
+	return op, err
+}
+
+func (ln *loggingNode) GetOperations(ctx context.Context) (ops []node.Operation, err error) {
+	logFields := baseFields(ctx)
+	msg := "GetOperations"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if ops, err = ln.next.GetOperations(ctx); err != nil {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		} else {
+			ln.logger.Info(msg, logFields...)
+		}
+	}(time.Now())
+
+	return ops, err
+}
+
+func (ln *loggingNode) CancelOperation(ctx context.Context, id string) (op node.Operation, err error) {
+	var dne node.ErrNotFound
+	logFields := baseFields(ctx)
+	logFields = append(logFields, zap.String("operation", id))
+	msg := "CancelOperation"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if op, err = ln.next.CancelOperation(ctx, id); err == nil {
+			ln.logger.Info(msg, logFields...)
+		} else if errors.As(err, &dne) {
+			logFields = append(logFields, zap.String("error", dne.Error()))
+			ln.logger.Warn(msg, logFields...)
+		} else {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		}
+	}(time.Now())
+
+	return op, err
+}
+
+func (ln *loggingNode) CreateContainer(ctx context.Context, imageName string, id string, spec node.ContainerSpec) (container node.Container, err error) {
 	logFields := baseFields(ctx)
 	logFields = append(logFields, zap.String("image", imageName))
 	logFields = append(logFields, zap.String("id", id))
+	logFields = append(logFields, zap.Strings("command", spec.Command))
+	logFields = append(logFields, zap.Strings("args", spec.Args))
 	msg := "CreateContainer"
 
 	defer func(took time.Time) {
 		logFields = append(logFields, zap.String("took", time.Since(took).String()))
 
-		if container, err = ln.next.CreateContainer(ctx, imageName, id); err != nil {
+		if container, err = ln.next.CreateContainer(ctx, imageName, id, spec); err != nil {
 			logFields = append(logFields, zap.String("error", err.Error()))
 			ln.logger.Error(msg, logFields...)
 		} else {
@@ -206,6 +483,49 @@ func (ln *loggingNode) DeleteContainer(ctx context.Context, id string) (err erro
 	return err
 }
 
+func (ln *loggingNode) UpdateContainerResources(ctx context.Context, id string, resources node.Resources) (container node.Container, err error) {
+	logFields := baseFields(ctx)
+	logFields = append(logFields, zap.String("id", id))
+	logFields = append(logFields, zap.Any("resources", resources))
+	msg := "UpdateContainerResources"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if container, err = ln.next.UpdateContainerResources(ctx, id, resources); err != nil {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		} else {
+			ln.logger.Info(msg, logFields...)
+		}
+	}(time.Now())
+
+	return container, err
+}
+
+func (ln *loggingNode) GetContainerHealth(ctx context.Context, id string) (health node.Health, err error) {
+	var dne node.ErrNotFound
+	logFields := baseFields(ctx)
+	logFields = append(logFields, zap.String("id", id))
+	msg := "GetContainerHealth"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if health, err = ln.next.GetContainerHealth(ctx, id); err == nil {
+			ln.logger.Info(msg, logFields...)
+		} else if errors.As(err, &dne) {
+			logFields = append(logFields, zap.String("error", dne.Error()))
+			ln.logger.Warn(msg, logFields...)
+		} else {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		}
+	}(time.Now())
+
+	return health, err
+}
+
 func (ln *loggingNode) CreateTask(ctx context.Context, containerID string) (task node.Task, err error) {
 	logFields := baseFields(ctx)
 	logFields = append(logFields, zap.String("container_id", containerID))
@@ -263,15 +583,16 @@ func (ln *loggingNode) GetTasks(ctx context.Context, filter string) (tasks []nod
 	return tasks, err
 }
 
-func (ln *loggingNode) KillTask(ctx context.Context, containerID string) (err error) {
+func (ln *loggingNode) KillTask(ctx context.Context, containerID, signal string, timeout time.Duration) (err error) {
 	logFields := baseFields(ctx)
 	logFields = append(logFields, zap.String("container_id", containerID))
+	logFields = append(logFields, zap.String("signal", signal), zap.String("timeout", timeout.String()))
 	msg := "KillTask"
 
 	defer func(took time.Time) {
 		logFields = append(logFields, zap.String("took", time.Since(took).String()))
 
-		if err = ln.next.KillTask(ctx, containerID); err != nil {
+		if err = ln.next.KillTask(ctx, containerID, signal, timeout); err != nil {
 			logFields = append(logFields, zap.String("error", err.Error()))
 			ln.logger.Error(msg, logFields...)
 		} else {
@@ -282,6 +603,44 @@ func (ln *loggingNode) KillTask(ctx context.Context, containerID string) (err er
 	return err
 }
 
+func (ln *loggingNode) PauseTask(ctx context.Context, containerID string) (task node.Task, err error) {
+	logFields := baseFields(ctx)
+	logFields = append(logFields, zap.String("container_id", containerID))
+	msg := "PauseTask"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if task, err = ln.next.PauseTask(ctx, containerID); err != nil {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		} else {
+			ln.logger.Info(msg, logFields...)
+		}
+	}(time.Now())
+
+	return task, err
+}
+
+func (ln *loggingNode) ResumeTask(ctx context.Context, containerID string) (task node.Task, err error) {
+	logFields := baseFields(ctx)
+	logFields = append(logFields, zap.String("container_id", containerID))
+	msg := "ResumeTask"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if task, err = ln.next.ResumeTask(ctx, containerID); err != nil {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		} else {
+			ln.logger.Info(msg, logFields...)
+		}
+	}(time.Now())
+
+	return task, err
+}
+
 func (ln *loggingNode) DeleteTask(ctx context.Context, containerID string) (exitStatus node.ExitStatus, err error) {
 	logFields := baseFields(ctx)
 	logFields = append(logFields, zap.String("container_id", containerID))
@@ -300,3 +659,295 @@ func (ln *loggingNode) DeleteTask(ctx context.Context, containerID string) (exit
 
 	return exitStatus, err
 }
+
+func (ln *loggingNode) GetLogs(ctx context.Context, containerID string, opts node.LogOptions) (records []node.LogRecord, err error) {
+	var dne node.ErrNotFound
+	logFields := baseFields(ctx)
+	logFields = append(logFields, zap.String("container_id", containerID))
+	logFields = append(logFields, zap.Time("since", opts.Since), zap.Int("tail", opts.Tail), zap.String("stream", opts.Stream))
+	msg := "GetLogs"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if records, err = ln.next.GetLogs(ctx, containerID, opts); err == nil {
+			logFields = append(logFields, zap.Int("records", len(records)))
+			ln.logger.Info(msg, logFields...)
+		} else if errors.As(err, &dne) {
+			logFields = append(logFields, zap.String("error", dne.Error()))
+			ln.logger.Warn(msg, logFields...)
+		} else {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		}
+	}(time.Now())
+
+	return records, err
+}
+
+func (ln *loggingNode) FollowLogs(ctx context.Context, containerID string, opts node.LogOptions) (records <-chan node.LogRecord, err error) {
+	var dne node.ErrNotFound
+	logFields := baseFields(ctx)
+	logFields = append(logFields, zap.String("container_id", containerID))
+	logFields = append(logFields, zap.Time("since", opts.Since), zap.Int("tail", opts.Tail), zap.String("stream", opts.Stream))
+	msg := "FollowLogs"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if records, err = ln.next.FollowLogs(ctx, containerID, opts); err == nil {
+			ln.logger.Info(msg, logFields...)
+		} else if errors.As(err, &dne) {
+			logFields = append(logFields, zap.String("error", dne.Error()))
+			ln.logger.Warn(msg, logFields...)
+		} else {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		}
+	}(time.Now())
+
+	return records, err
+}
+
+func (ln *loggingNode) Events(ctx context.Context, opts node.EventOptions) (events <-chan node.Event, err error) {
+	logFields := baseFields(ctx)
+	logFields = append(logFields, zap.String("topic", opts.Topic), zap.String("container_id", opts.ContainerID), zap.Uint64("since", opts.Since))
+	msg := "Events"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if events, err = ln.next.Events(ctx, opts); err != nil {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		} else {
+			ln.logger.Info(msg, logFields...)
+		}
+	}(time.Now())
+
+	return events, err
+}
+
+func (ln *loggingNode) ExecTask(ctx context.Context, containerID string, spec node.ProcessSpec, io node.TaskIO) (exitStatus node.ExitStatus, err error) {
+	logFields := baseFields(ctx)
+	logFields = append(logFields, zap.String("container_id", containerID))
+	logFields = append(logFields, zap.Strings("args", spec.Args), zap.String("user", spec.User), zap.Bool("terminal", spec.Terminal))
+	msg := "ExecTask"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if exitStatus, err = ln.next.ExecTask(ctx, containerID, spec, io); err != nil {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		} else {
+			logFields = append(logFields, zap.Uint32("exit_code", exitStatus.ExitCode()))
+			ln.logger.Info(msg, logFields...)
+		}
+	}(time.Now())
+
+	return exitStatus, err
+}
+
+func (ln *loggingNode) AttachTask(ctx context.Context, containerID string, io node.TaskIO) (exitStatus node.ExitStatus, err error) {
+	logFields := baseFields(ctx)
+	logFields = append(logFields, zap.String("container_id", containerID))
+	msg := "AttachTask"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if exitStatus, err = ln.next.AttachTask(ctx, containerID, io); err == nil {
+			logFields = append(logFields, zap.Uint32("exit_code", exitStatus.ExitCode()))
+			ln.logger.Info(msg, logFields...)
+		} else if errors.Is(err, context.Canceled) {
+			logFields = append(logFields, zap.Bool("detached", true))
+			ln.logger.Info(msg, logFields...)
+		} else {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		}
+	}(time.Now())
+
+	return exitStatus, err
+}
+
+func (ln *loggingNode) ApplyWorkload(ctx context.Context, w node.Workload) (status node.WorkloadStatus, err error) {
+	logFields := baseFields(ctx)
+	logFields = append(logFields, zap.String("workload", w.Name), zap.String("image", w.Image), zap.String("state", w.State))
+	msg := "ApplyWorkload"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if status, err = ln.next.ApplyWorkload(ctx, w); err != nil {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		} else {
+			logFields = append(logFields, zap.Bool("synced", status.Synced), zap.Strings("drift", status.Drift))
+			ln.logger.Info(msg, logFields...)
+		}
+	}(time.Now())
+
+	return status, err
+}
+
+func (ln *loggingNode) GetWorkload(ctx context.Context, name string) (status node.WorkloadStatus, err error) {
+	var dne node.ErrNotFound
+	logFields := baseFields(ctx)
+	logFields = append(logFields, zap.String("workload", name))
+	msg := "GetWorkload"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if status, err = ln.next.GetWorkload(ctx, name); err == nil {
+			ln.logger.Info(msg, logFields...)
+		} else if errors.As(err, &dne) {
+			logFields = append(logFields, zap.String("error", dne.Error()))
+			ln.logger.Warn(msg, logFields...)
+		} else {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		}
+	}(time.Now())
+
+	return status, err
+}
+
+func (ln *loggingNode) GetWorkloads(ctx context.Context) (statuses []node.WorkloadStatus, err error) {
+	logFields := baseFields(ctx)
+	msg := "GetWorkloads"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if statuses, err = ln.next.GetWorkloads(ctx); err != nil {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		} else {
+			ln.logger.Info(msg, logFields...)
+		}
+	}(time.Now())
+
+	return statuses, err
+}
+
+func (ln *loggingNode) DeleteWorkload(ctx context.Context, name string) (err error) {
+	var dne node.ErrNotFound
+	logFields := baseFields(ctx)
+	logFields = append(logFields, zap.String("workload", name))
+	msg := "DeleteWorkload"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if err = ln.next.DeleteWorkload(ctx, name); err == nil {
+			ln.logger.Info(msg, logFields...)
+		} else if errors.As(err, &dne) {
+			logFields = append(logFields, zap.String("error", dne.Error()))
+			ln.logger.Warn(msg, logFields...)
+		} else {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		}
+	}(time.Now())
+
+	return err
+}
+
+func (ln *loggingNode) CreatePod(ctx context.Context, id string, spec node.PodSpec) (pod node.Pod, err error) {
+	logFields := baseFields(ctx)
+	logFields = append(logFields, zap.String("id", id), zap.String("sandbox_image", spec.SandboxImage), zap.Int("members", len(spec.Members)))
+	msg := "CreatePod"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if pod, err = ln.next.CreatePod(ctx, id, spec); err != nil {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		} else {
+			logFields = append(logFields, zap.String("status", pod.Status))
+			ln.logger.Info(msg, logFields...)
+		}
+	}(time.Now())
+
+	return pod, err
+}
+
+func (ln *loggingNode) GetPod(ctx context.Context, id string) (pod node.Pod, err error) {
+	var dne node.ErrNotFound
+	logFields := baseFields(ctx)
+	logFields = append(logFields, zap.String("id", id))
+	msg := "GetPod"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if pod, err = ln.next.GetPod(ctx, id); err == nil {
+			ln.logger.Info(msg, logFields...)
+		} else if errors.As(err, &dne) {
+			logFields = append(logFields, zap.String("error", dne.Error()))
+			ln.logger.Warn(msg, logFields...)
+		} else {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		}
+	}(time.Now())
+
+	return pod, err
+}
+
+func (ln *loggingNode) GetPods(ctx context.Context) (pods []node.Pod, err error) {
+	logFields := baseFields(ctx)
+	msg := "GetPods"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if pods, err = ln.next.GetPods(ctx); err != nil {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		} else {
+			ln.logger.Info(msg, logFields...)
+		}
+	}(time.Now())
+
+	return pods, err
+}
+
+func (ln *loggingNode) DeletePod(ctx context.Context, id string) (err error) {
+	var dne node.ErrNotFound
+	logFields := baseFields(ctx)
+	logFields = append(logFields, zap.String("id", id))
+	msg := "DeletePod"
+
+	defer func(took time.Time) {
+		logFields = append(logFields, zap.String("took", time.Since(took).String()))
+
+		if err = ln.next.DeletePod(ctx, id); err == nil {
+			ln.logger.Info(msg, logFields...)
+		} else if errors.As(err, &dne) {
+			logFields = append(logFields, zap.String("error", dne.Error()))
+			ln.logger.Warn(msg, logFields...)
+		} else {
+			logFields = append(logFields, zap.String("error", err.Error()))
+			ln.logger.Error(msg, logFields...)
+		}
+	}(time.Now())
+
+	return err
+}
+
+func (ln *loggingNode) Close() (err error) {
+	msg := "Close"
+
+	if err = ln.next.Close(); err == nil {
+		ln.logger.Info(msg)
+	} else {
+		ln.logger.Error(msg, zap.String("error", err.Error()))
+	}
+
+	return err
+}
@@ -1,6 +1,7 @@
 package log
 
 import (
+	"sort"
 	"time"
 
 	"github.com/graphql-go/graphql"
@@ -11,37 +12,95 @@ import (
 // NewLoggingResolverSet handles wrapping *api.ResolverSet instances
 func NewLoggingResolverSet(logger *zap.Logger, rs *api.ResolverSet) *api.ResolverSet {
 	return &api.ResolverSet{
-		CreateImageResolver:     NewLoggingResolver(logger, "CreateImageResolver", rs.CreateImageResolver),
-		ImageResolver:           NewLoggingResolver(logger, "ImageResolver", rs.ImageResolver),
-		ImagesResolver:          NewLoggingResolver(logger, "ImagesResolver", rs.ImagesResolver),
-		DeleteImageResolver:     NewLoggingResolver(logger, "DeleteImageResolver", rs.DeleteImageResolver),
-		CreateContainerResolver: NewLoggingResolver(logger, "CreateContainerResolver", rs.CreateContainerResolver),
-		ContainerResolver:       NewLoggingResolver(logger, "ContainerResolver", rs.ContainerResolver),
-		ContainersResolver:      NewLoggingResolver(logger, "ContainersResolver", rs.ContainersResolver),
-		DeleteContainerResolver: NewLoggingResolver(logger, "DeleteContainerResolver", rs.DeleteContainerResolver),
-		CreateTaskResolver:      NewLoggingResolver(logger, "CreateTaskResolver", rs.CreateTaskResolver),
-		TaskResolver:            NewLoggingResolver(logger, "TaskResolver", rs.TaskResolver),
-		TasksResolver:           NewLoggingResolver(logger, "TasksResolver", rs.TasksResolver),
-		DeleteTaskResolver:      NewLoggingResolver(logger, "DeleteTaskResolver", rs.DeleteTaskResolver),
-		KillTaskResolver:        NewLoggingResolver(logger, "KillTaskResolver", rs.KillTaskResolver),
+		CreateImageResolver:              NewLoggingResolver(logger, "CreateImageResolver", rs.CreateImageResolver),
+		ImageResolver:                    NewLoggingResolver(logger, "ImageResolver", rs.ImageResolver),
+		ImagesResolver:                   NewLoggingResolver(logger, "ImagesResolver", rs.ImagesResolver),
+		DeleteImageResolver:              NewLoggingResolver(logger, "DeleteImageResolver", rs.DeleteImageResolver),
+		TagImageResolver:                 NewLoggingResolver(logger, "TagImageResolver", rs.TagImageResolver),
+		UntagImageResolver:               NewLoggingResolver(logger, "UntagImageResolver", rs.UntagImageResolver),
+		CreateContainerResolver:          NewLoggingResolver(logger, "CreateContainerResolver", rs.CreateContainerResolver),
+		ContainerResolver:                NewLoggingResolver(logger, "ContainerResolver", rs.ContainerResolver),
+		ContainersResolver:               NewLoggingResolver(logger, "ContainersResolver", rs.ContainersResolver),
+		DeleteContainerResolver:          NewLoggingResolver(logger, "DeleteContainerResolver", rs.DeleteContainerResolver),
+		UpdateContainerResourcesResolver: NewLoggingResolver(logger, "UpdateContainerResourcesResolver", rs.UpdateContainerResourcesResolver),
+		CreateTaskResolver:               NewLoggingResolver(logger, "CreateTaskResolver", rs.CreateTaskResolver),
+		TaskResolver:                     NewLoggingResolver(logger, "TaskResolver", rs.TaskResolver),
+		TasksResolver:                    NewLoggingResolver(logger, "TasksResolver", rs.TasksResolver),
+		DeleteTaskResolver:               NewLoggingResolver(logger, "DeleteTaskResolver", rs.DeleteTaskResolver),
+		KillTaskResolver:                 NewLoggingResolver(logger, "KillTaskResolver", rs.KillTaskResolver),
+		PauseTaskResolver:                NewLoggingResolver(logger, "PauseTaskResolver", rs.PauseTaskResolver),
+		ResumeTaskResolver:               NewLoggingResolver(logger, "ResumeTaskResolver", rs.ResumeTaskResolver),
+		ExecTaskResolver:                 NewLoggingResolver(logger, "ExecTaskResolver", rs.ExecTaskResolver),
+		LogsResolver:                     NewLoggingResolver(logger, "LogsResolver", rs.LogsResolver),
+		EventsResolver:                   NewLoggingResolver(logger, "EventsResolver", rs.EventsResolver),
+		PullJobResolver:                  NewLoggingResolver(logger, "PullJobResolver", rs.PullJobResolver),
+		PullJobsResolver:                 NewLoggingResolver(logger, "PullJobsResolver", rs.PullJobsResolver),
+		FollowPullJobResolver:            NewLoggingResolver(logger, "FollowPullJobResolver", rs.FollowPullJobResolver),
+		CreateImageAsyncResolver:         NewLoggingResolver(logger, "CreateImageAsyncResolver", rs.CreateImageAsyncResolver),
+		KillTaskAsyncResolver:            NewLoggingResolver(logger, "KillTaskAsyncResolver", rs.KillTaskAsyncResolver),
+		OperationResolver:                NewLoggingResolver(logger, "OperationResolver", rs.OperationResolver),
+		OperationsResolver:               NewLoggingResolver(logger, "OperationsResolver", rs.OperationsResolver),
+		CancelOperationResolver:          NewLoggingResolver(logger, "CancelOperationResolver", rs.CancelOperationResolver),
+		PruneImagesResolver:              NewLoggingResolver(logger, "PruneImagesResolver", rs.PruneImagesResolver),
+		ApplyResolver:                    NewLoggingResolver(logger, "ApplyResolver", rs.ApplyResolver),
+		WorkloadResolver:                 NewLoggingResolver(logger, "WorkloadResolver", rs.WorkloadResolver),
+		WorkloadsResolver:                NewLoggingResolver(logger, "WorkloadsResolver", rs.WorkloadsResolver),
+		DeleteWorkloadResolver:           NewLoggingResolver(logger, "DeleteWorkloadResolver", rs.DeleteWorkloadResolver),
+		CreatePodResolver:                NewLoggingResolver(logger, "CreatePodResolver", rs.CreatePodResolver),
+		PodResolver:                      NewLoggingResolver(logger, "PodResolver", rs.PodResolver),
+		PodsResolver:                     NewLoggingResolver(logger, "PodsResolver", rs.PodsResolver),
+		DeletePodResolver:                NewLoggingResolver(logger, "DeletePodResolver", rs.DeletePodResolver),
 	}
 }
 
-// NewLoggingResolver logs the current resolver name, its params and how long the resolver took to execute
+// payloadArgs are string arguments that carry data rather than identifiers, only their size is logged.
+var payloadArgs = map[string]bool{"stdin": true}
+
+// NewLoggingResolver logs the current resolver name, its params and how long the resolver took to execute.
+// Input objects such as container specs can hold secrets in env values, so only their keys are logged, and only the length of lists.
 func NewLoggingResolver(l *zap.Logger, resolver string, r graphql.FieldResolveFn) graphql.FieldResolveFn {
 	return func(p graphql.ResolveParams) (interface{}, error) {
 		defer func(took time.Time) {
 			var logFields []zap.Field
 
 			for k, v := range p.Args {
-				logFields = append(logFields, zap.String(k, v.(string)))
+				logFields = append(logFields, argField(k, v))
 			}
 
 			logFields = append(logFields, zap.String("took", time.Since(took).String()))
-			
+
 			l.Info(resolver, logFields...)
 		}(time.Now())
 
 		return r(p)
 	}
 }
+
+// argField returns the log field for the resolver argument k with the value v.
+func argField(k string, v interface{}) zap.Field {
+	switch value := v.(type) {
+	case string:
+
+		if payloadArgs[k] {
+			return zap.Int(k+"_bytes", len(value))
+		}
+
+		return zap.String(k, value)
+	case bool, int, float64:
+		return zap.Any(k, value)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+
+		for key := range value {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		return zap.Strings(k, keys)
+	case []interface{}:
+		return zap.Int(k+"_len", len(value))
+	default:
+		return zap.Skip()
+	}
+}
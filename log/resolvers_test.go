@@ -0,0 +1,87 @@
+package log_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/mokrz/clamor/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLoggingResolverArgs(t *testing.T) {
+	secret := "hunter2-token"
+
+	type resolverTest struct {
+		name     string
+		args     map[string]interface{}
+		wantKeys []string
+	}
+
+	tests := []resolverTest{
+		{
+			name: "container spec",
+			args: map[string]interface{}{
+				"namespace": "clamor-testing",
+				"id":        "web",
+				"spec":      map[string]interface{}{"env": []interface{}{"API_TOKEN=" + secret}, "args": []interface{}{"--password", secret}},
+			},
+			wantKeys: []string{"namespace", "id", "spec", "took"},
+		},
+		{
+			name: "exec",
+			args: map[string]interface{}{
+				"container_id": "web",
+				"process":      map[string]interface{}{"args": []interface{}{"env"}, "env": []interface{}{"SECRET=" + secret}},
+				"stdin":        secret,
+				"timeout":      5,
+			},
+			wantKeys: []string{"container_id", "process", "stdin_bytes", "timeout", "took"},
+		},
+		{
+			name: "workloads",
+			args: map[string]interface{}{
+				"workloads": []interface{}{map[string]interface{}{"name": "web", "spec": map[string]interface{}{"env": []interface{}{"KEY=" + secret}}}},
+			},
+			wantKeys: []string{"workloads_len", "took"},
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			core, logs := observer.New(zap.InfoLevel)
+			resolver := log.NewLoggingResolver(zap.New(core), "TestResolver", func(p graphql.ResolveParams) (interface{}, error) {
+				return nil, nil
+			})
+
+			if _, err := resolver(graphql.ResolveParams{Args: test.args}); err != nil {
+				t.Fatalf("resolver failed with error: %s", err.Error())
+			}
+
+			entries := logs.All()
+
+			if len(entries) != 1 {
+				t.Fatalf("resolver logged %d entries, want 1", len(entries))
+			}
+
+			fields := entries[0].ContextMap()
+
+			for _, k := range test.wantKeys {
+
+				if _, exists := fields[k]; !exists {
+					t.Errorf("log entry %v has no %s field", fields, k)
+				}
+			}
+
+			for k, v := range fields {
+
+				if strings.Contains(fmt.Sprint(v), secret) {
+					t.Errorf("log field %s holds the secret: %v", k, v)
+				}
+			}
+		})
+	}
+}
@@ -11,6 +11,59 @@ var imageArgs = graphql.FieldConfigArgument{
 	},
 }
 
+var tagImageArgs = graphql.FieldConfigArgument{
+	"source": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+	"target": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+	"namespace": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+}
+
+var pullJobArgs = graphql.FieldConfigArgument{
+	"ref": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+	"namespace": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+}
+
+var pullJobsArgs = graphql.FieldConfigArgument{
+	"namespace": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+}
+
+var pruneImagesArgs = graphql.FieldConfigArgument{
+	"namespace": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+	"dry_run": &graphql.ArgumentConfig{
+		Type:         graphql.Boolean,
+		DefaultValue: true,
+		Description:  "Only report what would be removed",
+	},
+}
+
+var operationArgs = graphql.FieldConfigArgument{
+	"id": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+	"namespace": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+}
+
+var operationsArgs = graphql.FieldConfigArgument{
+	"namespace": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+}
+
 var imagesArgs = graphql.FieldConfigArgument{
 	"filter": &graphql.ArgumentConfig{
 		Type:         graphql.String,
@@ -59,6 +112,44 @@ var tasksArgs = graphql.FieldConfigArgument{
 	},
 }
 
+var eventsArgs = graphql.FieldConfigArgument{
+	"topic": &graphql.ArgumentConfig{
+		Type:        graphql.String,
+		Description: "Topic, e.g. /tasks/exit, or the prefix of the topics to stream, e.g. /tasks. All topics when unset",
+	},
+	"container_id": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+	"since": &graphql.ArgumentConfig{
+		Type:        Int64,
+		Description: "Seq of the first backlogged event to replay, only new events are streamed when unset",
+	},
+	"namespace": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+}
+
+var logsArgs = graphql.FieldConfigArgument{
+	"container_id": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+	"since": &graphql.ArgumentConfig{
+		Type:        graphql.String,
+		Description: "RFC 3339 timestamp, only records written at or after it are returned",
+	},
+	"tail": &graphql.ArgumentConfig{
+		Type:        graphql.Int,
+		Description: "Number of most recent records to return, all when unset",
+	},
+	"stream": &graphql.ArgumentConfig{
+		Type:        graphql.String,
+		Description: "stdout or stderr, both when unset",
+	},
+	"namespace": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+}
+
 var createImageArgs = graphql.FieldConfigArgument{
 	"ref": &graphql.ArgumentConfig{
 		Type: graphql.String,
@@ -66,8 +157,145 @@ var createImageArgs = graphql.FieldConfigArgument{
 	"namespace": &graphql.ArgumentConfig{
 		Type: graphql.String,
 	},
+	"platforms": &graphql.ArgumentConfig{
+		Type:        graphql.NewList(graphql.String),
+		Description: "Platforms to pull, e.g. linux/arm64/v8, or \"all\". Defaults to the node's platform",
+	},
 }
 
+var labelInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "LabelInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"key": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"value": &graphql.InputObjectFieldConfig{
+			Type: graphql.String,
+		},
+	},
+})
+
+var resourcesInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "ResourcesInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"cpu_shares": &graphql.InputObjectFieldConfig{
+			Type:        Int64,
+			Description: "Relative CPU weight",
+		},
+		"cpu_quota": &graphql.InputObjectFieldConfig{
+			Type:        Int64,
+			Description: "CPU time in microseconds per cpu_period",
+		},
+		"cpu_period": &graphql.InputObjectFieldConfig{
+			Type:        Int64,
+			Description: "CFS period in microseconds, defaults to 100000 when cpu_quota is set",
+		},
+		"memory_limit": &graphql.InputObjectFieldConfig{
+			Type:        Int64,
+			Description: "Hard memory limit in bytes",
+		},
+		"memory_reservation": &graphql.InputObjectFieldConfig{
+			Type:        Int64,
+			Description: "Soft memory limit in bytes",
+		},
+		"memory_swap": &graphql.InputObjectFieldConfig{
+			Type:        Int64,
+			Description: "Memory plus swap limit in bytes, -1 for unlimited swap",
+		},
+		"pids_limit": &graphql.InputObjectFieldConfig{
+			Type:        Int64,
+			Description: "Maximum number of processes, -1 for unlimited",
+		},
+	},
+})
+
+var healthCheckInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "HealthCheckInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"exec": &graphql.InputObjectFieldConfig{
+			Type:        graphql.NewList(graphql.String),
+			Description: "Command run inside the task, the probe passes when it exits with 0",
+		},
+		"http_get": &graphql.InputObjectFieldConfig{
+			Type:        graphql.String,
+			Description: "URL the node GETs from inside the task's network namespace, the probe passes on a 2xx or 3xx response",
+		},
+		"tcp_socket": &graphql.InputObjectFieldConfig{
+			Type:        graphql.String,
+			Description: "host:port the node connects to from inside the task's network namespace, the probe passes once the connection is made",
+		},
+		"interval": &graphql.InputObjectFieldConfig{
+			Type:        graphql.Int,
+			Description: "Seconds between probes",
+		},
+		"timeout": &graphql.InputObjectFieldConfig{
+			Type:        graphql.Int,
+			Description: "Seconds a probe may take before it fails",
+		},
+		"retries": &graphql.InputObjectFieldConfig{
+			Type:        graphql.Int,
+			Description: "Probes in a row that have to fail for the task to be unhealthy",
+		},
+		"start_period": &graphql.InputObjectFieldConfig{
+			Type:        graphql.Int,
+			Description: "Seconds the task gets to start, failed probes during it don't count",
+		},
+	},
+})
+
+var containerSpecInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "ContainerSpecInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"command": &graphql.InputObjectFieldConfig{
+			Type:        graphql.NewList(graphql.String),
+			Description: "Replaces the image entrypoint and drops the image cmd",
+		},
+		"args": &graphql.InputObjectFieldConfig{
+			Type:        graphql.NewList(graphql.String),
+			Description: "Replaces the image cmd",
+		},
+		"env": &graphql.InputObjectFieldConfig{
+			Type:        graphql.NewList(graphql.String),
+			Description: "KEY=VALUE pairs added to the image environment",
+		},
+		"working_dir": &graphql.InputObjectFieldConfig{
+			Type: graphql.String,
+		},
+		"user": &graphql.InputObjectFieldConfig{
+			Type: graphql.String,
+		},
+		"hostname": &graphql.InputObjectFieldConfig{
+			Type: graphql.String,
+		},
+		"labels": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewList(labelInputType),
+		},
+		"resources": &graphql.InputObjectFieldConfig{
+			Type: resourcesInputType,
+		},
+		"stdin": &graphql.InputObjectFieldConfig{
+			Type:        graphql.Boolean,
+			Description: "Keep the task's stdin open for attached sessions",
+		},
+		"terminal": &graphql.InputObjectFieldConfig{
+			Type:        graphql.Boolean,
+			Description: "Give the task a pseudo terminal, stderr is merged into stdout",
+		},
+		"restart_policy": &graphql.InputObjectFieldConfig{
+			Type:        graphql.String,
+			Description: "When to restart the task after it exits: no, on-failure[:max-retries], always or unless-stopped",
+		},
+		"health_check": &graphql.InputObjectFieldConfig{
+			Type:        healthCheckInputType,
+			Description: "Probe the running task with exactly one of exec, http_get and tcp_socket",
+		},
+		"sandbox": &graphql.InputObjectFieldConfig{
+			Type:        graphql.String,
+			Description: "ID of a container whose running task's network, IPC and PID namespaces the task joins",
+		},
+	},
+})
+
 var createContainerArgs = graphql.FieldConfigArgument{
 	"id": &graphql.ArgumentConfig{
 		Type: graphql.String,
@@ -75,6 +303,82 @@ var createContainerArgs = graphql.FieldConfigArgument{
 	"image": &graphql.ArgumentConfig{
 		Type: graphql.String,
 	},
+	"spec": &graphql.ArgumentConfig{
+		Type: containerSpecInputType,
+	},
+	"namespace": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+}
+
+var updateContainerResourcesArgs = graphql.FieldConfigArgument{
+	"id": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+	"resources": &graphql.ArgumentConfig{
+		Type: graphql.NewNonNull(resourcesInputType),
+	},
+	"namespace": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+}
+
+var killTaskArgs = graphql.FieldConfigArgument{
+	"container_id": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+	"signal": &graphql.ArgumentConfig{
+		Type:        graphql.String,
+		Description: "Signal name or number, e.g. SIGHUP, HUP or 1. The task is stopped gracefully when unset",
+	},
+	"timeout": &graphql.ArgumentConfig{
+		Type:        graphql.Int,
+		Description: "Seconds to wait for the task to exit before it's sent SIGKILL",
+	},
+	"namespace": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+}
+
+var processInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "ProcessInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"args": &graphql.InputObjectFieldConfig{
+			Type:        graphql.NewNonNull(graphql.NewList(graphql.String)),
+			Description: "Command and arguments",
+		},
+		"env": &graphql.InputObjectFieldConfig{
+			Type:        graphql.NewList(graphql.String),
+			Description: "KEY=VALUE pairs added to the task environment",
+		},
+		"working_dir": &graphql.InputObjectFieldConfig{
+			Type: graphql.String,
+		},
+		"user": &graphql.InputObjectFieldConfig{
+			Type:        graphql.String,
+			Description: "User name or uid, optionally followed by :group or :gid, resolved against the container's /etc/passwd and /etc/group",
+		},
+		"terminal": &graphql.InputObjectFieldConfig{
+			Type:        graphql.Boolean,
+			Description: "Allocate a pseudo terminal, stderr is merged into stdout",
+		},
+	},
+})
+
+var execTaskArgs = graphql.FieldConfigArgument{
+	"container_id": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+	"process": &graphql.ArgumentConfig{
+		Type: graphql.NewNonNull(processInputType),
+	},
+	"stdin": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+	"timeout": &graphql.ArgumentConfig{
+		Type:        graphql.Int,
+		Description: "Seconds the process may run before it's killed",
+	},
 	"namespace": &graphql.ArgumentConfig{
 		Type: graphql.String,
 	},
@@ -88,3 +392,94 @@ var createTaskArgs = graphql.FieldConfigArgument{
 		Type: graphql.String,
 	},
 }
+
+var workloadInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "WorkloadInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"name": &graphql.InputObjectFieldConfig{
+			Type:        graphql.NewNonNull(graphql.String),
+			Description: "ID of the workload's container",
+		},
+		"image": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"spec": &graphql.InputObjectFieldConfig{
+			Type: containerSpecInputType,
+		},
+		"state": &graphql.InputObjectFieldConfig{
+			Type:        graphql.String,
+			Description: "Desired state of the workload's task: running, the default, or stopped",
+		},
+	},
+})
+
+var applyArgs = graphql.FieldConfigArgument{
+	"workloads": &graphql.ArgumentConfig{
+		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(workloadInputType))),
+	},
+	"namespace": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+}
+
+var workloadArgs = graphql.FieldConfigArgument{
+	"name": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+	"namespace": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+}
+
+var workloadsArgs = graphql.FieldConfigArgument{
+	"namespace": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+}
+
+var podMemberInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "PodMemberInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"name": &graphql.InputObjectFieldConfig{
+			Type:        graphql.NewNonNull(graphql.String),
+			Description: "Name of the member within its pod, its container ID is <pod ID>.<name>",
+		},
+		"image": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"spec": &graphql.InputObjectFieldConfig{
+			Type: containerSpecInputType,
+		},
+	},
+})
+
+var createPodArgs = graphql.FieldConfigArgument{
+	"id": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+	"sandbox_image": &graphql.ArgumentConfig{
+		Type:        graphql.String,
+		Description: "Image of the container holding the pod's namespaces, pulled if it isn't present",
+	},
+	"members": &graphql.ArgumentConfig{
+		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(podMemberInputType))),
+	},
+	"namespace": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+}
+
+var podArgs = graphql.FieldConfigArgument{
+	"id": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+	"namespace": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+}
+
+var podsArgs = graphql.FieldConfigArgument{
+	"namespace": &graphql.ArgumentConfig{
+		Type: graphql.String,
+	},
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/mokrz/clamor/node"
+)
+
+// subscriptionKey is the context key under which NewEventsHandler collects the event stream the events resolver opens.
+type subscriptionKey struct{}
+
+// Root object keys under which NewEventsHandler hands each item of a stream to the resolver that opened it.
+const (
+	subscriptionEventKey   = "event"
+	subscriptionPullJobKey = "pull_job"
+)
+
+// subscription is the stream a subscription resolver opened, along with the root object key its items go under.
+type subscription struct {
+	key   string
+	items <-chan interface{}
+}
+
+// events streams the given events until they end or ctx is done.
+func (s *subscription) events(ctx context.Context, events <-chan node.Event) {
+	items := make(chan interface{})
+
+	go func() {
+		defer close(items)
+
+		for e := range events {
+			select {
+			case items <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	s.key, s.items = subscriptionEventKey, items
+}
+
+// pullJobs streams the given pull job snapshots until they end or ctx is done.
+func (s *subscription) pullJobs(ctx context.Context, jobs <-chan node.PullJob) {
+	items := make(chan interface{})
+
+	go func() {
+		defer close(items)
+
+		for j := range jobs {
+			select {
+			case items <- j:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	s.key, s.items = subscriptionPullJobKey, items
+}
+
+// subscriptionItem returns the item a root object carries under key, if it's one NewEventsHandler made.
+func subscriptionItem(source interface{}, key string) (interface{}, bool) {
+	root, isRoot := source.(map[string]interface{})
+
+	if !isRoot {
+		return nil, false
+	}
+
+	item, isItem := root[key]
+
+	return item, isItem
+}
+
+// subscriptionEvent returns the event a root object carries, if it's one NewEventsHandler made.
+func subscriptionEvent(source interface{}) (node.Event, bool) {
+	item, _ := subscriptionItem(source, subscriptionEventKey)
+	event, isEvent := item.(node.Event)
+
+	return event, isEvent
+}
+
+// subscriptionPullJob returns the pull job a root object carries, if it's one NewEventsHandler made.
+func subscriptionPullJob(source interface{}) (node.PullJob, bool) {
+	item, _ := subscriptionItem(source, subscriptionPullJobKey)
+	job, isJob := item.(node.PullJob)
+
+	return job, isJob
+}
+
+// NewEventsHandler returns an HTTP handler that streams a subscription as newline delimited graphql results, one per item of the stream:
+// runtime events for events, and progress updates for pullJob.
+// The subscription document goes in the query parameter, as it does for /graphql, and must select a single field.
+// The document is executed once to subscribe, then again on each item. An events stream ends when the client goes away or falls too far behind,
+// in which case it can resubscribe with since set to the seq of the last event it got. A pullJob stream ends with the pull.
+func NewEventsHandler(schema graphql.Schema) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			sub   = &subscription{}
+			query = r.URL.Query().Get("query")
+		)
+
+		if !isSubscription(query) {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+
+		result := graphql.Do(graphql.Params{
+			Schema:        schema,
+			RequestString: query,
+			Context:       context.WithValue(r.Context(), subscriptionKey{}, sub),
+		})
+
+		if result.HasErrors() || sub.items == nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(result)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		flusher, canFlush := w.(http.Flusher)
+
+		if canFlush {
+			flusher.Flush()
+		}
+
+		enc := json.NewEncoder(w)
+
+		for item := range sub.items {
+			result = graphql.Do(graphql.Params{
+				Schema:        schema,
+				RequestString: query,
+				RootObject:    map[string]interface{}{sub.key: item},
+				Context:       r.Context(),
+			})
+
+			if encodeErr := enc.Encode(result); encodeErr != nil {
+				return
+			}
+
+			if canFlush {
+				flusher.Flush()
+			}
+		}
+	})
+}
+
+// isSubscription reports whether query parses into subscription operations only, so streaming it can't run queries or mutations.
+func isSubscription(query string) bool {
+	doc, parseErr := parser.Parse(parser.ParseParams{Source: query})
+
+	if parseErr != nil {
+		return false
+	}
+
+	for _, def := range doc.Definitions {
+
+		if op, isOp := def.(*ast.OperationDefinition); isOp && op.Operation != ast.OperationTypeSubscription {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,340 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/containerd/containerd/namespaces"
+	"github.com/gorilla/websocket"
+	"github.com/mokrz/clamor/node"
+)
+
+// execOutputLimit bounds how much of each output stream the execTask mutation returns.
+const execOutputLimit = 1 << 20
+
+// SessionStdinLimit bounds how many bytes of stdin frames a session holds on to for a process that hasn't read them yet.
+// A session whose client gets further ahead than that ends with an error frame.
+const SessionStdinLimit = 1 << 20
+
+// Stream ids prefixing the binary frames of an exec or attach session.
+const (
+	SessionStdin byte = iota
+	SessionStdout
+	SessionStderr
+)
+
+// Control frame types of an exec or attach session.
+// Clients send close_stdin once they're done writing, resize when their terminal changes size and detach to leave an attached task running.
+// The server ends the session with an exit, error or detached frame.
+const (
+	CloseStdinControl = "close_stdin"
+	ResizeControl     = "resize"
+	DetachControl     = "detach"
+	ExitControl       = "exit"
+	ErrorControl      = "error"
+	DetachedControl   = "detached"
+)
+
+// SessionControl is a JSON text frame of an exec or attach session.
+type SessionControl struct {
+	Type     string `json:"type"`
+	ExitCode uint32 `json:"exit_code,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Width    uint32 `json:"width,omitempty"`
+	Height   uint32 `json:"height,omitempty"`
+}
+
+var sessionUpgrader = websocket.Upgrader{}
+
+// NewExecHandler returns an HTTP handler that runs a process inside a container's task over a websocket.
+// It takes the namespace and container_id query parameters, the process's repeated arg and env parameters, and working_dir, user and tty.
+// Binary frames carry the process's stdio, each prefixed with its stream id. Closing the websocket kills the process.
+// The process belongs to the session, so detach frames are ignored.
+func NewExecHandler(svc node.TaskService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			spec        node.ProcessSpec
+			parseErr    error
+			query       = r.URL.Query()
+			containerID = query.Get("container_id")
+		)
+
+		spec.Args, spec.Env = query["arg"], query["env"]
+		spec.WorkingDir, spec.User = query.Get("working_dir"), query.Get("user")
+
+		if query.Get("tty") != "" {
+
+			if spec.Terminal, parseErr = strconv.ParseBool(query.Get("tty")); parseErr != nil {
+				http.Error(w, "invalid request", http.StatusBadRequest)
+				return
+			}
+		}
+
+		if spec.Validate() != nil || query.Get("namespace") == "" || containerID == "" {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+
+		serveSession(w, r, false, func(ctx context.Context, taskIO node.TaskIO) (node.ExitStatus, error) {
+			return svc.ExecTask(ctx, containerID, spec, taskIO)
+		})
+	})
+}
+
+// NewAttachHandler returns an HTTP handler that attaches a websocket to the stdio of a container's running task.
+// It takes the namespace and container_id query parameters and speaks the exec protocol, see NewExecHandler.
+// Stdin frames only reach tasks of containers created with stdin open. A detach frame, or closing the websocket, leaves the task running.
+func NewAttachHandler(svc node.TaskService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			query       = r.URL.Query()
+			containerID = query.Get("container_id")
+		)
+
+		if query.Get("namespace") == "" || containerID == "" {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+
+		serveSession(w, r, true, func(ctx context.Context, taskIO node.TaskIO) (node.ExitStatus, error) {
+			return svc.AttachTask(ctx, containerID, taskIO)
+		})
+	})
+}
+
+// serveSession upgrades the request to a websocket and connects it to the streams run hands to the runtime.
+// run's ctx is cancelled when the client goes away or, if detachable, asks to detach.
+func serveSession(w http.ResponseWriter, r *http.Request, detachable bool, run func(ctx context.Context, taskIO node.TaskIO) (node.ExitStatus, error)) {
+	conn, upgradeErr := sessionUpgrader.Upgrade(w, r, nil)
+
+	if upgradeErr != nil {
+		return
+	}
+
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(namespaces.WithNamespace(r.Context(), r.URL.Query().Get("namespace")))
+	defer cancel()
+
+	session := &streamSession{conn: conn, detachable: detachable, cancel: cancel}
+	stdin, stdinWriter := io.Pipe()
+	feed := newStdinFeed()
+	resize := make(chan node.ConsoleSize)
+	defer stdinWriter.Close()
+
+	go feed.run(ctx, stdinWriter)
+	go session.readClient(ctx, stdinWriter, feed, resize)
+
+	exitStatus, runErr := run(ctx, node.TaskIO{
+		Stdin:  stdin,
+		Stdout: session.stream(SessionStdout),
+		Stderr: session.stream(SessionStderr),
+		Resize: resize,
+	})
+
+	switch {
+	case session.isDetached():
+		session.control(SessionControl{Type: DetachedControl})
+	case session.failure() != nil:
+		session.control(SessionControl{Type: ErrorControl, Error: session.failure().Error()})
+	case runErr != nil:
+		session.control(SessionControl{Type: ErrorControl, Error: runErr.Error()})
+	default:
+		session.control(SessionControl{Type: ExitControl, ExitCode: exitStatus.ExitCode()})
+	}
+
+	session.write(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+}
+
+// streamSession serializes the frames written to a session's websocket and tracks how the session ended.
+type streamSession struct {
+	mu         sync.Mutex
+	conn       *websocket.Conn
+	detachable bool
+	detached   bool
+	// err is why the session was cut short, if it was.
+	err    error
+	cancel context.CancelFunc
+}
+
+func (s *streamSession) write(messageType int, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.conn.WriteMessage(messageType, data)
+}
+
+func (s *streamSession) control(c SessionControl) error {
+	data, err := json.Marshal(c)
+
+	if err != nil {
+		return err
+	}
+
+	return s.write(websocket.TextMessage, data)
+}
+
+func (s *streamSession) stream(id byte) io.Writer {
+	return sessionStreamWriter{session: s, id: id}
+}
+
+func (s *streamSession) isDetached() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.detached
+}
+
+func (s *streamSession) failure() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.err
+}
+
+// readClient handles the client's frames until it goes away, which cancels the session. Stdin frames are queued on feed.
+func (s *streamSession) readClient(ctx context.Context, stdin *io.PipeWriter, feed *stdinFeed, resize chan<- node.ConsoleSize) {
+	defer s.cancel()
+
+	for {
+		messageType, data, err := s.conn.ReadMessage()
+
+		if err != nil {
+			stdin.CloseWithError(err)
+			return
+		}
+
+		if messageType == websocket.BinaryMessage && len(data) > 0 && data[0] == SessionStdin {
+
+			if pushErr := feed.push(data[1:]); pushErr != nil {
+				s.mu.Lock()
+				s.err = pushErr
+				s.mu.Unlock()
+				stdin.CloseWithError(pushErr)
+				return
+			}
+
+			continue
+		}
+
+		var c SessionControl
+
+		if messageType != websocket.TextMessage || json.Unmarshal(data, &c) != nil {
+			continue
+		}
+
+		switch c.Type {
+		case CloseStdinControl:
+			feed.close()
+		case ResizeControl:
+			select {
+			case resize <- node.ConsoleSize{Width: c.Width, Height: c.Height}:
+			case <-ctx.Done():
+			}
+		case DetachControl:
+
+			if s.detachable {
+				s.mu.Lock()
+				s.detached = true
+				s.mu.Unlock()
+				s.cancel()
+			}
+		}
+	}
+}
+
+// stdinFeed queues a session's stdin frames for a goroutine of their own to write, so a process that isn't reading its stdin
+// doesn't hold up the resize, detach and close_stdin frames that follow them.
+type stdinFeed struct {
+	mu     sync.Mutex
+	frames [][]byte
+	// queued counts the bytes of the frames not written yet, including the one being written.
+	queued  int
+	closing bool
+	ready   chan struct{}
+}
+
+func newStdinFeed() *stdinFeed {
+	return &stdinFeed{ready: make(chan struct{}, 1)}
+}
+
+// push queues a frame behind the ones not written yet. It fails if that would queue more than SessionStdinLimit bytes.
+func (f *stdinFeed) push(frame []byte) (err error) {
+	f.mu.Lock()
+
+	switch {
+	case f.closing:
+	case f.queued+len(frame) > SessionStdinLimit:
+		err = fmt.Errorf("process is more than %d bytes of stdin behind", SessionStdinLimit)
+	default:
+		f.frames = append(f.frames, frame)
+		f.queued += len(frame)
+	}
+
+	f.mu.Unlock()
+	f.signal()
+
+	return err
+}
+
+// close closes stdin once the queued frames are written.
+func (f *stdinFeed) close() {
+	f.mu.Lock()
+	f.closing = true
+	f.mu.Unlock()
+	f.signal()
+}
+
+func (f *stdinFeed) signal() {
+	select {
+	case f.ready <- struct{}{}:
+	default:
+	}
+}
+
+// run writes the queued frames to stdin in order until the feed is closed or ctx is done.
+// Once the process is done reading, later frames are dropped.
+func (f *stdinFeed) run(ctx context.Context, stdin *io.PipeWriter) {
+	for {
+		f.mu.Lock()
+		frames, closing := f.frames, f.closing
+		f.frames = nil
+		f.mu.Unlock()
+
+		for _, frame := range frames {
+			stdin.Write(frame)
+			f.mu.Lock()
+			f.queued -= len(frame)
+			f.mu.Unlock()
+		}
+
+		if closing {
+			stdin.Close()
+			return
+		}
+
+		select {
+		case <-f.ready:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+type sessionStreamWriter struct {
+	session *streamSession
+	id      byte
+}
+
+func (w sessionStreamWriter) Write(p []byte) (int, error) {
+	if err := w.session.write(websocket.BinaryMessage, append([]byte{w.id}, p...)); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
@@ -11,6 +11,359 @@ var imageType = graphql.NewObject(graphql.ObjectConfig{
 		"name": &graphql.Field{
 			Type: graphql.String,
 		},
+		"digest": &graphql.Field{
+			Type: graphql.String,
+		},
+		"media_type": &graphql.Field{
+			Type: graphql.String,
+		},
+		"created_at": &graphql.Field{
+			Type: graphql.String,
+		},
+		"updated_at": &graphql.Field{
+			Type: graphql.String,
+		},
+		"labels": &graphql.Field{
+			Type: graphql.NewList(labelType),
+		},
+		"size": &graphql.Field{
+			Type: Int64,
+		},
+		"unpacked_size": &graphql.Field{
+			Type: Int64,
+		},
+		"platforms": &graphql.Field{
+			Type: graphql.NewList(graphql.String),
+		},
+		"present_platforms": &graphql.Field{
+			Type:        graphql.NewList(graphql.String),
+			Description: "The platforms whose content is stored on the node",
+		},
+		"config": &graphql.Field{
+			Type: imageConfigType,
+		},
+	},
+})
+
+var imageConfigType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "ImageConfig",
+	Fields: graphql.Fields{
+		"entrypoint": &graphql.Field{
+			Type: graphql.NewList(graphql.String),
+		},
+		"cmd": &graphql.Field{
+			Type: graphql.NewList(graphql.String),
+		},
+		"env": &graphql.Field{
+			Type: graphql.NewList(graphql.String),
+		},
+		"exposed_ports": &graphql.Field{
+			Type: graphql.NewList(graphql.String),
+		},
+		"user": &graphql.Field{
+			Type: graphql.String,
+		},
+	},
+})
+
+var layerProgressType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "LayerProgress",
+	Fields: graphql.Fields{
+		"digest": &graphql.Field{
+			Type: graphql.String,
+		},
+		"media_type": &graphql.Field{
+			Type: graphql.String,
+		},
+		"status": &graphql.Field{
+			Type: graphql.String,
+		},
+		"offset": &graphql.Field{
+			Type: Int64,
+		},
+		"total": &graphql.Field{
+			Type: Int64,
+		},
+	},
+})
+
+var pullJobType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "PullJob",
+	Fields: graphql.Fields{
+		"ref": &graphql.Field{
+			Type: graphql.String,
+		},
+		"status": &graphql.Field{
+			Type:        graphql.String,
+			Description: "One of resolving, downloading, unpacking, done and failed",
+		},
+		"layers": &graphql.Field{
+			Type: graphql.NewList(layerProgressType),
+		},
+		"offset": &graphql.Field{
+			Type:        Int64,
+			Description: "Bytes of the layers fetched so far",
+		},
+		"total": &graphql.Field{
+			Type:        Int64,
+			Description: "Bytes of the layers known so far",
+		},
+		"started_at": &graphql.Field{
+			Type: graphql.String,
+		},
+		"updated_at": &graphql.Field{
+			Type:        graphql.String,
+			Description: "When the pull last made progress, a running pull that isn't updated is stuck",
+		},
+		"finished_at": &graphql.Field{
+			Type: graphql.String,
+		},
+		"error": &graphql.Field{
+			Type: graphql.String,
+		},
+	},
+})
+
+var prunedImageType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "PrunedImage",
+	Fields: graphql.Fields{
+		"namespace": &graphql.Field{
+			Type: graphql.String,
+		},
+		"name": &graphql.Field{
+			Type: graphql.String,
+		},
+		"reason": &graphql.Field{
+			Type:        graphql.String,
+			Description: "The part of the policy the image is pruned under: keep_last, max_age or disk_usage",
+		},
+		"size": &graphql.Field{
+			Type:        Int64,
+			Description: "Estimated bytes removing the image frees, content and unpacked layers",
+		},
+	},
+})
+
+var imagePruneReportType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "ImagePruneReport",
+	Fields: graphql.Fields{
+		"dry_run": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Whether the images were only reported, not removed",
+		},
+		"images": &graphql.Field{
+			Type: graphql.NewList(prunedImageType),
+		},
+		"freed": &graphql.Field{
+			Type:        Int64,
+			Description: "Estimated bytes freed, the sum of the images' sizes",
+		},
+		"disk_used": &graphql.Field{
+			Type:        Int64,
+			Description: "Bytes used on the image filesystem before pruning",
+		},
+		"disk_capacity": &graphql.Field{
+			Type: Int64,
+		},
+	},
+})
+
+var operationType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Operation",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.String,
+		},
+		"kind": &graphql.Field{
+			Type:        graphql.String,
+			Description: "The mutation the operation runs, pullImage or killTask",
+		},
+		"target": &graphql.Field{
+			Type:        graphql.String,
+			Description: "The image ref or container ID the operation acts on",
+		},
+		"status": &graphql.Field{
+			Type:        graphql.String,
+			Description: "One of running, succeeded, failed and cancelled",
+		},
+		"image": &graphql.Field{
+			Type:        imageType,
+			Description: "The pulled image, once a pull has succeeded",
+		},
+		"error": &graphql.Field{
+			Type: graphql.String,
+		},
+		"started_at": &graphql.Field{
+			Type: graphql.String,
+		},
+		"finished_at": &graphql.Field{
+			Type: graphql.String,
+		},
+	},
+})
+
+var labelType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Label",
+	Fields: graphql.Fields{
+		"key": &graphql.Field{
+			Type: graphql.String,
+		},
+		"value": &graphql.Field{
+			Type: graphql.String,
+		},
+	},
+})
+
+var resourcesType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Resources",
+	Fields: graphql.Fields{
+		"cpu_shares": &graphql.Field{
+			Type: Int64,
+		},
+		"cpu_quota": &graphql.Field{
+			Type: Int64,
+		},
+		"cpu_period": &graphql.Field{
+			Type: Int64,
+		},
+		"memory_limit": &graphql.Field{
+			Type: Int64,
+		},
+		"memory_reservation": &graphql.Field{
+			Type: Int64,
+		},
+		"memory_swap": &graphql.Field{
+			Type: Int64,
+		},
+		"pids_limit": &graphql.Field{
+			Type: Int64,
+		},
+	},
+})
+
+var containerSpecType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "ContainerSpec",
+	Fields: graphql.Fields{
+		"command": &graphql.Field{
+			Type: graphql.NewList(graphql.String),
+		},
+		"args": &graphql.Field{
+			Type: graphql.NewList(graphql.String),
+		},
+		"env": &graphql.Field{
+			Type: graphql.NewList(graphql.String),
+		},
+		"working_dir": &graphql.Field{
+			Type: graphql.String,
+		},
+		"user": &graphql.Field{
+			Type: graphql.String,
+		},
+		"hostname": &graphql.Field{
+			Type: graphql.String,
+		},
+		"labels": &graphql.Field{
+			Type: graphql.NewList(labelType),
+		},
+		"resources": &graphql.Field{
+			Type: resourcesType,
+		},
+		"stdin": &graphql.Field{
+			Type: graphql.Boolean,
+		},
+		"terminal": &graphql.Field{
+			Type: graphql.Boolean,
+		},
+		"restart_policy": &graphql.Field{
+			Type: graphql.String,
+		},
+		"health_check": &graphql.Field{
+			Type: healthCheckType,
+		},
+		"sandbox": &graphql.Field{
+			Type: graphql.String,
+		},
+	},
+})
+
+var healthCheckType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "HealthCheck",
+	Fields: graphql.Fields{
+		"exec": &graphql.Field{
+			Type: graphql.NewList(graphql.String),
+		},
+		"http_get": &graphql.Field{
+			Type: graphql.String,
+		},
+		"tcp_socket": &graphql.Field{
+			Type: graphql.String,
+		},
+		"interval": &graphql.Field{
+			Type: graphql.Int,
+		},
+		"timeout": &graphql.Field{
+			Type: graphql.Int,
+		},
+		"retries": &graphql.Field{
+			Type: graphql.Int,
+		},
+		"start_period": &graphql.Field{
+			Type: graphql.Int,
+		},
+	},
+})
+
+var containerHealthType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "ContainerHealth",
+	Fields: graphql.Fields{
+		"status": &graphql.Field{
+			Type: graphql.String,
+		},
+		"failing_streak": &graphql.Field{
+			Type: graphql.Int,
+		},
+		"log": &graphql.Field{
+			Type: graphql.NewList(healthProbeType),
+		},
+	},
+})
+
+var healthProbeType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "HealthProbe",
+	Fields: graphql.Fields{
+		"start": &graphql.Field{
+			Type: graphql.String,
+		},
+		"end": &graphql.Field{
+			Type: graphql.String,
+		},
+		"passed": &graphql.Field{
+			Type: graphql.Boolean,
+		},
+		"output": &graphql.Field{
+			Type: graphql.String,
+		},
+	},
+})
+
+var containerRestartType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "ContainerRestart",
+	Fields: graphql.Fields{
+		"policy": &graphql.Field{
+			Type: graphql.String,
+		},
+		"count": &graphql.Field{
+			Type: graphql.Int,
+		},
+		"stopped": &graphql.Field{
+			Type: graphql.Boolean,
+		},
+		"last_exit_status": &graphql.Field{
+			Type: graphql.Int,
+		},
+		"last_exit_at": &graphql.Field{
+			Type: graphql.String,
+		},
 	},
 })
 
@@ -23,9 +376,75 @@ var containerType = graphql.NewObject(graphql.ObjectConfig{
 		"image": &graphql.Field{
 			Type: imageType,
 		},
+		"spec": &graphql.Field{
+			Type: containerSpecType,
+		},
 		"task": &graphql.Field{
 			Type: taskType,
 		},
+		"restart": &graphql.Field{
+			Type: containerRestartType,
+		},
+		"health": &graphql.Field{
+			Type: containerHealthType,
+		},
+	},
+})
+
+var podType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Pod",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.String,
+		},
+		"status": &graphql.Field{
+			Type:        graphql.String,
+			Description: "One of running, paused, stopped and degraded, aggregated from the tasks of the sandbox and members",
+		},
+		"sandbox": &graphql.Field{
+			Type:        containerType,
+			Description: "The container holding the pod's network, IPC and PID namespaces",
+		},
+		"members": &graphql.Field{
+			Type: graphql.NewList(containerType),
+		},
+	},
+})
+
+var workloadType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Workload",
+	Fields: graphql.Fields{
+		"name": &graphql.Field{
+			Type: graphql.String,
+		},
+		"image": &graphql.Field{
+			Type: graphql.String,
+		},
+		"state": &graphql.Field{
+			Type:        graphql.String,
+			Description: "Desired state of the workload's task, running or stopped",
+		},
+		"spec": &graphql.Field{
+			Type: containerSpecType,
+		},
+		"synced": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Whether the last reconcile left the container and task matching the workload",
+		},
+		"drift": &graphql.Field{
+			Type:        graphql.NewList(graphql.String),
+			Description: "What the last reconcile found out of place and set right",
+		},
+		"error": &graphql.Field{
+			Type:        graphql.String,
+			Description: "Why the last reconcile failed, it's retried on the next one",
+		},
+		"task_status": &graphql.Field{
+			Type: graphql.String,
+		},
+		"reconciled_at": &graphql.Field{
+			Type: graphql.String,
+		},
 	},
 })
 
@@ -47,6 +466,117 @@ var taskType = graphql.NewObject(graphql.ObjectConfig{
 		"status": &graphql.Field{
 			Type: graphql.String,
 		},
+		"metrics": &graphql.Field{
+			Type: taskMetricsType,
+		},
+	},
+})
+
+var taskMetricsType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "TaskMetrics",
+	Fields: graphql.Fields{
+		"time": &graphql.Field{
+			Type: graphql.String,
+		},
+		"cpu_usage": &graphql.Field{
+			Type: Int64,
+		},
+		"cpu_user": &graphql.Field{
+			Type: Int64,
+		},
+		"cpu_system": &graphql.Field{
+			Type: Int64,
+		},
+		"memory_usage": &graphql.Field{
+			Type: Int64,
+		},
+		"memory_limit": &graphql.Field{
+			Type: Int64,
+		},
+		"memory_cache": &graphql.Field{
+			Type: Int64,
+		},
+		"blkio_read": &graphql.Field{
+			Type: Int64,
+		},
+		"blkio_write": &graphql.Field{
+			Type: Int64,
+		},
+		"pids_current": &graphql.Field{
+			Type: Int64,
+		},
+		"pids_limit": &graphql.Field{
+			Type: Int64,
+		},
+	},
+})
+
+var execResultType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "ExecResult",
+	Fields: graphql.Fields{
+		"exit_code": &graphql.Field{
+			Type: graphql.Int,
+		},
+		"stdout": &graphql.Field{
+			Type: graphql.String,
+		},
+		"stderr": &graphql.Field{
+			Type: graphql.String,
+		},
+		"truncated": &graphql.Field{
+			Type: graphql.Boolean,
+		},
+	},
+})
+
+var logRecordType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "LogRecord",
+	Fields: graphql.Fields{
+		"time": &graphql.Field{
+			Type: graphql.String,
+		},
+		"stream": &graphql.Field{
+			Type: graphql.String,
+		},
+		"line": &graphql.Field{
+			Type: graphql.String,
+		},
+	},
+})
+
+var eventType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Event",
+	Fields: graphql.Fields{
+		"seq": &graphql.Field{
+			Type: Int64,
+		},
+		"time": &graphql.Field{
+			Type: graphql.String,
+		},
+		"namespace": &graphql.Field{
+			Type: graphql.String,
+		},
+		"topic": &graphql.Field{
+			Type: graphql.String,
+		},
+		"image": &graphql.Field{
+			Type: graphql.String,
+		},
+		"container_id": &graphql.Field{
+			Type: graphql.String,
+		},
+		"exec_id": &graphql.Field{
+			Type: graphql.String,
+		},
+		"pid": &graphql.Field{
+			Type: graphql.Int,
+		},
+		"exit_status": &graphql.Field{
+			Type: graphql.Int,
+		},
+		"exited_at": &graphql.Field{
+			Type: graphql.String,
+		},
 	},
 })
 
@@ -72,6 +602,61 @@ func NewImagesField(sp node.ImageService, r graphql.FieldResolveFn, args graphql
 	}
 }
 
+// NewPullJobField creates graphql fields for the pull job type.
+// The pull job field accepts the arguments defined by the given FieldConfigArgument and is resolved by the function r.
+func NewPullJobField(sp node.ImageService, r graphql.FieldResolveFn, args graphql.FieldConfigArgument) (field *graphql.Field) {
+	return &graphql.Field{
+		Type:        pullJobType,
+		Description: "Get image pull progress",
+		Args:        args,
+		Resolve:     r,
+	}
+}
+
+// NewPullJobsField creates graphql fields for the pull job list type.
+// The pull jobs field accepts the arguments defined by the given FieldConfigArgument and is resolved by the function r.
+func NewPullJobsField(sp node.ImageService, r graphql.FieldResolveFn, args graphql.FieldConfigArgument) (field *graphql.Field) {
+	return &graphql.Field{
+		Type:        graphql.NewList(pullJobType),
+		Description: "Get image pull list",
+		Args:        args,
+		Resolve:     r,
+	}
+}
+
+// NewImagePruneReportField creates graphql fields for the image prune report type.
+// The field accepts the arguments defined by the given FieldConfigArgument and is resolved by the function r.
+func NewImagePruneReportField(sp node.ImageService, r graphql.FieldResolveFn, args graphql.FieldConfigArgument) (field *graphql.Field) {
+	return &graphql.Field{
+		Type:        imagePruneReportType,
+		Description: "Prune images under the node's image GC policy",
+		Args:        args,
+		Resolve:     r,
+	}
+}
+
+// NewOperationField creates graphql fields for the operation type.
+// The operation field accepts the arguments defined by the given FieldConfigArgument and is resolved by the function r.
+func NewOperationField(sp node.OperationService, r graphql.FieldResolveFn, args graphql.FieldConfigArgument) (field *graphql.Field) {
+	return &graphql.Field{
+		Type:        operationType,
+		Description: "Get background operation",
+		Args:        args,
+		Resolve:     r,
+	}
+}
+
+// NewOperationsField creates graphql fields for the operation list type.
+// The operations field accepts the arguments defined by the given FieldConfigArgument and is resolved by the function r.
+func NewOperationsField(sp node.OperationService, r graphql.FieldResolveFn, args graphql.FieldConfigArgument) (field *graphql.Field) {
+	return &graphql.Field{
+		Type:        graphql.NewList(operationType),
+		Description: "Get background operation list",
+		Args:        args,
+		Resolve:     r,
+	}
+}
+
 // NewContainerField creates graphql fields for the container type.
 // The container field accepts the arguments defined by the given FieldConfigArgument and is resolved by the function r.
 func NewContainerField(sp node.ContainerService, r graphql.FieldResolveFn, args graphql.FieldConfigArgument) (field *graphql.Field) {
@@ -115,3 +700,80 @@ func NewTasksField(sp node.TaskService, r graphql.FieldResolveFn, args graphql.F
 		Resolve:     r,
 	}
 }
+
+// NewLogsField creates graphql fields for the log record list type.
+// The logs field accepts the arguments defined by the given FieldConfigArgument and is resolved by the function r.
+func NewLogsField(sp node.LogService, r graphql.FieldResolveFn, args graphql.FieldConfigArgument) (field *graphql.Field) {
+	return &graphql.Field{
+		Type:        graphql.NewList(logRecordType),
+		Description: "Get task output",
+		Args:        args,
+		Resolve:     r,
+	}
+}
+
+// NewEventField creates graphql fields for the event type.
+// The event field accepts the arguments defined by the given FieldConfigArgument and is resolved by the function r.
+func NewEventField(sp node.EventService, r graphql.FieldResolveFn, args graphql.FieldConfigArgument) (field *graphql.Field) {
+	return &graphql.Field{
+		Type:        eventType,
+		Description: "Watch runtime events",
+		Args:        args,
+		Resolve:     r,
+	}
+}
+
+// NewExecResultField creates graphql fields for the exec result type.
+// The exec result field accepts the arguments defined by the given FieldConfigArgument and is resolved by the function r.
+func NewExecResultField(sp node.TaskService, r graphql.FieldResolveFn, args graphql.FieldConfigArgument) (field *graphql.Field) {
+	return &graphql.Field{
+		Type:        execResultType,
+		Description: "Run a process in a task",
+		Args:        args,
+		Resolve:     r,
+	}
+}
+
+// NewWorkloadField creates graphql fields for the workload type.
+// The workload field accepts the arguments defined by the given FieldConfigArgument and is resolved by the function r.
+func NewWorkloadField(sp node.WorkloadService, r graphql.FieldResolveFn, args graphql.FieldConfigArgument) (field *graphql.Field) {
+	return &graphql.Field{
+		Type:        workloadType,
+		Description: "Get workload",
+		Args:        args,
+		Resolve:     r,
+	}
+}
+
+// NewWorkloadsField creates graphql fields for the workload list type.
+// The workloads field accepts the arguments defined by the given FieldConfigArgument and is resolved by the function r.
+func NewWorkloadsField(sp node.WorkloadService, r graphql.FieldResolveFn, args graphql.FieldConfigArgument) (field *graphql.Field) {
+	return &graphql.Field{
+		Type:        graphql.NewList(workloadType),
+		Description: "Get workload list",
+		Args:        args,
+		Resolve:     r,
+	}
+}
+
+// NewPodField creates graphql fields for the pod type.
+// The pod field accepts the arguments defined by the given FieldConfigArgument and is resolved by the function r.
+func NewPodField(sp node.PodService, r graphql.FieldResolveFn, args graphql.FieldConfigArgument) (field *graphql.Field) {
+	return &graphql.Field{
+		Type:        podType,
+		Description: "Get pod",
+		Args:        args,
+		Resolve:     r,
+	}
+}
+
+// NewPodsField creates graphql fields for the pod list type.
+// The pods field accepts the arguments defined by the given FieldConfigArgument and is resolved by the function r.
+func NewPodsField(sp node.PodService, r graphql.FieldResolveFn, args graphql.FieldConfigArgument) (field *graphql.Field) {
+	return &graphql.Field{
+		Type:        graphql.NewList(podType),
+		Description: "Get pod list",
+		Args:        args,
+		Resolve:     r,
+	}
+}
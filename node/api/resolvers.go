@@ -3,8 +3,12 @@ package api
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/containerd/containerd/namespaces"
+	"github.com/containerd/containerd/platforms"
 	"github.com/graphql-go/graphql"
 	"github.com/mokrz/clamor/node"
 )
@@ -15,63 +19,517 @@ type ResolverSet struct {
 	ImageResolver,
 	ImagesResolver,
 	DeleteImageResolver,
+	TagImageResolver,
+	UntagImageResolver,
 	CreateContainerResolver,
 	ContainerResolver,
 	ContainersResolver,
 	DeleteContainerResolver,
+	UpdateContainerResourcesResolver,
 	CreateTaskResolver,
 	TaskResolver,
 	TasksResolver,
 	DeleteTaskResolver,
-	KillTaskResolver graphql.FieldResolveFn
+	KillTaskResolver,
+	PauseTaskResolver,
+	ResumeTaskResolver,
+	ExecTaskResolver,
+	LogsResolver,
+	EventsResolver,
+	PullJobResolver,
+	PullJobsResolver,
+	FollowPullJobResolver,
+	CreateImageAsyncResolver,
+	KillTaskAsyncResolver,
+	OperationResolver,
+	OperationsResolver,
+	CancelOperationResolver,
+	PruneImagesResolver,
+	ApplyResolver,
+	WorkloadResolver,
+	WorkloadsResolver,
+	DeleteWorkloadResolver,
+	CreatePodResolver,
+	PodResolver,
+	PodsResolver,
+	DeletePodResolver graphql.FieldResolveFn
 }
 
 // NewResolverSet creates ResolverSet methods. The created resolvers interact with the node via the given node.Service implementation.
 func NewResolverSet(svc node.Service) *ResolverSet {
 	return &ResolverSet{
-		CreateImageResolver:     NewCreateImageResolver(svc),
-		ImageResolver:           NewImageResolver(svc),
-		ImagesResolver:          NewImagesResolver(svc),
-		DeleteImageResolver:     NewDeleteImageResolver(svc),
-		CreateContainerResolver: NewCreateContainerResolver(svc),
-		ContainerResolver:       NewContainerResolver(svc),
-		ContainersResolver:      NewContainersResolver(svc),
-		DeleteContainerResolver: NewDeleteContainerResolver(svc),
-		CreateTaskResolver:      NewCreateTaskResolver(svc),
-		TaskResolver:            NewTaskResolver(svc),
-		TasksResolver:           NewTasksResolver(svc),
-		DeleteTaskResolver:      NewDeleteTaskResolver(svc),
-		KillTaskResolver:        NewKillTaskResolver(svc),
+		CreateImageResolver:              NewCreateImageResolver(svc),
+		ImageResolver:                    NewImageResolver(svc),
+		ImagesResolver:                   NewImagesResolver(svc),
+		DeleteImageResolver:              NewDeleteImageResolver(svc),
+		TagImageResolver:                 NewTagImageResolver(svc),
+		UntagImageResolver:               NewUntagImageResolver(svc),
+		CreateContainerResolver:          NewCreateContainerResolver(svc),
+		ContainerResolver:                NewContainerResolver(svc),
+		ContainersResolver:               NewContainersResolver(svc),
+		DeleteContainerResolver:          NewDeleteContainerResolver(svc),
+		UpdateContainerResourcesResolver: NewUpdateContainerResourcesResolver(svc),
+		CreateTaskResolver:               NewCreateTaskResolver(svc),
+		TaskResolver:                     NewTaskResolver(svc),
+		TasksResolver:                    NewTasksResolver(svc),
+		DeleteTaskResolver:               NewDeleteTaskResolver(svc),
+		KillTaskResolver:                 NewKillTaskResolver(svc),
+		PauseTaskResolver:                NewPauseTaskResolver(svc),
+		ResumeTaskResolver:               NewResumeTaskResolver(svc),
+		ExecTaskResolver:                 NewExecTaskResolver(svc),
+		LogsResolver:                     NewLogsResolver(svc),
+		EventsResolver:                   NewEventsResolver(svc),
+		PullJobResolver:                  NewPullJobResolver(svc),
+		PullJobsResolver:                 NewPullJobsResolver(svc),
+		FollowPullJobResolver:            NewFollowPullJobResolver(svc),
+		CreateImageAsyncResolver:         NewCreateImageAsyncResolver(svc),
+		KillTaskAsyncResolver:            NewKillTaskAsyncResolver(svc),
+		OperationResolver:                NewOperationResolver(svc),
+		OperationsResolver:               NewOperationsResolver(svc),
+		CancelOperationResolver:          NewCancelOperationResolver(svc),
+		PruneImagesResolver:              NewPruneImagesResolver(svc),
+		ApplyResolver:                    NewApplyResolver(svc),
+		WorkloadResolver:                 NewWorkloadResolver(svc),
+		WorkloadsResolver:                NewWorkloadsResolver(svc),
+		DeleteWorkloadResolver:           NewDeleteWorkloadResolver(svc),
+		CreatePodResolver:                NewCreatePodResolver(svc),
+		PodResolver:                      NewPodResolver(svc),
+		PodsResolver:                     NewPodsResolver(svc),
+		DeletePodResolver:                NewDeletePodResolver(svc),
 	}
 }
 
 // Image holds metadata for a container image.
-// TODO: Add image properties (size, age, etc.).
 type Image struct {
-	Name string `json:"name"`
+	Name         string   `json:"name"`
+	Digest       string   `json:"digest"`
+	MediaType    string   `json:"media_type"`
+	CreatedAt    string   `json:"created_at"`
+	UpdatedAt    string   `json:"updated_at"`
+	Labels       []Label  `json:"labels"`
+	Size         int64    `json:"size"`
+	UnpackedSize int64    `json:"unpacked_size"`
+	Platforms    []string `json:"platforms"`
+	// PresentPlatforms lists the platforms whose content was pulled, out of Platforms.
+	PresentPlatforms []string     `json:"present_platforms"`
+	Config           *ImageConfig `json:"config"`
+}
+
+// ImageConfig holds the defaults an image sets for the containers created from it.
+type ImageConfig struct {
+	Entrypoint   []string `json:"entrypoint"`
+	Cmd          []string `json:"cmd"`
+	Env          []string `json:"env"`
+	ExposedPorts []string `json:"exposed_ports"`
+	User         string   `json:"user"`
+}
+
+// PullJob holds the progress of an image pull.
+type PullJob struct {
+	Ref        string          `json:"ref"`
+	Status     string          `json:"status"`
+	Layers     []LayerProgress `json:"layers"`
+	Offset     int64           `json:"offset"`
+	Total      int64           `json:"total"`
+	StartedAt  string          `json:"started_at"`
+	UpdatedAt  string          `json:"updated_at"`
+	FinishedAt string          `json:"finished_at"`
+	Error      string          `json:"error"`
+}
+
+// LayerProgress holds the download progress of an image layer.
+type LayerProgress struct {
+	Digest    string `json:"digest"`
+	MediaType string `json:"media_type"`
+	Status    string `json:"status"`
+	Offset    int64  `json:"offset"`
+	Total     int64  `json:"total"`
+}
+
+// ImagePruneReport holds the outcome of a run of the image garbage collector.
+type ImagePruneReport struct {
+	DryRun       bool          `json:"dry_run"`
+	Images       []PrunedImage `json:"images"`
+	Freed        int64         `json:"freed"`
+	DiskUsed     int64         `json:"disk_used"`
+	DiskCapacity int64         `json:"disk_capacity"`
+}
+
+// PrunedImage holds an image the image garbage collector removed, or would remove on a dry run, and why.
+type PrunedImage struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Reason    string `json:"reason"`
+	Size      int64  `json:"size"`
+}
+
+// Operation holds the state of a mutation running in the background. Image is set once a createImageAsync operation has succeeded.
+type Operation struct {
+	ID         string `json:"id"`
+	Kind       string `json:"kind"`
+	Target     string `json:"target"`
+	Status     string `json:"status"`
+	Image      *Image `json:"image"`
+	Error      string `json:"error"`
+	StartedAt  string `json:"started_at"`
+	FinishedAt string `json:"finished_at"`
 }
 
 // Container holds metadata for a container.
 // TODO: Add container properties (size, age, etc.).
 type Container struct {
-	ID    string `json:"id"`
-	Image Image  `json:"image"`
-	Task  Task   `json:"task"`
+	ID    string        `json:"id"`
+	Image Image         `json:"image"`
+	Spec  ContainerSpec `json:"spec"`
+	Task  Task          `json:"task"`
+	// Restart is nil for containers without a restart policy.
+	Restart *ContainerRestart `json:"restart"`
+	// Health is nil for containers without a health check or without a running task.
+	Health *ContainerHealth `json:"health"`
+}
+
+// Pod holds a pod's sandbox container and the member containers that share its namespaces.
+type Pod struct {
+	ID      string      `json:"id"`
+	Status  string      `json:"status"`
+	Sandbox Container   `json:"sandbox"`
+	Members []Container `json:"members"`
+}
+
+// Workload holds a workload applied to the node, and what its last reconcile found.
+type Workload struct {
+	Name         string        `json:"name"`
+	Image        string        `json:"image"`
+	State        string        `json:"state"`
+	Spec         ContainerSpec `json:"spec"`
+	Synced       bool          `json:"synced"`
+	Drift        []string      `json:"drift"`
+	Error        string        `json:"error"`
+	TaskStatus   string        `json:"task_status"`
+	ReconciledAt string        `json:"reconciled_at"`
+}
+
+// ContainerHealth holds the health of a container's running task and the results of its most recent probes.
+type ContainerHealth struct {
+	Status        string        `json:"status"`
+	FailingStreak int           `json:"failing_streak"`
+	Log           []HealthProbe `json:"log"`
+}
+
+// HealthProbe is the result of a single health probe.
+type HealthProbe struct {
+	Start  string `json:"start"`
+	End    string `json:"end"`
+	Passed bool   `json:"passed"`
+	Output string `json:"output"`
+}
+
+// ContainerRestart holds a container's restart policy and what the restart supervisor recorded about it.
+type ContainerRestart struct {
+	Policy         string `json:"policy"`
+	Count          int    `json:"count"`
+	Stopped        bool   `json:"stopped"`
+	LastExitStatus uint32 `json:"last_exit_status"`
+	LastExitAt     string `json:"last_exit_at"`
+}
+
+// ContainerSpec holds the process settings a container was created with.
+type ContainerSpec struct {
+	Command       []string  `json:"command"`
+	Args          []string  `json:"args"`
+	Env           []string  `json:"env"`
+	WorkingDir    string    `json:"working_dir"`
+	User          string    `json:"user"`
+	Hostname      string    `json:"hostname"`
+	Labels        []Label   `json:"labels"`
+	Resources     Resources `json:"resources"`
+	Stdin         bool      `json:"stdin"`
+	Terminal      bool      `json:"terminal"`
+	RestartPolicy string    `json:"restart_policy"`
+	// HealthCheck is nil for containers that aren't probed.
+	HealthCheck *HealthCheck `json:"health_check"`
+	Sandbox     string       `json:"sandbox"`
+}
+
+// HealthCheck describes how a container's running task is probed. Durations are in seconds.
+type HealthCheck struct {
+	Exec        []string `json:"exec"`
+	HTTPGet     string   `json:"http_get"`
+	TCPSocket   string   `json:"tcp_socket"`
+	Interval    int      `json:"interval"`
+	Timeout     int      `json:"timeout"`
+	Retries     int      `json:"retries"`
+	StartPeriod int      `json:"start_period"`
+}
+
+// Resources holds a container's cgroup limits. Zero values mean the limit is unset.
+type Resources struct {
+	CPUShares         uint64 `json:"cpu_shares"`
+	CPUQuota          int64  `json:"cpu_quota"`
+	CPUPeriod         uint64 `json:"cpu_period"`
+	MemoryLimit       int64  `json:"memory_limit"`
+	MemoryReservation int64  `json:"memory_reservation"`
+	MemorySwap        int64  `json:"memory_swap"`
+	PidsLimit         int64  `json:"pids_limit"`
+}
+
+// Label is a single key/value pair from a label map.
+type Label struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
 }
 
 // Task holds metadata for a container task.
-// TODO: Add task properties (PIDs, metrics, etc.).
+// Metrics is nil when the runtime can't report the task's resource usage, e.g. because it has exited.
 type Task struct {
-	ID          string   `json:"id"`
-	ContainerID string   `json:"container_id"`
-	PID         uint32   `json:"pid"`
-	PIDs        []uint32 `json:"pids"`
-	Status      string   `json:"status"`
+	ID          string       `json:"id"`
+	ContainerID string       `json:"container_id"`
+	PID         uint32       `json:"pid"`
+	PIDs        []uint32     `json:"pids"`
+	Status      string       `json:"status"`
+	Metrics     *TaskMetrics `json:"metrics"`
+}
+
+// TaskMetrics holds a task's resource usage. CPU times are in nanoseconds, memory and IO in bytes. Zero limits mean the limit is unset.
+type TaskMetrics struct {
+	Time        string `json:"time"`
+	CPUUsage    uint64 `json:"cpu_usage"`
+	CPUUser     uint64 `json:"cpu_user"`
+	CPUSystem   uint64 `json:"cpu_system"`
+	MemoryUsage uint64 `json:"memory_usage"`
+	MemoryLimit uint64 `json:"memory_limit"`
+	MemoryCache uint64 `json:"memory_cache"`
+	BlkioRead   uint64 `json:"blkio_read"`
+	BlkioWrite  uint64 `json:"blkio_write"`
+	PidsCurrent uint64 `json:"pids_current"`
+	PidsLimit   uint64 `json:"pids_limit"`
+}
+
+// ExecResult holds the outcome of a process exec'd to completion.
+// Each output stream keeps its first execOutputLimit bytes, Truncated reports whether anything was cut.
+type ExecResult struct {
+	ExitCode  uint32 `json:"exit_code"`
+	Stdout    string `json:"stdout"`
+	Stderr    string `json:"stderr"`
+	Truncated bool   `json:"truncated"`
+}
+
+// LogRecord holds a single line of task output.
+type LogRecord struct {
+	Time   string `json:"time"`
+	Stream string `json:"stream"`
+	Line   string `json:"line"`
+}
+
+// Event holds a runtime event. Fields that don't apply to its topic are empty.
+type Event struct {
+	Seq         uint64 `json:"seq"`
+	Time        string `json:"time"`
+	Namespace   string `json:"namespace"`
+	Topic       string `json:"topic"`
+	Image       string `json:"image"`
+	ContainerID string `json:"container_id"`
+	ExecID      string `json:"exec_id"`
+	PID         uint32 `json:"pid"`
+	ExitStatus  uint32 `json:"exit_status"`
+	ExitedAt    string `json:"exited_at"`
+}
+
+func getEventInfo(e node.Event) Event {
+	event := Event{
+		Seq:         e.Seq,
+		Time:        e.Timestamp.Format(time.RFC3339Nano),
+		Namespace:   e.Namespace,
+		Topic:       e.Topic,
+		Image:       e.Image,
+		ContainerID: e.ContainerID,
+		ExecID:      e.ExecID,
+		PID:         e.Pid,
+		ExitStatus:  e.ExitStatus,
+	}
+
+	if !e.ExitedAt.IsZero() {
+		event.ExitedAt = e.ExitedAt.Format(time.RFC3339Nano)
+	}
+
+	return event
+}
+
+func getLogRecordInfo(r node.LogRecord) LogRecord {
+	return LogRecord{
+		Time:   r.Time.Format(time.RFC3339Nano),
+		Stream: r.Stream,
+		Line:   r.Line,
+	}
 }
 
 func getImageInfo(ctx context.Context, i node.Image) Image {
-	return Image{
-		Name: i.Name(),
+	target := i.Target()
+	image := Image{
+		Name:      i.Name(),
+		Digest:    target.Digest.String(),
+		MediaType: target.MediaType,
+		CreatedAt: i.CreatedAt().Format(time.RFC3339Nano),
+		UpdatedAt: i.UpdatedAt().Format(time.RFC3339Nano),
+		Labels:    getLabels(i.Labels()),
+		Config:    getImageConfig(i.Config(ctx)),
+	}
+
+	// Sizes and platforms are left empty when the image's content can't be read, e.g. while it's being garbage collected.
+	image.Size, _ = i.Size(ctx)
+	image.UnpackedSize, _ = i.UnpackedSize(ctx)
+
+	if ps, psErr := i.Platforms(ctx); psErr == nil {
+		for _, p := range ps {
+			image.Platforms = append(image.Platforms, platforms.Format(p))
+		}
+	}
+
+	if ps, psErr := i.PresentPlatforms(ctx); psErr == nil {
+		for _, p := range ps {
+			image.PresentPlatforms = append(image.PresentPlatforms, platforms.Format(p))
+		}
+	}
+
+	return image
+}
+
+func getImageConfig(c node.ImageConfig, err error) *ImageConfig {
+	if err != nil {
+		return nil
+	}
+
+	return &ImageConfig{
+		Entrypoint:   c.Entrypoint,
+		Cmd:          c.Cmd,
+		Env:          c.Env,
+		ExposedPorts: c.ExposedPorts,
+		User:         c.User,
+	}
+}
+
+func getPullJobInfo(j node.PullJob) PullJob {
+	job := PullJob{
+		Ref:       j.Ref,
+		Status:    j.Status,
+		Offset:    j.Offset,
+		Total:     j.Total,
+		StartedAt: j.StartedAt.Format(time.RFC3339Nano),
+		UpdatedAt: j.UpdatedAt.Format(time.RFC3339Nano),
+		Error:     j.Error,
+	}
+
+	if j.Finished() {
+		job.FinishedAt = j.FinishedAt.Format(time.RFC3339Nano)
+	}
+
+	for _, l := range j.Layers {
+		job.Layers = append(job.Layers, LayerProgress{
+			Digest:    l.Digest,
+			MediaType: l.MediaType,
+			Status:    l.Status,
+			Offset:    l.Offset,
+			Total:     l.Total,
+		})
+	}
+
+	return job
+}
+
+// NewPullJobResolver returns a graphql resolver that looks up the running or most recent pull of the given image ref in the given namespace
+func NewPullJobResolver(svc node.ImageService) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			namespace, ref           string
+			job                      node.PullJob
+			namespaceValid, refValid bool
+			getJobErr                error
+		)
+
+		if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+			return nil, fmt.Errorf("invalid request")
+		}
+
+		if ref, refValid = p.Args["ref"].(string); !refValid {
+			return nil, fmt.Errorf("invalid request")
+		}
+
+		if job, getJobErr = svc.GetPullJob(namespaces.WithNamespace(context.Background(), namespace), ref); getJobErr != nil {
+			return nil, fmt.Errorf("pullJob resolver failed: %w", getJobErr)
+		}
+
+		return getPullJobInfo(job), nil
+	}
+}
+
+// NewPullJobsResolver returns a graphql resolver that looks up the running and recently finished pulls in the given namespace
+func NewPullJobsResolver(svc node.ImageService) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			namespace      string
+			jobs           []node.PullJob
+			namespaceValid bool
+			getJobsErr     error
+		)
+
+		if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+			return nil, fmt.Errorf("invalid request")
+		}
+
+		if jobs, getJobsErr = svc.GetPullJobs(namespaces.WithNamespace(context.Background(), namespace)); getJobsErr != nil {
+			return nil, fmt.Errorf("pullJobs resolver failed: %w", getJobsErr)
+		}
+
+		var decoratedJobs []PullJob
+
+		for _, job := range jobs {
+			decoratedJobs = append(decoratedJobs, getPullJobInfo(job))
+		}
+
+		return decoratedJobs, nil
+	}
+}
+
+// NewFollowPullJobResolver returns a graphql resolver that streams the progress of the running or next pull of the given image ref in the given namespace.
+// Like NewEventsResolver, it only streams when NewEventsHandler executes it, and resolves each progress update from the root object.
+func NewFollowPullJobResolver(svc node.ImageService) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			namespace, ref                        string
+			sub                                   *subscription
+			namespaceValid, refValid, streamValid bool
+		)
+
+		if job, isJob := subscriptionPullJob(p.Source); isJob {
+			return getPullJobInfo(job), nil
+		}
+
+		if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+			return nil, fmt.Errorf("invalid request")
+		}
+
+		if ref, refValid = p.Args["ref"].(string); !refValid {
+			return nil, fmt.Errorf("invalid request")
+		}
+
+		if p.Context != nil {
+			sub, streamValid = p.Context.Value(subscriptionKey{}).(*subscription)
+		}
+
+		if !streamValid {
+			return nil, fmt.Errorf("pullJob resolver failed: subscriptions are streamed from /events")
+		}
+
+		ctx := namespaces.WithNamespace(p.Context, namespace)
+		jobs, followErr := svc.FollowPullJob(ctx, ref)
+
+		if followErr != nil {
+			return nil, fmt.Errorf("pullJob resolver failed: %w", followErr)
+		}
+
+		sub.pullJobs(ctx, jobs)
+
+		return nil, nil
 	}
 }
 
@@ -139,10 +597,105 @@ func NewImagesResolver(svc node.ImageService) graphql.FieldResolveFn {
 	}
 }
 
-func getContainerInfo(ctx context.Context, c node.Container) Container {
+func getLabels(m map[string]string) (labels []Label) {
+	for k, v := range m {
+		labels = append(labels, Label{Key: k, Value: v})
+	}
+
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Key < labels[j].Key })
+
+	return labels
+}
+
+func getContainerSpecInfo(s node.ContainerSpec) ContainerSpec {
+	return ContainerSpec{
+		Command:       s.Command,
+		Args:          s.Args,
+		Env:           s.Env,
+		WorkingDir:    s.WorkingDir,
+		User:          s.User,
+		Hostname:      s.Hostname,
+		Labels:        getLabels(s.Labels),
+		Resources:     Resources(s.Resources),
+		Stdin:         s.Stdin,
+		Terminal:      s.Terminal,
+		RestartPolicy: s.RestartPolicy,
+		Sandbox:       s.Sandbox,
+		HealthCheck:   getHealthCheckInfo(s.HealthCheck),
+	}
+}
+
+func getHealthCheckInfo(c *node.HealthCheck) *HealthCheck {
+	if c == nil {
+		return nil
+	}
+
+	return &HealthCheck{
+		Exec:        c.Exec,
+		HTTPGet:     c.HTTPGet,
+		TCPSocket:   c.TCPSocket,
+		Interval:    int(time.Duration(c.Interval) / time.Second),
+		Timeout:     int(time.Duration(c.Timeout) / time.Second),
+		Retries:     c.Retries,
+		StartPeriod: int(time.Duration(c.StartPeriod) / time.Second),
+	}
+}
+
+// getContainerHealthInfo looks up the health of a container's running task, nil if it isn't probed.
+func getContainerHealthInfo(ctx context.Context, svc node.ContainerService, id string) *ContainerHealth {
+	health, healthErr := svc.GetContainerHealth(ctx, id)
+
+	if healthErr != nil || health.Status == "" {
+		return nil
+	}
+
+	info := &ContainerHealth{Status: health.Status, FailingStreak: health.FailingStreak, Log: []HealthProbe{}}
+
+	for _, probe := range health.Log {
+		info.Log = append(info.Log, HealthProbe{
+			Start:  probe.Start.Format(time.RFC3339Nano),
+			End:    probe.End.Format(time.RFC3339Nano),
+			Passed: probe.Passed,
+			Output: probe.Output,
+		})
+	}
+
+	return info
+}
+
+// getContainerRestartInfo reads the restart status off a container's labels, nil if it has no restart policy.
+func getContainerRestartInfo(ctx context.Context, c node.Container) *ContainerRestart {
+	labels, labelsErr := c.Labels(ctx)
+
+	if labelsErr != nil {
+		return nil
+	}
+
+	status, supervised, statusErr := node.ParseRestartStatus(labels)
+
+	if !supervised || statusErr != nil {
+		return nil
+	}
+
+	restart := &ContainerRestart{
+		Policy:         status.Policy.String(),
+		Count:          status.Count,
+		Stopped:        status.Stopped,
+		LastExitStatus: status.LastExitStatus,
+	}
+
+	if !status.LastExitAt.IsZero() {
+		restart.LastExitAt = status.LastExitAt.Format(time.RFC3339Nano)
+	}
+
+	return restart
+}
+
+func getContainerInfo(ctx context.Context, svc node.ContainerService, c node.Container) Container {
 	var (
 		containerTask           node.Task
 		containerImage          node.Image
+		containerSpec           node.ContainerSpec
 		getTaskErr, getImageErr error
 	)
 
@@ -150,19 +703,215 @@ func getContainerInfo(ctx context.Context, c node.Container) Container {
 		return Container{}
 	}
 
+	containerSpec, _ = c.Spec(ctx)
+
 	if containerTask, getTaskErr = c.Task(ctx, nil); getTaskErr != nil {
 		return Container{
-			ID:    c.ID(),
-			Image: getImageInfo(ctx, containerImage),
-			Task:  Task{},
+			ID:      c.ID(),
+			Image:   getImageInfo(ctx, containerImage),
+			Spec:    getContainerSpecInfo(containerSpec),
+			Task:    Task{},
+			Restart: getContainerRestartInfo(ctx, c),
+			Health:  getContainerHealthInfo(ctx, svc, c.ID()),
 		}
 	}
 
 	return Container{
-		ID:    c.ID(),
-		Image: getImageInfo(ctx, containerImage),
-		Task:  getTaskInfo(ctx, containerTask),
+		ID:      c.ID(),
+		Image:   getImageInfo(ctx, containerImage),
+		Spec:    getContainerSpecInfo(containerSpec),
+		Task:    getTaskInfo(ctx, containerTask),
+		Restart: getContainerRestartInfo(ctx, c),
+		Health:  getContainerHealthInfo(ctx, svc, c.ID()),
+	}
+}
+
+// getStrings converts a graphql list argument into a string slice.
+func getStrings(raw interface{}) (strs []string, err error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	items, itemsValid := raw.([]interface{})
+
+	if !itemsValid {
+		return nil, fmt.Errorf("invalid request")
+	}
+
+	for _, item := range items {
+		str, strValid := item.(string)
+
+		if !strValid {
+			return nil, fmt.Errorf("invalid request")
+		}
+
+		strs = append(strs, str)
+	}
+
+	return strs, nil
+}
+
+// getLabelMap converts a graphql LabelInput list argument into a label map.
+func getLabelMap(raw interface{}) (labels map[string]string, err error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	items, itemsValid := raw.([]interface{})
+
+	if !itemsValid {
+		return nil, fmt.Errorf("invalid request")
+	}
+
+	labels = make(map[string]string)
+
+	for _, item := range items {
+		label, labelValid := item.(map[string]interface{})
+
+		if !labelValid {
+			return nil, fmt.Errorf("invalid request")
+		}
+
+		key, keyValid := label["key"].(string)
+		value, _ := label["value"].(string)
+
+		if !keyValid {
+			return nil, fmt.Errorf("invalid request")
+		}
+
+		labels[key] = value
+	}
+
+	return labels, nil
+}
+
+// getResources converts a graphql ResourcesInput argument into node.Resources.
+func getResources(raw interface{}) (resources node.Resources, err error) {
+	if raw == nil {
+		return resources, nil
+	}
+
+	input, inputValid := raw.(map[string]interface{})
+
+	if !inputValid {
+		return resources, fmt.Errorf("invalid request")
+	}
+
+	for field, dst := range map[string]*int64{
+		"cpu_quota":          &resources.CPUQuota,
+		"memory_limit":       &resources.MemoryLimit,
+		"memory_reservation": &resources.MemoryReservation,
+		"memory_swap":        &resources.MemorySwap,
+		"pids_limit":         &resources.PidsLimit,
+	} {
+		if input[field] == nil {
+			continue
+		}
+
+		if *dst, err = getInt64(input[field]); err != nil {
+			return resources, err
+		}
+	}
+
+	for field, dst := range map[string]*uint64{
+		"cpu_shares": &resources.CPUShares,
+		"cpu_period": &resources.CPUPeriod,
+	} {
+		if input[field] == nil {
+			continue
+		}
+
+		v, vErr := getInt64(input[field])
+
+		if vErr != nil || v < 0 {
+			return resources, fmt.Errorf("invalid request")
+		}
+
+		*dst = uint64(v)
+	}
+
+	return resources, nil
+}
+
+// getContainerSpec converts a graphql ContainerSpecInput argument into a node.ContainerSpec.
+func getContainerSpec(raw interface{}) (spec node.ContainerSpec, err error) {
+	if raw == nil {
+		return spec, nil
+	}
+
+	input, inputValid := raw.(map[string]interface{})
+
+	if !inputValid {
+		return spec, fmt.Errorf("invalid request")
+	}
+
+	if spec.Command, err = getStrings(input["command"]); err != nil {
+		return spec, err
+	}
+
+	if spec.Args, err = getStrings(input["args"]); err != nil {
+		return spec, err
+	}
+
+	if spec.Env, err = getStrings(input["env"]); err != nil {
+		return spec, err
+	}
+
+	if spec.Labels, err = getLabelMap(input["labels"]); err != nil {
+		return spec, err
+	}
+
+	if spec.Resources, err = getResources(input["resources"]); err != nil {
+		return spec, err
+	}
+
+	spec.WorkingDir, _ = input["working_dir"].(string)
+	spec.User, _ = input["user"].(string)
+	spec.Hostname, _ = input["hostname"].(string)
+	spec.Stdin, _ = input["stdin"].(bool)
+	spec.Terminal, _ = input["terminal"].(bool)
+	spec.RestartPolicy, _ = input["restart_policy"].(string)
+	spec.Sandbox, _ = input["sandbox"].(string)
+
+	if spec.HealthCheck, err = getHealthCheck(input["health_check"]); err != nil {
+		return spec, err
+	}
+
+	return spec, nil
+}
+
+// getHealthCheck converts a graphql HealthCheckInput argument into a node.HealthCheck, nil if it's unset.
+func getHealthCheck(raw interface{}) (check *node.HealthCheck, err error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	input, inputValid := raw.(map[string]interface{})
+
+	if !inputValid {
+		return nil, fmt.Errorf("invalid request")
+	}
+
+	check = &node.HealthCheck{}
+
+	if check.Exec, err = getStrings(input["exec"]); err != nil {
+		return nil, err
+	}
+
+	check.HTTPGet, _ = input["http_get"].(string)
+	check.TCPSocket, _ = input["tcp_socket"].(string)
+	check.Retries, _ = input["retries"].(int)
+
+	for field, dst := range map[string]*node.Duration{
+		"interval":     &check.Interval,
+		"timeout":      &check.Timeout,
+		"start_period": &check.StartPeriod,
+	} {
+		seconds, _ := input[field].(int)
+		*dst = node.Duration(time.Duration(seconds) * time.Second)
 	}
+
+	return check, nil
 }
 
 // NewContainerResolver returns a graphql resolver that looks up the given container ID in the given namespace
@@ -189,7 +938,7 @@ func NewContainerResolver(svc node.ContainerService) graphql.FieldResolveFn {
 			return nil, fmt.Errorf("container resolver failed: %w", getContainerErr)
 		}
 
-		return getContainerInfo(ctx, container), nil
+		return getContainerInfo(ctx, svc, container), nil
 	}
 }
 
@@ -222,7 +971,7 @@ func NewContainersResolver(svc node.ContainerService) graphql.FieldResolveFn {
 
 		var decoratedContainers []Container
 		for _, container := range containers {
-			decoratedContainers = append(decoratedContainers, getContainerInfo(ctx, container))
+			decoratedContainers = append(decoratedContainers, getContainerInfo(ctx, svc, container))
 		}
 
 		return decoratedContainers, nil
@@ -241,10 +990,31 @@ func getPIDs(s []node.ProcessInfo, e error) (ret []uint32) {
 
 func getTaskInfo(ctx context.Context, t node.Task) Task {
 	return Task{
-		ID:     t.ID(),
-		Status: node.TaskStatus(ctx, t),
-		PIDs:   getPIDs(t.Pids(ctx)),
-		PID:    t.Pid(),
+		ID:      t.ID(),
+		Status:  node.TaskStatus(ctx, t),
+		PIDs:    getPIDs(t.Pids(ctx)),
+		PID:     t.Pid(),
+		Metrics: getTaskMetrics(t.Metrics(ctx)),
+	}
+}
+
+func getTaskMetrics(m node.Metrics, err error) *TaskMetrics {
+	if err != nil {
+		return nil
+	}
+
+	return &TaskMetrics{
+		Time:        m.Timestamp.Format(time.RFC3339Nano),
+		CPUUsage:    m.CPUUsage,
+		CPUUser:     m.CPUUser,
+		CPUSystem:   m.CPUSystem,
+		MemoryUsage: m.MemoryUsage,
+		MemoryLimit: m.MemoryLimit,
+		MemoryCache: m.MemoryCache,
+		BlkioRead:   m.BlkioRead,
+		BlkioWrite:  m.BlkioWrite,
+		PidsCurrent: m.PidsCurrent,
+		PidsLimit:   m.PidsLimit,
 	}
 }
 
@@ -315,14 +1085,149 @@ func NewTasksResolver(svc node.TaskService) graphql.FieldResolveFn {
 	}
 }
 
-// NewCreateImageResolver returns a graphql resolver that creates a container image from the given ref, pulling from remote registries if necessary
-func NewCreateImageResolver(svc node.ImageService) graphql.FieldResolveFn {
+// NewLogsResolver returns a graphql resolver that reads back the recorded task output of the given container.
+// since is an RFC 3339 timestamp, tail limits the result to the most recent records and stream selects stdout or stderr.
+func NewLogsResolver(svc node.LogService) graphql.FieldResolveFn {
 	return func(p graphql.ResolveParams) (interface{}, error) {
 		var (
-			namespace, ref           string
-			image                    node.Image
-			namespaceValid, refValid bool
-			imagePullErr             error
+			namespace, containerID, since, stream                                string
+			opts                                                                 node.LogOptions
+			records                                                              []node.LogRecord
+			namespaceValid, containerIDValid, sinceValid, tailValid, streamValid bool
+			sinceErr, getLogsErr                                                 error
+		)
+
+		if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+			return nil, fmt.Errorf("invalid request")
+		}
+
+		if containerID, containerIDValid = p.Args["container_id"].(string); !containerIDValid {
+			return nil, fmt.Errorf("invalid request")
+		}
+
+		if p.Args["since"] != nil {
+
+			if since, sinceValid = p.Args["since"].(string); !sinceValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+
+			if opts.Since, sinceErr = time.Parse(time.RFC3339Nano, since); sinceErr != nil {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		if p.Args["tail"] != nil {
+
+			if opts.Tail, tailValid = p.Args["tail"].(int); !tailValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		if p.Args["stream"] != nil {
+
+			if stream, streamValid = p.Args["stream"].(string); !streamValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+
+			opts.Stream = stream
+		}
+
+		ctx := namespaces.WithNamespace(context.Background(), namespace)
+
+		if records, getLogsErr = svc.GetLogs(ctx, containerID, opts); getLogsErr != nil {
+			return nil, fmt.Errorf("logs resolver failed: %w", getLogsErr)
+		}
+
+		decoratedRecords := []LogRecord{}
+
+		for _, r := range records {
+			decoratedRecords = append(decoratedRecords, getLogRecordInfo(r))
+		}
+
+		return decoratedRecords, nil
+	}
+}
+
+// NewEventsResolver returns a graphql resolver for the events subscription, see NewEventsHandler, which streams it.
+// topic selects a topic or the topics under it, container_id a single container's events, and since the first backlogged event to replay.
+// Outside of NewEventsHandler there's no stream to deliver events on, so it fails.
+func NewEventsResolver(svc node.EventService) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			namespace, topic, containerID           string
+			opts                                    node.EventOptions
+			sub                                     *subscription
+			since                                   int64
+			namespaceValid, topicValid, streamValid bool
+			containerIDValid                        bool
+			sinceErr                                error
+		)
+
+		if event, isEvent := subscriptionEvent(p.Source); isEvent {
+			return getEventInfo(event), nil
+		}
+
+		if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+			return nil, fmt.Errorf("invalid request")
+		}
+
+		if p.Args["topic"] != nil {
+
+			if topic, topicValid = p.Args["topic"].(string); !topicValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+
+			opts.Topic = topic
+		}
+
+		if p.Args["container_id"] != nil {
+
+			if containerID, containerIDValid = p.Args["container_id"].(string); !containerIDValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+
+			opts.ContainerID = containerID
+		}
+
+		if p.Args["since"] != nil {
+
+			if since, sinceErr = getInt64(p.Args["since"]); sinceErr != nil || since < 0 {
+				return nil, fmt.Errorf("invalid request")
+			}
+
+			opts.Since = uint64(since)
+		}
+
+		if p.Context != nil {
+			sub, streamValid = p.Context.Value(subscriptionKey{}).(*subscription)
+		}
+
+		if !streamValid {
+			return nil, fmt.Errorf("events resolver failed: subscriptions are streamed from /events")
+		}
+
+		ctx := namespaces.WithNamespace(p.Context, namespace)
+		events, eventsErr := svc.Events(ctx, opts)
+
+		if eventsErr != nil {
+			return nil, fmt.Errorf("events resolver failed: %w", eventsErr)
+		}
+
+		sub.events(ctx, events)
+
+		return nil, nil
+	}
+}
+
+// NewCreateImageResolver returns a graphql resolver that creates a container image from the given ref, pulling from remote registries if necessary
+func NewCreateImageResolver(svc node.ImageService) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			namespace, ref             string
+			pullPlatforms              []string
+			image                      node.Image
+			namespaceValid, refValid   bool
+			platformsErr, imagePullErr error
 		)
 
 		if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
@@ -333,9 +1238,13 @@ func NewCreateImageResolver(svc node.ImageService) graphql.FieldResolveFn {
 			return nil, fmt.Errorf("invalid request")
 		}
 
+		if pullPlatforms, platformsErr = getStrings(p.Args["platforms"]); platformsErr != nil {
+			return nil, platformsErr
+		}
+
 		ctx := namespaces.WithNamespace(context.Background(), namespace)
 
-		if image, imagePullErr = svc.PullImage(ctx, ref); imagePullErr != nil {
+		if image, imagePullErr = svc.PullImage(ctx, ref, pullPlatforms...); imagePullErr != nil {
 			return nil, fmt.Errorf("createImage resolver failed to create image %s: %w", ref, imagePullErr)
 		}
 
@@ -349,8 +1258,9 @@ func NewCreateContainerResolver(sp node.ContainerService) graphql.FieldResolveFn
 		var (
 			namespace, ID, imageName                string
 			container                               node.Container
+			spec                                    node.ContainerSpec
 			namespaceValid, imageNameValid, IDValid bool
-			containerCreateErr                      error
+			specErr, containerCreateErr             error
 		)
 
 		if p.Args["namespace"] != nil {
@@ -374,13 +1284,17 @@ func NewCreateContainerResolver(sp node.ContainerService) graphql.FieldResolveFn
 			}
 		}
 
+		if spec, specErr = getContainerSpec(p.Args["spec"]); specErr != nil {
+			return nil, specErr
+		}
+
 		ctx := namespaces.WithNamespace(context.Background(), namespace)
 
-		if container, containerCreateErr = sp.CreateContainer(ctx, imageName, ID); containerCreateErr != nil {
+		if container, containerCreateErr = sp.CreateContainer(ctx, imageName, ID, spec); containerCreateErr != nil {
 			return nil, fmt.Errorf("createContainer resolver failed to create container %s: %w", ID, containerCreateErr)
 		}
 
-		return getContainerInfo(ctx, container), nil
+		return getContainerInfo(ctx, sp, container), nil
 	}
 }
 
@@ -418,127 +1332,1005 @@ func NewCreateTaskResolver(ns node.TaskService) graphql.FieldResolveFn {
 	}
 }
 
-// NewKillTaskResolver returns a graphql resolver that kills the task associated with the given container
-func NewKillTaskResolver(ns node.TaskService) graphql.FieldResolveFn {
+// NewExecTaskResolver returns a graphql resolver that runs a process inside the given container's task and returns its exit code and output.
+// stdin is fed to the process and then closed. timeout is in seconds, the process is killed when it runs out.
+func NewExecTaskResolver(ns node.TaskService) graphql.FieldResolveFn {
 	return func(p graphql.ResolveParams) (interface{}, error) {
 		var (
-			namespace, containerID           string
-			namespaceValid, containerIDValid bool
-			killTaskErr                      error
+			namespace, containerID, stdin                              string
+			timeout                                                    int
+			spec                                                       node.ProcessSpec
+			exitStatus                                                 node.ExitStatus
+			namespaceValid, containerIDValid, stdinValid, timeoutValid bool
+			specErr, execErr                                           error
+			stdout, stderr                                             = node.NewCappedBuffer(execOutputLimit), node.NewCappedBuffer(execOutputLimit)
 		)
 
-		if p.Args["namespace"] != nil {
+		if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+			return nil, fmt.Errorf("invalid request")
+		}
 
-			if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+		if containerID, containerIDValid = p.Args["container_id"].(string); !containerIDValid {
+			return nil, fmt.Errorf("invalid request")
+		}
+
+		if spec, specErr = getProcessSpec(p.Args["process"]); specErr != nil {
+			return nil, specErr
+		}
+
+		if p.Args["stdin"] != nil {
+
+			if stdin, stdinValid = p.Args["stdin"].(string); !stdinValid {
 				return nil, fmt.Errorf("invalid request")
 			}
 		}
 
-		if p.Args["container_id"] != nil {
+		if p.Args["timeout"] != nil {
 
-			if containerID, containerIDValid = p.Args["container_id"].(string); !containerIDValid {
+			if timeout, timeoutValid = p.Args["timeout"].(int); !timeoutValid || timeout < 0 {
 				return nil, fmt.Errorf("invalid request")
 			}
 		}
 
-		if killTaskErr = ns.KillTask(namespaces.WithNamespace(context.Background(), namespace), containerID); killTaskErr != nil {
-			return nil, fmt.Errorf("killTask resolver failed to kill task for %s: %w", containerID, killTaskErr)
+		ctx := namespaces.WithNamespace(context.Background(), namespace)
+
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+			defer cancel()
 		}
 
-		return nil, nil
+		taskIO := node.TaskIO{Stdin: strings.NewReader(stdin), Stdout: stdout, Stderr: stderr}
+
+		if exitStatus, execErr = ns.ExecTask(ctx, containerID, spec, taskIO); execErr != nil {
+			return nil, fmt.Errorf("execTask resolver failed to exec in container %s: %w", containerID, execErr)
+		}
+
+		return ExecResult{
+			ExitCode:  exitStatus.ExitCode(),
+			Stdout:    stdout.String(),
+			Stderr:    stderr.String(),
+			Truncated: stdout.Truncated() || stderr.Truncated(),
+		}, nil
 	}
 }
 
-// NewDeleteImageResolver returns a graphql resolver that deletes the given image
-func NewDeleteImageResolver(ns node.ImageService) graphql.FieldResolveFn {
+// getProcessSpec converts a graphql ProcessInput argument into a node.ProcessSpec.
+func getProcessSpec(raw interface{}) (spec node.ProcessSpec, err error) {
+	input, inputValid := raw.(map[string]interface{})
+
+	if !inputValid {
+		return spec, fmt.Errorf("invalid request")
+	}
+
+	if spec.Args, err = getStrings(input["args"]); err != nil {
+		return spec, err
+	}
+
+	if spec.Env, err = getStrings(input["env"]); err != nil {
+		return spec, err
+	}
+
+	for field, dst := range map[string]*string{"working_dir": &spec.WorkingDir, "user": &spec.User} {
+		if input[field] == nil {
+			continue
+		}
+
+		var fieldValid bool
+
+		if *dst, fieldValid = input[field].(string); !fieldValid {
+			return spec, fmt.Errorf("invalid request")
+		}
+	}
+
+	if input["terminal"] != nil {
+		var terminalValid bool
+
+		if spec.Terminal, terminalValid = input["terminal"].(bool); !terminalValid {
+			return spec, fmt.Errorf("invalid request")
+		}
+	}
+
+	return spec, nil
+}
+
+func getImagePruneReportInfo(r node.ImagePruneReport) ImagePruneReport {
+	report := ImagePruneReport{
+		DryRun:       r.DryRun,
+		Images:       []PrunedImage{},
+		Freed:        r.Freed,
+		DiskUsed:     r.DiskUsage.Used,
+		DiskCapacity: r.DiskUsage.Capacity,
+	}
+
+	for _, img := range r.Images {
+		report.Images = append(report.Images, PrunedImage(img))
+	}
+
+	return report
+}
+
+// NewPruneImagesResolver returns a graphql resolver that removes the images of the given namespace the node's image GC policy has no use for.
+// Unless dry_run is false, it only reports what it would remove.
+func NewPruneImagesResolver(svc node.ImageService) graphql.FieldResolveFn {
 	return func(p graphql.ResolveParams) (interface{}, error) {
 		var (
-			namespace, ref           string
-			namespaceValid, refValid bool
-			deleteImageErr           error
+			namespace                   string
+			report                      node.ImagePruneReport
+			dryRun                      = true
+			namespaceValid, dryRunValid bool
+			pruneErr                    error
 		)
 
-		if p.Args["namespace"] != nil {
-
-			if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
-				return nil,fmt.Errorf("invalid request")
-			}
+		if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+			return nil, fmt.Errorf("invalid request")
 		}
 
-		if p.Args["ref"] != nil {
+		if p.Args["dry_run"] != nil {
 
-			if ref, refValid = p.Args["ref"].(string); !refValid {
+			if dryRun, dryRunValid = p.Args["dry_run"].(bool); !dryRunValid {
 				return nil, fmt.Errorf("invalid request")
 			}
 		}
 
-		if deleteImageErr = ns.DeleteImage(namespaces.WithNamespace(context.Background(), namespace), ref); deleteImageErr != nil {
-			return nil, fmt.Errorf("deleteImage resolver failed to delete %s: %w", ref, deleteImageErr)
+		if report, pruneErr = svc.PruneImages(namespaces.WithNamespace(context.Background(), namespace), dryRun); pruneErr != nil {
+			return nil, fmt.Errorf("pruneImages resolver failed: %w", pruneErr)
 		}
 
-		return nil, nil
+		return getImagePruneReportInfo(report), nil
 	}
 }
 
-// NewDeleteContainerResolver returns a graphql resolver that deletes the given container
-func NewDeleteContainerResolver(ns node.ContainerService) graphql.FieldResolveFn {
+func getOperationInfo(ctx context.Context, o node.Operation) Operation {
+	op := Operation{
+		ID:        o.ID,
+		Kind:      o.Kind,
+		Target:    o.Target,
+		Status:    o.Status,
+		Error:     o.Error,
+		StartedAt: o.StartedAt.Format(time.RFC3339Nano),
+	}
+
+	if o.Finished() {
+		op.FinishedAt = o.FinishedAt.Format(time.RFC3339Nano)
+	}
+
+	if image, isImage := o.Result.(node.Image); isImage {
+		info := getImageInfo(ctx, image)
+		op.Image = &info
+	}
+
+	return op
+}
+
+// NewCreateImageAsyncResolver returns a graphql resolver that starts pulling the given ref in the background and returns the operation doing it
+func NewCreateImageAsyncResolver(svc node.OperationService) graphql.FieldResolveFn {
 	return func(p graphql.ResolveParams) (interface{}, error) {
 		var (
-			namespace, ID           string
-			namespaceValid, IDValid bool
-			deleteContainerErr      error
+			namespace, ref           string
+			pullPlatforms            []string
+			op                       node.Operation
+			namespaceValid, refValid bool
+			platformsErr, startErr   error
 		)
 
-		if p.Args["namespace"] != nil {
+		if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+			return nil, fmt.Errorf("invalid request")
+		}
 
-			if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+		if ref, refValid = p.Args["ref"].(string); !refValid {
+			return nil, fmt.Errorf("invalid request")
+		}
+
+		if pullPlatforms, platformsErr = getStrings(p.Args["platforms"]); platformsErr != nil {
+			return nil, platformsErr
+		}
+
+		ctx := namespaces.WithNamespace(context.Background(), namespace)
+
+		if op, startErr = svc.PullImageAsync(ctx, ref, pullPlatforms...); startErr != nil {
+			return nil, fmt.Errorf("createImageAsync resolver failed to start pulling %s: %w", ref, startErr)
+		}
+
+		return getOperationInfo(ctx, op), nil
+	}
+}
+
+// NewKillTaskAsyncResolver returns a graphql resolver that starts killing the task associated with the given container in the background
+// and returns the operation doing it. It takes the arguments of killTask.
+func NewKillTaskAsyncResolver(svc node.OperationService) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			namespace, containerID, signal                              string
+			timeout                                                     int
+			op                                                          node.Operation
+			namespaceValid, containerIDValid, signalValid, timeoutValid bool
+			startErr                                                    error
+		)
+
+		if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+			return nil, fmt.Errorf("invalid request")
+		}
+
+		if containerID, containerIDValid = p.Args["container_id"].(string); !containerIDValid {
+			return nil, fmt.Errorf("invalid request")
+		}
+
+		if p.Args["signal"] != nil {
+
+			if signal, signalValid = p.Args["signal"].(string); !signalValid {
 				return nil, fmt.Errorf("invalid request")
 			}
 		}
 
-		if p.Args["id"] != nil {
+		if p.Args["timeout"] != nil {
 
-			if ID, IDValid = p.Args["id"].(string); !IDValid {
+			if timeout, timeoutValid = p.Args["timeout"].(int); !timeoutValid || timeout < 0 {
 				return nil, fmt.Errorf("invalid request")
 			}
 		}
 
-		if deleteContainerErr = ns.DeleteContainer(namespaces.WithNamespace(context.Background(), namespace), ID); deleteContainerErr != nil {
-			return nil, fmt.Errorf("deleteContainer resolver failed to delete %s: %w", ID, deleteContainerErr)
+		ctx := namespaces.WithNamespace(context.Background(), namespace)
+
+		if op, startErr = svc.KillTaskAsync(ctx, containerID, signal, time.Duration(timeout)*time.Second); startErr != nil {
+			return nil, fmt.Errorf("killTaskAsync resolver failed to start killing task for %s: %w", containerID, startErr)
 		}
 
-		return nil, nil
+		return getOperationInfo(ctx, op), nil
 	}
 }
 
-// NewDeleteTaskResolver returns a graphql resolver that deletes the given task
-func NewDeleteTaskResolver(ns node.TaskService) graphql.FieldResolveFn {
+// NewOperationResolver returns a graphql resolver that looks up the operation with the given ID in the given namespace
+func NewOperationResolver(svc node.OperationService) graphql.FieldResolveFn {
 	return func(p graphql.ResolveParams) (interface{}, error) {
 		var (
-			namespace, containerID           string
-			exitStatus                       node.ExitStatus
-			namespaceValid, containerIDValid bool
-			deleteTaskErr                    error
+			namespace, id           string
+			op                      node.Operation
+			namespaceValid, idValid bool
+			getOpErr                error
 		)
 
-		if p.Args["namespace"] != nil {
+		if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+			return nil, fmt.Errorf("invalid request")
+		}
 
-			if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
-				return nil, fmt.Errorf("invalid request")
-			}
+		if id, idValid = p.Args["id"].(string); !idValid {
+			return nil, fmt.Errorf("invalid request")
 		}
 
-		if p.Args["container_id"] != nil {
+		ctx := namespaces.WithNamespace(context.Background(), namespace)
 
-			if containerID, containerIDValid = p.Args["container_id"].(string); !containerIDValid {
-				return nil, fmt.Errorf("invalid request")
-			}
+		if op, getOpErr = svc.GetOperation(ctx, id); getOpErr != nil {
+			return nil, fmt.Errorf("operation resolver failed: %w", getOpErr)
 		}
 
-		if exitStatus, deleteTaskErr = ns.DeleteTask(namespaces.WithNamespace(context.Background(), namespace), containerID); deleteTaskErr != nil {
-			return nil, fmt.Errorf("deleteTask resolver failed to delete task for %s: %w", containerID, deleteTaskErr)
+		return getOperationInfo(ctx, op), nil
+	}
+}
+
+// NewOperationsResolver returns a graphql resolver that looks up the running and recently finished operations in the given namespace
+func NewOperationsResolver(svc node.OperationService) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			namespace      string
+			ops            []node.Operation
+			namespaceValid bool
+			getOpsErr      error
+		)
+
+		if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+			return nil, fmt.Errorf("invalid request")
 		}
 
-		return exitStatus, nil
+		ctx := namespaces.WithNamespace(context.Background(), namespace)
+
+		if ops, getOpsErr = svc.GetOperations(ctx); getOpsErr != nil {
+			return nil, fmt.Errorf("operations resolver failed: %w", getOpsErr)
+		}
+
+		var decoratedOps []Operation
+
+		for _, op := range ops {
+			decoratedOps = append(decoratedOps, getOperationInfo(ctx, op))
+		}
+
+		return decoratedOps, nil
+	}
+}
+
+// NewCancelOperationResolver returns a graphql resolver that cancels the operation with the given ID in the given namespace and returns it once it has stopped
+func NewCancelOperationResolver(svc node.OperationService) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			namespace, id           string
+			op                      node.Operation
+			namespaceValid, idValid bool
+			cancelErr               error
+		)
+
+		if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+			return nil, fmt.Errorf("invalid request")
+		}
+
+		if id, idValid = p.Args["id"].(string); !idValid {
+			return nil, fmt.Errorf("invalid request")
+		}
+
+		ctx := namespaces.WithNamespace(context.Background(), namespace)
+
+		if op, cancelErr = svc.CancelOperation(ctx, id); cancelErr != nil {
+			return nil, fmt.Errorf("cancelOperation resolver failed: %w", cancelErr)
+		}
+
+		return getOperationInfo(ctx, op), nil
+	}
+}
+
+// NewKillTaskResolver returns a graphql resolver that signals the task associated with the given container.
+// Without a signal the task is stopped gracefully, see node.Node.KillTask. timeout is in seconds.
+func NewKillTaskResolver(ns node.TaskService) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			namespace, containerID, signal                              string
+			timeout                                                     int
+			namespaceValid, containerIDValid, signalValid, timeoutValid bool
+			killTaskErr                                                 error
+		)
+
+		if p.Args["namespace"] != nil {
+
+			if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		if p.Args["container_id"] != nil {
+
+			if containerID, containerIDValid = p.Args["container_id"].(string); !containerIDValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		if p.Args["signal"] != nil {
+
+			if signal, signalValid = p.Args["signal"].(string); !signalValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		if p.Args["timeout"] != nil {
+
+			if timeout, timeoutValid = p.Args["timeout"].(int); !timeoutValid || timeout < 0 {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		ctx := namespaces.WithNamespace(context.Background(), namespace)
+
+		if killTaskErr = ns.KillTask(ctx, containerID, signal, time.Duration(timeout)*time.Second); killTaskErr != nil {
+			return nil, fmt.Errorf("killTask resolver failed to kill task for %s: %w", containerID, killTaskErr)
+		}
+
+		return nil, nil
+	}
+}
+
+// NewPauseTaskResolver returns a graphql resolver that freezes the task associated with the given container.
+func NewPauseTaskResolver(ns node.TaskService) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			namespace, containerID           string
+			namespaceValid, containerIDValid bool
+			task                             node.Task
+			pauseTaskErr                     error
+		)
+
+		if p.Args["namespace"] != nil {
+
+			if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		if p.Args["container_id"] != nil {
+
+			if containerID, containerIDValid = p.Args["container_id"].(string); !containerIDValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		ctx := namespaces.WithNamespace(context.Background(), namespace)
+
+		if task, pauseTaskErr = ns.PauseTask(ctx, containerID); pauseTaskErr != nil {
+			return nil, fmt.Errorf("pauseTask resolver failed to pause task for container %s: %w", containerID, pauseTaskErr)
+		}
+
+		return getTaskInfo(ctx, task), nil
+	}
+}
+
+// NewResumeTaskResolver returns a graphql resolver that thaws the paused task associated with the given container.
+func NewResumeTaskResolver(ns node.TaskService) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			namespace, containerID           string
+			namespaceValid, containerIDValid bool
+			task                             node.Task
+			resumeTaskErr                    error
+		)
+
+		if p.Args["namespace"] != nil {
+
+			if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		if p.Args["container_id"] != nil {
+
+			if containerID, containerIDValid = p.Args["container_id"].(string); !containerIDValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		ctx := namespaces.WithNamespace(context.Background(), namespace)
+
+		if task, resumeTaskErr = ns.ResumeTask(ctx, containerID); resumeTaskErr != nil {
+			return nil, fmt.Errorf("resumeTask resolver failed to resume task for container %s: %w", containerID, resumeTaskErr)
+		}
+
+		return getTaskInfo(ctx, task), nil
+	}
+}
+
+// NewDeleteImageResolver returns a graphql resolver that deletes the given image
+func NewDeleteImageResolver(ns node.ImageService) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			namespace, ref           string
+			namespaceValid, refValid bool
+			deleteImageErr           error
+		)
+
+		if p.Args["namespace"] != nil {
+
+			if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		if p.Args["ref"] != nil {
+
+			if ref, refValid = p.Args["ref"].(string); !refValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		if deleteImageErr = ns.DeleteImage(namespaces.WithNamespace(context.Background(), namespace), ref); deleteImageErr != nil {
+			return nil, fmt.Errorf("deleteImage resolver failed to delete %s: %w", ref, deleteImageErr)
+		}
+
+		return nil, nil
+	}
+}
+
+// NewTagImageResolver returns a graphql resolver that gives the source image the target name, retagging the image that went by it.
+func NewTagImageResolver(svc node.ImageService) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			namespace, source, target                string
+			image                                    node.Image
+			namespaceValid, sourceValid, targetValid bool
+			tagImageErr                              error
+		)
+
+		if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+			return nil, fmt.Errorf("invalid request")
+		}
+
+		if source, sourceValid = p.Args["source"].(string); !sourceValid {
+			return nil, fmt.Errorf("invalid request")
+		}
+
+		if target, targetValid = p.Args["target"].(string); !targetValid {
+			return nil, fmt.Errorf("invalid request")
+		}
+
+		ctx := namespaces.WithNamespace(context.Background(), namespace)
+
+		if image, tagImageErr = svc.TagImage(ctx, source, target); tagImageErr != nil {
+			return nil, fmt.Errorf("tagImage resolver failed to tag %s as %s: %w", source, target, tagImageErr)
+		}
+
+		return getImageInfo(ctx, image), nil
+	}
+}
+
+// NewUntagImageResolver returns a graphql resolver that removes the given name of an image that has others
+func NewUntagImageResolver(svc node.ImageService) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			namespace, ref           string
+			namespaceValid, refValid bool
+			untagImageErr            error
+		)
+
+		if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+			return nil, fmt.Errorf("invalid request")
+		}
+
+		if ref, refValid = p.Args["ref"].(string); !refValid {
+			return nil, fmt.Errorf("invalid request")
+		}
+
+		if untagImageErr = svc.UntagImage(namespaces.WithNamespace(context.Background(), namespace), ref); untagImageErr != nil {
+			return nil, fmt.Errorf("untagImage resolver failed to untag %s: %w", ref, untagImageErr)
+		}
+
+		return nil, nil
+	}
+}
+
+// NewDeleteContainerResolver returns a graphql resolver that deletes the given container
+func NewDeleteContainerResolver(ns node.ContainerService) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			namespace, ID           string
+			namespaceValid, IDValid bool
+			deleteContainerErr      error
+		)
+
+		if p.Args["namespace"] != nil {
+
+			if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		if p.Args["id"] != nil {
+
+			if ID, IDValid = p.Args["id"].(string); !IDValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		if deleteContainerErr = ns.DeleteContainer(namespaces.WithNamespace(context.Background(), namespace), ID); deleteContainerErr != nil {
+			return nil, fmt.Errorf("deleteContainer resolver failed to delete %s: %w", ID, deleteContainerErr)
+		}
+
+		return nil, nil
+	}
+}
+
+// NewDeleteTaskResolver returns a graphql resolver that deletes the given task
+func NewDeleteTaskResolver(ns node.TaskService) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			namespace, containerID           string
+			exitStatus                       node.ExitStatus
+			namespaceValid, containerIDValid bool
+			deleteTaskErr                    error
+		)
+
+		if p.Args["namespace"] != nil {
+
+			if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		if p.Args["container_id"] != nil {
+
+			if containerID, containerIDValid = p.Args["container_id"].(string); !containerIDValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		if exitStatus, deleteTaskErr = ns.DeleteTask(namespaces.WithNamespace(context.Background(), namespace), containerID); deleteTaskErr != nil {
+			return nil, fmt.Errorf("deleteTask resolver failed to delete task for %s: %w", containerID, deleteTaskErr)
+		}
+
+		return exitStatus, nil
+	}
+}
+
+// NewUpdateContainerResourcesResolver returns a graphql resolver that replaces the resource limits of the given container and its running task
+func NewUpdateContainerResourcesResolver(ns node.ContainerService) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			namespace, ID           string
+			container               node.Container
+			resources               node.Resources
+			namespaceValid, IDValid bool
+			resourcesErr, updateErr error
+		)
+
+		if p.Args["namespace"] != nil {
+
+			if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		if p.Args["id"] != nil {
+
+			if ID, IDValid = p.Args["id"].(string); !IDValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		if resources, resourcesErr = getResources(p.Args["resources"]); resourcesErr != nil {
+			return nil, resourcesErr
+		}
+
+		ctx := namespaces.WithNamespace(context.Background(), namespace)
+
+		if container, updateErr = ns.UpdateContainerResources(ctx, ID, resources); updateErr != nil {
+			return nil, fmt.Errorf("updateContainerResources resolver failed to update %s: %w", ID, updateErr)
+		}
+
+		return getContainerInfo(ctx, ns, container), nil
+	}
+}
+
+func getWorkloadInfo(s node.WorkloadStatus) Workload {
+	workload := Workload{
+		Name:       s.Workload.Name,
+		Image:      s.Workload.Image,
+		State:      s.Workload.State,
+		Spec:       getContainerSpecInfo(s.Workload.Spec),
+		Synced:     s.Synced,
+		Drift:      s.Drift,
+		Error:      s.Error,
+		TaskStatus: s.TaskStatus,
+	}
+
+	if !s.ReconciledAt.IsZero() {
+		workload.ReconciledAt = s.ReconciledAt.Format(time.RFC3339Nano)
+	}
+
+	return workload
+}
+
+// getWorkloads converts a graphql WorkloadInput list argument into node.Workloads.
+func getWorkloads(raw interface{}) (workloads []node.Workload, err error) {
+	items, itemsValid := raw.([]interface{})
+
+	if !itemsValid {
+		return nil, fmt.Errorf("invalid request")
+	}
+
+	for _, item := range items {
+		input, inputValid := item.(map[string]interface{})
+
+		if !inputValid {
+			return nil, fmt.Errorf("invalid request")
+		}
+
+		var w node.Workload
+		w.Name, _ = input["name"].(string)
+		w.Image, _ = input["image"].(string)
+		w.State, _ = input["state"].(string)
+
+		if w.Spec, err = getContainerSpec(input["spec"]); err != nil {
+			return nil, err
+		}
+
+		workloads = append(workloads, w)
+	}
+
+	return workloads, nil
+}
+
+// NewApplyResolver returns a graphql resolver that applies the given workloads in order and returns their status after their first reconcile.
+// Workloads keep being reconciled in the background, see node.Node.ApplyWorkload.
+func NewApplyResolver(svc node.WorkloadService) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			namespace              string
+			workloads              []node.Workload
+			statuses               []node.WorkloadStatus
+			namespaceValid         bool
+			workloadsErr, applyErr error
+		)
+
+		if p.Args["namespace"] != nil {
+
+			if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		if workloads, workloadsErr = getWorkloads(p.Args["workloads"]); workloadsErr != nil {
+			return nil, workloadsErr
+		}
+
+		if statuses, applyErr = node.ApplyManifest(svc, node.Manifest{Namespace: namespace, Workloads: workloads}); applyErr != nil {
+			return nil, fmt.Errorf("apply resolver failed: %w", applyErr)
+		}
+
+		var decoratedWorkloads []Workload
+
+		for _, status := range statuses {
+			decoratedWorkloads = append(decoratedWorkloads, getWorkloadInfo(status))
+		}
+
+		return decoratedWorkloads, nil
+	}
+}
+
+// NewWorkloadResolver returns a graphql resolver that gets the status of the given workload
+func NewWorkloadResolver(svc node.WorkloadService) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			namespace, name           string
+			status                    node.WorkloadStatus
+			namespaceValid, nameValid bool
+			getWorkloadErr            error
+		)
+
+		if p.Args["namespace"] != nil {
+
+			if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		if p.Args["name"] != nil {
+
+			if name, nameValid = p.Args["name"].(string); !nameValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		if status, getWorkloadErr = svc.GetWorkload(namespaces.WithNamespace(context.Background(), namespace), name); getWorkloadErr != nil {
+			return nil, fmt.Errorf("workload resolver failed to get %s: %w", name, getWorkloadErr)
+		}
+
+		return getWorkloadInfo(status), nil
+	}
+}
+
+// NewWorkloadsResolver returns a graphql resolver that gets the status of the workloads applied in the given namespace
+func NewWorkloadsResolver(svc node.WorkloadService) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			namespace       string
+			statuses        []node.WorkloadStatus
+			namespaceValid  bool
+			getWorkloadsErr error
+		)
+
+		if p.Args["namespace"] != nil {
+
+			if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		if statuses, getWorkloadsErr = svc.GetWorkloads(namespaces.WithNamespace(context.Background(), namespace)); getWorkloadsErr != nil {
+			return nil, fmt.Errorf("workloads resolver failed: %w", getWorkloadsErr)
+		}
+
+		var decoratedWorkloads []Workload
+
+		for _, status := range statuses {
+			decoratedWorkloads = append(decoratedWorkloads, getWorkloadInfo(status))
+		}
+
+		return decoratedWorkloads, nil
+	}
+}
+
+// NewDeleteWorkloadResolver returns a graphql resolver that deletes the given workload along with its container and task
+func NewDeleteWorkloadResolver(svc node.WorkloadService) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			namespace, name           string
+			namespaceValid, nameValid bool
+			deleteWorkloadErr         error
+		)
+
+		if p.Args["namespace"] != nil {
+
+			if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		if p.Args["name"] != nil {
+
+			if name, nameValid = p.Args["name"].(string); !nameValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		if deleteWorkloadErr = svc.DeleteWorkload(namespaces.WithNamespace(context.Background(), namespace), name); deleteWorkloadErr != nil {
+			return nil, fmt.Errorf("deleteWorkload resolver failed to delete %s: %w", name, deleteWorkloadErr)
+		}
+
+		return nil, nil
+	}
+}
+
+func getPodInfo(ctx context.Context, svc node.ContainerService, p node.Pod) Pod {
+	pod := Pod{
+		ID:      p.ID,
+		Status:  p.Status,
+		Sandbox: getContainerInfo(ctx, svc, p.Sandbox),
+	}
+
+	for _, m := range p.Members {
+		pod.Members = append(pod.Members, getContainerInfo(ctx, svc, m))
+	}
+
+	return pod
+}
+
+// getPodMembers converts a graphql PodMemberInput list argument into node.PodMembers.
+func getPodMembers(raw interface{}) (members []node.PodMember, err error) {
+	items, itemsValid := raw.([]interface{})
+
+	if !itemsValid {
+		return nil, fmt.Errorf("invalid request")
+	}
+
+	for _, item := range items {
+		input, inputValid := item.(map[string]interface{})
+
+		if !inputValid {
+			return nil, fmt.Errorf("invalid request")
+		}
+
+		var m node.PodMember
+		m.Name, _ = input["name"].(string)
+		m.Image, _ = input["image"].(string)
+
+		if m.Spec, err = getContainerSpec(input["spec"]); err != nil {
+			return nil, err
+		}
+
+		members = append(members, m)
+	}
+
+	return members, nil
+}
+
+// NewCreatePodResolver returns a graphql resolver that creates a pod with the given ID and members, and starts its tasks
+func NewCreatePodResolver(svc node.Service) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			namespace, ID           string
+			pod                     node.Pod
+			spec                    node.PodSpec
+			namespaceValid, IDValid bool
+			membersErr, createErr   error
+		)
+
+		if p.Args["namespace"] != nil {
+
+			if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		if p.Args["id"] != nil {
+
+			if ID, IDValid = p.Args["id"].(string); !IDValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		spec.SandboxImage, _ = p.Args["sandbox_image"].(string)
+
+		if spec.Members, membersErr = getPodMembers(p.Args["members"]); membersErr != nil {
+			return nil, membersErr
+		}
+
+		ctx := namespaces.WithNamespace(context.Background(), namespace)
+
+		if pod, createErr = svc.CreatePod(ctx, ID, spec); createErr != nil {
+			return nil, fmt.Errorf("createPod resolver failed to create %s: %w", ID, createErr)
+		}
+
+		return getPodInfo(ctx, svc, pod), nil
+	}
+}
+
+// NewPodResolver returns a graphql resolver that gets the given pod
+func NewPodResolver(svc node.Service) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			namespace, ID           string
+			pod                     node.Pod
+			namespaceValid, IDValid bool
+			getPodErr               error
+		)
+
+		if p.Args["namespace"] != nil {
+
+			if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		if p.Args["id"] != nil {
+
+			if ID, IDValid = p.Args["id"].(string); !IDValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		ctx := namespaces.WithNamespace(context.Background(), namespace)
+
+		if pod, getPodErr = svc.GetPod(ctx, ID); getPodErr != nil {
+			return nil, fmt.Errorf("pod resolver failed to get %s: %w", ID, getPodErr)
+		}
+
+		return getPodInfo(ctx, svc, pod), nil
+	}
+}
+
+// NewPodsResolver returns a graphql resolver that gets the pods in the given namespace
+func NewPodsResolver(svc node.Service) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			namespace      string
+			pods           []node.Pod
+			namespaceValid bool
+			getPodsErr     error
+		)
+
+		if p.Args["namespace"] != nil {
+
+			if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		ctx := namespaces.WithNamespace(context.Background(), namespace)
+
+		if pods, getPodsErr = svc.GetPods(ctx); getPodsErr != nil {
+			return nil, fmt.Errorf("pods resolver failed: %w", getPodsErr)
+		}
+
+		var decoratedPods []Pod
+
+		for _, pod := range pods {
+			decoratedPods = append(decoratedPods, getPodInfo(ctx, svc, pod))
+		}
+
+		return decoratedPods, nil
+	}
+}
+
+// NewDeletePodResolver returns a graphql resolver that deletes the given pod along with its containers and tasks
+func NewDeletePodResolver(svc node.PodService) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			namespace, ID           string
+			namespaceValid, IDValid bool
+			deletePodErr            error
+		)
+
+		if p.Args["namespace"] != nil {
+
+			if namespace, namespaceValid = p.Args["namespace"].(string); !namespaceValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		if p.Args["id"] != nil {
+
+			if ID, IDValid = p.Args["id"].(string); !IDValid {
+				return nil, fmt.Errorf("invalid request")
+			}
+		}
+
+		if deletePodErr = svc.DeletePod(namespaces.WithNamespace(context.Background(), namespace), ID); deletePodErr != nil {
+			return nil, fmt.Errorf("deletePod resolver failed to delete %s: %w", ID, deletePodErr)
+		}
+
+		return nil, nil
 	}
 }
@@ -2,19 +2,38 @@ package api_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/containerd/containerd"
 	"github.com/containerd/containerd/cio"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/platforms"
 	"github.com/graphql-go/graphql"
 	"github.com/mokrz/clamor/node"
 	"github.com/mokrz/clamor/node/api"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 type image struct {
 	name string
+	// digest is the content digest of an image tagged from another. Other images' is the digest of their name.
+	digest digest.Digest
+	// present lists the platforms the image was pulled for, linux/amd64 if it was pulled for the node's.
+	present []ocispec.Platform
 }
 
+// imageCreatedAt is when every fake image was created and last updated.
+var imageCreatedAt = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
 func NewImage(n string) node.Image {
 	return &image{name: n}
 }
@@ -23,6 +42,50 @@ func (i *image) Name() string {
 	return i.name
 }
 
+func (i *image) Target() ocispec.Descriptor {
+	if i.digest == "" {
+		return ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString(i.name), Size: 512}
+	}
+
+	return ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: i.digest, Size: 512}
+}
+
+func (i *image) Labels() map[string]string {
+	return map[string]string{"ref": i.name}
+}
+
+func (i *image) CreatedAt() time.Time {
+	return imageCreatedAt
+}
+
+func (i *image) UpdatedAt() time.Time {
+	return imageCreatedAt
+}
+
+func (i *image) Size(ctx context.Context) (int64, error) {
+	return 3 << 20, nil
+}
+
+func (i *image) UnpackedSize(ctx context.Context) (int64, error) {
+	return 8 << 30, nil
+}
+
+func (i *image) Platforms(ctx context.Context) ([]ocispec.Platform, error) {
+	return []ocispec.Platform{{OS: "linux", Architecture: "amd64"}, {OS: "linux", Architecture: "arm64", Variant: "v8"}}, nil
+}
+
+func (i *image) PresentPlatforms(ctx context.Context) ([]ocispec.Platform, error) {
+	if len(i.present) == 0 {
+		return []ocispec.Platform{{OS: "linux", Architecture: "amd64"}}, nil
+	}
+
+	return i.present, nil
+}
+
+func (i *image) Config(ctx context.Context) (node.ImageConfig, error) {
+	return node.ImageConfig{Entrypoint: []string{"/entrypoint.sh"}, Cmd: []string{"serve"}, Env: []string{"PATH=/bin"}, ExposedPorts: []string{"80/tcp"}, User: "nobody"}, nil
+}
+
 type imageSvc struct {
 	images map[string]node.Image
 }
@@ -33,10 +96,27 @@ func NewImageService(seedImages map[string]node.Image) node.ImageService {
 	}
 }
 
-func (is *imageSvc) PullImage(ctx context.Context, name string) (image node.Image, err error) {
-	image = NewImage(name)
-	is.images[name] = image
-	return image, nil
+func (is *imageSvc) PullImage(ctx context.Context, name string, pullPlatforms ...string) (pulled node.Image, err error) {
+	img := &image{name: name}
+
+	for _, spec := range pullPlatforms {
+
+		if spec == node.AllPlatforms {
+			img.present, _ = img.Platforms(ctx)
+			break
+		}
+
+		p, parseErr := platforms.Parse(spec)
+
+		if parseErr != nil {
+			return nil, fmt.Errorf("invalid platform %s: %w", spec, errdefs.ErrInvalidArgument)
+		}
+
+		img.present = append(img.present, p)
+	}
+
+	is.images[name] = img
+	return img, nil
 }
 
 func (is *imageSvc) GetImage(ctx context.Context, name string) (image node.Image, err error) {
@@ -63,10 +143,133 @@ func (is *imageSvc) DeleteImage(ctx context.Context, name string) (err error) {
 	return nil
 }
 
+// TagImage stores a fake image named target with the content of the one named source.
+func (is *imageSvc) TagImage(ctx context.Context, source, target string) (tagged node.Image, err error) {
+	src, imageValid := is.images[source]
+
+	if !imageValid {
+		return nil, node.ErrNotFound{}
+	}
+
+	tagged = &image{name: target, digest: src.Target().Digest}
+	is.images[target] = tagged
+
+	return tagged, nil
+}
+
+// UntagImage deletes the fake image with the given name unless no other has its content.
+func (is *imageSvc) UntagImage(ctx context.Context, name string) (err error) {
+	img, imageValid := is.images[name]
+
+	if !imageValid {
+		return node.ErrNotFound{}
+	}
+
+	for other, i := range is.images {
+
+		if other != name && i.Target().Digest == img.Target().Digest {
+			delete(is.images, name)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("image %s has no other name: %w", name, errdefs.ErrFailedPrecondition)
+}
+
+func (is *imageSvc) GetPullJob(ctx context.Context, ref string) (job node.PullJob, err error) {
+
+	if _, imageValid := is.images[ref]; !imageValid {
+		return node.PullJob{}, fmt.Errorf("invalid pull job")
+	}
+
+	return pulledJob(ref), nil
+}
+
+func (is *imageSvc) GetPullJobs(ctx context.Context) (jobs []node.PullJob, err error) {
+
+	for name := range is.images {
+		jobs = append(jobs, pulledJob(name))
+	}
+
+	return jobs, nil
+}
+
+func (is *imageSvc) FollowPullJob(ctx context.Context, ref string) (jobs <-chan node.PullJob, err error) {
+	js := make(chan node.PullJob, 1)
+	js <- pulledJob(ref)
+	close(js)
+
+	return js, nil
+}
+
+// ImportImages takes the fake archive in r to be a list of image names, one per line.
+func (is *imageSvc) ImportImages(ctx context.Context, r io.Reader) (images []node.Image, err error) {
+	archive, _ := ioutil.ReadAll(r)
+
+	for _, name := range strings.Fields(string(archive)) {
+		is.images[name] = NewImage(name)
+		images = append(images, is.images[name])
+	}
+
+	if len(images) == 0 {
+		return nil, fmt.Errorf("invalid archive: %w", errdefs.ErrInvalidArgument)
+	}
+
+	return images, nil
+}
+
+// ExportImages writes a fake archive that ImportImages reads back.
+func (is *imageSvc) ExportImages(ctx context.Context, w io.Writer, names ...string) (err error) {
+
+	for _, name := range names {
+
+		if _, imageValid := is.images[name]; !imageValid {
+			return node.ErrNotFound{}
+		}
+	}
+
+	_, err = io.WriteString(w, strings.Join(names, "\n"))
+
+	return err
+}
+
+// PruneImages would prune every fake image.
+func (is *imageSvc) PruneImages(ctx context.Context, dryRun bool) (report node.ImagePruneReport, err error) {
+	report.DryRun = dryRun
+
+	for name := range is.images {
+		report.Images = append(report.Images, node.PrunedImage{Namespace: testNamespace, Name: name, Reason: node.MaxAgeReason, Size: 1 << 20})
+		report.Freed += 1 << 20
+
+		if !dryRun {
+			delete(is.images, name)
+		}
+	}
+
+	return report, nil
+}
+
+// pulledJob is the finished pull job of every fake image.
+func pulledJob(ref string) node.PullJob {
+	return node.PullJob{
+		Ref:        ref,
+		Status:     node.PullDone,
+		Layers:     []node.LayerProgress{{Digest: digest.FromString(ref).String(), Status: node.LayerDone, Offset: 512, Total: 512}},
+		Offset:     512,
+		Total:      512,
+		StartedAt:  imageCreatedAt,
+		UpdatedAt:  imageCreatedAt,
+		FinishedAt: imageCreatedAt,
+	}
+}
+
 type container struct {
 	id    string
 	image node.Image
+	spec  node.ContainerSpec
 	task  node.Task
+	// labels are the labels set after creation, e.g. the restart supervisor's.
+	labels map[string]string
 }
 
 func NewContainer(containerID string, i node.Image, t node.Task) node.Container {
@@ -77,6 +280,15 @@ func NewContainer(containerID string, i node.Image, t node.Task) node.Container
 	}
 }
 
+func NewContainerWithSpec(containerID string, i node.Image, t node.Task, spec node.ContainerSpec) node.Container {
+	return &container{
+		id:    containerID,
+		image: i,
+		spec:  spec,
+		task:  t,
+	}
+}
+
 func (c *container) ID() string {
 	return c.id
 }
@@ -89,18 +301,52 @@ func (c *container) Task(ctx context.Context, attach cio.Attach) (node.Task, err
 	return c.task, nil
 }
 
+func (c *container) Spec(ctx context.Context) (node.ContainerSpec, error) {
+	return c.spec, nil
+}
+
+func (c *container) Labels(ctx context.Context) (map[string]string, error) {
+	labels := make(map[string]string)
+
+	for k, v := range c.spec.Labels {
+		labels[k] = v
+	}
+
+	if c.spec.RestartPolicy != "" {
+		labels[node.RestartPolicyLabel] = c.spec.RestartPolicy
+	}
+
+	for k, v := range c.labels {
+		labels[k] = v
+	}
+
+	return labels, nil
+}
+
 type containerService struct {
 	containers map[string]node.Container
+	// health holds the health of the containers that are probed.
+	health map[string]node.Health
 }
 
 func NewContainerService(seedContainers map[string]node.Container) node.ContainerService {
 	return &containerService{
 		containers: seedContainers,
+		health:     make(map[string]node.Health),
 	}
 }
 
-func (cs *containerService) CreateContainer(ctx context.Context, imageName string, id string) (container node.Container, err error) {
-	c := NewContainer(id, NewImage(imageName), NewTask(id, 1, node.Status{}, nil))
+func (cs *containerService) CreateContainer(ctx context.Context, imageName string, id string, spec node.ContainerSpec) (container node.Container, err error) {
+	if spec.HealthCheck != nil {
+
+		if err = spec.HealthCheck.Validate(); err != nil {
+			return nil, err
+		}
+
+		cs.health[id] = node.Health{Status: node.HealthStarting}
+	}
+
+	c := NewContainerWithSpec(id, NewImage(imageName), NewTask(id, 1, node.Status{}, nil), spec)
 	cs.containers[id] = c
 	return c, nil
 }
@@ -124,6 +370,30 @@ func (cs *containerService) GetContainers(ctx context.Context, filter string) (c
 	return containers, nil
 }
 
+func (cs *containerService) UpdateContainerResources(ctx context.Context, id string, resources node.Resources) (container node.Container, err error) {
+	var containerValid bool
+
+	if container, containerValid = cs.containers[id]; !containerValid {
+		return nil, fmt.Errorf("invalid container")
+	}
+
+	spec, _ := container.Spec(ctx)
+	spec.Resources = resources
+	image, _ := container.Image(ctx)
+	task, _ := container.Task(ctx, nil)
+	container = NewContainerWithSpec(id, image, task, spec)
+	cs.containers[id] = container
+	return container, nil
+}
+
+func (cs *containerService) GetContainerHealth(ctx context.Context, id string) (health node.Health, err error) {
+	if _, containerValid := cs.containers[id]; !containerValid {
+		return node.Health{}, fmt.Errorf("invalid container")
+	}
+
+	return cs.health[id], nil
+}
+
 func (cs *containerService) DeleteContainer(ctx context.Context, id string) (err error) {
 	delete(cs.containers, id)
 	return nil
@@ -161,6 +431,14 @@ func (t *task) Pids(ctx context.Context) ([]node.ProcessInfo, error) {
 	return t.pids, nil
 }
 
+func (t *task) Metrics(ctx context.Context) (node.Metrics, error) {
+	if t.status.Status == containerd.Stopped {
+		return node.Metrics{}, fmt.Errorf("process already finished")
+	}
+
+	return node.Metrics{MemoryUsage: 6 << 30, PidsCurrent: uint64(len(t.pids))}, nil
+}
+
 type taskService struct {
 	tasks map[string]node.Task
 }
@@ -196,15 +474,249 @@ func (ts *taskService) GetTasks(ctx context.Context, filter string) (tasks []nod
 	return tasks, nil
 }
 
-func (ts *taskService) KillTask(ctx context.Context, containerID string) (err error) {
+func (ts *taskService) KillTask(ctx context.Context, containerID, signal string, timeout time.Duration) (err error) {
 	return nil
 }
 
+func (ts *taskService) ExecTask(ctx context.Context, containerID string, spec node.ProcessSpec, io node.TaskIO) (exitStatus node.ExitStatus, err error) {
+
+	if _, taskValid := ts.tasks[containerID]; !taskValid {
+		return node.ExitStatus{}, fmt.Errorf("invalid task")
+	}
+
+	if io.Stdout != nil {
+		fmt.Fprint(io.Stdout, strings.Join(spec.Args, " "))
+	}
+
+	return node.ExitStatus(*containerd.NewExitStatus(0, time.Now(), nil)), nil
+}
+
+func (ts *taskService) AttachTask(ctx context.Context, containerID string, io node.TaskIO) (exitStatus node.ExitStatus, err error) {
+
+	if _, taskValid := ts.tasks[containerID]; !taskValid {
+		return node.ExitStatus{}, fmt.Errorf("invalid task")
+	}
+
+	<-ctx.Done()
+
+	return node.ExitStatus{}, ctx.Err()
+}
+
+func (ts *taskService) PauseTask(ctx context.Context, containerID string) (task node.Task, err error) {
+	return ts.setStatus(containerID, containerd.Running, containerd.Paused)
+}
+
+func (ts *taskService) ResumeTask(ctx context.Context, containerID string) (task node.Task, err error) {
+	return ts.setStatus(containerID, containerd.Paused, containerd.Running)
+}
+
+func (ts *taskService) setStatus(containerID string, from, to containerd.ProcessStatus) (node.Task, error) {
+	t, taskValid := ts.tasks[containerID].(*task)
+
+	if !taskValid {
+		return nil, fmt.Errorf("invalid task")
+	}
+
+	if t.status.Status != from {
+		return nil, fmt.Errorf("task is %s", t.status.Status)
+	}
+
+	t.status.Status = to
+
+	return t, nil
+}
+
 func (ts *taskService) DeleteTask(ctx context.Context, containerID string) (exitStatus node.ExitStatus, err error) {
 	delete(ts.tasks, containerID)
 	return node.ExitStatus{}, nil
 }
 
+type logService struct {
+	records map[string][]node.LogRecord
+}
+
+func NewLogService(seedRecords map[string][]node.LogRecord) node.LogService {
+	return &logService{
+		records: seedRecords,
+	}
+}
+
+func (ls *logService) GetLogs(ctx context.Context, containerID string, opts node.LogOptions) (records []node.LogRecord, err error) {
+	all, containerValid := ls.records[containerID]
+
+	if !containerValid {
+		return nil, fmt.Errorf("invalid container")
+	}
+
+	for _, r := range all {
+
+		if (opts.Stream == "" || opts.Stream == r.Stream) && !r.Time.Before(opts.Since) {
+			records = append(records, r)
+		}
+	}
+
+	if opts.Tail > 0 && len(records) > opts.Tail {
+		records = records[len(records)-opts.Tail:]
+	}
+
+	return records, nil
+}
+
+func (ls *logService) FollowLogs(ctx context.Context, containerID string, opts node.LogOptions) (records <-chan node.LogRecord, err error) {
+	recorded, err := ls.GetLogs(ctx, containerID, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	followed := make(chan node.LogRecord, len(recorded))
+
+	for _, r := range recorded {
+		followed <- r
+	}
+
+	close(followed)
+
+	return followed, nil
+}
+
+type workloadService struct {
+	statuses map[string]node.WorkloadStatus
+}
+
+func NewWorkloadService() node.WorkloadService {
+	return &workloadService{
+		statuses: make(map[string]node.WorkloadStatus),
+	}
+}
+
+// ApplyWorkload pretends the workload's container and task were created the first time it's applied, and found in place afterwards.
+func (ws *workloadService) ApplyWorkload(ctx context.Context, w node.Workload) (status node.WorkloadStatus, err error) {
+	if err = w.Validate(); err != nil {
+		return node.WorkloadStatus{}, err
+	}
+
+	if w.State == "" {
+		w.State = node.WorkloadRunning
+	}
+
+	status = node.WorkloadStatus{Workload: w, Synced: true, ReconciledAt: imageCreatedAt}
+
+	if _, applied := ws.statuses[w.Name]; !applied {
+		status.Drift = []string{"container missing", "task missing"}
+	}
+
+	if w.State == node.WorkloadRunning {
+		status.TaskStatus = string(containerd.Running)
+	}
+
+	ws.statuses[w.Name] = status
+
+	return status, nil
+}
+
+func (ws *workloadService) GetWorkload(ctx context.Context, name string) (status node.WorkloadStatus, err error) {
+	status, applied := ws.statuses[name]
+
+	if !applied {
+		return node.WorkloadStatus{}, errdefs.ErrNotFound
+	}
+
+	return status, nil
+}
+
+func (ws *workloadService) GetWorkloads(ctx context.Context) (statuses []node.WorkloadStatus, err error) {
+	for _, status := range ws.statuses {
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Workload.Name < statuses[j].Workload.Name })
+
+	return statuses, nil
+}
+
+func (ws *workloadService) DeleteWorkload(ctx context.Context, name string) (err error) {
+	if _, applied := ws.statuses[name]; !applied {
+		return errdefs.ErrNotFound
+	}
+
+	delete(ws.statuses, name)
+
+	return nil
+}
+
+type podService struct {
+	pods map[string]node.Pod
+}
+
+func NewPodService() node.PodService {
+	return &podService{
+		pods: make(map[string]node.Pod),
+	}
+}
+
+// CreatePod pretends the sandbox and member containers were created with running tasks.
+func (ps *podService) CreatePod(ctx context.Context, id string, spec node.PodSpec) (pod node.Pod, err error) {
+	if err = spec.Validate(); err != nil {
+		return node.Pod{}, err
+	}
+
+	if _, exists := ps.pods[id]; exists {
+		return node.Pod{}, errdefs.ErrAlreadyExists
+	}
+
+	if spec.SandboxImage == "" {
+		spec.SandboxImage = node.DefaultSandboxImage
+	}
+
+	running := node.Status{Status: containerd.Running}
+	pod = node.Pod{
+		ID:      id,
+		Sandbox: NewContainer(id, NewImage(spec.SandboxImage), NewTask(id, 1, running, nil)),
+		Status:  node.PodRunning,
+	}
+
+	for i, m := range spec.Members {
+		memberID := node.PodContainerID(id, m.Name)
+		m.Spec.Sandbox = id
+		pod.Members = append(pod.Members, NewContainerWithSpec(memberID, NewImage(m.Image), NewTask(memberID, uint32(i+2), running, nil), m.Spec))
+	}
+
+	ps.pods[id] = pod
+
+	return pod, nil
+}
+
+func (ps *podService) GetPod(ctx context.Context, id string) (pod node.Pod, err error) {
+	pod, exists := ps.pods[id]
+
+	if !exists {
+		return node.Pod{}, errdefs.ErrNotFound
+	}
+
+	return pod, nil
+}
+
+func (ps *podService) GetPods(ctx context.Context) (pods []node.Pod, err error) {
+	for _, pod := range ps.pods {
+		pods = append(pods, pod)
+	}
+
+	sort.Slice(pods, func(i, j int) bool { return pods[i].ID < pods[j].ID })
+
+	return pods, nil
+}
+
+func (ps *podService) DeletePod(ctx context.Context, id string) (err error) {
+	if _, exists := ps.pods[id]; !exists {
+		return errdefs.ErrNotFound
+	}
+
+	delete(ps.pods, id)
+
+	return nil
+}
+
 var (
 	weirdString     = "@#%4$1^'`_|+%20"
 	testImage       = "docker.io/library/hello-world:latest"
@@ -527,28 +1039,86 @@ func TestNewCreateImageResolver(t *testing.T) {
 	}
 }
 
-func TestNewCreateContainerResolver(t *testing.T) {
-	type resolverArgs struct {
-		resolveParamArgs map[string]interface{}
+func TestCreateImagePlatformsRoundTrip(t *testing.T) {
+	svc := &service{ImageService: NewImageService(map[string]node.Image{})}
+	schema, schemaErr := api.NewGraphQLSchema(svc, api.NewResolverSet(svc))
+
+	if schemaErr != nil {
+		t.Fatalf("failed to create schema with error: %s", schemaErr.Error())
 	}
 
-	type createContainerResolverTest struct {
+	type roundTripTest struct {
 		name    string
-		args    resolverArgs
+		args    string
+		want    string
 		wantErr bool
 	}
 
-	containerSvc := NewContainerService(map[string]node.Container{
-		testContainerID: NewContainer(testContainerID, NewImage(seedImage), NewTask(testContainerID, 1, node.Status{}, []node.ProcessInfo{})),
-	})
-	tests := []createContainerResolverTest{
-		{name: "empty namespace", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": "", "id": testContainerID, "image_name": testImage}}, wantErr: true},
-		{name: "weird namespace", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": weirdString, "id": testContainerID, "image_name": testImage}}, wantErr: true},
-		{name: "nil container ID", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "id": nil, "image_name": testImage}}, wantErr: true},
-		{name: "weird container ID", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "id": weirdString, "image_name": testImage}}, wantErr: true},
-		{name: "nil image name", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "id": testContainerID, "image_name": nil}}, wantErr: true},
-		{name: "weird image name", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "id": testContainerID, "image_name": weirdString}}, wantErr: true},
+	tests := []roundTripTest{
+		{
+			name: "node's platform",
+			args: ``,
+			want: `{"createImage":{"platforms":["linux/amd64","linux/arm64/v8"],"present_platforms":["linux/amd64"]}}`,
+		},
+		{
+			name: "other platform",
+			args: `, platforms: ["linux/arm64/v8"]`,
+			want: `{"createImage":{"platforms":["linux/amd64","linux/arm64/v8"],"present_platforms":["linux/arm64/v8"]}}`,
+		},
+		{
+			name: "all platforms",
+			args: `, platforms: ["all"]`,
+			want: `{"createImage":{"platforms":["linux/amd64","linux/arm64/v8"],"present_platforms":["linux/amd64","linux/arm64/v8"]}}`,
+		},
+		{name: "invalid platform", args: `, platforms: ["` + weirdString + `"]`, wantErr: true},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			result := graphql.Do(graphql.Params{
+				Schema:        schema,
+				RequestString: `mutation { createImage(namespace: "` + testNamespace + `", ref: "` + seedImage + `"` + test.args + `) { platforms present_platforms } }`,
+			})
+
+			if result.HasErrors() && !test.wantErr {
+				t.Fatalf("createImage failed with errors: %v", result.Errors)
+			} else if !result.HasErrors() && test.wantErr {
+				t.Fatalf("createImage succeeded, want error")
+			}
+
+			if got, _ := json.Marshal(result.Data); !test.wantErr && string(got) != test.want {
+				t.Errorf("createImage returned %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNewCreateContainerResolver(t *testing.T) {
+	type resolverArgs struct {
+		resolveParamArgs map[string]interface{}
+	}
+
+	type createContainerResolverTest struct {
+		name    string
+		args    resolverArgs
+		wantErr bool
+	}
+
+	containerSvc := NewContainerService(map[string]node.Container{
+		testContainerID: NewContainer(testContainerID, NewImage(seedImage), NewTask(testContainerID, 1, node.Status{}, []node.ProcessInfo{})),
+	})
+	tests := []createContainerResolverTest{
+		{name: "empty namespace", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": "", "id": testContainerID, "image_name": testImage}}, wantErr: true},
+		{name: "weird namespace", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": weirdString, "id": testContainerID, "image_name": testImage}}, wantErr: true},
+		{name: "nil container ID", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "id": nil, "image_name": testImage}}, wantErr: true},
+		{name: "weird container ID", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "id": weirdString, "image_name": testImage}}, wantErr: true},
+		{name: "nil image name", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "id": testContainerID, "image_name": nil}}, wantErr: true},
+		{name: "weird image name", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "id": testContainerID, "image_name": weirdString}}, wantErr: true},
 		{name: "valid namespace valid container ID valid image name", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "id": testContainerID, "image_name": testImage}}, wantErr: false},
+		{name: "weird spec", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "id": testContainerID, "image_name": testImage, "spec": weirdString}}, wantErr: true},
+		{name: "weird spec env", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "id": testContainerID, "image_name": testImage, "spec": map[string]interface{}{"env": weirdString}}}, wantErr: true},
+		{name: "valid spec", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "id": testContainerID, "image_name": testImage, "spec": map[string]interface{}{"command": []interface{}{"/bin/sh"}, "env": []interface{}{"A=b"}, "labels": []interface{}{map[string]interface{}{"key": "k", "value": "v"}}}}}, wantErr: false},
 	}
 
 	for _, test := range tests {
@@ -631,6 +1201,10 @@ func TestNewKillTaskResolver(t *testing.T) {
 		{name: "nil container ID", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": nil}}, wantErr: true},
 		{name: "weird container ID", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": weirdString}}, wantErr: true},
 		{name: "valid namespace valid container ID", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": testContainerID}}, wantErr: false},
+		{name: "weird signal", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": testContainerID, "signal": 9}}, wantErr: true},
+		{name: "weird timeout", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": testContainerID, "timeout": weirdString}}, wantErr: true},
+		{name: "negative timeout", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": testContainerID, "timeout": -1}}, wantErr: true},
+		{name: "signal and timeout", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": testContainerID, "signal": "SIGINT", "timeout": 5}}, wantErr: false},
 	}
 
 	for _, test := range tests {
@@ -648,6 +1222,106 @@ func TestNewKillTaskResolver(t *testing.T) {
 	}
 }
 
+func TestNewExecTaskResolver(t *testing.T) {
+	type resolverArgs struct {
+		resolveParamArgs map[string]interface{}
+	}
+
+	type execTaskResolverTest struct {
+		name       string
+		args       resolverArgs
+		wantErr    bool
+		wantStdout string
+	}
+
+	taskSvc := NewTaskService(map[string]node.Task{
+		testContainerID: NewTask(testContainerID, 1, node.Status{}, []node.ProcessInfo{}),
+	})
+	echo := map[string]interface{}{"args": []interface{}{"echo", "hi"}}
+	tests := []execTaskResolverTest{
+		{name: "nil namespace", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": nil, "container_id": testContainerID, "process": echo}}, wantErr: true},
+		{name: "nil container ID", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": nil, "process": echo}}, wantErr: true},
+		{name: "weird container ID", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": weirdString, "process": echo}}, wantErr: true},
+		{name: "nil process", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": testContainerID, "process": nil}}, wantErr: true},
+		{name: "weird args", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": testContainerID, "process": map[string]interface{}{"args": weirdString}}}, wantErr: true},
+		{name: "weird user", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": testContainerID, "process": map[string]interface{}{"args": []interface{}{"echo"}, "user": 0}}}, wantErr: true},
+		{name: "weird terminal", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": testContainerID, "process": map[string]interface{}{"args": []interface{}{"echo"}, "terminal": weirdString}}}, wantErr: true},
+		{name: "weird stdin", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": testContainerID, "process": echo, "stdin": 1}}, wantErr: true},
+		{name: "negative timeout", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": testContainerID, "process": echo, "timeout": -1}}, wantErr: true},
+		{name: "valid", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": testContainerID, "process": echo, "stdin": "in", "timeout": 5}}, wantStdout: "echo hi"},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			execTaskResolver := api.NewExecTaskResolver(taskSvc)
+			result, err := execTaskResolver(graphql.ResolveParams{
+				Args: test.args.resolveParamArgs,
+			})
+
+			if err != nil && !test.wantErr {
+				t.Fatalf("exec task resolver failed with error: " + err.Error())
+			} else if err == nil && test.wantErr {
+				t.Fatalf("exec task resolver succeeded, want error")
+			}
+
+			if test.wantErr {
+				return
+			}
+
+			if got := result.(api.ExecResult); got.Stdout != test.wantStdout || got.ExitCode != 0 || got.Truncated {
+				t.Errorf("exec task resolver returned %+v, want stdout %q", got, test.wantStdout)
+			}
+		})
+	}
+}
+
+func TestPauseResumeTaskResolvers(t *testing.T) {
+	type resolverArgs struct {
+		resolveParamArgs map[string]interface{}
+	}
+
+	type pauseResumeTaskResolverTest struct {
+		name       string
+		resolver   func(node.TaskService) graphql.FieldResolveFn
+		args       resolverArgs
+		wantStatus string
+		wantErr    bool
+	}
+
+	taskSvc := NewTaskService(map[string]node.Task{
+		testContainerID: NewTask(testContainerID, 1, node.Status{Status: containerd.Running}, []node.ProcessInfo{}),
+	})
+	validArgs := resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": testContainerID}}
+	tests := []pauseResumeTaskResolverTest{
+		{name: "weird namespace", resolver: api.NewPauseTaskResolver, args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": 1, "container_id": testContainerID}}, wantErr: true},
+		{name: "weird container ID", resolver: api.NewPauseTaskResolver, args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": weirdString}}, wantErr: true},
+		{name: "resume running", resolver: api.NewResumeTaskResolver, args: validArgs, wantErr: true},
+		{name: "pause", resolver: api.NewPauseTaskResolver, args: validArgs, wantStatus: "paused"},
+		{name: "pause paused", resolver: api.NewPauseTaskResolver, args: validArgs, wantErr: true},
+		{name: "resume", resolver: api.NewResumeTaskResolver, args: validArgs, wantStatus: "running"},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			result, err := test.resolver(taskSvc)(graphql.ResolveParams{
+				Args: test.args.resolveParamArgs,
+			})
+
+			if err != nil && !test.wantErr {
+				t.Fatalf("task resolver failed with error: " + err.Error())
+			} else if err == nil && test.wantErr {
+				t.Fatalf("task resolver succeeded, want error")
+			}
+
+			if task, taskValid := result.(api.Task); !test.wantErr && (!taskValid || task.Status != test.wantStatus) {
+				t.Errorf("task resolver returned %+v, want status %q", result, test.wantStatus)
+			}
+		})
+	}
+}
+
 func TestNewDeleteTaskResolver(t *testing.T) {
 	type resolverArgs struct {
 		resolveParamArgs map[string]interface{}
@@ -758,3 +1432,924 @@ func TestNewDeleteImageResolver(t *testing.T) {
 		})
 	}
 }
+
+type service struct {
+	node.ImageService
+	node.ContainerService
+	node.TaskService
+	node.LogService
+	node.EventService
+	node.OperationService
+	node.WorkloadService
+	node.PodService
+}
+
+func (s *service) Close() (err error) {
+	return nil
+}
+
+func TestCreateContainerSpecRoundTrip(t *testing.T) {
+	svc := &service{
+		ImageService:     NewImageService(map[string]node.Image{seedImage: NewImage(seedImage)}),
+		ContainerService: NewContainerService(map[string]node.Container{}),
+		TaskService:      NewTaskService(map[string]node.Task{}),
+		LogService:       NewLogService(map[string][]node.LogRecord{}),
+	}
+
+	schema, schemaErr := api.NewGraphQLSchema(svc, api.NewResolverSet(svc))
+
+	if schemaErr != nil {
+		t.Fatalf("failed to create schema with error: %s", schemaErr.Error())
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema: schema,
+		RequestString: `mutation {
+			createContainer(namespace: "` + testNamespace + `", id: "` + testContainerID + `", image: "` + seedImage + `", spec: {
+				command: ["/bin/sh", "-c"], args: ["echo hi"], env: ["A=b"], working_dir: "/srv", user: "nobody", hostname: "box",
+				labels: [{key: "team", value: "infra"}], stdin: true, terminal: true
+			}) {
+				id
+				spec { command args env working_dir user hostname labels { key value } stdin terminal }
+			}
+		}`,
+	})
+
+	if result.HasErrors() {
+		t.Fatalf("createContainer failed with errors: %v", result.Errors)
+	}
+
+	got, _ := json.Marshal(result.Data)
+	want := `{"createContainer":{"id":"` + testContainerID + `","spec":{"args":["echo hi"],"command":["/bin/sh","-c"],"env":["A=b"],"hostname":"box","labels":[{"key":"team","value":"infra"}],"stdin":true,"terminal":true,"user":"nobody","working_dir":"/srv"}}}`
+
+	if string(got) != want {
+		t.Errorf("createContainer returned %s, want %s", got, want)
+	}
+}
+
+func TestContainerRestartRoundTrip(t *testing.T) {
+	restarted := &container{
+		id:    "restarted",
+		image: NewImage(seedImage),
+		task:  NewTask("restarted", 1, node.Status{}, nil),
+		spec:  node.ContainerSpec{RestartPolicy: "on-failure:3"},
+		labels: map[string]string{
+			node.RestartCountLabel:   "2",
+			node.RestartStoppedLabel: "false",
+			node.LastExitStatusLabel: "137",
+			node.LastExitAtLabel:     "2020-01-02T03:04:05Z",
+		},
+	}
+	svc := &service{
+		ImageService: NewImageService(map[string]node.Image{seedImage: NewImage(seedImage)}),
+		ContainerService: NewContainerService(map[string]node.Container{
+			"restarted":     restarted,
+			testContainerID: NewContainer(testContainerID, NewImage(seedImage), NewTask(testContainerID, 1, node.Status{}, nil)),
+		}),
+	}
+	schema, schemaErr := api.NewGraphQLSchema(svc, api.NewResolverSet(svc))
+
+	if schemaErr != nil {
+		t.Fatalf("failed to create schema with error: %s", schemaErr.Error())
+	}
+
+	type roundTripTest struct {
+		name    string
+		request string
+		want    string
+	}
+
+	tests := []roundTripTest{
+		{
+			name:    "restarted container",
+			request: `{ container(namespace: "` + testNamespace + `", id: "restarted") { restart { policy count stopped last_exit_status last_exit_at } } }`,
+			want:    `{"container":{"restart":{"count":2,"last_exit_at":"2020-01-02T03:04:05Z","last_exit_status":137,"policy":"on-failure:3","stopped":false}}}`,
+		},
+		{
+			name:    "unsupervised container",
+			request: `{ container(namespace: "` + testNamespace + `", id: "` + testContainerID + `") { restart { policy } } }`,
+			want:    `{"container":{"restart":null}}`,
+		},
+		{
+			name:    "created with policy",
+			request: `mutation { createContainer(namespace: "` + testNamespace + `", id: "new", image: "` + seedImage + `", spec: {restart_policy: "always"}) { spec { restart_policy } restart { policy count stopped last_exit_at } } }`,
+			want:    `{"createContainer":{"restart":{"count":0,"last_exit_at":"","policy":"always","stopped":false},"spec":{"restart_policy":"always"}}}`,
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			result := graphql.Do(graphql.Params{Schema: schema, RequestString: test.request})
+
+			if result.HasErrors() {
+				t.Fatalf("request failed with errors: %v", result.Errors)
+			}
+
+			if got, _ := json.Marshal(result.Data); string(got) != test.want {
+				t.Errorf("request returned %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestContainerHealthRoundTrip(t *testing.T) {
+	containerSvc := NewContainerService(map[string]node.Container{
+		testContainerID: NewContainer(testContainerID, NewImage(seedImage), NewTask(testContainerID, 1, node.Status{}, nil)),
+	})
+	containerSvc.(*containerService).health[testContainerID] = node.Health{
+		Status:        node.HealthUnhealthy,
+		FailingStreak: 3,
+		Log: []node.HealthProbe{
+			{Start: imageCreatedAt, End: imageCreatedAt.Add(time.Second), Output: "503 Service Unavailable"},
+		},
+	}
+	svc := &service{
+		ImageService:     NewImageService(map[string]node.Image{seedImage: NewImage(seedImage)}),
+		ContainerService: containerSvc,
+	}
+	schema, schemaErr := api.NewGraphQLSchema(svc, api.NewResolverSet(svc))
+
+	if schemaErr != nil {
+		t.Fatalf("failed to create schema with error: %s", schemaErr.Error())
+	}
+
+	type roundTripTest struct {
+		name    string
+		request string
+		want    string
+		wantErr bool
+	}
+
+	probed := imageCreatedAt.Format(time.RFC3339Nano)
+	tests := []roundTripTest{
+		{
+			name:    "probed container",
+			request: `{ container(namespace: "` + testNamespace + `", id: "` + testContainerID + `") { health { status failing_streak log { start end passed output } } } }`,
+			want:    `{"container":{"health":{"failing_streak":3,"log":[{"end":"` + imageCreatedAt.Add(time.Second).Format(time.RFC3339Nano) + `","output":"503 Service Unavailable","passed":false,"start":"` + probed + `"}],"status":"unhealthy"}}}`,
+		},
+		{
+			name: "created with health check",
+			request: `mutation { createContainer(namespace: "` + testNamespace + `", id: "new", image: "` + seedImage + `", spec: {
+				health_check: {http_get: "http://127.0.0.1:8080/healthz", interval: 10, timeout: 2, retries: 5, start_period: 30}
+			}) { spec { health_check { exec http_get tcp_socket interval timeout retries start_period } } health { status failing_streak log { passed } } } }`,
+			want: `{"createContainer":{"health":{"failing_streak":0,"log":[],"status":"starting"},"spec":{"health_check":{"exec":[],"http_get":"http://127.0.0.1:8080/healthz","interval":10,"retries":5,"start_period":30,"tcp_socket":"","timeout":2}}}}`,
+		},
+		{
+			name:    "created without health check",
+			request: `mutation { createContainer(namespace: "` + testNamespace + `", id: "plain", image: "` + seedImage + `") { spec { health_check { retries } } health { status } } }`,
+			want:    `{"createContainer":{"health":null,"spec":{"health_check":null}}}`,
+		},
+		{
+			name:    "two probes",
+			request: `mutation { createContainer(namespace: "` + testNamespace + `", id: "twice", image: "` + seedImage + `", spec: {health_check: {exec: ["true"], tcp_socket: "127.0.0.1:5432"}}) { id } }`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			result := graphql.Do(graphql.Params{Schema: schema, RequestString: test.request})
+
+			if result.HasErrors() && !test.wantErr {
+				t.Fatalf("request failed with errors: %v", result.Errors)
+			} else if !result.HasErrors() && test.wantErr {
+				t.Fatalf("request succeeded, want error")
+			}
+
+			if got, _ := json.Marshal(result.Data); !test.wantErr && string(got) != test.want {
+				t.Errorf("request returned %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestWorkloadRoundTrip(t *testing.T) {
+	svc := &service{WorkloadService: NewWorkloadService()}
+	schema, schemaErr := api.NewGraphQLSchema(svc, api.NewResolverSet(svc))
+
+	if schemaErr != nil {
+		t.Fatalf("failed to create schema with error: %s", schemaErr.Error())
+	}
+
+	type roundTripTest struct {
+		name    string
+		request string
+		want    string
+		wantErr bool
+	}
+
+	reconciled := imageCreatedAt.Format(time.RFC3339Nano)
+	tests := []roundTripTest{
+		{
+			name: "apply",
+			request: `mutation { apply(namespace: "` + testNamespace + `", workloads: [
+				{name: "web", image: "` + seedImage + `", spec: {env: ["PORT=80"], restart_policy: "always"}},
+				{name: "batch", image: "` + testImage + `", state: "stopped"}
+			]) { name image state spec { env restart_policy } synced drift error task_status reconciled_at } }`,
+			want: `{"apply":[` +
+				`{"drift":["container missing","task missing"],"error":"","image":"` + seedImage + `","name":"web","reconciled_at":"` + reconciled + `","spec":{"env":["PORT=80"],"restart_policy":"always"},"state":"running","synced":true,"task_status":"running"},` +
+				`{"drift":["container missing","task missing"],"error":"","image":"` + testImage + `","name":"batch","reconciled_at":"` + reconciled + `","spec":{"env":[],"restart_policy":""},"state":"stopped","synced":true,"task_status":""}]}`,
+		},
+		{
+			name:    "reapply",
+			request: `mutation { apply(namespace: "` + testNamespace + `", workloads: [{name: "web", image: "` + seedImage + `"}]) { name drift } }`,
+			want:    `{"apply":[{"drift":[],"name":"web"}]}`,
+		},
+		{
+			name:    "workload",
+			request: `{ workload(namespace: "` + testNamespace + `", name: "batch") { name state synced } }`,
+			want:    `{"workload":{"name":"batch","state":"stopped","synced":true}}`,
+		},
+		{
+			name:    "workloads",
+			request: `{ workloads(namespace: "` + testNamespace + `") { name } }`,
+			want:    `{"workloads":[{"name":"batch"},{"name":"web"}]}`,
+		},
+		{
+			name:    "delete",
+			request: `mutation { deleteWorkload(namespace: "` + testNamespace + `", name: "batch") { name } }`,
+			want:    `{"deleteWorkload":null}`,
+		},
+		{
+			name:    "deleted",
+			request: `{ workload(namespace: "` + testNamespace + `", name: "batch") { name } }`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid state",
+			request: `mutation { apply(namespace: "` + testNamespace + `", workloads: [{name: "web", image: "` + seedImage + `", state: "paused"}]) { name } }`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid image",
+			request: `mutation { apply(namespace: "` + testNamespace + `", workloads: [{name: "web", image: "` + weirdString + `"}]) { name } }`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			result := graphql.Do(graphql.Params{Schema: schema, RequestString: test.request})
+
+			if result.HasErrors() && !test.wantErr {
+				t.Fatalf("request failed with errors: %v", result.Errors)
+			} else if !result.HasErrors() && test.wantErr {
+				t.Fatalf("request succeeded, want error")
+			}
+
+			if got, _ := json.Marshal(result.Data); !test.wantErr && string(got) != test.want {
+				t.Errorf("request returned %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPodRoundTrip(t *testing.T) {
+	svc := &service{
+		ContainerService: NewContainerService(map[string]node.Container{}),
+		PodService:       NewPodService(),
+	}
+	schema, schemaErr := api.NewGraphQLSchema(svc, api.NewResolverSet(svc))
+
+	if schemaErr != nil {
+		t.Fatalf("failed to create schema with error: %s", schemaErr.Error())
+	}
+
+	type roundTripTest struct {
+		name    string
+		request string
+		want    string
+		wantErr bool
+	}
+
+	tests := []roundTripTest{
+		{
+			name: "create",
+			request: `mutation { createPod(namespace: "` + testNamespace + `", id: "web", members: [
+				{name: "app", image: "` + seedImage + `", spec: {env: ["PORT=80"]}},
+				{name: "sidecar", image: "` + testImage + `"}
+			]) { id status sandbox { id image { name } task { status } } members { id image { name } spec { env sandbox } task { status } } } }`,
+			want: `{"createPod":{"id":"web","members":[` +
+				`{"id":"web.app","image":{"name":"` + seedImage + `"},"spec":{"env":["PORT=80"],"sandbox":"web"},"task":{"status":"running"}},` +
+				`{"id":"web.sidecar","image":{"name":"` + testImage + `"},"spec":{"env":[],"sandbox":"web"},"task":{"status":"running"}}],` +
+				`"sandbox":{"id":"web","image":{"name":"` + node.DefaultSandboxImage + `"},"task":{"status":"running"}},"status":"running"}}`,
+		},
+		{
+			name:    "pod",
+			request: `{ pod(namespace: "` + testNamespace + `", id: "web") { id status members { id } } }`,
+			want:    `{"pod":{"id":"web","members":[{"id":"web.app"},{"id":"web.sidecar"}],"status":"running"}}`,
+		},
+		{
+			name:    "pods",
+			request: `{ pods(namespace: "` + testNamespace + `") { id } }`,
+			want:    `{"pods":[{"id":"web"}]}`,
+		},
+		{
+			name:    "duplicate",
+			request: `mutation { createPod(namespace: "` + testNamespace + `", id: "web", members: [{name: "app", image: "` + seedImage + `"}]) { id } }`,
+			wantErr: true,
+		},
+		{
+			name:    "no members",
+			request: `mutation { createPod(namespace: "` + testNamespace + `", id: "empty", members: []) { id } }`,
+			wantErr: true,
+		},
+		{
+			name:    "duplicate member",
+			request: `mutation { createPod(namespace: "` + testNamespace + `", id: "twins", members: [{name: "app", image: "` + seedImage + `"}, {name: "app", image: "` + testImage + `"}]) { id } }`,
+			wantErr: true,
+		},
+		{
+			name:    "member with sandbox",
+			request: `mutation { createPod(namespace: "` + testNamespace + `", id: "nested", members: [{name: "app", image: "` + seedImage + `", spec: {sandbox: "web"}}]) { id } }`,
+			wantErr: true,
+		},
+		{
+			name:    "delete",
+			request: `mutation { deletePod(namespace: "` + testNamespace + `", id: "web") { id } }`,
+			want:    `{"deletePod":null}`,
+		},
+		{
+			name:    "deleted",
+			request: `{ pod(namespace: "` + testNamespace + `", id: "web") { id } }`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			result := graphql.Do(graphql.Params{Schema: schema, RequestString: test.request})
+
+			if result.HasErrors() && !test.wantErr {
+				t.Fatalf("request failed with errors: %v", result.Errors)
+			} else if !result.HasErrors() && test.wantErr {
+				t.Fatalf("request succeeded, want error")
+			}
+
+			if got, _ := json.Marshal(result.Data); !test.wantErr && string(got) != test.want {
+				t.Errorf("request returned %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNewUpdateContainerResourcesResolver(t *testing.T) {
+	type resolverArgs struct {
+		resolveParamArgs map[string]interface{}
+	}
+
+	type updateContainerResourcesResolverTest struct {
+		name    string
+		args    resolverArgs
+		wantErr bool
+	}
+
+	containerSvc := NewContainerService(map[string]node.Container{
+		testContainerID: NewContainer(testContainerID, NewImage(seedImage), NewTask(testContainerID, 1, node.Status{}, []node.ProcessInfo{})),
+	})
+	tests := []updateContainerResourcesResolverTest{
+		{name: "weird container ID", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "id": weirdString, "resources": map[string]interface{}{}}}, wantErr: true},
+		{name: "weird resources", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "id": testContainerID, "resources": weirdString}}, wantErr: true},
+		{name: "weird memory limit", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "id": testContainerID, "resources": map[string]interface{}{"memory_limit": weirdString}}}, wantErr: true},
+		{name: "negative cpu shares", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "id": testContainerID, "resources": map[string]interface{}{"cpu_shares": int64(-1)}}}, wantErr: true},
+		{name: "unknown container", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "id": "missing", "resources": map[string]interface{}{}}}, wantErr: true},
+		{name: "valid resources", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "id": testContainerID, "resources": map[string]interface{}{"memory_limit": int64(1 << 32), "cpu_shares": int64(512)}}}, wantErr: false},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			resolver := api.NewUpdateContainerResourcesResolver(containerSvc)
+			i, err := resolver(graphql.ResolveParams{
+				Args: test.args.resolveParamArgs,
+			})
+
+			if err != nil && !test.wantErr {
+				t.Errorf("update container resources resolver failed with error: " + err.Error())
+			} else if err == nil && test.wantErr {
+				t.Errorf("update container resources resolver succeeded, want error")
+			}
+
+			if _, containerValid := i.(api.Container); !containerValid && !test.wantErr {
+				t.Errorf("update container resources resolver returned incorrect type")
+			}
+		})
+	}
+}
+
+func TestUpdateContainerResourcesRoundTrip(t *testing.T) {
+	svc := &service{
+		ImageService: NewImageService(map[string]node.Image{seedImage: NewImage(seedImage)}),
+		ContainerService: NewContainerService(map[string]node.Container{
+			testContainerID: NewContainer(testContainerID, NewImage(seedImage), NewTask(testContainerID, 1, node.Status{}, nil)),
+		}),
+		TaskService: NewTaskService(map[string]node.Task{}),
+		LogService:  NewLogService(map[string][]node.LogRecord{}),
+	}
+
+	schema, schemaErr := api.NewGraphQLSchema(svc, api.NewResolverSet(svc))
+
+	if schemaErr != nil {
+		t.Fatalf("failed to create schema with error: %s", schemaErr.Error())
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema: schema,
+		RequestString: `mutation {
+			updateContainerResources(namespace: "` + testNamespace + `", id: "` + testContainerID + `", resources: {
+				memory_limit: 8589934592, cpu_quota: 50000, pids_limit: -1
+			}) {
+				spec { resources { cpu_quota cpu_period memory_limit pids_limit } }
+			}
+		}`,
+	})
+
+	if result.HasErrors() {
+		t.Fatalf("updateContainerResources failed with errors: %v", result.Errors)
+	}
+
+	got, _ := json.Marshal(result.Data)
+	want := `{"updateContainerResources":{"spec":{"resources":{"cpu_period":0,"cpu_quota":50000,"memory_limit":8589934592,"pids_limit":-1}}}}`
+
+	if string(got) != want {
+		t.Errorf("updateContainerResources returned %s, want %s", got, want)
+	}
+}
+
+func TestTaskMetricsRoundTrip(t *testing.T) {
+	svc := &service{
+		ImageService:     NewImageService(map[string]node.Image{}),
+		ContainerService: NewContainerService(map[string]node.Container{}),
+		TaskService: NewTaskService(map[string]node.Task{
+			"running": NewTask("running", 1, node.Status{Status: containerd.Running}, []node.ProcessInfo{{Pid: 1}, {Pid: 2}}),
+			"stopped": NewTask("stopped", 1, node.Status{Status: containerd.Stopped}, []node.ProcessInfo{}),
+		}),
+		LogService: NewLogService(map[string][]node.LogRecord{}),
+	}
+
+	schema, schemaErr := api.NewGraphQLSchema(svc, api.NewResolverSet(svc))
+
+	if schemaErr != nil {
+		t.Fatalf("failed to create schema with error: %s", schemaErr.Error())
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema: schema,
+		RequestString: `{
+			running: task(namespace: "` + testNamespace + `", container_id: "running") { metrics { memory_usage memory_limit pids_current } }
+			stopped: task(namespace: "` + testNamespace + `", container_id: "stopped") { metrics { memory_usage } }
+		}`,
+	})
+
+	if result.HasErrors() {
+		t.Fatalf("task query failed with errors: %v", result.Errors)
+	}
+
+	got, _ := json.Marshal(result.Data)
+	want := `{"running":{"metrics":{"memory_limit":0,"memory_usage":6442450944,"pids_current":2}},"stopped":{"metrics":null}}`
+
+	if string(got) != want {
+		t.Errorf("task query returned %s, want %s", got, want)
+	}
+}
+
+func TestImageMetadataRoundTrip(t *testing.T) {
+	svc := &service{
+		ImageService: NewImageService(map[string]node.Image{
+			seedImage: NewImage(seedImage),
+		}),
+		ContainerService: NewContainerService(map[string]node.Container{}),
+		TaskService:      NewTaskService(map[string]node.Task{}),
+		LogService:       NewLogService(map[string][]node.LogRecord{}),
+	}
+
+	schema, schemaErr := api.NewGraphQLSchema(svc, api.NewResolverSet(svc))
+
+	if schemaErr != nil {
+		t.Fatalf("failed to create schema with error: %s", schemaErr.Error())
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema: schema,
+		RequestString: `{
+			image(namespace: "` + testNamespace + `", ref: "` + seedImage + `") {
+				digest media_type created_at labels { key value } size unpacked_size platforms
+				config { entrypoint cmd env exposed_ports user }
+			}
+		}`,
+	})
+
+	if result.HasErrors() {
+		t.Fatalf("image query failed with errors: %v", result.Errors)
+	}
+
+	got, _ := json.Marshal(result.Data)
+	want := `{"image":{"config":{"cmd":["serve"],"entrypoint":["/entrypoint.sh"],"env":["PATH=/bin"],"exposed_ports":["80/tcp"],"user":"nobody"},` +
+		`"created_at":"2020-01-02T03:04:05Z","digest":"` + digest.FromString(seedImage).String() + `","labels":[{"key":"ref","value":"` + seedImage + `"}],` +
+		`"media_type":"` + ocispec.MediaTypeImageManifest + `","platforms":["linux/amd64","linux/arm64/v8"],"size":3145728,"unpacked_size":8589934592}}`
+
+	if string(got) != want {
+		t.Errorf("image query returned %s, want %s", got, want)
+	}
+}
+
+func TestNewPullJobResolvers(t *testing.T) {
+	type resolverArgs struct {
+		resolveParamArgs map[string]interface{}
+		source           interface{}
+	}
+
+	type pullJobResolverTest struct {
+		name     string
+		resolver graphql.FieldResolveFn
+		args     resolverArgs
+		wantErr  bool
+	}
+
+	imageSvc := NewImageService(map[string]node.Image{
+		seedImage: NewImage(seedImage),
+	})
+
+	tests := []pullJobResolverTest{
+		{name: "weird namespace", resolver: api.NewPullJobResolver(imageSvc), args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": 1, "ref": seedImage}}, wantErr: true},
+		{name: "nil ref", resolver: api.NewPullJobResolver(imageSvc), args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "ref": nil}}, wantErr: true},
+		{name: "never pulled", resolver: api.NewPullJobResolver(imageSvc), args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "ref": testImage}}, wantErr: true},
+		{name: "pulled", resolver: api.NewPullJobResolver(imageSvc), args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "ref": seedImage}}},
+		{name: "follow not streamed", resolver: api.NewFollowPullJobResolver(imageSvc), args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "ref": seedImage}}, wantErr: true},
+		{name: "follow update", resolver: api.NewFollowPullJobResolver(imageSvc), args: resolverArgs{source: map[string]interface{}{"pull_job": pulledJob(seedImage)}}},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			i, err := test.resolver(graphql.ResolveParams{
+				Args:   test.args.resolveParamArgs,
+				Source: test.args.source,
+			})
+
+			if err != nil && !test.wantErr {
+				t.Fatalf("pullJob resolver failed with error: " + err.Error())
+			} else if err == nil && test.wantErr {
+				t.Fatalf("pullJob resolver succeeded, want error")
+			}
+
+			if job, jobValid := i.(api.PullJob); !test.wantErr && (!jobValid || job.Ref != seedImage || job.Status != node.PullDone || job.FinishedAt != "2020-01-02T03:04:05Z") {
+				t.Errorf("pullJob resolver returned %+v, want the finished pull of %s", i, seedImage)
+			}
+		})
+	}
+}
+
+func TestPruneImagesRoundTrip(t *testing.T) {
+	imageSvc := NewImageService(map[string]node.Image{seedImage: NewImage(seedImage)})
+	svc := &service{ImageService: imageSvc}
+	schema, schemaErr := api.NewGraphQLSchema(svc, api.NewResolverSet(svc))
+
+	if schemaErr != nil {
+		t.Fatalf("failed to create schema with error: %s", schemaErr.Error())
+	}
+
+	type roundTripTest struct {
+		name     string
+		args     string
+		want     string
+		wantLeft int
+	}
+
+	tests := []roundTripTest{
+		{
+			name:     "dry run by default",
+			args:     `namespace: "` + testNamespace + `"`,
+			want:     `{"pruneImages":{"dry_run":true,"freed":1048576,"images":[{"name":"` + seedImage + `","reason":"max_age","size":1048576}]}}`,
+			wantLeft: 1,
+		},
+		{
+			name: "prune",
+			args: `namespace: "` + testNamespace + `", dry_run: false`,
+			want: `{"pruneImages":{"dry_run":false,"freed":1048576,"images":[{"name":"` + seedImage + `","reason":"max_age","size":1048576}]}}`,
+		},
+		{
+			name: "nothing left",
+			args: `namespace: "` + testNamespace + `", dry_run: false`,
+			want: `{"pruneImages":{"dry_run":false,"freed":0,"images":[]}}`,
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			result := graphql.Do(graphql.Params{
+				Schema:        schema,
+				RequestString: `mutation { pruneImages(` + test.args + `) { dry_run freed images { name reason size } } }`,
+			})
+
+			if result.HasErrors() {
+				t.Fatalf("pruneImages failed with errors: %v", result.Errors)
+			}
+
+			got, _ := json.Marshal(result.Data)
+
+			if string(got) != test.want {
+				t.Errorf("pruneImages returned %s, want %s", got, test.want)
+			}
+
+			if left, _ := imageSvc.GetImages(context.Background(), ""); len(left) != test.wantLeft {
+				t.Errorf("pruneImages left %d images, want %d", len(left), test.wantLeft)
+			}
+		})
+	}
+}
+
+func TestTagImageRoundTrip(t *testing.T) {
+	var (
+		imageSvc = NewImageService(map[string]node.Image{seedImage: NewImage(seedImage)})
+		svc      = &service{ImageService: imageSvc}
+		prod     = "docker.io/library/nginx:prod"
+		seed     = digest.FromString(seedImage).String()
+	)
+
+	schema, schemaErr := api.NewGraphQLSchema(svc, api.NewResolverSet(svc))
+
+	if schemaErr != nil {
+		t.Fatalf("failed to create schema with error: %s", schemaErr.Error())
+	}
+
+	type roundTripTest struct {
+		name     string
+		mutation string
+		want     string
+		wantErr  bool
+		wantLeft []string
+	}
+
+	tests := []roundTripTest{
+		{
+			name:     "tag",
+			mutation: `tagImage(namespace: "` + testNamespace + `", source: "` + seedImage + `", target: "` + prod + `") { name digest }`,
+			want:     `{"tagImage":{"digest":"` + seed + `","name":"` + prod + `"}}`,
+			wantLeft: []string{seedImage, prod},
+		},
+		{
+			name:     "tag missing image",
+			mutation: `tagImage(namespace: "` + testNamespace + `", source: "` + weirdString + `", target: "` + prod + `") { name }`,
+			wantErr:  true,
+			wantLeft: []string{seedImage, prod},
+		},
+		{
+			name:     "tag without target",
+			mutation: `tagImage(namespace: "` + testNamespace + `", source: "` + seedImage + `") { name }`,
+			wantErr:  true,
+			wantLeft: []string{seedImage, prod},
+		},
+		{
+			name:     "untag",
+			mutation: `untagImage(namespace: "` + testNamespace + `", ref: "` + seedImage + `") { name }`,
+			want:     `{"untagImage":null}`,
+			wantLeft: []string{prod},
+		},
+		{
+			name:     "untag last name",
+			mutation: `untagImage(namespace: "` + testNamespace + `", ref: "` + prod + `") { name }`,
+			wantErr:  true,
+			wantLeft: []string{prod},
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			result := graphql.Do(graphql.Params{
+				Schema:        schema,
+				RequestString: `mutation { ` + test.mutation + ` }`,
+			})
+
+			if result.HasErrors() && !test.wantErr {
+				t.Fatalf("mutation failed with errors: %v", result.Errors)
+			} else if !result.HasErrors() && test.wantErr {
+				t.Fatalf("mutation succeeded, want error")
+			}
+
+			if got, _ := json.Marshal(result.Data); !test.wantErr && string(got) != test.want {
+				t.Errorf("mutation returned %s, want %s", got, test.want)
+			}
+
+			var left []string
+			images, _ := imageSvc.GetImages(context.Background(), "")
+
+			for _, i := range images {
+				left = append(left, i.Name())
+			}
+
+			sort.Strings(left)
+
+			if !reflect.DeepEqual(left, test.wantLeft) {
+				t.Errorf("mutation left images %v, want %v", left, test.wantLeft)
+			}
+		})
+	}
+}
+
+func TestOperationsRoundTrip(t *testing.T) {
+	_, svc, cleanup := newRunningNode(t, node.ContainerSpec{})
+	defer cleanup()
+
+	schema, schemaErr := api.NewGraphQLSchema(svc, api.NewResolverSet(svc))
+
+	if schemaErr != nil {
+		t.Fatalf("failed to create schema with error: %s", schemaErr.Error())
+	}
+
+	do := func(request string) (data map[string]api.Operation) {
+		result := graphql.Do(graphql.Params{Schema: schema, RequestString: request})
+
+		if result.HasErrors() {
+			t.Fatalf("%s failed with errors: %v", request, result.Errors)
+		}
+
+		encoded, _ := json.Marshal(result.Data)
+		json.Unmarshal(encoded, &data)
+
+		return data
+	}
+
+	// wait polls the operation query until the operation with the given ID is over.
+	wait := func(id string) api.Operation {
+		deadline := time.Now().Add(5 * time.Second)
+
+		for {
+			op := do(`{ operation(namespace: "` + testNamespace + `", id: "` + id + `") { id kind target status error finished_at image { name } } }`)["operation"]
+
+			if op.Status != node.OperationRunning || time.Now().After(deadline) {
+				return op
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	pull := do(`mutation { createImageAsync(namespace: "` + testNamespace + `", ref: "` + testImage + `") { id kind target status } }`)["createImageAsync"]
+
+	if pull.ID == "" || pull.Kind != node.PullImageOperation || pull.Target != testImage {
+		t.Fatalf("createImageAsync returned %+v, want a pullImage operation of %s", pull, testImage)
+	}
+
+	if pulled := wait(pull.ID); pulled.Status != node.OperationSucceeded || pulled.Image == nil || pulled.Image.Name != testImage || pulled.FinishedAt == "" {
+		t.Errorf("operation query returned %+v, want a succeeded pull of %s", pulled, testImage)
+	}
+
+	kill := do(`mutation { killTaskAsync(namespace: "` + testNamespace + `", container_id: "` + testContainerID + `", timeout: 5) { id kind target } }`)["killTaskAsync"]
+
+	if killed := wait(kill.ID); killed.Kind != node.KillTaskOperation || killed.Target != testContainerID || killed.Status != node.OperationSucceeded || killed.Image != nil {
+		t.Errorf("operation query returned %+v, want a succeeded kill of %s", killed, testContainerID)
+	}
+
+	if cancelled := do(`mutation { cancelOperation(namespace: "` + testNamespace + `", id: "` + kill.ID + `") { id status } }`)["cancelOperation"]; cancelled.Status != node.OperationSucceeded {
+		t.Errorf("cancelOperation returned %+v for a finished operation, want it left as it was", cancelled)
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ operations(namespace: "` + testNamespace + `") { id } }`,
+	})
+	got, _ := json.Marshal(result.Data)
+	want := `{"operations":[{"id":"` + pull.ID + `"},{"id":"` + kill.ID + `"}]}`
+
+	if string(got) != want {
+		t.Errorf("operations query returned %s, want %s", got, want)
+	}
+
+	result = graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ operation(namespace: "` + testNamespace + `", id: "op-unknown") { id } }`,
+	})
+
+	if !result.HasErrors() {
+		t.Errorf("operation query of an unknown ID succeeded, want error")
+	}
+}
+
+func TestNewEventsResolver(t *testing.T) {
+	type resolverArgs struct {
+		resolveParamArgs map[string]interface{}
+		source           interface{}
+	}
+
+	type eventsResolverTest struct {
+		name      string
+		args      resolverArgs
+		wantEvent api.Event
+		wantErr   bool
+	}
+
+	exitedAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	exit := node.Event{Seq: 7, Timestamp: exitedAt, Namespace: testNamespace, Topic: node.TaskExitTopic, ContainerID: testContainerID, Pid: 1, ExitStatus: 137, ExitedAt: exitedAt}
+	tests := []eventsResolverTest{
+		{name: "weird namespace", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": 1}}, wantErr: true},
+		{name: "weird topic", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "topic": 1}}, wantErr: true},
+		{name: "negative since", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "since": -1}}, wantErr: true},
+		{name: "not streamed", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace}}, wantErr: true},
+		{
+			name: "event",
+			args: resolverArgs{source: map[string]interface{}{"event": exit}},
+			wantEvent: api.Event{
+				Seq: 7, Time: "2020-01-02T03:04:05Z", Namespace: testNamespace, Topic: node.TaskExitTopic, ContainerID: testContainerID,
+				PID: 1, ExitStatus: 137, ExitedAt: "2020-01-02T03:04:05Z",
+			},
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			eventsResolver := api.NewEventsResolver(nil)
+			i, err := eventsResolver(graphql.ResolveParams{
+				Args:   test.args.resolveParamArgs,
+				Source: test.args.source,
+			})
+
+			if err != nil && !test.wantErr {
+				t.Fatalf("events resolver failed with error: " + err.Error())
+			} else if err == nil && test.wantErr {
+				t.Fatalf("events resolver succeeded, want error")
+			}
+
+			if event, eventValid := i.(api.Event); !test.wantErr && (!eventValid || event != test.wantEvent) {
+				t.Errorf("events resolver returned %+v, want %+v", i, test.wantEvent)
+			}
+		})
+	}
+}
+
+func TestNewLogsResolver(t *testing.T) {
+	type resolverArgs struct {
+		resolveParamArgs map[string]interface{}
+	}
+
+	type logsResolverTest struct {
+		name      string
+		args      resolverArgs
+		wantLines []string
+		wantErr   bool
+	}
+
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	logSvc := NewLogService(map[string][]node.LogRecord{
+		testContainerID: {
+			{Time: start, Stream: node.StdoutStream, Line: "one"},
+			{Time: start.Add(time.Second), Stream: node.StderrStream, Line: "two"},
+			{Time: start.Add(2 * time.Second), Stream: node.StdoutStream, Line: "three"},
+		},
+	})
+	tests := []logsResolverTest{
+		{name: "nil namespace", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": nil, "container_id": testContainerID}}, wantErr: true},
+		{name: "nil container ID", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": nil}}, wantErr: true},
+		{name: "unknown container ID", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": weirdString}}, wantErr: true},
+		{name: "weird since", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": testContainerID, "since": weirdString}}, wantErr: true},
+		{name: "weird tail", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": testContainerID, "tail": weirdString}}, wantErr: true},
+		{name: "weird stream", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": testContainerID, "stream": 1}}, wantErr: true},
+		{name: "all", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": testContainerID}}, wantLines: []string{"one", "two", "three"}},
+		{name: "since", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": testContainerID, "since": "2020-01-02T03:04:06Z"}}, wantLines: []string{"two", "three"}},
+		{name: "tail", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": testContainerID, "tail": 1}}, wantLines: []string{"three"}},
+		{name: "stream", args: resolverArgs{resolveParamArgs: map[string]interface{}{"namespace": testNamespace, "container_id": testContainerID, "stream": node.StderrStream}}, wantLines: []string{"two"}},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			logsResolver := api.NewLogsResolver(logSvc)
+			i, err := logsResolver(graphql.ResolveParams{
+				Args: test.args.resolveParamArgs,
+			})
+
+			if err != nil && !test.wantErr {
+				t.Errorf("logs resolver failed with error: " + err.Error())
+			} else if err == nil && test.wantErr {
+				t.Errorf("logs resolver succeeded, want error")
+			}
+
+			if test.wantErr {
+				return
+			}
+
+			records, recordsValid := i.([]api.LogRecord)
+
+			if !recordsValid {
+				t.Fatalf("logs resolver returned incorrect type")
+			}
+
+			var lines []string
+
+			for _, r := range records {
+				lines = append(lines, r.Line)
+			}
+
+			if !reflect.DeepEqual(lines, test.wantLines) {
+				t.Errorf("logs resolver returned %v, want %v", lines, test.wantLines)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Int64 is a graphql scalar for integers that don't fit graphql.Int's 32 bits, like byte counts.
+// It serializes to a JSON number and accepts integer literals and variables.
+var Int64 = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Int64",
+	Description: "The `Int64` scalar type represents a signed 64-bit integer.",
+	Serialize:   coerceInt64,
+	ParseValue:  coerceInt64,
+	ParseLiteral: func(valueAST ast.Value) interface{} {
+		if v, isInt := valueAST.(*ast.IntValue); isInt {
+			if i, err := strconv.ParseInt(v.Value, 10, 64); err == nil {
+				return i
+			}
+		}
+
+		return nil
+	},
+})
+
+func coerceInt64(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	case uint32:
+		return int64(v)
+	case uint64:
+		return int64(v)
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v)
+		}
+	case string:
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return i
+		}
+	}
+
+	return nil
+}
+
+// getInt64 converts an Int64 argument into an int64.
+func getInt64(raw interface{}) (int64, error) {
+	if i, isInt64 := coerceInt64(raw).(int64); isInt64 {
+		return i, nil
+	}
+
+	return 0, fmt.Errorf("invalid request")
+}
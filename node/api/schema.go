@@ -5,7 +5,7 @@ import (
 	"github.com/mokrz/clamor/node"
 )
 
-// NewGraphQLSchema returns a new graphql schema instance containing root Query and root Mutation types.
+// NewGraphQLSchema returns a new graphql schema instance containing root Query, Mutation and Subscription types.
 // It's responsible for allocating the remainder of the API's graphql fields and wiring them to their respective resolvers + arguments.
 func NewGraphQLSchema(ns node.Service, resolverSet *ResolverSet) (schema graphql.Schema, err error) {
 	queryType := graphql.NewObject(graphql.ObjectConfig{
@@ -13,25 +13,56 @@ func NewGraphQLSchema(ns node.Service, resolverSet *ResolverSet) (schema graphql
 		Fields: graphql.Fields{
 			"image":      NewImageField(ns, resolverSet.ImageResolver, imageArgs),
 			"images":     NewImagesField(ns, resolverSet.ImagesResolver, imagesArgs),
+			"pullJob":    NewPullJobField(ns, resolverSet.PullJobResolver, pullJobArgs),
+			"pullJobs":   NewPullJobsField(ns, resolverSet.PullJobsResolver, pullJobsArgs),
+			"operation":  NewOperationField(ns, resolverSet.OperationResolver, operationArgs),
+			"operations": NewOperationsField(ns, resolverSet.OperationsResolver, operationsArgs),
 			"container":  NewContainerField(ns, resolverSet.ContainerResolver, containerArgs),
 			"containers": NewContainersField(ns, resolverSet.ContainersResolver, containersArgs),
 			"task":       NewTaskField(ns, resolverSet.TaskResolver, taskArgs),
 			"tasks":      NewTasksField(ns, resolverSet.TasksResolver, tasksArgs),
+			"logs":       NewLogsField(ns, resolverSet.LogsResolver, logsArgs),
+			"workload":   NewWorkloadField(ns, resolverSet.WorkloadResolver, workloadArgs),
+			"workloads":  NewWorkloadsField(ns, resolverSet.WorkloadsResolver, workloadsArgs),
+			"pod":        NewPodField(ns, resolverSet.PodResolver, podArgs),
+			"pods":       NewPodsField(ns, resolverSet.PodsResolver, podsArgs),
 		},
 	})
 
 	mutationType := graphql.NewObject(graphql.ObjectConfig{
 		Name: "Mutation",
 		Fields: graphql.Fields{
-			"createImage":     NewImageField(ns, resolverSet.CreateImageResolver, createImageArgs),
-			"createContainer": NewContainerField(ns, resolverSet.ContainerResolver, createContainerArgs),
-			"createTask":      NewTaskField(ns, resolverSet.CreateTaskResolver, createTaskArgs),
-			"deleteImage":     NewImageField(ns, resolverSet.DeleteImageResolver, imageArgs),
-			"deleteContainer": NewContainerField(ns, resolverSet.DeleteContainerResolver, containerArgs),
-			"deleteTask":      NewTaskField(ns, resolverSet.DeleteTaskResolver, taskArgs),
-			"killTask":        NewTaskField(ns, resolverSet.KillTaskResolver, taskArgs),
+			"createImage":              NewImageField(ns, resolverSet.CreateImageResolver, createImageArgs),
+			"createContainer":          NewContainerField(ns, resolverSet.CreateContainerResolver, createContainerArgs),
+			"createTask":               NewTaskField(ns, resolverSet.CreateTaskResolver, createTaskArgs),
+			"deleteImage":              NewImageField(ns, resolverSet.DeleteImageResolver, imageArgs),
+			"tagImage":                 NewImageField(ns, resolverSet.TagImageResolver, tagImageArgs),
+			"untagImage":               NewImageField(ns, resolverSet.UntagImageResolver, imageArgs),
+			"deleteContainer":          NewContainerField(ns, resolverSet.DeleteContainerResolver, containerArgs),
+			"updateContainerResources": NewContainerField(ns, resolverSet.UpdateContainerResourcesResolver, updateContainerResourcesArgs),
+			"deleteTask":               NewTaskField(ns, resolverSet.DeleteTaskResolver, taskArgs),
+			"killTask":                 NewTaskField(ns, resolverSet.KillTaskResolver, killTaskArgs),
+			"pauseTask":                NewTaskField(ns, resolverSet.PauseTaskResolver, taskArgs),
+			"resumeTask":               NewTaskField(ns, resolverSet.ResumeTaskResolver, taskArgs),
+			"execTask":                 NewExecResultField(ns, resolverSet.ExecTaskResolver, execTaskArgs),
+			"createImageAsync":         NewOperationField(ns, resolverSet.CreateImageAsyncResolver, createImageArgs),
+			"killTaskAsync":            NewOperationField(ns, resolverSet.KillTaskAsyncResolver, killTaskArgs),
+			"cancelOperation":          NewOperationField(ns, resolverSet.CancelOperationResolver, operationArgs),
+			"pruneImages":              NewImagePruneReportField(ns, resolverSet.PruneImagesResolver, pruneImagesArgs),
+			"apply":                    NewWorkloadsField(ns, resolverSet.ApplyResolver, applyArgs),
+			"deleteWorkload":           NewWorkloadField(ns, resolverSet.DeleteWorkloadResolver, workloadArgs),
+			"createPod":                NewPodField(ns, resolverSet.CreatePodResolver, createPodArgs),
+			"deletePod":                NewPodField(ns, resolverSet.DeletePodResolver, podArgs),
 		},
 	})
 
-	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType, Mutation: mutationType})
+	subscriptionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Subscription",
+		Fields: graphql.Fields{
+			"events":  NewEventField(ns, resolverSet.EventsResolver, eventsArgs),
+			"pullJob": NewPullJobField(ns, resolverSet.FollowPullJobResolver, pullJobArgs),
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType, Mutation: mutationType, Subscription: subscriptionType})
 }
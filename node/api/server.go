@@ -4,23 +4,33 @@ Package api defines Node GraphQL fields, their resolvers, their arguments and th
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/namespaces"
 	"github.com/graphql-go/graphql"
+	"github.com/mokrz/clamor/node"
 )
 
 // Server holds various API server resources.
+// Node backs the streaming endpoints that don't fit graphql's request/response model.
 type Server struct {
 	SockAddr string
 	Schema   graphql.Schema
+	Node     node.Service
 }
 
 // NewServer returns Server instances.
-func NewServer(schema graphql.Schema, sockAddr string) (apiServer *Server) {
+func NewServer(schema graphql.Schema, svc node.Service, sockAddr string) (apiServer *Server) {
 	return &Server{
 		SockAddr: sockAddr,
 		Schema:   schema,
+		Node:     svc,
 	}
 }
 
@@ -35,5 +45,211 @@ func (as Server) Serve() (err error) {
 		json.NewEncoder(w).Encode(result)
 	})
 
+	http.Handle("/logs", NewLogsHandler(as.Node))
+	http.Handle("/exec", NewExecHandler(as.Node))
+	http.Handle("/attach", NewAttachHandler(as.Node))
+	http.Handle("/events", NewEventsHandler(as.Schema))
+	http.Handle("/images/import", NewImportHandler(as.Node))
+	http.Handle("/images/export", NewExportHandler(as.Node))
+
 	return http.ListenAndServe(as.SockAddr, nil)
 }
+
+// NewLogsHandler returns an HTTP handler that writes a container's task output as newline delimited LogRecord JSON.
+// It takes the namespace, container_id, since, tail and stream query parameters of the logs query.
+// With follow=true the response is kept open and new output is written as it's produced, until the task exits or the client goes away.
+func NewLogsHandler(svc node.LogService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			opts                node.LogOptions
+			records             <-chan node.LogRecord
+			follow              bool
+			parseErr, streamErr error
+			query               = r.URL.Query()
+			containerID         = query.Get("container_id")
+		)
+
+		if query.Get("since") != "" {
+
+			if opts.Since, parseErr = time.Parse(time.RFC3339Nano, query.Get("since")); parseErr != nil {
+				http.Error(w, "invalid request", http.StatusBadRequest)
+				return
+			}
+		}
+
+		if query.Get("tail") != "" {
+
+			if opts.Tail, parseErr = strconv.Atoi(query.Get("tail")); parseErr != nil {
+				http.Error(w, "invalid request", http.StatusBadRequest)
+				return
+			}
+		}
+
+		if query.Get("follow") != "" {
+
+			if follow, parseErr = strconv.ParseBool(query.Get("follow")); parseErr != nil {
+				http.Error(w, "invalid request", http.StatusBadRequest)
+				return
+			}
+		}
+
+		if opts.Stream = query.Get("stream"); opts.Validate() != nil || query.Get("namespace") == "" || containerID == "" {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+
+		ctx := namespaces.WithNamespace(r.Context(), query.Get("namespace"))
+
+		if follow {
+			records, streamErr = svc.FollowLogs(ctx, containerID, opts)
+		} else {
+			records, streamErr = sentLogs(ctx, svc, containerID, opts)
+		}
+
+		if streamErr != nil {
+			var dne node.ErrNotFound
+
+			if errors.As(streamErr, &dne) {
+				http.Error(w, dne.Error(), http.StatusNotFound)
+			} else {
+				http.Error(w, streamErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		flusher, canFlush := w.(http.Flusher)
+
+		if canFlush {
+			flusher.Flush()
+		}
+
+		enc := json.NewEncoder(w)
+
+		for record := range records {
+
+			if encodeErr := enc.Encode(getLogRecordInfo(record)); encodeErr != nil {
+				return
+			}
+
+			if canFlush {
+				flusher.Flush()
+			}
+		}
+	})
+}
+
+// sentLogs adapts GetLogs to the channel FollowLogs returns.
+func sentLogs(ctx context.Context, svc node.LogService, containerID string, opts node.LogOptions) (<-chan node.LogRecord, error) {
+	records, err := svc.GetLogs(ctx, containerID, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sent := make(chan node.LogRecord, len(records))
+
+	for _, r := range records {
+		sent <- r
+	}
+
+	close(sent)
+
+	return sent, nil
+}
+
+// NewImportHandler returns an HTTP handler that imports the OCI image layout or docker save tarball POSTed to it into the namespace query parameter's namespace.
+// The tarball is streamed into the image store as it's uploaded. The imported images are written back as a JSON list of Image.
+func NewImportHandler(svc node.ImageService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			imported  []node.Image
+			importErr error
+			namespace = r.URL.Query().Get("namespace")
+		)
+
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if namespace == "" {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+
+		ctx := namespaces.WithNamespace(r.Context(), namespace)
+
+		if imported, importErr = svc.ImportImages(ctx, r.Body); importErr != nil {
+
+			if errors.Is(importErr, errdefs.ErrInvalidArgument) {
+				http.Error(w, importErr.Error(), http.StatusBadRequest)
+			} else {
+				http.Error(w, importErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		decoratedImages := []Image{}
+
+		for _, image := range imported {
+			decoratedImages = append(decoratedImages, getImageInfo(ctx, image))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(decoratedImages)
+	})
+}
+
+// NewExportHandler returns an HTTP handler that streams the images named by the name query parameters, in the namespace query parameter's namespace,
+// as a single OCI image layout tarball that the import endpoint and docker load read back.
+func NewExportHandler(svc node.ImageService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			query = r.URL.Query()
+			names = query["name"]
+		)
+
+		if query.Get("namespace") == "" || len(names) == 0 {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+
+		ctx := namespaces.WithNamespace(r.Context(), query.Get("namespace"))
+		archive := &exportWriter{ResponseWriter: w}
+
+		if exportErr := svc.ExportImages(ctx, archive, names...); exportErr != nil {
+			var dne node.ErrNotFound
+
+			// Once the tarball has started, the status is sent and the client is left with a truncated archive.
+			if archive.started {
+				return
+			}
+
+			if errors.As(exportErr, &dne) {
+				http.Error(w, dne.Error(), http.StatusNotFound)
+			} else {
+				http.Error(w, exportErr.Error(), http.StatusInternalServerError)
+			}
+		}
+	})
+}
+
+// exportWriter sends the tarball headers along with the first bytes of an export, so failures before then can still be reported with a status.
+type exportWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (ew *exportWriter) Write(p []byte) (int, error) {
+	if !ew.started {
+		ew.started = true
+		ew.Header().Set("Content-Type", "application/x-tar")
+		ew.Header().Set("Content-Disposition", `attachment; filename="images.tar"`)
+	}
+
+	return ew.ResponseWriter.Write(p)
+}
@@ -0,0 +1,768 @@
+package api_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/containerd/containerd/namespaces"
+	"github.com/gorilla/websocket"
+	"github.com/graphql-go/graphql"
+	"github.com/mokrz/clamor/node"
+	"github.com/mokrz/clamor/node/api"
+)
+
+func TestLogsHandler(t *testing.T) {
+	type handlerTest struct {
+		name       string
+		query      url.Values
+		wantStatus int
+		wantLines  []string
+	}
+
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	logSvc := NewLogService(map[string][]node.LogRecord{
+		testContainerID: {
+			{Time: start, Stream: node.StdoutStream, Line: "one"},
+			{Time: start.Add(time.Second), Stream: node.StderrStream, Line: "two"},
+		},
+	})
+	tests := []handlerTest{
+		{name: "missing namespace", query: url.Values{"container_id": {testContainerID}}, wantStatus: http.StatusBadRequest},
+		{name: "weird tail", query: url.Values{"namespace": {testNamespace}, "container_id": {testContainerID}, "tail": {weirdString}}, wantStatus: http.StatusBadRequest},
+		{name: "weird since", query: url.Values{"namespace": {testNamespace}, "container_id": {testContainerID}, "since": {weirdString}}, wantStatus: http.StatusBadRequest},
+		{name: "weird stream", query: url.Values{"namespace": {testNamespace}, "container_id": {testContainerID}, "stream": {weirdString}}, wantStatus: http.StatusBadRequest},
+		{name: "weird follow", query: url.Values{"namespace": {testNamespace}, "container_id": {testContainerID}, "follow": {weirdString}}, wantStatus: http.StatusBadRequest},
+		{name: "all", query: url.Values{"namespace": {testNamespace}, "container_id": {testContainerID}}, wantStatus: http.StatusOK, wantLines: []string{"one", "two"}},
+		{name: "follow tail", query: url.Values{"namespace": {testNamespace}, "container_id": {testContainerID}, "tail": {"1"}, "follow": {"true"}}, wantStatus: http.StatusOK, wantLines: []string{"two"}},
+	}
+
+	server := httptest.NewServer(api.NewLogsHandler(logSvc))
+	defer server.Close()
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := http.Get(server.URL + "?" + test.query.Encode())
+
+			if err != nil {
+				t.Fatalf("logs request failed with error: %s", err.Error())
+			}
+
+			defer resp.Body.Close()
+
+			if resp.StatusCode != test.wantStatus {
+				t.Fatalf("logs handler returned status %d, want %d", resp.StatusCode, test.wantStatus)
+			}
+
+			if test.wantStatus != http.StatusOK {
+				return
+			}
+
+			var lines []string
+			dec := json.NewDecoder(resp.Body)
+
+			for dec.More() {
+				var record api.LogRecord
+
+				if decodeErr := dec.Decode(&record); decodeErr != nil {
+					t.Fatalf("failed to decode log record with error: %s", decodeErr.Error())
+				}
+
+				lines = append(lines, record.Line)
+			}
+
+			if fmt.Sprint(lines) != fmt.Sprint(test.wantLines) {
+				t.Errorf("logs handler returned %v, want %v", lines, test.wantLines)
+			}
+		})
+	}
+}
+
+func TestLogsHandlerFollow(t *testing.T) {
+	backend, svc, cleanup := newRunningNode(t, node.ContainerSpec{})
+	defer cleanup()
+
+	ctx := namespaces.WithNamespace(context.Background(), testNamespace)
+	taskIO, _ := backend.TaskIO(ctx, testContainerID)
+	fmt.Fprintln(taskIO.Stdout, "before")
+
+	server := httptest.NewServer(api.NewLogsHandler(svc))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?" + url.Values{"namespace": {testNamespace}, "container_id": {testContainerID}, "follow": {"true"}}.Encode())
+
+	if err != nil {
+		t.Fatalf("logs request failed with error: %s", err.Error())
+	}
+
+	defer resp.Body.Close()
+	lines := make(chan string)
+
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(resp.Body)
+
+		for scanner.Scan() {
+			var record api.LogRecord
+			json.Unmarshal(scanner.Bytes(), &record)
+			lines <- record.Line
+		}
+	}()
+
+	for _, want := range []string{"before", "after"} {
+		select {
+		case got := <-lines:
+			if got != want {
+				t.Fatalf("followed logs returned %q, want %q", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %q", want)
+		}
+
+		if want == "before" {
+			fmt.Fprintln(taskIO.Stderr, "after")
+		}
+	}
+
+	container, _ := backend.LoadContainer(ctx, testContainerID)
+	task, _ := container.LoadTask(ctx)
+	task.Kill(ctx, syscall.SIGKILL)
+
+	select {
+	case line, open := <-lines:
+		if open {
+			t.Errorf("followed logs returned %q after the task exited, want end of stream", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("followed logs didn't end after the task exited")
+	}
+}
+
+func TestEventsHandler(t *testing.T) {
+	type handlerTest struct {
+		name       string
+		query      string
+		wantStatus int
+	}
+
+	_, svc, cleanup := newRunningNode(t, node.ContainerSpec{})
+	defer cleanup()
+
+	schema, schemaErr := api.NewGraphQLSchema(svc, api.NewResolverSet(svc))
+
+	if schemaErr != nil {
+		t.Fatalf("failed to create schema with error: %s", schemaErr.Error())
+	}
+
+	server := httptest.NewServer(api.NewEventsHandler(schema))
+	defer server.Close()
+
+	tests := []handlerTest{
+		{name: "query", query: `{ tasks(namespace: "` + testNamespace + `") { id } }`, wantStatus: http.StatusBadRequest},
+		{name: "mutation", query: `mutation { deleteTask(namespace: "` + testNamespace + `", container_id: "` + testContainerID + `") { id } }`, wantStatus: http.StatusBadRequest},
+		{name: "missing namespace", query: `subscription { events { seq } }`, wantStatus: http.StatusBadRequest},
+		{name: "weird topic", query: `subscription { events(namespace: "` + testNamespace + `", topic: "tasks") { seq } }`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := http.Get(server.URL + "?" + url.Values{"query": {test.query}}.Encode())
+
+			if err != nil {
+				t.Fatalf("events request failed with error: %s", err.Error())
+			}
+
+			resp.Body.Close()
+
+			if resp.StatusCode != test.wantStatus {
+				t.Errorf("events handler returned status %d, want %d", resp.StatusCode, test.wantStatus)
+			}
+		})
+	}
+
+	// The streams are cut before the server is closed, which waits for them to end.
+	ctx, cancel := context.WithCancel(namespaces.WithNamespace(context.Background(), testNamespace))
+	defer cancel()
+
+	subscribe := func(since int) <-chan api.Event {
+		query := `subscription { events(namespace: "` + testNamespace + `", topic: "/tasks", container_id: "` + testContainerID + `", since: ` + fmt.Sprint(since) + `) {
+			seq topic container_id exit_status
+		} }`
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?"+url.Values{"query": {query}}.Encode(), nil)
+		resp, err := http.DefaultClient.Do(req)
+
+		if err != nil {
+			t.Fatalf("events request failed with error: %s", err.Error())
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			t.Fatalf("events handler returned status %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+
+		events := make(chan api.Event)
+
+		go func() {
+			defer resp.Body.Close()
+			defer close(events)
+			scanner := bufio.NewScanner(resp.Body)
+
+			for scanner.Scan() {
+				var result struct {
+					Data struct {
+						Events api.Event `json:"events"`
+					} `json:"data"`
+				}
+
+				json.Unmarshal(scanner.Bytes(), &result)
+
+				select {
+				case events <- result.Data.Events:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		return events
+	}
+	expect := func(events <-chan api.Event, topic string) api.Event {
+		select {
+		case e := <-events:
+			if e.Topic != topic || e.ContainerID != testContainerID {
+				t.Fatalf("events handler streamed %+v, want a %s event for %s", e, topic, testContainerID)
+			}
+
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for a %s event", topic)
+		}
+
+		return api.Event{}
+	}
+
+	live := subscribe(0)
+	svc.PauseTask(ctx, testContainerID)
+	paused := expect(live, node.TaskPausedTopic)
+	svc.ResumeTask(ctx, testContainerID)
+	expect(live, node.TaskResumedTopic)
+	svc.KillTask(ctx, testContainerID, "SIGKILL", 0)
+
+	if exit := expect(live, node.TaskExitTopic); exit.ExitStatus != 128+uint32(syscall.SIGKILL) {
+		t.Errorf("events handler streamed exit status %d, want %d", exit.ExitStatus, 128+uint32(syscall.SIGKILL))
+	}
+
+	resumed := subscribe(int(paused.Seq) + 1)
+	expect(resumed, node.TaskResumedTopic)
+	expect(resumed, node.TaskExitTopic)
+}
+
+func TestPullJobHandler(t *testing.T) {
+	_, svc, cleanup := newRunningNode(t, node.ContainerSpec{})
+	defer cleanup()
+
+	schema, schemaErr := api.NewGraphQLSchema(svc, api.NewResolverSet(svc))
+
+	if schemaErr != nil {
+		t.Fatalf("failed to create schema with error: %s", schemaErr.Error())
+	}
+
+	server := httptest.NewServer(api.NewEventsHandler(schema))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(namespaces.WithNamespace(context.Background(), testNamespace))
+	defer cancel()
+
+	query := `subscription { pullJob(namespace: "` + testNamespace + `", ref: "` + testImage + `") { status offset total layers { status } } }`
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?"+url.Values{"query": {query}}.Encode(), nil)
+	resp, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		t.Fatalf("pullJob request failed with error: %s", err.Error())
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("events handler returned status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	pulled := make(chan error, 1)
+
+	go func() {
+		_, pullErr := svc.PullImage(ctx, testImage)
+		pulled <- pullErr
+	}()
+
+	var (
+		statuses []string
+		last     api.PullJob
+		scanner  = bufio.NewScanner(resp.Body)
+	)
+
+	// The stream ends with the pull. Each update carries the job's progress as of then, so a slow reader can skip statuses but never goes back.
+	for scanner.Scan() {
+		var result struct {
+			Data struct {
+				PullJob api.PullJob `json:"pullJob"`
+			} `json:"data"`
+		}
+
+		if decodeErr := json.Unmarshal(scanner.Bytes(), &result); decodeErr != nil {
+			t.Fatalf("failed to decode %s with error: %s", scanner.Text(), decodeErr.Error())
+		}
+
+		last = result.Data.PullJob
+
+		if len(statuses) == 0 || statuses[len(statuses)-1] != last.Status {
+			statuses = append(statuses, last.Status)
+		}
+	}
+
+	if pullErr := <-pulled; pullErr != nil {
+		t.Fatalf("failed to pull image with error: %s", pullErr.Error())
+	}
+
+	wantStatuses := []string{node.PullResolving, node.PullDownloading, node.PullUnpacking, node.PullDone}
+	remaining := wantStatuses
+
+	for _, status := range statuses {
+		for len(remaining) > 0 && remaining[0] != status {
+			remaining = remaining[1:]
+		}
+	}
+
+	if len(remaining) != 1 || last.Status != node.PullDone {
+		t.Errorf("events handler streamed statuses %v, want them in the order of %v, ending with done", statuses, wantStatuses)
+	}
+
+	if last.Offset != 3072 || last.Total != 3072 || len(last.Layers) != 2 || last.Layers[1].Status != node.LayerDone {
+		t.Errorf("events handler streamed final progress %+v, want both layers done", last)
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ pullJobs(namespace: "` + testNamespace + `") { ref status finished_at } }`,
+	})
+	got, _ := json.Marshal(result.Data)
+
+	if result.HasErrors() || !strings.Contains(string(got), `"status":"done"`) || strings.Contains(string(got), `"finished_at":""`) {
+		t.Errorf("pullJobs query returned %s with errors %v, want finished jobs", got, result.Errors)
+	}
+}
+
+func TestImageArchiveHandlers(t *testing.T) {
+	_, svc, cleanup := newRunningNode(t, node.ContainerSpec{})
+	defer cleanup()
+
+	exportServer := httptest.NewServer(api.NewExportHandler(svc))
+	defer exportServer.Close()
+	importServer := httptest.NewServer(api.NewImportHandler(svc))
+	defer importServer.Close()
+
+	resp, err := http.Get(exportServer.URL + "?" + url.Values{"namespace": {testNamespace}, "name": {testImage}}.Encode())
+
+	if err != nil {
+		t.Fatalf("export request failed with error: %s", err.Error())
+	}
+
+	archive, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/x-tar" || len(archive) == 0 {
+		t.Fatalf("export handler returned status %d and %d bytes of %s, want a tarball", resp.StatusCode, len(archive), resp.Header.Get("Content-Type"))
+	}
+
+	otherNamespace := testNamespace + "-import"
+
+	if resp, err = http.Post(importServer.URL+"?namespace="+otherNamespace, "application/x-tar", strings.NewReader(string(archive))); err != nil {
+		t.Fatalf("import request failed with error: %s", err.Error())
+	}
+
+	var imported []api.Image
+	json.NewDecoder(resp.Body).Decode(&imported)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || len(imported) != 1 || imported[0].Name != testImage {
+		t.Errorf("import handler returned status %d and %+v, want %s", resp.StatusCode, imported, testImage)
+	}
+
+	if _, getErr := svc.GetImage(namespaces.WithNamespace(context.Background(), otherNamespace), testImage); getErr != nil {
+		t.Errorf("node.GetImage of the imported image failed with error: %s", getErr.Error())
+	}
+
+	type handlerTest struct {
+		name       string
+		method     string
+		url        string
+		body       string
+		wantStatus int
+	}
+
+	tests := []handlerTest{
+		{name: "export without names", method: http.MethodGet, url: exportServer.URL + "?namespace=" + testNamespace, wantStatus: http.StatusBadRequest},
+		{name: "export missing image", method: http.MethodGet, url: exportServer.URL + "?" + url.Values{"namespace": {testNamespace}, "name": {testImage, weirdString}}.Encode(), wantStatus: http.StatusNotFound},
+		{name: "import without namespace", method: http.MethodPost, url: importServer.URL, body: string(archive), wantStatus: http.StatusBadRequest},
+		{name: "import with get", method: http.MethodGet, url: importServer.URL + "?namespace=" + testNamespace, wantStatus: http.StatusMethodNotAllowed},
+		{name: "import weird archive", method: http.MethodPost, url: importServer.URL + "?namespace=" + testNamespace, body: weirdString, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(test.method, test.url, strings.NewReader(test.body))
+			resp, err := http.DefaultClient.Do(req)
+
+			if err != nil {
+				t.Fatalf("request failed with error: %s", err.Error())
+			}
+
+			resp.Body.Close()
+
+			if resp.StatusCode != test.wantStatus {
+				t.Errorf("handler returned status %d, want %d", resp.StatusCode, test.wantStatus)
+			}
+		})
+	}
+}
+
+func TestExecHandler(t *testing.T) {
+	type handlerTest struct {
+		name       string
+		query      url.Values
+		stdin      string
+		wantStatus int
+		wantStdout string
+		wantExit   api.SessionControl
+	}
+
+	_, svc, cleanup := newRunningNode(t, node.ContainerSpec{})
+	defer cleanup()
+
+	tests := []handlerTest{
+		{name: "missing namespace", query: url.Values{"container_id": {testContainerID}, "arg": {"echo"}}, wantStatus: http.StatusBadRequest},
+		{name: "missing args", query: url.Values{"namespace": {testNamespace}, "container_id": {testContainerID}}, wantStatus: http.StatusBadRequest},
+		{name: "weird env", query: url.Values{"namespace": {testNamespace}, "container_id": {testContainerID}, "arg": {"echo"}, "env": {weirdString}}, wantStatus: http.StatusBadRequest},
+		{name: "weird tty", query: url.Values{"namespace": {testNamespace}, "container_id": {testContainerID}, "arg": {"echo"}, "tty": {weirdString}}, wantStatus: http.StatusBadRequest},
+		{name: "echo", query: url.Values{"namespace": {testNamespace}, "container_id": {testContainerID}, "arg": {"echo", "hello"}}, wantStatus: http.StatusSwitchingProtocols, wantStdout: "hello\n", wantExit: api.SessionControl{Type: api.ExitControl}},
+		{name: "cat", query: url.Values{"namespace": {testNamespace}, "container_id": {testContainerID}, "arg": {"cat"}}, stdin: "meow", wantStatus: http.StatusSwitchingProtocols, wantStdout: "meow", wantExit: api.SessionControl{Type: api.ExitControl}},
+		{name: "false", query: url.Values{"namespace": {testNamespace}, "container_id": {testContainerID}, "arg": {"false"}}, wantStatus: http.StatusSwitchingProtocols, wantExit: api.SessionControl{Type: api.ExitControl, ExitCode: 1}},
+		{name: "unknown container", query: url.Values{"namespace": {testNamespace}, "container_id": {"nope"}, "arg": {"echo"}}, wantStatus: http.StatusSwitchingProtocols, wantExit: api.SessionControl{Type: api.ErrorControl}},
+	}
+
+	server := httptest.NewServer(api.NewExecHandler(svc))
+	defer server.Close()
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"?"+test.query.Encode(), nil)
+
+			if resp == nil {
+				t.Fatalf("exec request failed with error: %s", err.Error())
+			}
+
+			if resp.StatusCode != test.wantStatus {
+				t.Fatalf("exec handler returned status %d, want %d", resp.StatusCode, test.wantStatus)
+			}
+
+			if test.wantStatus != http.StatusSwitchingProtocols {
+				return
+			}
+
+			defer conn.Close()
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+			if test.stdin != "" {
+				conn.WriteMessage(websocket.BinaryMessage, append([]byte{api.SessionStdin}, test.stdin...))
+			}
+
+			conn.WriteJSON(api.SessionControl{Type: api.CloseStdinControl})
+
+			var stdout []byte
+
+			for {
+				messageType, data, readErr := conn.ReadMessage()
+
+				if readErr != nil {
+					t.Fatalf("exec session ended before the process exited with error: %s", readErr.Error())
+				}
+
+				if messageType == websocket.BinaryMessage && data[0] == api.SessionStdout {
+					stdout = append(stdout, data[1:]...)
+					continue
+				}
+
+				var exit api.SessionControl
+
+				if decodeErr := json.Unmarshal(data, &exit); decodeErr != nil {
+					t.Fatalf("failed to decode exec control frame with error: %s", decodeErr.Error())
+				}
+
+				if exit.Type != test.wantExit.Type || exit.ExitCode != test.wantExit.ExitCode {
+					t.Errorf("exec handler ended with %+v, want %+v", exit, test.wantExit)
+				}
+
+				break
+			}
+
+			if string(stdout) != test.wantStdout {
+				t.Errorf("exec handler wrote %q to stdout, want %q", stdout, test.wantStdout)
+			}
+		})
+	}
+}
+
+func TestExecHandlerResize(t *testing.T) {
+	_, svc, cleanup := newRunningNode(t, node.ContainerSpec{})
+	defer cleanup()
+
+	server := httptest.NewServer(api.NewExecHandler(svc))
+	defer server.Close()
+
+	conn := dialSession(t, server, url.Values{"namespace": {testNamespace}, "container_id": {testContainerID}, "arg": {"stty", "size"}, "tty": {"true"}})
+	defer conn.Close()
+
+	if line := readSessionLine(t, conn); line != "0 0" {
+		t.Fatalf("stty printed %q before the resize, want %q", line, "0 0")
+	}
+
+	conn.WriteJSON(api.SessionControl{Type: api.ResizeControl, Width: 80, Height: 24})
+
+	// The resize is applied concurrently with the stdin frames, so stty is polled until it reports the new size.
+	for line := ""; line != "24 80"; {
+		conn.WriteMessage(websocket.BinaryMessage, []byte{api.SessionStdin, '\n'})
+		line = readSessionLine(t, conn)
+	}
+
+	conn.WriteJSON(api.SessionControl{Type: api.CloseStdinControl})
+
+	if end := readSessionEnd(t, conn); end.Type != api.ExitControl || end.ExitCode != 0 {
+		t.Errorf("exec session ended with %+v, want exit code 0", end)
+	}
+}
+
+func TestAttachHandler(t *testing.T) {
+	backend, svc, cleanup := newRunningNode(t, node.ContainerSpec{Stdin: true})
+	defer cleanup()
+
+	ctx := namespaces.WithNamespace(context.Background(), testNamespace)
+	taskIO, _ := backend.TaskIO(ctx, testContainerID)
+	server := httptest.NewServer(api.NewAttachHandler(svc))
+	defer server.Close()
+
+	for _, query := range []url.Values{{"namespace": {testNamespace}}, {"container_id": {testContainerID}}} {
+		resp, err := http.Get(server.URL + "?" + query.Encode())
+
+		if err != nil {
+			t.Fatalf("attach request failed with error: %s", err.Error())
+		}
+
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("attach handler returned status %d for %v, want %d", resp.StatusCode, query, http.StatusBadRequest)
+		}
+	}
+
+	unknown := dialSession(t, server, url.Values{"namespace": {testNamespace}, "container_id": {"nope"}})
+	defer unknown.Close()
+
+	if end := readSessionEnd(t, unknown); end.Type != api.ErrorControl {
+		t.Errorf("attach session to an unknown container ended with %+v, want an error", end)
+	}
+
+	attach := func() *websocket.Conn {
+		conn := dialSession(t, server, url.Values{"namespace": {testNamespace}, "container_id": {testContainerID}})
+
+		// The task reading the session's stdin shows the session is attached.
+		conn.WriteMessage(websocket.BinaryMessage, append([]byte{api.SessionStdin}, "ping"...))
+		ping := make([]byte, 4)
+
+		if _, readErr := io.ReadFull(taskIO.Stdin, ping); readErr != nil || string(ping) != "ping" {
+			t.Fatalf("task read %q from its stdin, want %q", ping, "ping")
+		}
+
+		return conn
+	}
+
+	first := attach()
+	defer first.Close()
+	fmt.Fprintln(taskIO.Stdout, "pong")
+
+	if line := readSessionLine(t, first); line != "pong" {
+		t.Errorf("attach session read %q, want %q", line, "pong")
+	}
+
+	first.WriteJSON(api.SessionControl{Type: api.DetachControl})
+
+	if end := readSessionEnd(t, first); end.Type != api.DetachedControl {
+		t.Errorf("attach session ended with %+v, want detached", end)
+	}
+
+	if task, _ := svc.GetTask(ctx, testContainerID); node.TaskStatus(ctx, task) != "running" {
+		t.Fatalf("task is %q after the session detached, want running", node.TaskStatus(ctx, task))
+	}
+
+	second := attach()
+	defer second.Close()
+
+	if killErr := svc.KillTask(ctx, testContainerID, "SIGKILL", 0); killErr != nil {
+		t.Fatalf("failed to kill task with error: %s", killErr.Error())
+	}
+
+	if end := readSessionEnd(t, second); end.Type != api.ExitControl || end.ExitCode != 128+uint32(syscall.SIGKILL) {
+		t.Errorf("attach session ended with %+v, want exit code %d", end, 128+uint32(syscall.SIGKILL))
+	}
+}
+
+func TestAttachHandlerUnreadStdin(t *testing.T) {
+	_, svc, cleanup := newRunningNode(t, node.ContainerSpec{Stdin: true})
+	defer cleanup()
+
+	server := httptest.NewServer(api.NewAttachHandler(svc))
+	defer server.Close()
+
+	conn := dialSession(t, server, url.Values{"namespace": {testNamespace}, "container_id": {testContainerID}})
+	defer conn.Close()
+
+	// Nothing reads the task's stdin, so these frames back up behind it.
+	for i := 0; i < 4; i++ {
+		conn.WriteMessage(websocket.BinaryMessage, append([]byte{api.SessionStdin}, "unread\n"...))
+	}
+
+	conn.WriteJSON(api.SessionControl{Type: api.DetachControl})
+
+	if end := readSessionEnd(t, conn); end.Type != api.DetachedControl {
+		t.Errorf("attach session ended with %+v, want detached", end)
+	}
+}
+
+func TestAttachHandlerStdinLimit(t *testing.T) {
+	_, svc, cleanup := newRunningNode(t, node.ContainerSpec{Stdin: true})
+	defer cleanup()
+
+	server := httptest.NewServer(api.NewAttachHandler(svc))
+	defer server.Close()
+
+	conn := dialSession(t, server, url.Values{"namespace": {testNamespace}, "container_id": {testContainerID}})
+	defer conn.Close()
+
+	// Nothing reads the task's stdin, so the session holds on to these frames until they're past its limit.
+	frame := append([]byte{api.SessionStdin}, bytes.Repeat([]byte("x"), 64<<10)...)
+
+	for written := 0; written <= api.SessionStdinLimit; written += len(frame) - 1 {
+
+		if writeErr := conn.WriteMessage(websocket.BinaryMessage, frame); writeErr != nil {
+			break
+		}
+	}
+
+	if end := readSessionEnd(t, conn); end.Type != api.ErrorControl {
+		t.Errorf("attach session ended with %+v, want an error", end)
+	}
+
+	ctx := namespaces.WithNamespace(context.Background(), testNamespace)
+
+	if task, _ := svc.GetTask(ctx, testContainerID); node.TaskStatus(ctx, task) != "running" {
+		t.Errorf("task is %q after the session ended, want running", node.TaskStatus(ctx, task))
+	}
+}
+
+func dialSession(t *testing.T, server *httptest.Server, query url.Values) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"?"+query.Encode(), nil)
+
+	if err != nil {
+		t.Fatalf("failed to open session with error: %s", err.Error())
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	return conn
+}
+
+// readSessionLine reads stdout frames up to the end of a line and returns the line.
+func readSessionLine(t *testing.T, conn *websocket.Conn) string {
+	var line []byte
+
+	for !strings.HasSuffix(string(line), "\n") {
+		messageType, data, err := conn.ReadMessage()
+
+		if err != nil {
+			t.Fatalf("failed to read session output with error: %s", err.Error())
+		}
+
+		if messageType != websocket.BinaryMessage || data[0] != api.SessionStdout {
+			t.Fatalf("session sent %q, want stdout", data)
+		}
+
+		line = append(line, data[1:]...)
+	}
+
+	return strings.TrimSuffix(string(line), "\n")
+}
+
+// readSessionEnd skips output frames until the session's closing control frame.
+func readSessionEnd(t *testing.T, conn *websocket.Conn) (end api.SessionControl) {
+	for {
+		messageType, data, err := conn.ReadMessage()
+
+		if err != nil {
+			t.Fatalf("session ended without a control frame with error: %s", err.Error())
+		}
+
+		if messageType != websocket.TextMessage {
+			continue
+		}
+
+		if decodeErr := json.Unmarshal(data, &end); decodeErr != nil {
+			t.Fatalf("failed to decode session control frame with error: %s", decodeErr.Error())
+		}
+
+		return end
+	}
+}
+
+// newRunningNode returns a Node with a memory backend and a running task in testContainerID, which is created with the given spec.
+func newRunningNode(t *testing.T, spec node.ContainerSpec) (backend *node.MemoryBackend, svc node.Service, cleanup func()) {
+	logDir, tempDirErr := ioutil.TempDir("", "clamor-logs")
+
+	if tempDirErr != nil {
+		t.Fatalf("failed to create log directory with error: %s", tempDirErr.Error())
+	}
+
+	backend = node.NewMemoryBackend(node.RemoteImage{Ref: testImage, Layers: []int64{1024, 2048}})
+	svc = node.NewNode(backend, node.NewLogStore(logDir))
+	ctx := namespaces.WithNamespace(context.Background(), testNamespace)
+
+	if _, pullErr := svc.PullImage(ctx, testImage); pullErr != nil {
+		t.Fatalf("failed to pull seed image with error: %s", pullErr.Error())
+	}
+
+	if _, createErr := svc.CreateContainer(ctx, testImage, testContainerID, spec); createErr != nil {
+		t.Fatalf("failed to create seed container with error: %s", createErr.Error())
+	}
+
+	if _, createTaskErr := svc.CreateTask(ctx, testContainerID); createTaskErr != nil {
+		t.Fatalf("failed to create seed task with error: %s", createTaskErr.Error())
+	}
+
+	return backend, svc, func() {
+		svc.Close()
+		os.RemoveAll(logDir)
+	}
+}
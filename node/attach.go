@@ -0,0 +1,281 @@
+package node
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/containerd/containerd/namespaces"
+)
+
+// streamHub connects the stdio of the tasks a Node runs to their logs and to the sessions attached to them.
+// Entries live from task creation, or the first attach after a restart, until the task is deleted.
+type streamHub struct {
+	mu    sync.Mutex
+	tasks map[string]*taskStreams
+	// connecting holds the connects in flight, which run without mu so a slow one holds up only the callers of its own task.
+	connecting map[string]*streamConnect
+}
+
+// streamConnect is a connect in flight. done is closed once ts and err are set.
+type streamConnect struct {
+	done chan struct{}
+	ts   *taskStreams
+	err  error
+	// closed is set, under the hub's mu, when the task's streams are closed before the connect finishes.
+	closed bool
+}
+
+func newStreamHub() *streamHub {
+	return &streamHub{
+		tasks:      make(map[string]*taskStreams),
+		connecting: make(map[string]*streamConnect),
+	}
+}
+
+// open registers the streams of a new task of the given container, replacing those of any previous task.
+// Output written to the returned TaskIO goes to logIO and to attached sessions. Its stdin stays open for sessions to write to when stdin is set.
+func (h *streamHub) open(ctx context.Context, containerID string, logIO TaskIO, stdin bool) (TaskIO, error) {
+	key, err := streamKey(ctx, containerID)
+
+	if err != nil {
+		return TaskIO{}, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if previous, exists := h.tasks[key]; exists {
+		previous.close()
+	}
+
+	ts, taskIO := newTaskStreams(logIO, stdin)
+	h.tasks[key] = ts
+
+	return taskIO, nil
+}
+
+// get returns the streams of the given container's task. If the hub doesn't know the task, e.g. because it was created before a restart,
+// they're set up by connect, which reconnects the task's stdio. Concurrent callers share a single connection.
+func (h *streamHub) get(ctx context.Context, containerID string, connect func() (*taskStreams, error)) (*taskStreams, error) {
+	key, err := streamKey(ctx, containerID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+
+	if ts, exists := h.tasks[key]; exists {
+		h.mu.Unlock()
+		return ts, nil
+	}
+
+	if pending, exists := h.connecting[key]; exists {
+		h.mu.Unlock()
+		<-pending.done
+
+		return pending.ts, pending.err
+	}
+
+	pending := &streamConnect{done: make(chan struct{})}
+	h.connecting[key] = pending
+	h.mu.Unlock()
+
+	pending.ts, pending.err = connect()
+
+	h.mu.Lock()
+	delete(h.connecting, key)
+
+	// A task created while connect ran has registered streams of its own, the reconnected ones belong to the task it replaced.
+	if current, exists := h.tasks[key]; exists && pending.err == nil {
+		pending.ts.close()
+		pending.ts = current
+	} else if pending.err == nil && pending.closed {
+		pending.ts.close()
+	} else if pending.err == nil {
+		h.tasks[key] = pending.ts
+	}
+
+	h.mu.Unlock()
+	close(pending.done)
+
+	return pending.ts, pending.err
+}
+
+// close ends the sessions attached to the given container's task and closes its stdin.
+func (h *streamHub) close(ctx context.Context, containerID string) {
+	key, err := streamKey(ctx, containerID)
+
+	if err != nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if ts, exists := h.tasks[key]; exists {
+		delete(h.tasks, key)
+		ts.close()
+	}
+
+	if pending, exists := h.connecting[key]; exists {
+		pending.closed = true
+	}
+}
+
+func streamKey(ctx context.Context, containerID string) (string, error) {
+	namespace, err := namespaces.NamespaceRequired(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	return namespace + "/" + containerID, nil
+}
+
+// taskStreams fans a task's output out to its attached sessions and funnels their input into its stdin.
+type taskStreams struct {
+	mu       sync.Mutex
+	stdin    *io.PipeWriter
+	sessions map[*attachment]struct{}
+	closed   bool
+}
+
+// newTaskStreams returns the streams of a task along with the TaskIO the task itself reads and writes.
+// logIO receives the task's output before the sessions do. The task gets no stdin unless stdin is set.
+func newTaskStreams(logIO TaskIO, stdin bool) (*taskStreams, TaskIO) {
+	ts := &taskStreams{sessions: make(map[*attachment]struct{})}
+	taskIO := TaskIO{
+		Stdout: streamTee{streams: ts, stream: StdoutStream, log: logIO.Stdout},
+		Stderr: streamTee{streams: ts, stream: StderrStream, log: logIO.Stderr},
+	}
+
+	if stdin {
+		taskIO.Stdin, ts.stdin = io.Pipe()
+	}
+
+	return ts, taskIO
+}
+
+// attach subscribes a new session to the task's output.
+func (ts *taskStreams) attach() *attachment {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	a := &attachment{chunks: make(chan streamChunk, followBuffer)}
+
+	if ts.closed {
+		close(a.chunks)
+	} else {
+		ts.sessions[a] = struct{}{}
+	}
+
+	return a
+}
+
+// detach unsubscribes the given session. Its chunks channel is closed behind the output already queued.
+func (ts *taskStreams) detach(a *attachment) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if _, attached := ts.sessions[a]; attached {
+		delete(ts.sessions, a)
+		close(a.chunks)
+	}
+}
+
+// broadcast hands a copy of p to every attached session. Sessions that fell too far behind are detached rather than holding up the task.
+func (ts *taskStreams) broadcast(stream string, p []byte) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	for a := range ts.sessions {
+		select {
+		case a.chunks <- streamChunk{stream: stream, data: append([]byte(nil), p...)}:
+		default:
+			delete(ts.sessions, a)
+			close(a.chunks)
+		}
+	}
+}
+
+func (ts *taskStreams) close() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	ts.closed = true
+
+	for a := range ts.sessions {
+		delete(ts.sessions, a)
+		close(a.chunks)
+	}
+
+	if ts.stdin != nil {
+		ts.stdin.Close()
+	}
+}
+
+// attachment is a session's view of a task's output.
+type attachment struct {
+	chunks chan streamChunk
+}
+
+type streamChunk struct {
+	stream string
+	data   []byte
+}
+
+// streamTee writes a task's output stream to its log, then to the attached sessions.
+type streamTee struct {
+	streams *taskStreams
+	stream  string
+	log     io.Writer
+}
+
+func (w streamTee) Write(p []byte) (n int, err error) {
+	n = len(p)
+
+	if w.log != nil {
+		n, err = w.log.Write(p)
+	}
+
+	w.streams.broadcast(w.stream, p)
+
+	return n, err
+}
+
+// applyResizes hands the terminal sizes received on sizes to resize until done is closed.
+// Resizing is best effort: a size the runtime rejects is dropped.
+func applyResizes(ctx context.Context, sizes <-chan ConsoleSize, resize func(ctx context.Context, width, height uint32) error, done <-chan struct{}) {
+	if sizes == nil {
+		return
+	}
+
+	go func() {
+		for {
+			select {
+			case size, open := <-sizes:
+				if !open {
+					return
+				}
+
+				resize(ctx, size.Width, size.Height)
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+// copyStdin forwards a session's input to a task's stdin, or discards it if the task has none.
+// The task's stdin stays open when the session's input ends, so other sessions can still write to it.
+func copyStdin(stdin *io.PipeWriter, input io.Reader) {
+	if stdin == nil {
+		io.Copy(ioutil.Discard, input)
+		return
+	}
+
+	io.Copy(stdin, input)
+}
@@ -0,0 +1,101 @@
+package node
+
+import (
+	"context"
+	"io"
+	"net"
+	"syscall"
+)
+
+// Backend is the container runtime a Node drives.
+// Implementations report missing objects with errdefs.ErrNotFound and bad input with errdefs.ErrInvalidArgument, the same way containerd does,
+// and scope every call to the namespace carried by ctx.
+type Backend interface {
+	// Pull fetches and unpacks the image ref points to, reporting its progress through opts.
+	Pull(ctx context.Context, ref string, opts PullOptions) (Image, error)
+	GetImage(ctx context.Context, name string) (Image, error)
+	ListImages(ctx context.Context, filters ...string) ([]Image, error)
+	DeleteImage(ctx context.Context, name string) error
+	// TagImage names the image source also goes by target. An existing image named target is pointed at source's content instead.
+	TagImage(ctx context.Context, source, target string) (Image, error)
+	// Import stores the images named in an OCI image layout or docker save tarball, unpacked for the node's platform.
+	Import(ctx context.Context, r io.Reader) ([]Image, error)
+	// Export writes the images with the given names to w as an OCI image layout tarball that docker load understands too.
+	Export(ctx context.Context, w io.Writer, names ...string) error
+
+	NewContainer(ctx context.Context, id string, image Image, spec ContainerSpec) (RuntimeContainer, error)
+	LoadContainer(ctx context.Context, id string) (RuntimeContainer, error)
+	Containers(ctx context.Context, filters ...string) ([]RuntimeContainer, error)
+	DeleteContainer(ctx context.Context, id string) error
+
+	// Namespaces lists the namespaces that hold images, containers or anything else.
+	Namespaces(ctx context.Context) ([]string, error)
+	// DiskUsage reports the usage of the filesystem that holds image content and snapshots.
+	DiskUsage(ctx context.Context) (DiskUsage, error)
+
+	// Subscribe streams the runtime's events that match any of the given containerd filters, e.g. namespace==default.
+	// The stream ends with an error on errs, or when ctx is done.
+	Subscribe(ctx context.Context, filters ...string) (events <-chan Event, errs <-chan error)
+}
+
+// RuntimeContainer is a Container as seen by a Backend. It adds task lifecycle control to the read-only Container view.
+type RuntimeContainer interface {
+	Container
+	NewTask(ctx context.Context, io TaskIO) (RuntimeTask, error)
+	LoadTask(ctx context.Context) (RuntimeTask, error)
+	// StopSignal returns the signal that asks the container's process to exit: the image's configured stop signal, SIGTERM by default.
+	StopSignal(ctx context.Context) (syscall.Signal, error)
+	// UpdateResources persists new limits for tasks created from now on. It doesn't touch a running task.
+	UpdateResources(ctx context.Context, r Resources) error
+	// JoinNamespaces makes the container's tasks created from now on join the network, IPC and PID namespaces of the process with the given pid.
+	JoinNamespaces(ctx context.Context, pid uint32) error
+	// SetLabels sets the given labels on the container, leaving its other labels as they are.
+	SetLabels(ctx context.Context, labels map[string]string) error
+	// AttachTask reconnects the stdio of the container's existing task to io, e.g. after the streams it was created with went away in a restart.
+	AttachTask(ctx context.Context, io TaskIO) (RuntimeTask, error)
+}
+
+// RuntimeTask is a Task as seen by a Backend. It adds process control to the read-only Task view.
+type RuntimeTask interface {
+	Task
+	Start(ctx context.Context) error
+	Wait(ctx context.Context) (<-chan ExitStatus, error)
+	Kill(ctx context.Context, signal syscall.Signal) error
+	// Pause freezes the task's cgroup. Its processes keep their state but aren't scheduled until Resume.
+	Pause(ctx context.Context) error
+	Resume(ctx context.Context) error
+	// Update applies new limits to the task's cgroups.
+	Update(ctx context.Context, r Resources) error
+	// Resize sets the size of the task's terminal. Tasks without one can't be resized.
+	Resize(ctx context.Context, width, height uint32) error
+	// Dial connects to address from inside the task's network namespace, so 127.0.0.1 is the task's own loopback.
+	Dial(ctx context.Context, network, address string) (net.Conn, error)
+	Delete(ctx context.Context) (ExitStatus, error)
+	// Exec prepares an additional process with the given ID inside the running task. It doesn't run until it's started.
+	Exec(ctx context.Context, id string, spec ProcessSpec, io TaskIO) (RuntimeProcess, error)
+}
+
+// RuntimeProcess is a process exec'd inside a task.
+type RuntimeProcess interface {
+	ID() string
+	Pid() uint32
+	Start(ctx context.Context) error
+	Wait(ctx context.Context) (<-chan ExitStatus, error)
+	Kill(ctx context.Context, signal syscall.Signal) error
+	Resize(ctx context.Context, width, height uint32) error
+	// Delete releases the process's resources once it has exited.
+	Delete(ctx context.Context) (ExitStatus, error)
+}
+
+// TaskIO holds the streams a new task's stdio is connected to. Nil streams are discarded.
+// Resize carries terminal size changes for Node to apply while it runs an exec or attach session. Backends ignore it.
+type TaskIO struct {
+	Stdin          io.Reader
+	Stdout, Stderr io.Writer
+	Resize         <-chan ConsoleSize
+}
+
+// ConsoleSize is the size of a terminal in character cells.
+type ConsoleSize struct {
+	Width, Height uint32
+}
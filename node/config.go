@@ -4,14 +4,78 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 )
 
+// Backend names accepted by Config.Backend.
+const (
+	ContainerdBackendName = "containerd"
+	MemoryBackendName     = "memory"
+)
+
+// DefaultLogDir is used when Config.LogDir is empty.
+const DefaultLogDir = "/var/lib/clamor/logs"
+
+// DefaultContainerdRoot is used when Config.ContainerdRoot is empty.
+const DefaultContainerdRoot = "/var/lib/containerd"
+
 // Config holds administrative settings
 type Config struct {
 	Name           string `json:"name"`
 	ContainerdPath string `json:"containerd_path"`
 	APIHost        string `json:"api_host"`
 	APIPort        int    `json:"api_port"`
+	// LogDir is where task output is kept, one file per container. Defaults to DefaultLogDir.
+	LogDir string `json:"log_dir"`
+
+	// Backend selects the runtime the node drives: "containerd" (the default) or "memory".
+	Backend string `json:"backend"`
+	// MemoryImages lists the refs the memory backend's simulated registry serves.
+	MemoryImages []string `json:"memory_images"`
+	// Registries holds the credentials, mirrors and connection settings the containerd backend pulls with, keyed by registry host.
+	// Pulls from other registries are anonymous and go straight to the registry.
+	Registries Registries `json:"registries"`
+	// ContainerdRoot is the containerd daemon's root directory, whose filesystem holds image content and snapshots. Defaults to DefaultContainerdRoot.
+	ContainerdRoot string `json:"containerd_root"`
+	// ImageGC is the policy unused images are removed under. By default they're kept.
+	ImageGC ImageGCPolicy `json:"image_gc"`
+	// Manifests lists workload manifest files, see LoadManifest, applied when the node starts.
+	Manifests []string `json:"manifests"`
+	// ManifestDir is a directory of workload manifest files the node keeps applied, see ManifestWatcher. Empty disables it.
+	ManifestDir string `json:"manifest_dir"`
+	// ManifestPollInterval is how often ManifestDir is checked for changes. Defaults to DefaultManifestPollInterval.
+	ManifestPollInterval Duration `json:"manifest_poll_interval"`
+	// ReconcileInterval is how often applied workloads are checked for drift. Defaults to DefaultReconcileInterval.
+	ReconcileInterval Duration `json:"reconcile_interval"`
+	// SandboxImage runs the sandbox container of pods that don't name another image, e.g. a mirror's copy of pause. Defaults to DefaultSandboxImage.
+	SandboxImage string `json:"sandbox_image"`
+}
+
+// Duration is a time.Duration written in config files as a string, e.g. "1h30m".
+type Duration time.Duration
+
+// UnmarshalJSON parses the duration string in data.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string: %w", err)
+	}
+
+	parsed, err := time.ParseDuration(s)
+
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(parsed)
+
+	return nil
+}
+
+// MarshalJSON writes d as a duration string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
 }
 
 // LoadConfig reads the given .json file into a node.Config instance
@@ -2,25 +2,105 @@ package node
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"syscall"
 
 	"github.com/containerd/containerd"
 	"github.com/containerd/containerd/cio"
+	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/identifiers"
+	"github.com/containerd/containerd/oci"
+	"github.com/containerd/typeurl"
+	"github.com/gogo/protobuf/types"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
 )
 
+// specExtension is the containerd container extension holding the ContainerSpec a container was created with.
+const specExtension = "io.clamor.spec"
+
+func init() {
+	typeurl.Register(&ContainerSpec{}, "io.clamor", "ContainerSpec")
+}
+
 // Container wraps containerd.Container
 type Container interface {
 	ID() string
 	Image(context.Context) (Image, error)
 	Task(context.Context, cio.Attach) (Task, error)
+	Spec(context.Context) (ContainerSpec, error)
+	// Labels returns the container's current labels, including the ones Node keeps its restart state in.
+	Labels(context.Context) (map[string]string, error)
 }
 
-func newContainer(c containerd.Container) Container {
+// ContainerSpec describes how a container's process is set up. Zero values fall back to the image config.
+type ContainerSpec struct {
+	// Command replaces the image entrypoint. Setting it also drops the image cmd, like docker run --entrypoint.
+	Command []string `json:"command,omitempty"`
+	// Args replaces the image cmd.
+	Args []string `json:"args,omitempty"`
+	// Env holds KEY=VALUE pairs that are added to, or override, the image environment.
+	Env        []string          `json:"env,omitempty"`
+	WorkingDir string            `json:"working_dir,omitempty"`
+	User       string            `json:"user,omitempty"`
+	Hostname   string            `json:"hostname,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	Resources  Resources         `json:"resources,omitempty"`
+	// Stdin keeps the task's stdin open so attached sessions can write to it, like docker run -i.
+	Stdin bool `json:"stdin,omitempty"`
+	// Terminal gives the task a pseudo terminal, like docker run -t. Its stderr is merged into stdout.
+	Terminal bool `json:"terminal,omitempty"`
+	// RestartPolicy says when the task is restarted after it exits: no, on-failure[:max-retries], always or unless-stopped.
+	// It's kept in the container's RestartPolicyLabel. The empty string leaves the container unsupervised.
+	RestartPolicy string `json:"restart_policy,omitempty"`
+	// HealthCheck probes the running task, see Node.GetContainerHealth. Nil leaves the task unprobed.
+	HealthCheck *HealthCheck `json:"health_check,omitempty"`
+	// Sandbox is the ID of a container whose running task's network, IPC and PID namespaces this container's tasks join, see Node.CreatePod.
+	Sandbox string `json:"sandbox,omitempty"`
+}
+
+// Validate reports malformed spec values.
+func (s ContainerSpec) Validate() error {
+	for _, e := range s.Env {
+		if strings.Index(e, "=") < 1 {
+			return fmt.Errorf("env entry %q must have the form KEY=VALUE: %w", e, errdefs.ErrInvalidArgument)
+		}
+	}
+
+	if s.RestartPolicy != "" {
+
+		if _, err := ParseRestartPolicy(s.RestartPolicy); err != nil {
+			return err
+		}
+	}
+
+	if s.HealthCheck != nil {
+
+		if err := s.HealthCheck.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if s.Sandbox != "" {
+
+		if err := identifiers.Validate(s.Sandbox); err != nil {
+			return fmt.Errorf("sandbox %q: %w", s.Sandbox, err)
+		}
+	}
+
+	return s.Resources.Validate()
+}
+
+func newContainer(client *containerd.Client, c containerd.Container) RuntimeContainer {
 	return &container{
+		client:       client,
 		ctrContainer: c,
 	}
 }
 
 type container struct {
+	client       *containerd.Client
 	ctrContainer containerd.Container
 }
 
@@ -29,11 +109,223 @@ func (c *container) ID() string {
 }
 
 func (c *container) Image(ctx context.Context) (Image, error) {
-	return c.ctrContainer.Image(ctx)
+	info, err := c.ctrContainer.Info(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := c.client.ImageService().Get(ctx, info.Image)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newImage(c.client, record), nil
 }
 
 func (c *container) Task(ctx context.Context, attach cio.Attach) (Task, error) {
 	task, err := c.ctrContainer.Task(ctx, attach)
 
-	return newTask(task), err
+	return newTask(c.client, c.ctrContainer, task), err
+}
+
+func (c *container) Spec(ctx context.Context) (ContainerSpec, error) {
+	extensions, err := c.ctrContainer.Extensions(ctx)
+
+	if err != nil {
+		return ContainerSpec{}, err
+	}
+
+	if ext, exists := extensions[specExtension]; exists {
+		v, err := typeurl.UnmarshalAny(&ext)
+
+		if err != nil {
+			return ContainerSpec{}, fmt.Errorf("failed to decode spec of container %s: %w", c.ID(), err)
+		}
+
+		return *v.(*ContainerSpec), nil
+	}
+
+	// Containers created outside of clamor only carry an OCI spec, so report what it resolved to.
+	ociSpec, err := c.ctrContainer.Spec(ctx)
+
+	if err != nil {
+		return ContainerSpec{}, err
+	}
+
+	labels, err := c.ctrContainer.Labels(ctx)
+
+	if err != nil {
+		return ContainerSpec{}, err
+	}
+
+	spec := ContainerSpec{Hostname: ociSpec.Hostname, Labels: labels}
+
+	if ociSpec.Process != nil {
+		spec.Args = ociSpec.Process.Args
+		spec.Env = ociSpec.Process.Env
+		spec.WorkingDir = ociSpec.Process.Cwd
+		spec.User = fmt.Sprintf("%d:%d", ociSpec.Process.User.UID, ociSpec.Process.User.GID)
+		spec.Terminal = ociSpec.Process.Terminal
+	}
+
+	return spec, nil
+}
+
+func (c *container) Labels(ctx context.Context) (map[string]string, error) {
+	return c.ctrContainer.Labels(ctx)
+}
+
+func (c *container) SetLabels(ctx context.Context, labels map[string]string) error {
+	_, err := c.ctrContainer.SetLabels(ctx, labels)
+
+	return err
+}
+
+func (c *container) UpdateResources(ctx context.Context, r Resources) error {
+	return c.ctrContainer.Update(ctx, func(ctx context.Context, client *containerd.Client, rec *containers.Container) error {
+		v, err := typeurl.UnmarshalAny(rec.Spec)
+
+		if err != nil {
+			return err
+		}
+
+		ociSpec := v.(*oci.Spec)
+
+		if err = withResources(r)(ctx, client, rec, ociSpec); err != nil {
+			return err
+		}
+
+		if rec.Spec, err = typeurl.MarshalAny(ociSpec); err != nil {
+			return err
+		}
+
+		spec := ContainerSpec{}
+
+		if ext, exists := rec.Extensions[specExtension]; exists {
+			decoded, err := typeurl.UnmarshalAny(&ext)
+
+			if err != nil {
+				return err
+			}
+
+			spec = *decoded.(*ContainerSpec)
+		}
+
+		spec.Resources = r
+		ext, err := typeurl.MarshalAny(&spec)
+
+		if err != nil {
+			return err
+		}
+
+		if rec.Extensions == nil {
+			rec.Extensions = make(map[string]types.Any)
+		}
+
+		rec.Extensions[specExtension] = *ext
+
+		return nil
+	})
+}
+
+// sharedNamespaces are the namespaces a container joins when it has a Sandbox, by the name of their file in /proc/<pid>/ns.
+var sharedNamespaces = map[specs.LinuxNamespaceType]string{
+	specs.NetworkNamespace: "net",
+	specs.IPCNamespace:     "ipc",
+	specs.PIDNamespace:     "pid",
+}
+
+func (c *container) JoinNamespaces(ctx context.Context, pid uint32) error {
+	return c.ctrContainer.Update(ctx, func(ctx context.Context, client *containerd.Client, rec *containers.Container) error {
+		v, err := typeurl.UnmarshalAny(rec.Spec)
+
+		if err != nil {
+			return err
+		}
+
+		ociSpec := v.(*oci.Spec)
+
+		for nsType, file := range sharedNamespaces {
+			ns := specs.LinuxNamespace{Type: nsType, Path: fmt.Sprintf("/proc/%d/ns/%s", pid, file)}
+
+			if err = oci.WithLinuxNamespace(ns)(ctx, client, rec, ociSpec); err != nil {
+				return err
+			}
+		}
+
+		rec.Spec, err = typeurl.MarshalAny(ociSpec)
+
+		return err
+	})
+}
+
+func (c *container) NewTask(ctx context.Context, io TaskIO) (RuntimeTask, error) {
+	opts, err := c.ioOpts(ctx, io)
+
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := c.ctrContainer.NewTask(ctx, cio.NewCreator(opts...))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newTask(c.client, c.ctrContainer, task), nil
+}
+
+func (c *container) AttachTask(ctx context.Context, io TaskIO) (RuntimeTask, error) {
+	opts, err := c.ioOpts(ctx, io)
+
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := c.Task(ctx, cio.NewAttach(opts...))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return task.(RuntimeTask), nil
+}
+
+// ioOpts connects io to a task of the container, through a terminal if the container's process has one.
+func (c *container) ioOpts(ctx context.Context, io TaskIO) ([]cio.Opt, error) {
+	s, err := c.ctrContainer.Spec(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []cio.Opt{cio.WithStreams(io.Stdin, io.Stdout, io.Stderr)}
+
+	if s.Process != nil && s.Process.Terminal {
+		opts = append(opts, cio.WithTerminal)
+	}
+
+	return opts, nil
+}
+
+func (c *container) StopSignal(ctx context.Context) (syscall.Signal, error) {
+	labels, err := c.ctrContainer.Labels(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return stopSignal(labels)
+}
+
+func (c *container) LoadTask(ctx context.Context) (RuntimeTask, error) {
+	task, err := c.ctrContainer.Task(ctx, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newTask(c.client, c.ctrContainer, task), nil
 }
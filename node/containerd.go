@@ -0,0 +1,546 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/containerd/containerd"
+	apievents "github.com/containerd/containerd/api/events"
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/events"
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/images/archive"
+	"github.com/containerd/containerd/oci"
+	"github.com/containerd/containerd/platforms"
+	"github.com/containerd/containerd/remotes"
+	"github.com/containerd/containerd/remotes/docker"
+	"github.com/containerd/typeurl"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// NewContainerdBackend returns a Backend that drives the containerd daemon behind the given client, whose root directory is root.
+// Images are pulled through the mirrors and with the credentials and connection settings the given Registries hold for their registry.
+func NewContainerdBackend(ctr *containerd.Client, root string, registries Registries) Backend {
+	return &containerdBackend{
+		client:     ctr,
+		root:       root,
+		registries: registries,
+	}
+}
+
+type containerdBackend struct {
+	client     *containerd.Client
+	root       string
+	registries Registries
+}
+
+// pullProgressInterval is how often the containerd backend checks the content store for the progress of a pull.
+const pullProgressInterval = 100 * time.Millisecond
+
+func (b *containerdBackend) Pull(ctx context.Context, ref string, opts PullOptions) (Image, error) {
+	remoteOpts := []containerd.RemoteOpt{containerd.WithResolver(b.resolver())}
+
+	if opts.Progress != nil {
+		var (
+			layers         = &pullLayers{}
+			watchCtx, stop = context.WithCancel(ctx)
+			watched        = make(chan struct{})
+		)
+
+		remoteOpts = append(remoteOpts, containerd.WithImageHandler(layers))
+
+		go func() {
+			defer close(watched)
+			b.watchPull(watchCtx, layers, opts.Progress)
+		}()
+
+		defer func() {
+			stop()
+			<-watched
+		}()
+	}
+
+	if len(opts.Platforms) == 0 && !opts.AllPlatforms {
+		img, err := b.client.Pull(ctx, ref, append(remoteOpts, containerd.WithPullUnpack)...)
+
+		if err != nil {
+			return nil, pullErr(ref, err)
+		}
+
+		return b.GetImage(ctx, img.Name())
+	}
+
+	return b.pullPlatforms(ctx, ref, opts, remoteOpts)
+}
+
+// pullPlatforms fetches the content of the platforms opts asks for and unpacks the node's platform, if it's one of them.
+// Pulls only fetch a single platform, the one that matches best, so this fetches and unpacks separately.
+func (b *containerdBackend) pullPlatforms(ctx context.Context, ref string, opts PullOptions, remoteOpts []containerd.RemoteOpt) (Image, error) {
+	matcher := opts.matcher()
+	record, err := b.client.Fetch(ctx, ref, append(remoteOpts, containerd.WithPlatformMatcher(matcher))...)
+
+	if err != nil {
+		return nil, pullErr(ref, err)
+	}
+
+	for _, p := range opts.Platforms {
+		available, _, _, missing, checkErr := images.Check(ctx, b.client.ContentStore(), record.Target, platforms.Only(p))
+
+		if checkErr != nil {
+			return nil, checkErr
+		} else if !available || len(missing) > 0 {
+			return nil, fmt.Errorf("no match for platform %s in manifest of %q: %w", platforms.Format(p), ref, errdefs.ErrNotFound)
+		}
+	}
+
+	if matcher.Match(platforms.DefaultSpec()) {
+		unpackErr := containerd.NewImageWithPlatform(b.client, record, platforms.Default()).Unpack(ctx, containerd.DefaultSnapshotter)
+
+		if unpackErr != nil && !errors.Is(unpackErr, errdefs.ErrNotFound) {
+			return nil, fmt.Errorf("failed to unpack image %s: %w", ref, unpackErr)
+		}
+	}
+
+	return b.GetImage(ctx, record.Name)
+}
+
+// pullErr reports a ref that can't be resolved as not found, the way the other errdefs.ErrNotFound errors of pulls are.
+func pullErr(ref string, err error) error {
+	if err.Error() == "failed to resolve reference \""+ref+"\": object required" {
+		return fmt.Errorf("image %q: %w", ref, errdefs.ErrNotFound)
+	}
+
+	return err
+}
+
+func (b *containerdBackend) GetImage(ctx context.Context, name string) (Image, error) {
+	record, err := b.client.ImageService().Get(ctx, name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newImage(b.client, record), nil
+}
+
+func (b *containerdBackend) ListImages(ctx context.Context, filters ...string) (images []Image, err error) {
+	records, err := b.client.ImageService().List(ctx, filters...)
+
+	for _, record := range records {
+		images = append(images, newImage(b.client, record))
+	}
+
+	return images, err
+}
+
+func (b *containerdBackend) DeleteImage(ctx context.Context, name string) error {
+	return b.client.ImageService().Delete(ctx, name)
+}
+
+// TagImage creates an image record named target with the target and labels of the one named source, or points the existing target record at them.
+func (b *containerdBackend) TagImage(ctx context.Context, source, target string) (Image, error) {
+	store := b.client.ImageService()
+	record, err := store.Get(ctx, source)
+
+	if err != nil {
+		return nil, err
+	}
+
+	record.Name = target
+	tagged, err := store.Create(ctx, record)
+
+	if errors.Is(err, errdefs.ErrAlreadyExists) {
+		tagged, err = store.Update(ctx, record, "target", "labels")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newImage(b.client, tagged), nil
+}
+
+func (b *containerdBackend) Import(ctx context.Context, r io.Reader) (imported []Image, err error) {
+	records, err := b.client.Import(ctx, r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+
+		if err = containerd.NewImage(b.client, record).Unpack(ctx, containerd.DefaultSnapshotter); err != nil {
+			return nil, fmt.Errorf("failed to unpack image %s: %w", record.Name, err)
+		}
+
+		imported = append(imported, newImage(b.client, record))
+	}
+
+	return imported, nil
+}
+
+// Export writes the images with the given names with the content of the platforms present for them.
+// containerd exports one set of platforms for all the given images, so an image missing the content of a platform
+// another one has must be exported on its own.
+func (b *containerdBackend) Export(ctx context.Context, w io.Writer, names ...string) error {
+	var (
+		imgs    []Image
+		present [][]ocispec.Platform
+		all     []ocispec.Platform
+		opts    []archive.ExportOpt
+	)
+
+	for _, name := range names {
+		img, err := b.GetImage(ctx, name)
+
+		if err != nil {
+			return err
+		}
+
+		ps, err := img.PresentPlatforms(ctx)
+
+		if err != nil {
+			return fmt.Errorf("failed to check the content of image %q: %w", name, err)
+		}
+
+		if len(ps) == 0 {
+			return fmt.Errorf("image %q has no platform content to export: %w", name, errdefs.ErrFailedPrecondition)
+		}
+
+		imgs = append(imgs, img)
+		present = append(present, ps)
+		all = append(all, ps...)
+		opts = append(opts, archive.WithImage(b.client.ImageService(), name))
+	}
+
+	exported := platforms.Ordered(all...)
+
+	for i, img := range imgs {
+		ps, err := img.Platforms(ctx)
+
+		if err != nil {
+			return fmt.Errorf("failed to get the platforms of image %q: %w", img.Name(), err)
+		}
+
+		own := platforms.Any(present[i]...)
+
+		for _, p := range ps {
+
+			if exported.Match(p) && !own.Match(p) {
+				return fmt.Errorf("image %q is missing the content of %s, which another image exported with it has; export it on its own: %w", img.Name(), platforms.Format(p), errdefs.ErrFailedPrecondition)
+			}
+		}
+	}
+
+	return b.client.Export(ctx, w, append(opts, archive.WithPlatform(exported))...)
+}
+
+func (b *containerdBackend) NewContainer(ctx context.Context, id string, i Image, spec ContainerSpec) (RuntimeContainer, error) {
+	img, err := b.ctrImage(ctx, i)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := b.client.NewContainer(
+		ctx,
+		id,
+		containerd.WithImage(img),
+		containerd.WithNewSnapshot(id, img),
+		containerd.WithNewSpec(specOpts(img, spec)...),
+		containerd.WithContainerLabels(spec.Labels),
+		containerd.WithImageStopSignal(img, "SIGTERM"),
+		containerd.WithContainerExtension(specExtension, &spec),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newContainer(b.client, c), nil
+}
+
+func (b *containerdBackend) LoadContainer(ctx context.Context, id string) (RuntimeContainer, error) {
+	c, err := b.client.LoadContainer(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newContainer(b.client, c), nil
+}
+
+func (b *containerdBackend) Containers(ctx context.Context, filters ...string) (cs []RuntimeContainer, err error) {
+	containers, err := b.client.Containers(ctx, filters...)
+
+	for _, c := range containers {
+		cs = append(cs, newContainer(b.client, c))
+	}
+
+	return cs, err
+}
+
+func (b *containerdBackend) DeleteContainer(ctx context.Context, id string) error {
+	c, err := b.client.LoadContainer(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	return c.Delete(ctx, containerd.WithSnapshotCleanup)
+}
+
+func (b *containerdBackend) Namespaces(ctx context.Context) ([]string, error) {
+	return b.client.NamespaceService().List(ctx)
+}
+
+// DiskUsage reports the usage of the filesystem containerd's root directory is on. Everything else on it counts too.
+func (b *containerdBackend) DiskUsage(ctx context.Context) (DiskUsage, error) {
+	var stat syscall.Statfs_t
+
+	if err := syscall.Statfs(b.root, &stat); err != nil {
+		return DiskUsage{}, fmt.Errorf("failed to stat %s: %w", b.root, err)
+	}
+
+	return DiskUsage{
+		Used:     int64(stat.Blocks-stat.Bfree) * int64(stat.Bsize),
+		Capacity: int64(stat.Blocks) * int64(stat.Bsize),
+	}, nil
+}
+
+func (b *containerdBackend) Subscribe(ctx context.Context, filters ...string) (<-chan Event, <-chan error) {
+	envelopes, errs := b.client.Subscribe(ctx, filters...)
+	es := make(chan Event)
+
+	go func() {
+		for {
+			select {
+			case envelope := <-envelopes:
+				e, known := decodeEvent(envelope)
+
+				if !known {
+					continue
+				}
+
+				select {
+				case es <- e:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return es, errs
+}
+
+// pullLayers is an image handler that collects the layers a pull fetches.
+type pullLayers struct {
+	mu       sync.Mutex
+	resolved bool
+	descs    []ocispec.Descriptor
+}
+
+func (l *pullLayers) Handle(ctx context.Context, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.resolved = true
+
+	if images.IsLayerType(desc.MediaType) {
+		l.descs = append(l.descs, desc)
+	}
+
+	return nil, nil
+}
+
+func (l *pullLayers) get() (bool, []ocispec.Descriptor) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.resolved, append([]ocispec.Descriptor(nil), l.descs...)
+}
+
+// watchPull reports the progress of a pull until ctx is done. Layers being fetched have an active ingest in the content store,
+// fetched layers are in the content store. The pull is unpacking once all of its layers are fetched.
+func (b *containerdBackend) watchPull(ctx context.Context, layers *pullLayers, progress func(PullProgress)) {
+	var (
+		last   PullProgress
+		ticker = time.NewTicker(pullProgressInterval)
+		store  = b.client.ContentStore()
+	)
+
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		resolved, descs := layers.get()
+
+		if !resolved {
+			continue
+		}
+
+		statuses, statusErr := store.ListStatuses(ctx)
+
+		if statusErr != nil {
+			continue
+		}
+
+		ingests := make(map[string]content.Status)
+
+		for _, s := range statuses {
+			ingests[s.Ref] = s
+		}
+
+		p := PullProgress{Status: PullUnpacking}
+
+		for _, desc := range descs {
+			layer := LayerProgress{Digest: desc.Digest.String(), MediaType: desc.MediaType, Status: LayerWaiting, Total: desc.Size}
+
+			if ingest, fetching := ingests[remotes.MakeRefKey(ctx, desc)]; fetching {
+				layer.Status, layer.Offset = LayerDownloading, ingest.Offset
+			} else if _, infoErr := store.Info(ctx, desc.Digest); infoErr == nil {
+				layer.Status, layer.Offset = LayerDone, desc.Size
+			}
+
+			if layer.Status != LayerDone {
+				p.Status = PullDownloading
+			}
+
+			p.Layers = append(p.Layers, layer)
+		}
+
+		if len(descs) == 0 {
+			p.Status = PullDownloading
+		}
+
+		if !reflect.DeepEqual(p, last) {
+			progress(p)
+			last = p
+		}
+	}
+}
+
+// resolver returns a resolver for registries that goes through the mirrors b's Registries configure and authenticates with the credentials they hold.
+func (b *containerdBackend) resolver() remotes.Resolver {
+	return docker.NewResolver(docker.ResolverOptions{Hosts: b.registries.Hosts})
+}
+
+// decodeEvent converts the containerd events that have a topic of their own into Events. It reports false for the others.
+func decodeEvent(envelope *events.Envelope) (Event, bool) {
+	payload, err := typeurl.UnmarshalAny(envelope.Event)
+
+	if err != nil {
+		return Event{}, false
+	}
+
+	e := Event{Timestamp: envelope.Timestamp, Namespace: envelope.Namespace, Topic: envelope.Topic}
+
+	switch p := payload.(type) {
+	case *apievents.ImageCreate:
+		e.Image = p.Name
+	case *apievents.ImageUpdate:
+		e.Image = p.Name
+	case *apievents.ImageDelete:
+		e.Image = p.Name
+	case *apievents.ContainerCreate:
+		e.ContainerID, e.Image = p.ID, p.Image
+	case *apievents.ContainerUpdate:
+		e.ContainerID, e.Image = p.ID, p.Image
+	case *apievents.ContainerDelete:
+		e.ContainerID = p.ID
+	case *apievents.TaskCreate:
+		e.ContainerID, e.Pid = p.ContainerID, p.Pid
+	case *apievents.TaskStart:
+		e.ContainerID, e.Pid = p.ContainerID, p.Pid
+	case *apievents.TaskExit:
+		e.ContainerID, e.Pid, e.ExitStatus, e.ExitedAt = p.ContainerID, p.Pid, p.ExitStatus, p.ExitedAt
+
+		// containerd reports the exit of a task's own process under the container's ID.
+		if p.ID != p.ContainerID {
+			e.ExecID = p.ID
+		}
+	case *apievents.TaskDelete:
+		e.ContainerID, e.Pid, e.ExitStatus, e.ExitedAt = p.ContainerID, p.Pid, p.ExitStatus, p.ExitedAt
+	case *apievents.TaskOOM:
+		e.ContainerID = p.ContainerID
+	case *apievents.TaskExecAdded:
+		e.ContainerID, e.ExecID = p.ContainerID, p.ExecID
+	case *apievents.TaskExecStarted:
+		e.ContainerID, e.ExecID, e.Pid = p.ContainerID, p.ExecID, p.Pid
+	case *apievents.TaskPaused:
+		e.ContainerID = p.ContainerID
+	case *apievents.TaskResumed:
+		e.ContainerID = p.ContainerID
+	default:
+		return Event{}, false
+	}
+
+	return e, true
+}
+
+// specOpts maps a ContainerSpec onto the OCI spec options applied on top of the image config.
+func specOpts(img containerd.Image, spec ContainerSpec) []oci.SpecOpts {
+	var opts []oci.SpecOpts
+
+	switch {
+	case len(spec.Command) > 0:
+		args := append(append([]string{}, spec.Command...), spec.Args...)
+		opts = append(opts, oci.WithImageConfig(img), oci.WithProcessArgs(args...))
+	case len(spec.Args) > 0:
+		opts = append(opts, oci.WithImageConfigArgs(img, spec.Args))
+	default:
+		opts = append(opts, oci.WithImageConfig(img))
+	}
+
+	if len(spec.Env) > 0 {
+		opts = append(opts, oci.WithEnv(spec.Env))
+	}
+
+	if spec.WorkingDir != "" {
+		opts = append(opts, oci.WithProcessCwd(spec.WorkingDir))
+	}
+
+	if spec.User != "" {
+		opts = append(opts, oci.WithUser(spec.User))
+	}
+
+	if spec.Hostname != "" {
+		opts = append(opts, oci.WithHostname(spec.Hostname))
+	}
+
+	if spec.Terminal {
+		opts = append(opts, oci.WithTTY)
+	}
+
+	return append(opts, withResources(spec.Resources))
+}
+
+// ctrImage unwraps Image values produced by this backend, falling back to a lookup by name for foreign implementations.
+func (b *containerdBackend) ctrImage(ctx context.Context, i Image) (containerd.Image, error) {
+	if img, isCtrImage := i.(*image); isCtrImage {
+		return img.ctrImage, nil
+	}
+
+	img, err := b.client.GetImage(ctx, i.Name())
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve image %s: %w", i.Name(), err)
+	}
+
+	return img, nil
+}
@@ -0,0 +1,283 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/filters"
+	"github.com/containerd/containerd/namespaces"
+)
+
+// Event topics. They're the same as containerd's, other containerd events aren't re-published.
+const (
+	ImageCreateTopic     = "/images/create"
+	ImageUpdateTopic     = "/images/update"
+	ImageDeleteTopic     = "/images/delete"
+	ContainerCreateTopic = "/containers/create"
+	ContainerUpdateTopic = "/containers/update"
+	ContainerDeleteTopic = "/containers/delete"
+	TaskCreateTopic      = "/tasks/create"
+	TaskStartTopic       = "/tasks/start"
+	TaskExitTopic        = "/tasks/exit"
+	TaskDeleteTopic      = "/tasks/delete"
+	TaskOOMTopic         = "/tasks/oom"
+	TaskExecAddedTopic   = "/tasks/exec-added"
+	TaskExecStartedTopic = "/tasks/exec-started"
+	TaskPausedTopic      = "/tasks/paused"
+	TaskResumedTopic     = "/tasks/resumed"
+)
+
+// eventBacklog is how many of a namespace's most recent events are kept for subscribers to resume from.
+const eventBacklog = 1024
+
+// eventRetry is how long to wait before resubscribing to a Backend whose event stream failed.
+const eventRetry = time.Second
+
+// Event is something that happened to an image, a container or a task. Fields that don't apply to its topic are left empty.
+type Event struct {
+	// Seq numbers the events of a namespace in the order the Node received them, starting at 1.
+	Seq       uint64
+	Timestamp time.Time
+	Namespace string
+	Topic     string
+	// Image is the name of the image of image events, and the image of container events.
+	Image       string
+	ContainerID string
+	// ExecID is the exec'd process that exec events and exit events are about. It's empty for the task's own process.
+	ExecID     string
+	Pid        uint32
+	ExitStatus uint32
+	ExitedAt   time.Time
+}
+
+// EventOptions selects the events streamed by Events.
+type EventOptions struct {
+	// Topic keeps the events of a single topic, or of every topic under it, e.g. "/tasks". The empty string keeps all events.
+	Topic string
+	// ContainerID keeps the events about a single container and its task.
+	ContainerID string
+	// Since replays the backlogged events from the one numbered Since on before streaming new ones. Zero only streams new events.
+	Since uint64
+}
+
+// Validate reports option values Events can't honour.
+func (o EventOptions) Validate() error {
+	if o.Topic != "" && !strings.HasPrefix(o.Topic, "/") {
+		return fmt.Errorf("event topic %q must start with /: %w", o.Topic, errdefs.ErrInvalidArgument)
+	}
+
+	return nil
+}
+
+func (o EventOptions) match(e Event) bool {
+	topic := o.Topic == "" || e.Topic == o.Topic || strings.HasPrefix(e.Topic, strings.TrimSuffix(o.Topic, "/")+"/")
+
+	return topic && (o.ContainerID == "" || o.ContainerID == e.ContainerID)
+}
+
+func adaptEvent(e Event) filters.Adaptor {
+	return filters.AdapterFunc(func(fieldpath []string) (string, bool) {
+		if len(fieldpath) == 0 {
+			return "", false
+		}
+
+		switch fieldpath[0] {
+		case "namespace":
+			return e.Namespace, len(e.Namespace) > 0
+		case "topic":
+			return e.Topic, len(e.Topic) > 0
+		}
+
+		return "", false
+	})
+}
+
+// eventHub re-publishes the events of a Backend to a Node's subscribers, with one feed per namespace.
+// A namespace's feed starts with its first subscription and keeps running for the life of the Node, so its backlog covers the events since.
+type eventHub struct {
+	backend Backend
+
+	mu    sync.Mutex
+	feeds map[string]*eventFeed
+}
+
+func newEventHub(backend Backend) *eventHub {
+	return &eventHub{
+		backend: backend,
+		feeds:   make(map[string]*eventFeed),
+	}
+}
+
+// subscribe streams the events of ctx's namespace selected by opts until ctx is done.
+// A subscriber that falls more than followBuffer events behind is cut off. It can resume from the Seq of the last event it got.
+func (h *eventHub) subscribe(ctx context.Context, opts EventOptions) (<-chan Event, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	namespace, err := namespaces.NamespaceRequired(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return h.feed(namespace).subscribe(ctx, opts), nil
+}
+
+// feed returns the feed of the given namespace, starting it if needed.
+// A new feed has subscribed to the Backend by the time it's returned, so no later event is missed.
+func (h *eventHub) feed(namespace string) *eventFeed {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if f, exists := h.feeds[namespace]; exists {
+		return f
+	}
+
+	f := &eventFeed{subs: make(map[*eventSubscriber]struct{})}
+	h.feeds[namespace] = f
+	subscribed := make(chan struct{})
+
+	go f.run(namespaces.WithNamespace(context.Background(), namespace), h.backend, "namespace=="+namespace, subscribed)
+	<-subscribed
+
+	return f
+}
+
+// eventFeed numbers the events of a namespace, keeps the most recent ones and hands them to subscribers.
+type eventFeed struct {
+	mu      sync.Mutex
+	seq     uint64
+	backlog []Event
+	subs    map[*eventSubscriber]struct{}
+}
+
+type eventSubscriber struct {
+	opts   EventOptions
+	events chan Event
+}
+
+// run publishes the events the Backend sends, resubscribing whenever its stream fails. subscribed is closed after the first subscription.
+func (f *eventFeed) run(ctx context.Context, backend Backend, filter string, subscribed chan<- struct{}) {
+	for {
+		subCtx, cancel := context.WithCancel(ctx)
+		events, errs := backend.Subscribe(subCtx, filter)
+
+		if subscribed != nil {
+			close(subscribed)
+			subscribed = nil
+		}
+
+		f.relay(events, errs)
+		cancel()
+		time.Sleep(eventRetry)
+	}
+}
+
+// relay publishes events until the stream fails.
+func (f *eventFeed) relay(events <-chan Event, errs <-chan error) {
+	for {
+		select {
+		case e, open := <-events:
+			if !open {
+				return
+			}
+
+			f.publish(e)
+		case <-errs:
+			return
+		}
+	}
+}
+
+func (f *eventFeed) publish(e Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.seq++
+	e.Seq = f.seq
+	f.backlog = append(f.backlog, e)
+
+	if len(f.backlog) > eventBacklog {
+		f.backlog = f.backlog[1:]
+	}
+
+	for sub := range f.subs {
+		if !sub.opts.match(e) {
+			continue
+		}
+
+		select {
+		case sub.events <- e:
+		default:
+			delete(f.subs, sub)
+			close(sub.events)
+		}
+	}
+}
+
+func (f *eventFeed) subscribe(ctx context.Context, opts EventOptions) <-chan Event {
+	// The backlog is copied under the same lock that registers the subscriber, so no event is missed or repeated.
+	f.mu.Lock()
+	var backlog []Event
+
+	if opts.Since > 0 {
+		for _, e := range f.backlog {
+			if e.Seq >= opts.Since && opts.match(e) {
+				backlog = append(backlog, e)
+			}
+		}
+	}
+
+	sub := &eventSubscriber{opts: opts, events: make(chan Event, followBuffer)}
+	f.subs[sub] = struct{}{}
+	f.mu.Unlock()
+
+	events := make(chan Event)
+
+	go func() {
+		defer close(events)
+		defer f.unsubscribe(sub)
+
+		for _, e := range backlog {
+			select {
+			case events <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		for {
+			select {
+			case e, open := <-sub.events:
+				if !open {
+					return
+				}
+
+				select {
+				case events <- e:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events
+}
+
+func (f *eventFeed) unsubscribe(sub *eventSubscriber) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, subscribed := f.subs[sub]; subscribed {
+		delete(f.subs, sub)
+		close(sub.events)
+	}
+}
@@ -0,0 +1,273 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/namespaces"
+	"github.com/containerd/containerd/reference"
+)
+
+// Reasons an image is pruned for, after the part of the ImageGCPolicy that removes it.
+const (
+	KeepLastReason  = "keep_last"
+	MaxAgeReason    = "max_age"
+	DiskUsageReason = "disk_usage"
+)
+
+// ImageGCPolicy decides which images the image garbage collector removes.
+// Images that containers reference are never removed, whatever the policy says.
+type ImageGCPolicy struct {
+	// Interval is how often the collector runs in the background. Without one it only runs on PruneImages.
+	Interval Duration `json:"interval"`
+	// KeepLast is how many images of each repository are kept, the most recently updated ones. Zero keeps them all.
+	KeepLast int `json:"keep_last"`
+	// MaxAge is how long an image is kept after it was last updated. Zero keeps images however old they are.
+	MaxAge Duration `json:"max_age"`
+	// HighWatermark and LowWatermark are percentages of the image filesystem's capacity. Once its usage reaches HighWatermark,
+	// the least recently updated images are removed until it's expected to be down to LowWatermark. A zero HighWatermark disables them.
+	HighWatermark int `json:"high_watermark"`
+	LowWatermark  int `json:"low_watermark"`
+}
+
+// Validate reports whether p makes sense.
+func (p ImageGCPolicy) Validate() error {
+	switch {
+	case p.Interval < 0 || p.MaxAge < 0:
+		return fmt.Errorf("image gc durations must not be negative: %w", errdefs.ErrInvalidArgument)
+	case p.KeepLast < 0:
+		return fmt.Errorf("image gc keep last %d must not be negative: %w", p.KeepLast, errdefs.ErrInvalidArgument)
+	case p.HighWatermark < 0 || p.HighWatermark > 100 || p.LowWatermark < 0 || p.LowWatermark > 100:
+		return fmt.Errorf("image gc watermarks must be percentages: %w", errdefs.ErrInvalidArgument)
+	case p.LowWatermark > p.HighWatermark:
+		return fmt.Errorf("image gc low watermark %d exceeds high watermark %d: %w", p.LowWatermark, p.HighWatermark, errdefs.ErrInvalidArgument)
+	}
+
+	return nil
+}
+
+// DiskUsage describes the filesystem that holds image content and snapshots, in bytes.
+type DiskUsage struct {
+	Used     int64
+	Capacity int64
+}
+
+// PrunedImage is an image the collector removed, or would remove on a dry run.
+type PrunedImage struct {
+	Namespace string
+	Name      string
+	Reason    string
+	// Size estimates the space removing the image frees: its content plus its unpacked layers. Layers shared with other images count for each of them.
+	Size int64
+}
+
+// ImagePruneReport is the outcome of a run of the image garbage collector.
+type ImagePruneReport struct {
+	DryRun bool
+	// Images lists the pruned images in the order they were removed.
+	Images []PrunedImage
+	// Freed adds up the pruned images' sizes.
+	Freed int64
+	// DiskUsage is the image filesystem's usage before the run.
+	DiskUsage DiskUsage
+}
+
+// imageGC removes the images of a Node its policy has no use for.
+type imageGC struct {
+	backend Backend
+	policy  ImageGCPolicy
+	// mu keeps runs from overlapping, so a background run and PruneImages can't both remove the same images.
+	mu sync.Mutex
+}
+
+// gcImage is an image considered by a run of the collector.
+type gcImage struct {
+	PrunedImage
+	updatedAt time.Time
+}
+
+// run collects the images of all namespaces every policy.Interval until ctx is done. Failed runs are left for the next one to make up for.
+func (gc *imageGC) run(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(gc.policy.Interval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if nss, err := gc.backend.Namespaces(ctx); err == nil {
+			gc.collect(ctx, nss, false)
+		}
+	}
+}
+
+// collect applies the policy to the images of the given namespaces and removes the ones it prunes, unless dryRun is set.
+// Disk usage is the whole node's, so the watermarks prune the least recently updated images of the given namespaces until the node's usage is low enough.
+func (gc *imageGC) collect(ctx context.Context, nss []string, dryRun bool) (report ImagePruneReport, err error) {
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+
+	report.DryRun = dryRun
+
+	if report.DiskUsage, err = gc.backend.DiskUsage(ctx); err != nil {
+		return report, fmt.Errorf("failed to get disk usage: %w", err)
+	}
+
+	var (
+		pruned, kept []gcImage
+		usage        = report.DiskUsage.Used
+		now          = time.Now()
+	)
+
+	for _, ns := range nss {
+		candidates, candidatesErr := gc.candidates(namespaces.WithNamespace(ctx, ns))
+
+		if candidatesErr != nil {
+			return report, fmt.Errorf("failed to get images of namespace %s: %w", ns, candidatesErr)
+		}
+
+		for _, img := range candidates {
+
+			if img.Reason == "" && gc.policy.MaxAge > 0 && now.Sub(img.updatedAt) > time.Duration(gc.policy.MaxAge) {
+				img.Reason = MaxAgeReason
+			}
+
+			if img.Reason == "" {
+				kept = append(kept, img)
+				continue
+			}
+
+			pruned = append(pruned, img)
+			usage -= img.Size
+		}
+	}
+
+	if capacity := report.DiskUsage.Capacity; gc.policy.HighWatermark > 0 && capacity > 0 && report.DiskUsage.Used*100 >= int64(gc.policy.HighWatermark)*capacity {
+		sort.SliceStable(kept, func(i, j int) bool {
+			return kept[i].updatedAt.Before(kept[j].updatedAt)
+		})
+
+		for _, img := range kept {
+
+			if usage*100 <= int64(gc.policy.LowWatermark)*capacity {
+				break
+			}
+
+			img.Reason = DiskUsageReason
+			pruned = append(pruned, img)
+			usage -= img.Size
+		}
+	}
+
+	for _, img := range pruned {
+
+		if !dryRun {
+			deleteErr := gc.backend.DeleteImage(namespaces.WithNamespace(ctx, img.Namespace), img.Name)
+
+			if errors.Is(deleteErr, errdefs.ErrNotFound) {
+				continue
+			} else if deleteErr != nil {
+				return report, fmt.Errorf("failed to delete image %s: %w", img.Name, deleteErr)
+			}
+		}
+
+		report.Images = append(report.Images, img.PrunedImage)
+		report.Freed += img.Size
+	}
+
+	return report, nil
+}
+
+// candidates returns the images of ctx's namespace that no container references, most recently updated first.
+// Those that are beyond policy.KeepLast in their repository carry KeepLastReason. Referenced images count towards KeepLast.
+func (gc *imageGC) candidates(ctx context.Context) (candidates []gcImage, err error) {
+	namespace, err := namespaces.NamespaceRequired(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	imgs, err := gc.backend.ListImages(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	containers, err := gc.backend.Containers(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := make(map[string]bool)
+
+	for _, c := range containers {
+		img, imageErr := c.Image(ctx)
+
+		if errors.Is(imageErr, errdefs.ErrNotFound) {
+			continue
+		} else if imageErr != nil {
+			return nil, fmt.Errorf("failed to get image of container %s: %w", c.ID(), imageErr)
+		}
+
+		referenced[img.Name()] = true
+	}
+
+	sort.SliceStable(imgs, func(i, j int) bool {
+		return imgs[i].UpdatedAt().After(imgs[j].UpdatedAt())
+	})
+
+	repositories := make(map[string]int)
+
+	for _, img := range imgs {
+		repository := img.Name()
+
+		if spec, parseErr := reference.Parse(img.Name()); parseErr == nil {
+			repository = spec.Locator
+		}
+
+		repositories[repository]++
+
+		if referenced[img.Name()] {
+			continue
+		}
+
+		candidate := gcImage{
+			PrunedImage: PrunedImage{Namespace: namespace, Name: img.Name()},
+			updatedAt:   img.UpdatedAt(),
+		}
+
+		if gc.policy.KeepLast > 0 && repositories[repository] > gc.policy.KeepLast {
+			candidate.Reason = KeepLastReason
+		}
+
+		if candidate.Size, err = imageDiskSize(ctx, img); err != nil {
+			return nil, fmt.Errorf("failed to get size of image %s: %w", img.Name(), err)
+		}
+
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates, nil
+}
+
+// imageDiskSize is the space an image takes: its content plus its unpacked layers.
+func imageDiskSize(ctx context.Context, img Image) (int64, error) {
+	size, err := img.Size(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	unpacked, err := img.UnpackedSize(ctx)
+
+	return size + unpacked, err
+}
@@ -0,0 +1,395 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/namespaces"
+)
+
+// Health statuses of a container's running task. Containers without a HealthCheck, or without a running task, have an empty status.
+const (
+	// HealthStarting is the status of a task until a probe passes or, once its start period is over, enough probes fail.
+	HealthStarting  = "starting"
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+)
+
+// Health check defaults, the same as docker's.
+const (
+	DefaultHealthInterval = 30 * time.Second
+	DefaultHealthTimeout  = 30 * time.Second
+	DefaultHealthRetries  = 3
+)
+
+// healthHistory is how many probe results a container's Health keeps.
+const healthHistory = 5
+
+// healthOutputLimit is how much of an exec probe's output is kept.
+const healthOutputLimit = 4096
+
+// HealthCheck describes how the node probes a container's running task. Exactly one of Exec, HTTPGet and TCPSocket is set.
+type HealthCheck struct {
+	// Exec is a command run inside the task, e.g. ["pg_isready", "-q"]. The probe passes when it exits with 0.
+	Exec []string `json:"exec,omitempty"`
+	// HTTPGet is a URL the node GETs from inside the task's network namespace, e.g. "http://127.0.0.1:8080/healthz" for the task's own loopback.
+	// The probe passes on a 2xx or 3xx response.
+	HTTPGet string `json:"http_get,omitempty"`
+	// TCPSocket is a host:port the node connects to from inside the task's network namespace. The probe passes once the connection is made.
+	TCPSocket string `json:"tcp_socket,omitempty"`
+	// Interval is the time between probes. Defaults to DefaultHealthInterval.
+	Interval Duration `json:"interval,omitempty"`
+	// Timeout is how long a probe may take before it fails. Defaults to DefaultHealthTimeout.
+	Timeout Duration `json:"timeout,omitempty"`
+	// Retries is how many probes in a row have to fail for the task to be unhealthy. Defaults to DefaultHealthRetries.
+	Retries int `json:"retries,omitempty"`
+	// StartPeriod is how long the task gets to start. Probes that fail during it don't count towards Retries.
+	StartPeriod Duration `json:"start_period,omitempty"`
+}
+
+// Validate reports health checks that don't have exactly one well-formed probe, or that have negative settings.
+func (c HealthCheck) Validate() error {
+	probes := 0
+
+	if len(c.Exec) > 0 {
+		probes++
+
+		if c.Exec[0] == "" {
+			return fmt.Errorf("health check exec must name a command: %w", errdefs.ErrInvalidArgument)
+		}
+	}
+
+	if c.HTTPGet != "" {
+		probes++
+
+		if u, err := url.Parse(c.HTTPGet); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("health check http_get %q must be an http or https URL: %w", c.HTTPGet, errdefs.ErrInvalidArgument)
+		}
+	}
+
+	if c.TCPSocket != "" {
+		probes++
+
+		if _, _, err := net.SplitHostPort(c.TCPSocket); err != nil {
+			return fmt.Errorf("health check tcp_socket %q must have the form host:port: %w", c.TCPSocket, errdefs.ErrInvalidArgument)
+		}
+	}
+
+	if probes != 1 {
+		return fmt.Errorf("health check must have exactly one of exec, http_get and tcp_socket: %w", errdefs.ErrInvalidArgument)
+	}
+
+	if c.Interval < 0 || c.Timeout < 0 || c.StartPeriod < 0 || c.Retries < 0 {
+		return fmt.Errorf("health check interval, timeout, start period and retries can't be negative: %w", errdefs.ErrInvalidArgument)
+	}
+
+	return nil
+}
+
+// withDefaults fills the unset settings of c with the defaults.
+func (c HealthCheck) withDefaults() HealthCheck {
+	if c.Interval == 0 {
+		c.Interval = Duration(DefaultHealthInterval)
+	}
+
+	if c.Timeout == 0 {
+		c.Timeout = Duration(DefaultHealthTimeout)
+	}
+
+	if c.Retries == 0 {
+		c.Retries = DefaultHealthRetries
+	}
+
+	return c
+}
+
+// Health is the health of a container's running task, as its HealthCheck's probes found it.
+type Health struct {
+	Status string
+	// FailingStreak counts the probes that failed in a row.
+	FailingStreak int
+	// Log holds the results of the most recent probes, oldest first.
+	Log []HealthProbe
+}
+
+// HealthProbe is the result of a single probe.
+type HealthProbe struct {
+	Start, End time.Time
+	Passed     bool
+	// Output is what an exec probe printed, the response status of an HTTP probe, or why a probe failed.
+	Output string
+}
+
+// healthMonitor probes the running tasks of the containers that have a HealthCheck and keeps their Health.
+type healthMonitor struct {
+	node *Node
+
+	mu       sync.Mutex
+	monitors map[string]*taskHealth
+	// closed is set by close, after which no more tasks are probed. probes tracks the probe loops until they return.
+	closed bool
+	probes sync.WaitGroup
+}
+
+// taskHealth is the Health of one task, kept up to date by its probe loop until cancel is called.
+type taskHealth struct {
+	health Health
+	cancel context.CancelFunc
+}
+
+func newHealthMonitor(n *Node) *healthMonitor {
+	return &healthMonitor{node: n, monitors: make(map[string]*taskHealth)}
+}
+
+// healthKey keys the monitors of containers by namespace and ID.
+func healthKey(namespace, containerID string) string {
+	return namespace + "/" + containerID
+}
+
+// scan starts probing the running tasks that were created before the node started.
+func (m *healthMonitor) scan(ctx context.Context) {
+	nss, err := m.node.Backend.Namespaces(ctx)
+
+	if err != nil {
+		return
+	}
+
+	for _, ns := range nss {
+		ctx := namespaces.WithNamespace(ctx, ns)
+		cs, listErr := m.node.Backend.Containers(ctx)
+
+		if listErr != nil {
+			continue
+		}
+
+		for _, c := range cs {
+			spec, specErr := c.Spec(ctx)
+
+			if specErr != nil || spec.HealthCheck == nil {
+				continue
+			}
+
+			if task, loadErr := c.LoadTask(ctx); loadErr == nil {
+
+				if status, statusErr := task.Status(ctx, nil); statusErr == nil && (status.Status == containerd.Running || status.Status == containerd.Paused) {
+					m.start(ctx, c.ID(), *spec.HealthCheck)
+				}
+			}
+		}
+	}
+}
+
+// start starts probing the given container's task, replacing the probes of its previous task. Nothing is probed once the monitor is closed.
+func (m *healthMonitor) start(ctx context.Context, containerID string, check HealthCheck) {
+	namespace, _ := namespaces.Namespace(ctx)
+	key := healthKey(namespace, containerID)
+	probeCtx, cancel := context.WithCancel(namespaces.WithNamespace(context.Background(), namespace))
+	h := &taskHealth{health: Health{Status: HealthStarting}, cancel: cancel}
+
+	m.mu.Lock()
+
+	if m.closed {
+		m.mu.Unlock()
+		cancel()
+		return
+	}
+
+	if previous, exists := m.monitors[key]; exists {
+		previous.cancel()
+	}
+
+	m.monitors[key] = h
+	m.probes.Add(1)
+	m.mu.Unlock()
+
+	go func() {
+		defer m.probes.Done()
+		m.run(probeCtx, key, containerID, check.withDefaults(), h)
+	}()
+}
+
+// close stops probing every task and waits for the probes in flight to return.
+func (m *healthMonitor) close() {
+	m.mu.Lock()
+	m.closed = true
+
+	for _, h := range m.monitors {
+		h.cancel()
+	}
+
+	m.mu.Unlock()
+	m.probes.Wait()
+}
+
+// run probes the task every check.Interval until it stops running or ctx is done. Probes are skipped while the task is paused.
+func (m *healthMonitor) run(ctx context.Context, key, containerID string, check HealthCheck, h *taskHealth) {
+	defer m.stop(key, h)
+
+	started := time.Now()
+	ticker := time.NewTicker(time.Duration(check.Interval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		task, err := m.node.getTask(ctx, containerID)
+
+		if err != nil {
+			return
+		}
+
+		status, err := task.Status(ctx, nil)
+
+		if err != nil || status.Status == containerd.Stopped {
+			return
+		} else if status.Status != containerd.Running {
+			continue
+		}
+
+		probe := m.probe(ctx, containerID, check)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		m.mu.Lock()
+		h.record(probe, check, time.Since(started) < time.Duration(check.StartPeriod))
+		m.mu.Unlock()
+	}
+}
+
+// stop forgets the Health of the task h was probing, unless a newer task is probed already.
+func (m *healthMonitor) stop(key string, h *taskHealth) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h.cancel()
+
+	if m.monitors[key] == h {
+		delete(m.monitors, key)
+	}
+}
+
+// record adds the result of a probe to the task's Health. Callers must hold the monitor's lock.
+func (h *taskHealth) record(probe HealthProbe, check HealthCheck, starting bool) {
+	h.health.Log = append(h.health.Log, probe)
+
+	if len(h.health.Log) > healthHistory {
+		h.health.Log = h.health.Log[len(h.health.Log)-healthHistory:]
+	}
+
+	switch {
+	case probe.Passed:
+		h.health.Status, h.health.FailingStreak = HealthHealthy, 0
+	case starting && h.health.Status == HealthStarting:
+	default:
+		h.health.FailingStreak++
+
+		if h.health.FailingStreak >= check.Retries {
+			h.health.Status = HealthUnhealthy
+		}
+	}
+}
+
+// probe runs the check's probe once, giving up after check.Timeout.
+func (m *healthMonitor) probe(ctx context.Context, containerID string, check HealthCheck) (probe HealthProbe) {
+	probe.Start = time.Now().UTC()
+
+	defer func() {
+		probe.End = time.Now().UTC()
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(check.Timeout))
+	defer cancel()
+
+	switch {
+	case len(check.Exec) > 0:
+		output := NewCappedBuffer(healthOutputLimit)
+		exitStatus, err := m.node.ExecTask(ctx, containerID, ProcessSpec{Args: check.Exec}, TaskIO{Stdout: output, Stderr: output})
+
+		if err != nil {
+			probe.Output = err.Error()
+			return probe
+		}
+
+		probe.Passed, probe.Output = exitStatus.ExitCode() == 0, output.String()
+
+		if !probe.Passed && probe.Output == "" {
+			probe.Output = fmt.Sprintf("exited with %d", exitStatus.ExitCode())
+		}
+	case check.HTTPGet != "":
+		task, err := m.node.getTask(ctx, containerID)
+
+		if err != nil {
+			probe.Output = err.Error()
+			return probe
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, check.HTTPGet, nil)
+
+		if err != nil {
+			probe.Output = err.Error()
+			return probe
+		}
+
+		client := &http.Client{Transport: &http.Transport{DialContext: task.Dial, DisableKeepAlives: true}}
+		resp, err := client.Do(req)
+
+		if err != nil {
+			probe.Output = err.Error()
+			return probe
+		}
+
+		resp.Body.Close()
+		probe.Passed, probe.Output = resp.StatusCode >= 200 && resp.StatusCode < 400, resp.Status
+	default:
+		task, err := m.node.getTask(ctx, containerID)
+
+		if err != nil {
+			probe.Output = err.Error()
+			return probe
+		}
+
+		conn, err := task.Dial(ctx, "tcp", check.TCPSocket)
+
+		if err != nil {
+			probe.Output = err.Error()
+			return probe
+		}
+
+		conn.Close()
+		probe.Passed = true
+	}
+
+	return probe
+}
+
+// get returns the Health of the given container's task, the zero Health if it isn't probed.
+func (m *healthMonitor) get(ctx context.Context, containerID string) Health {
+	namespace, _ := namespaces.Namespace(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, exists := m.monitors[healthKey(namespace, containerID)]
+
+	if !exists {
+		return Health{}
+	}
+
+	health := h.health
+	health.Log = append([]HealthProbe(nil), h.health.Log...)
+
+	return health
+}
@@ -1,24 +1,175 @@
 package node
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
 	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/platforms"
+	"github.com/opencontainers/image-spec/identity"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 // Image wraps containerd.Image
 type Image interface {
 	Name() string
+	// Target describes the image's root content: a manifest, or an index of manifests for several platforms.
+	Target() ocispec.Descriptor
+	Labels() map[string]string
+	CreatedAt() time.Time
+	UpdatedAt() time.Time
+	// Size returns the size of the image's content for the node's platform: its manifest, config and compressed layers.
+	Size(ctx context.Context) (int64, error)
+	// UnpackedSize returns the disk space taken by the image's unpacked layers. It's 0 for images that aren't unpacked.
+	UnpackedSize(ctx context.Context) (int64, error)
+	// Platforms returns the platforms the image is built for, whether their content was pulled or not.
+	Platforms(ctx context.Context) ([]ocispec.Platform, error)
+	// PresentPlatforms returns the platforms whose content, manifest, config and layers, is all stored on the node.
+	PresentPlatforms(ctx context.Context) ([]ocispec.Platform, error)
+	// Config returns the image config's defaults for the node's platform.
+	Config(ctx context.Context) (ImageConfig, error)
+}
+
+// ImageConfig holds the defaults an image config sets for the containers created from it.
+type ImageConfig struct {
+	Entrypoint []string
+	Cmd        []string
+	Env        []string
+	// ExposedPorts lists ports in the port/protocol form, e.g. 80/tcp.
+	ExposedPorts []string
+	User         string
 }
 
-func newImage(i containerd.Image) Image {
+func newImageConfig(c ocispec.ImageConfig) ImageConfig {
+	config := ImageConfig{
+		Entrypoint: c.Entrypoint,
+		Cmd:        c.Cmd,
+		Env:        c.Env,
+		User:       c.User,
+	}
+
+	for port := range c.ExposedPorts {
+		config.ExposedPorts = append(config.ExposedPorts, port)
+	}
+
+	sort.Strings(config.ExposedPorts)
+
+	return config
+}
+
+func newImage(client *containerd.Client, record images.Image) Image {
 	return &image{
-		ctrImage: i,
+		client:   client,
+		record:   record,
+		ctrImage: containerd.NewImage(client, record),
 	}
 }
 
 type image struct {
+	client   *containerd.Client
+	record   images.Image
 	ctrImage containerd.Image
 }
 
 func (i *image) Name() string {
-	return i.ctrImage.Name()
+	return i.record.Name
+}
+
+func (i *image) Target() ocispec.Descriptor {
+	return i.record.Target
+}
+
+func (i *image) Labels() map[string]string {
+	return i.record.Labels
+}
+
+func (i *image) CreatedAt() time.Time {
+	return i.record.CreatedAt
+}
+
+func (i *image) UpdatedAt() time.Time {
+	return i.record.UpdatedAt
+}
+
+func (i *image) Size(ctx context.Context) (int64, error) {
+	return i.ctrImage.Size(ctx)
+}
+
+// UnpackedSize adds up the usage of the snapshots the image's layers were unpacked into, each of which only holds its own layer's changes.
+func (i *image) UnpackedSize(ctx context.Context) (size int64, err error) {
+	diffIDs, err := i.ctrImage.RootFS(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	snapshotter := i.client.SnapshotService(containerd.DefaultSnapshotter)
+
+	for n := range diffIDs {
+		usage, usageErr := snapshotter.Usage(ctx, identity.ChainID(diffIDs[:n+1]).String())
+
+		if errors.Is(usageErr, errdefs.ErrNotFound) {
+			return 0, nil
+		} else if usageErr != nil {
+			return 0, usageErr
+		}
+
+		size += usage.Size
+	}
+
+	return size, nil
+}
+
+func (i *image) Platforms(ctx context.Context) ([]ocispec.Platform, error) {
+	return images.Platforms(ctx, i.client.ContentStore(), i.record.Target)
+}
+
+func (i *image) PresentPlatforms(ctx context.Context) (present []ocispec.Platform, err error) {
+	ps, err := i.Platforms(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range ps {
+		available, _, _, missing, checkErr := images.Check(ctx, i.client.ContentStore(), i.record.Target, platforms.Only(p))
+
+		if checkErr != nil {
+			return nil, checkErr
+		}
+
+		if available && len(missing) == 0 {
+			present = append(present, p)
+		}
+	}
+
+	return present, nil
+}
+
+func (i *image) Config(ctx context.Context) (ImageConfig, error) {
+	desc, err := i.record.Config(ctx, i.client.ContentStore(), platforms.Default())
+
+	if err != nil {
+		return ImageConfig{}, err
+	}
+
+	blob, err := content.ReadBlob(ctx, i.client.ContentStore(), desc)
+
+	if err != nil {
+		return ImageConfig{}, err
+	}
+
+	var config ocispec.Image
+
+	if err = json.Unmarshal(blob, &config); err != nil {
+		return ImageConfig{}, err
+	}
+
+	return newImageConfig(config.Config), nil
 }
@@ -0,0 +1,441 @@
+package node
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/identifiers"
+	"github.com/containerd/containerd/namespaces"
+)
+
+// Task output streams recorded by a LogStore.
+const (
+	StdoutStream = "stdout"
+	StderrStream = "stderr"
+)
+
+// maxLogLine bounds how much of an unterminated line is buffered before it's recorded on its own.
+const maxLogLine = 16 * 1024
+
+// followBuffer is how many live records a follower may fall behind by before it's disconnected.
+// Task output is never held up by a slow reader.
+const followBuffer = 1024
+
+// LogRecord is a single line of task output.
+type LogRecord struct {
+	Time   time.Time `json:"time"`
+	Stream string    `json:"stream"`
+	Line   string    `json:"log"`
+}
+
+// LogOptions selects the records returned by LogStore.Read.
+type LogOptions struct {
+	// Since drops records written before it. The zero value keeps all records.
+	Since time.Time
+	// Tail keeps the last Tail matching records. Values below 1 keep all records.
+	Tail int
+	// Stream keeps the records of a single stream. The empty string keeps both.
+	Stream string
+}
+
+// Validate reports option values Read can't honour.
+func (o LogOptions) Validate() error {
+	switch o.Stream {
+	case "", StdoutStream, StderrStream:
+		return nil
+	default:
+		return fmt.Errorf("unknown log stream %q: %w", o.Stream, errdefs.ErrInvalidArgument)
+	}
+}
+
+func (o LogOptions) match(r LogRecord) bool {
+	return (o.Stream == "" || o.Stream == r.Stream) && !r.Time.Before(o.Since)
+}
+
+// LogStore keeps task output as timestamped, stream-tagged JSON lines in one file per container: <Dir>/<namespace>/<container id>.log.
+// Files survive task deletion, so output stays readable until the container itself is removed.
+type LogStore struct {
+	Dir string
+
+	mu    sync.Mutex
+	files map[string]*logFile
+}
+
+// NewLogStore returns LogStore instances that write under the given directory.
+func NewLogStore(dir string) *LogStore {
+	return &LogStore{
+		Dir:   dir,
+		files: make(map[string]*logFile),
+	}
+}
+
+// Open starts appending output to the log file of the given container in ctx's namespace.
+// It returns the TaskIO to create the container's task with. Close flushes and releases it once the task is gone.
+func (ls *LogStore) Open(ctx context.Context, containerID string) (TaskIO, error) {
+	path, err := ls.path(ctx, containerID)
+
+	if err != nil {
+		return TaskIO{}, err
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if lf, isOpen := ls.files[path]; isOpen {
+		lf.close()
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return TaskIO{}, fmt.Errorf("failed to create log directory for container %s: %w", containerID, err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+
+	if err != nil {
+		return TaskIO{}, fmt.Errorf("failed to open log file for container %s: %w", containerID, err)
+	}
+
+	lf := &logFile{file: file, enc: json.NewEncoder(file), subs: make(map[*logSubscriber]struct{})}
+	lf.stdout = &logWriter{file: lf, stream: StdoutStream}
+	lf.stderr = &logWriter{file: lf, stream: StderrStream}
+	ls.files[path] = lf
+
+	return TaskIO{Stdout: lf.stdout, Stderr: lf.stderr}, nil
+}
+
+// Close records any unterminated output of the given container and closes its log file.
+// Closing a container without an open log is a no-op.
+func (ls *LogStore) Close(ctx context.Context, containerID string) error {
+	path, err := ls.path(ctx, containerID)
+
+	if err != nil {
+		return err
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	lf, isOpen := ls.files[path]
+
+	if !isOpen {
+		return nil
+	}
+
+	delete(ls.files, path)
+
+	return lf.close()
+}
+
+// Remove closes and deletes the log file of the given container.
+func (ls *LogStore) Remove(ctx context.Context, containerID string) error {
+	if err := ls.Close(ctx, containerID); err != nil {
+		return err
+	}
+
+	path, _ := ls.path(ctx, containerID)
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove log file for container %s: %w", containerID, err)
+	}
+
+	return nil
+}
+
+// Read returns the given container's records selected by opts, oldest first.
+// A container that never ran a task has no records.
+func (ls *LogStore) Read(ctx context.Context, containerID string, opts LogOptions) (records []LogRecord, err error) {
+	if err = opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	path, err := ls.path(ctx, containerID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return readLogFile(path, containerID, opts)
+}
+
+// Follow streams the given container's records selected by opts: first those already written, then new ones as the task writes them.
+// opts.Tail only limits the records already written. The stream ends when ctx is done, when the log is closed,
+// or once until is closed and the records recorded before that have been delivered.
+func (ls *LogStore) Follow(ctx context.Context, containerID string, opts LogOptions, until <-chan struct{}) (<-chan LogRecord, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	path, err := ls.path(ctx, containerID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// The file lock is held from the backlog read until the subscription is in place, so no record is missed or repeated.
+	ls.mu.Lock()
+	lf := ls.files[path]
+
+	if lf != nil {
+		lf.mu.Lock()
+	}
+
+	ls.mu.Unlock()
+
+	var sub *logSubscriber
+	backlog, err := readLogFile(path, containerID, opts)
+
+	if err == nil && lf != nil && !lf.closed {
+		sub = &logSubscriber{opts: opts, records: make(chan LogRecord, followBuffer)}
+		lf.subs[sub] = struct{}{}
+	}
+
+	if lf != nil {
+		lf.mu.Unlock()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	records := make(chan LogRecord)
+
+	go func() {
+		defer close(records)
+
+		if sub != nil {
+			defer lf.unsubscribe(sub)
+		}
+
+		for _, r := range backlog {
+			select {
+			case records <- r:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if sub == nil {
+			return
+		}
+
+		for {
+			select {
+			case r, open := <-sub.records:
+				if !open {
+					return
+				}
+
+				select {
+				case records <- r:
+				case <-ctx.Done():
+					return
+				}
+			case <-until:
+				// Unsubscribing closes sub.records behind the records already queued, so the loop drains them before it returns.
+				until = nil
+				lf.unsubscribe(sub)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return records, nil
+}
+
+func readLogFile(path, containerID string, opts LogOptions) (records []LogRecord, err error) {
+	file, err := os.Open(path)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to open log file for container %s: %w", containerID, err)
+	}
+
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+
+	for {
+		line, readErr := reader.ReadBytes('\n')
+
+		// A line without its terminator is still being written, so it's left for the next read.
+		if readErr == io.EOF {
+			break
+		} else if readErr != nil {
+			return nil, fmt.Errorf("failed to read log file for container %s: %w", containerID, readErr)
+		}
+
+		var record LogRecord
+
+		if decodeErr := json.Unmarshal(line, &record); decodeErr != nil {
+			return nil, fmt.Errorf("corrupt log file for container %s: %w", containerID, decodeErr)
+		}
+
+		if !opts.match(record) {
+			continue
+		}
+
+		records = append(records, record)
+
+		if opts.Tail > 0 && len(records) > opts.Tail {
+			records = records[1:]
+		}
+	}
+
+	return records, nil
+}
+
+func (ls *LogStore) path(ctx context.Context, containerID string) (string, error) {
+	namespace, err := namespaces.NamespaceRequired(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	if err = identifiers.Validate(namespace); err != nil {
+		return "", err
+	}
+
+	if err = identifiers.Validate(containerID); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(ls.Dir, namespace, containerID+".log"), nil
+}
+
+// logFile serializes the records of a container's streams into its log file.
+// It also hands each record to the followers subscribed to the file.
+type logFile struct {
+	mu             sync.Mutex
+	file           *os.File
+	enc            *json.Encoder
+	stdout, stderr *logWriter
+	subs           map[*logSubscriber]struct{}
+	closed         bool
+}
+
+func (lf *logFile) record(stream string, line []byte) error {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	record := LogRecord{Time: time.Now().UTC(), Stream: stream, Line: string(line)}
+
+	if err := lf.enc.Encode(record); err != nil {
+		return err
+	}
+
+	for sub := range lf.subs {
+
+		if !sub.opts.match(record) {
+			continue
+		}
+
+		select {
+		case sub.records <- record:
+		default:
+			delete(lf.subs, sub)
+			close(sub.records)
+		}
+	}
+
+	return nil
+}
+
+func (lf *logFile) unsubscribe(sub *logSubscriber) {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	if _, subscribed := lf.subs[sub]; subscribed {
+		delete(lf.subs, sub)
+		close(sub.records)
+	}
+}
+
+func (lf *logFile) close() error {
+	stdoutErr, stderrErr := lf.stdout.flush(), lf.stderr.flush()
+
+	lf.mu.Lock()
+	lf.closed = true
+
+	for sub := range lf.subs {
+		delete(lf.subs, sub)
+		close(sub.records)
+	}
+
+	lf.mu.Unlock()
+
+	if err := lf.file.Close(); err != nil {
+		return err
+	}
+
+	if stdoutErr != nil {
+		return stdoutErr
+	}
+
+	return stderrErr
+}
+
+// logSubscriber is a follower's view of a logFile.
+type logSubscriber struct {
+	opts    LogOptions
+	records chan LogRecord
+}
+
+// logWriter splits a stream into lines and records each one as it completes.
+type logWriter struct {
+	mu     sync.Mutex
+	file   *logFile
+	stream string
+	buf    []byte
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+
+	for {
+		end := bytes.IndexByte(w.buf, '\n')
+		next := end + 1
+
+		if end < 0 || end > maxLogLine {
+
+			if len(w.buf) < maxLogLine {
+				return len(p), nil
+			}
+
+			end, next = maxLogLine, maxLogLine
+		}
+
+		if err := w.file.record(w.stream, bytes.TrimSuffix(w.buf[:end], []byte("\r"))); err != nil {
+			return 0, err
+		}
+
+		w.buf = append(w.buf[:0], w.buf[next:]...)
+	}
+}
+
+func (w *logWriter) flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	line := w.buf
+	w.buf = nil
+
+	return w.file.record(w.stream, line)
+}
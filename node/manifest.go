@@ -0,0 +1,184 @@
+package node
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/namespaces"
+)
+
+// DefaultManifestPollInterval is how often a ManifestWatcher looks for changed manifests when no other interval is set.
+const DefaultManifestPollInterval = 5 * time.Second
+
+// ManifestWatcher keeps the workloads listed in a directory of manifest files applied, the way kubelet runs static pods.
+// Workloads are applied when their manifest is added or changed, and deleted once no manifest lists them anymore.
+// Only regular .json files are read, hidden ones are skipped. Everything goes through the given WorkloadService.
+// Workloads whose manifest was removed while the node was down are left as they are.
+type ManifestWatcher struct {
+	svc      WorkloadService
+	dir      string
+	interval time.Duration
+
+	mu sync.Mutex
+	// manifests holds the manifests applied last, by path.
+	manifests map[string]watchedManifest
+	// owned holds the workloads applied from the directory.
+	owned map[workloadKey]bool
+}
+
+// watchedManifest is a manifest applied from the directory, and the digest of the file it was read from.
+type watchedManifest struct {
+	digest   string
+	manifest Manifest
+}
+
+// workloadKey identifies a workload across namespaces.
+type workloadKey struct {
+	namespace, name string
+}
+
+// NewManifestWatcher returns a ManifestWatcher for the given directory, which looks for changed manifests every interval.
+// An interval of 0 is DefaultManifestPollInterval.
+func NewManifestWatcher(svc WorkloadService, dir string, interval time.Duration) *ManifestWatcher {
+	if interval <= 0 {
+		interval = DefaultManifestPollInterval
+	}
+
+	return &ManifestWatcher{
+		svc:       svc,
+		dir:       dir,
+		interval:  interval,
+		manifests: make(map[string]watchedManifest),
+		owned:     make(map[workloadKey]bool),
+	}
+}
+
+// Run syncs the directory right away and then every interval, until ctx is done. Errors of failed syncs are handed to report.
+func (w *ManifestWatcher) Run(ctx context.Context, report func(err error)) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.Sync(); err != nil && report != nil {
+			report(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync applies the manifests added or changed since the last Sync, and deletes the workloads no manifest lists anymore.
+// A manifest that can't be read or applied is tried again on the next Sync, the workloads it listed before are kept meanwhile.
+// The workloads it did apply are owned all the same, so they're deleted once no manifest lists them anymore.
+// Sync carries on past such manifests, and returns their errors together.
+func (w *ManifestWatcher) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	entries, err := ioutil.ReadDir(w.dir)
+
+	if err != nil {
+		return fmt.Errorf("failed to read manifest directory %s: %w", w.dir, err)
+	}
+
+	var (
+		failures []string
+		// partial holds the workloads applied from manifests that failed part way.
+		partial   []workloadKey
+		manifests = make(map[string]watchedManifest)
+	)
+
+	for _, entry := range entries {
+
+		if !entry.Mode().IsRegular() || strings.HasPrefix(entry.Name(), ".") || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		path := filepath.Join(w.dir, entry.Name())
+		previous, known := w.manifests[path]
+		data, readErr := ioutil.ReadFile(path)
+
+		if readErr != nil {
+			failures = append(failures, fmt.Sprintf("failed to read manifest %s: %s", path, readErr))
+		} else if sum := sha256.Sum256(data); !known || previous.digest != hex.EncodeToString(sum[:]) {
+
+			if applied, appliedKeys, applyErr := w.apply(path, data); applyErr != nil {
+				failures = append(failures, applyErr.Error())
+				partial = append(partial, appliedKeys...)
+			} else {
+				previous, known = watchedManifest{digest: hex.EncodeToString(sum[:]), manifest: applied}, true
+			}
+		}
+
+		if known {
+			manifests[path] = previous
+		}
+	}
+
+	listed := make(map[workloadKey]bool)
+
+	for _, key := range partial {
+		listed[key] = true
+	}
+
+	for _, m := range manifests {
+
+		for _, workload := range m.manifest.Workloads {
+			listed[workloadKey{namespace: m.manifest.Namespace, name: workload.Name}] = true
+		}
+	}
+
+	for key := range w.owned {
+
+		if listed[key] {
+			continue
+		}
+
+		ctx := namespaces.WithNamespace(context.Background(), key.namespace)
+
+		if deleteErr := w.svc.DeleteWorkload(ctx, key.name); deleteErr != nil && !errors.Is(deleteErr, errdefs.ErrNotFound) {
+			failures = append(failures, fmt.Sprintf("failed to delete workload %s in namespace %s: %s", key.name, key.namespace, deleteErr))
+			listed[key] = true
+		}
+	}
+
+	w.manifests, w.owned = manifests, listed
+
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to sync manifest directory %s: %s", w.dir, strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+// apply parses the given manifest file's content and applies its workloads. It returns the workloads it applied even when others failed.
+func (w *ManifestWatcher) apply(path string, data []byte) (m Manifest, applied []workloadKey, err error) {
+	if m, err = parseManifest(path, data); err != nil {
+		return Manifest{}, nil, err
+	}
+
+	statuses, err := ApplyManifest(w.svc, m)
+
+	for _, status := range statuses {
+		applied = append(applied, workloadKey{namespace: m.Namespace, name: status.Workload.Name})
+	}
+
+	if err != nil {
+		return Manifest{}, applied, fmt.Errorf("failed to apply manifest %s: %w", path, err)
+	}
+
+	return m, applied, nil
+}
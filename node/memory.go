@@ -0,0 +1,1836 @@
+package node
+
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/cio"
+	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/filters"
+	"github.com/containerd/containerd/identifiers"
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/namespaces"
+	"github.com/containerd/containerd/platforms"
+	"github.com/containerd/containerd/reference"
+	"github.com/containerd/containerd/snapshots"
+	distref "github.com/docker/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	imagespecs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// memorySnapshotter is the snapshotter name recorded on containers created by a MemoryBackend.
+const memorySnapshotter = "memory"
+
+// memoryImageMediaType is the media type of the blobs a MemoryBackend exports. They hold the RemoteImage a simulated image was pulled from.
+const memoryImageMediaType = "application/vnd.clamor.memory.image.v1+json"
+
+// memoryArchiveBlobLimit is the size of the largest blob a MemoryBackend keeps while it imports an archive. Larger ones are layers, which it only needs the size of.
+const memoryArchiveBlobLimit = 64 << 10
+
+// RemoteImage describes an image the MemoryBackend's simulated registry can serve.
+type RemoteImage struct {
+	Ref string
+	// StopSignal is the image config's stop signal. Containers of images without one are stopped with SIGTERM.
+	StopSignal string
+	// IgnoredSignals lists signals the image's simulated processes survive. SIGKILL can't be ignored.
+	IgnoredSignals []syscall.Signal
+	Labels         map[string]string
+	Config         ImageConfig
+	// Platforms lists the platforms the image has content for. It defaults to the node's platform.
+	// Images with several platforms are pulled as an index of manifests.
+	Platforms    []ocispec.Platform
+	Size         int64
+	UnpackedSize int64
+	// Layers lists the sizes of the image's layers, whose download pulls report progress on. Images without any have a single layer of Size bytes.
+	Layers []int64
+}
+
+// MemoryBackend is a Backend that simulates images, containers, snapshots and task lifecycles in process memory.
+// It never talks to a containerd daemon, so it's suitable for tests, demos and development machines.
+// Image metadata, such as sizes, platforms and configs, is whatever its RemoteImages declare.
+// Simulated tasks run until they're killed. They only produce output that's written through TaskIO.
+// Their metrics count wall time spent running as CPU usage, one pid per process and no memory or block IO.
+// Events are published for image, container and task changes, with the topics and fields containerd would give them.
+// Exec'd processes understand echo, cat, stty, true and false; other commands aren't found.
+type MemoryBackend struct {
+	// DiskCapacity is the size of the simulated image filesystem. Stored images use up their size plus their unpacked size of it.
+	// Set it before the backend is used.
+	DiskCapacity int64
+
+	mu          sync.Mutex
+	remotes     map[string]RemoteImage
+	namespaces  map[string]*memoryNamespace
+	subscribers map[*memorySubscriber]struct{}
+	lastPid     uint32
+}
+
+type memoryNamespace struct {
+	name       string
+	images     map[string]*memoryImage
+	containers map[string]*memoryContainer
+	snapshots  map[string]snapshots.Info
+}
+
+// NewMemoryBackend returns MemoryBackend instances whose registry serves the given remote images.
+func NewMemoryBackend(remotes ...RemoteImage) *MemoryBackend {
+	b := &MemoryBackend{
+		remotes:     make(map[string]RemoteImage),
+		namespaces:  make(map[string]*memoryNamespace),
+		subscribers: make(map[*memorySubscriber]struct{}),
+		lastPid:     1000,
+	}
+
+	for _, r := range remotes {
+		if len(r.Platforms) == 0 {
+			r.Platforms = []ocispec.Platform{platforms.DefaultSpec()}
+		}
+
+		b.remotes[r.Ref] = r
+	}
+
+	return b
+}
+
+// running reports whether a task with the given pid is running or paused in the namespace. Callers must hold the backend's lock.
+func (ns *memoryNamespace) running(pid uint32) bool {
+	for _, c := range ns.containers {
+
+		if c.task != nil && c.task.pid == pid && (c.task.status == containerd.Running || c.task.status == containerd.Paused) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// namespace returns the state for the namespace carried by ctx, creating it on first use.
+// Callers must hold b.mu.
+func (b *MemoryBackend) namespace(ctx context.Context) (*memoryNamespace, error) {
+	name, err := namespaces.NamespaceRequired(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ns, exists := b.namespaces[name]
+
+	if !exists {
+		ns = &memoryNamespace{
+			name:       name,
+			images:     make(map[string]*memoryImage),
+			containers: make(map[string]*memoryContainer),
+			snapshots:  make(map[string]snapshots.Info),
+		}
+		b.namespaces[name] = ns
+	}
+
+	return ns, nil
+}
+
+// Pull resolves ref against the simulated registry, stores the resulting image and commits its unpacked snapshot.
+// Its layers are reported as downloaded halfway, then fully, one after the other.
+func (b *MemoryBackend) Pull(ctx context.Context, ref string, opts PullOptions) (Image, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ns, err := b.namespace(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = reference.Parse(ref); err != nil {
+		return nil, fmt.Errorf("failed to resolve reference %q: %v: %w", ref, err, errdefs.ErrInvalidArgument)
+	}
+
+	remote, exists := b.remotes[ref]
+
+	if !exists {
+		return nil, fmt.Errorf("failed to resolve reference %q: %w", ref, errdefs.ErrNotFound)
+	}
+
+	var (
+		matcher = opts.matcher()
+		present []ocispec.Platform
+	)
+
+	for _, p := range remote.Platforms {
+
+		if matcher.Match(p) {
+			present = append(present, p)
+		}
+	}
+
+	for _, p := range opts.Platforms {
+
+		if !hasPlatform(remote.Platforms, platforms.Only(p)) {
+			return nil, fmt.Errorf("no match for platform %s in manifest of %q: %w", platforms.Format(p), ref, errdefs.ErrNotFound)
+		}
+	}
+
+	if len(present) == 0 {
+		return nil, fmt.Errorf("no match for platform in manifest of %q: %w", ref, errdefs.ErrNotFound)
+	}
+
+	if len(opts.Platforms) == 0 && !opts.AllPlatforms {
+		// Like containerd, pulls for the node's platform only fetch the one that matches best.
+		sort.SliceStable(present, func(i, j int) bool {
+			return matcher.Less(present[i], present[j])
+		})
+
+		present = present[:1]
+	}
+
+	if opts.Progress != nil {
+		reportPull(ref, remote, opts.Progress)
+	}
+
+	return b.store(ns, remote, present), nil
+}
+
+// hasPlatform reports whether any of ps matches.
+func hasPlatform(ps []ocispec.Platform, matcher platforms.Matcher) bool {
+	for _, p := range ps {
+
+		if matcher.Match(p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// store records the image remote describes under its ref, replacing the one stored before, and commits its unpacked snapshot.
+// The content of the given platforms is added to what the image has stored. Callers must hold b.mu.
+func (b *MemoryBackend) store(ns *memoryNamespace, remote RemoteImage, present []ocispec.Platform) *memoryImage {
+	ref := remote.Ref
+	now := time.Now().UTC()
+	target := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromString(ref),
+		Size:      remote.Size,
+	}
+
+	if len(remote.Platforms) > 1 {
+		target.MediaType = ocispec.MediaTypeImageIndex
+	}
+
+	img, exists := ns.images[ref]
+
+	if !exists {
+		img = &memoryImage{
+			backend: b,
+			record:  images.Image{Name: ref, CreatedAt: now},
+		}
+		ns.images[ref] = img
+	}
+
+	img.remote = remote
+
+	for _, p := range present {
+
+		if !hasPlatform(img.present, platforms.NewMatcher(p)) {
+			img.present = append(img.present, p)
+		}
+	}
+
+	img.record.Labels = remote.Labels
+	img.record.Target = target
+	img.record.UpdatedAt = now
+
+	if exists {
+		b.publish(ns, ImageUpdateTopic, Event{Image: ref})
+	} else {
+		b.publish(ns, ImageCreateTopic, Event{Image: ref})
+	}
+
+	if _, exists = ns.snapshots[target.Digest.String()]; !exists {
+		ns.snapshots[target.Digest.String()] = snapshots.Info{
+			Kind:    snapshots.KindCommitted,
+			Name:    target.Digest.String(),
+			Created: now,
+			Updated: now,
+		}
+	}
+
+	return img
+}
+
+// GetImage returns the stored image with the given name.
+func (b *MemoryBackend) GetImage(ctx context.Context, name string) (Image, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ns, err := b.namespace(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	img, exists := ns.images[name]
+
+	if !exists {
+		return nil, fmt.Errorf("image %q: %w", name, errdefs.ErrNotFound)
+	}
+
+	return img, nil
+}
+
+// ListImages returns the stored images matching any of the given containerd filters.
+func (b *MemoryBackend) ListImages(ctx context.Context, fs ...string) (is []Image, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ns, err := b.namespace(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := filters.ParseAll(fs...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, img := range ns.images {
+		if filter.Match(adaptImage(img.record)) {
+			is = append(is, img)
+		}
+	}
+
+	sort.Slice(is, func(i, j int) bool { return is[i].Name() < is[j].Name() })
+
+	return is, nil
+}
+
+// DeleteImage removes the image record with the given name. Unpacked snapshots are left in place, as containerd leaves them to its garbage collector.
+func (b *MemoryBackend) DeleteImage(ctx context.Context, name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ns, err := b.namespace(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if _, exists := ns.images[name]; !exists {
+		return fmt.Errorf("image %q: %w", name, errdefs.ErrNotFound)
+	}
+
+	delete(ns.images, name)
+	b.publish(ns, ImageDeleteTopic, Event{Image: name})
+
+	return nil
+}
+
+// TagImage stores a copy of the image named source under the name target, replacing the image target named before.
+func (b *MemoryBackend) TagImage(ctx context.Context, source, target string) (Image, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ns, err := b.namespace(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	src, exists := ns.images[source]
+
+	if !exists {
+		return nil, fmt.Errorf("image %q: %w", source, errdefs.ErrNotFound)
+	}
+
+	now := time.Now().UTC()
+	img, exists := ns.images[target]
+
+	if !exists {
+		img = &memoryImage{
+			backend: b,
+			record:  images.Image{Name: target, CreatedAt: now},
+		}
+		ns.images[target] = img
+	}
+
+	img.remote = src.remote
+	img.remote.Ref = target
+	img.present = append([]ocispec.Platform(nil), src.present...)
+
+	img.record.Labels = src.record.Labels
+	img.record.Target = src.record.Target
+	img.record.UpdatedAt = now
+
+	if exists {
+		b.publish(ns, ImageUpdateTopic, Event{Image: target})
+	} else {
+		b.publish(ns, ImageCreateTopic, Event{Image: target})
+	}
+
+	return img, nil
+}
+
+// Import stores the images named in the given tarball.
+// Images exported by a MemoryBackend come back as they were, with the content of the platforms that were present. The images of other OCI image layouts and docker save tarballs are taken to be
+// for the node's platform, with the size of their manifest or layers and an empty config.
+func (b *MemoryBackend) Import(ctx context.Context, r io.Reader) (imported []Image, err error) {
+	if _, err = namespaces.NamespaceRequired(ctx); err != nil {
+		return nil, err
+	}
+
+	archivedImages, err := readMemoryArchive(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ns, err := b.namespace(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, archived := range archivedImages {
+		imported = append(imported, b.store(ns, archived.RemoteImage, archived.Present))
+	}
+
+	return imported, nil
+}
+
+// Export writes the images with the given names as an OCI image layout whose manifests are blobs holding the images' RemoteImages.
+// Only the content of the platforms present for each image is exported, as containerd does.
+func (b *MemoryBackend) Export(ctx context.Context, w io.Writer, names ...string) error {
+	var (
+		manifests []ocispec.Descriptor
+		blobs     = make(map[digest.Digest][]byte)
+	)
+
+	b.mu.Lock()
+	ns, err := b.namespace(ctx)
+
+	for _, name := range names {
+
+		if err != nil {
+			break
+		}
+
+		img, exists := ns.images[name]
+
+		if !exists {
+			err = fmt.Errorf("image %q: %w", name, errdefs.ErrNotFound)
+			break
+		}
+
+		archived := memoryArchiveImage{RemoteImage: img.remote, Present: img.present}
+		archived.Ref = name
+		blob, _ := json.Marshal(archived)
+		manifest := ocispec.Descriptor{
+			MediaType:   memoryImageMediaType,
+			Digest:      digest.FromBytes(blob),
+			Size:        int64(len(blob)),
+			Annotations: map[string]string{images.AnnotationImageName: name},
+		}
+
+		if spec, parseErr := reference.Parse(name); parseErr == nil {
+			manifest.Annotations[ocispec.AnnotationRefName] = spec.Object
+		}
+
+		blobs[manifest.Digest] = blob
+		manifests = append(manifests, manifest)
+	}
+
+	b.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	layout, _ := json.Marshal(ocispec.ImageLayout{Version: ocispec.ImageLayoutVersion})
+
+	if err = writeTarFile(tw, ocispec.ImageLayoutFile, layout); err != nil {
+		return err
+	}
+
+	for _, manifest := range manifests {
+
+		if err = writeTarFile(tw, path.Join("blobs", manifest.Digest.Algorithm().String(), manifest.Digest.Encoded()), blobs[manifest.Digest]); err != nil {
+			return err
+		}
+	}
+
+	index, _ := json.Marshal(ocispec.Index{Versioned: imagespecs.Versioned{SchemaVersion: 2}, Manifests: manifests})
+
+	if err = writeTarFile(tw, "index.json", index); err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
+
+func writeTarFile(tw *tar.Writer, name string, data []byte) error {
+	if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0444, Size: int64(len(data))}); err != nil {
+		return err
+	}
+
+	_, err := tw.Write(data)
+
+	return err
+}
+
+// dockerArchiveManifest is an entry of the manifest.json of a docker save tarball.
+type dockerArchiveManifest struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+// memoryArchiveImage is the blob a MemoryBackend exports an image as.
+type memoryArchiveImage struct {
+	RemoteImage
+	// Present lists the platforms whose content was exported.
+	Present []ocispec.Platform
+}
+
+// readMemoryArchive returns the images named in an OCI image layout or docker save tarball.
+// Images from other archives are taken to have the content of all their platforms present.
+// Images are named after their containerd image name annotation, their OCI ref name or their docker repo tags, the same way containerd names them.
+func readMemoryArchive(r io.Reader) (archivedImages []memoryArchiveImage, err error) {
+	var (
+		tr              = tar.NewReader(r)
+		layout          ocispec.ImageLayout
+		index           ocispec.Index
+		dockerManifests []dockerArchiveManifest
+		blobs           = make(map[string][]byte)
+		sizes           = make(map[string]int64)
+	)
+
+	for {
+		hdr, nextErr := tr.Next()
+
+		if nextErr == io.EOF {
+			break
+		} else if nextErr != nil {
+			return nil, fmt.Errorf("failed to read archive: %v: %w", nextErr, errdefs.ErrInvalidArgument)
+		}
+
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+			continue
+		}
+
+		name := path.Clean(hdr.Name)
+		sizes[name] = hdr.Size
+
+		switch name {
+		case ocispec.ImageLayoutFile:
+			err = json.NewDecoder(tr).Decode(&layout)
+		case "index.json":
+			err = json.NewDecoder(tr).Decode(&index)
+		case "manifest.json":
+			err = json.NewDecoder(tr).Decode(&dockerManifests)
+		default:
+
+			if hdr.Size <= memoryArchiveBlobLimit {
+				blobs[name], err = ioutil.ReadAll(tr)
+			}
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from archive: %v: %w", name, err, errdefs.ErrInvalidArgument)
+		}
+	}
+
+	switch {
+	case layout.Version != "":
+
+		if layout.Version != ocispec.ImageLayoutVersion {
+			return nil, fmt.Errorf("unsupported OCI layout version %s: %w", layout.Version, errdefs.ErrInvalidArgument)
+		}
+
+		for _, manifest := range index.Manifests {
+			archived := memoryArchiveImage{RemoteImage: RemoteImage{Ref: manifest.Annotations[images.AnnotationImageName], Size: manifest.Size}}
+
+			if archived.Ref == "" {
+				archived.Ref = manifest.Annotations[ocispec.AnnotationRefName]
+			}
+
+			if archived.Ref == "" {
+				continue
+			}
+
+			if manifest.MediaType == memoryImageMediaType {
+				blob, exists := blobs[path.Join("blobs", manifest.Digest.Algorithm().String(), manifest.Digest.Encoded())]
+
+				if !exists || json.Unmarshal(blob, &archived) != nil {
+					return nil, fmt.Errorf("archive is missing image %s: %w", archived.Ref, errdefs.ErrInvalidArgument)
+				}
+			}
+
+			if manifest.Platform != nil {
+				archived.Platforms = []ocispec.Platform{*manifest.Platform}
+			}
+
+			archivedImages = append(archivedImages, archived)
+		}
+	case dockerManifests != nil:
+
+		for _, manifest := range dockerManifests {
+			archived := memoryArchiveImage{}
+
+			for _, layer := range manifest.Layers {
+				archived.Layers = append(archived.Layers, sizes[path.Clean(layer)])
+				archived.Size += sizes[path.Clean(layer)]
+			}
+
+			for _, tag := range manifest.RepoTags {
+				named, parseErr := distref.ParseDockerRef(tag)
+
+				if parseErr != nil {
+					return nil, fmt.Errorf("invalid repo tag %q: %v: %w", tag, parseErr, errdefs.ErrInvalidArgument)
+				}
+
+				archived.Ref = named.String()
+				archivedImages = append(archivedImages, archived)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unrecognized image archive format: %w", errdefs.ErrInvalidArgument)
+	}
+
+	for i := range archivedImages {
+
+		if len(archivedImages[i].Platforms) == 0 {
+			archivedImages[i].Platforms = []ocispec.Platform{platforms.DefaultSpec()}
+		}
+
+		if archivedImages[i].Present == nil {
+			archivedImages[i].Present = archivedImages[i].Platforms
+		}
+	}
+
+	return archivedImages, nil
+}
+
+// Namespaces lists the namespaces that have been used, in order.
+func (b *MemoryBackend) Namespaces(ctx context.Context) (nss []string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for name := range b.namespaces {
+		nss = append(nss, name)
+	}
+
+	sort.Strings(nss)
+
+	return nss, nil
+}
+
+// DiskUsage adds up the sizes and unpacked sizes of the images stored in all namespaces.
+// Images that share their target, e.g. tags of the same image, are counted once.
+func (b *MemoryBackend) DiskUsage(ctx context.Context) (DiskUsage, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	usage := DiskUsage{Capacity: b.DiskCapacity}
+
+	for _, ns := range b.namespaces {
+		counted := make(map[string]bool)
+
+		for _, img := range ns.images {
+
+			if counted[img.record.Target.Digest.String()] {
+				continue
+			}
+
+			counted[img.record.Target.Digest.String()] = true
+			usage.Used += img.remote.Size + img.remote.UnpackedSize
+		}
+	}
+
+	return usage, nil
+}
+
+// NewContainer stores a container record for the given image and spec and prepares its active snapshot.
+func (b *MemoryBackend) NewContainer(ctx context.Context, id string, i Image, spec ContainerSpec) (RuntimeContainer, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ns, err := b.namespace(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = identifiers.Validate(id); err != nil {
+		return nil, fmt.Errorf("container id %q: %w", id, err)
+	}
+
+	img, exists := ns.images[i.Name()]
+
+	if !exists {
+		return nil, fmt.Errorf("image %q: %w", i.Name(), errdefs.ErrNotFound)
+	}
+
+	if _, exists = ns.containers[id]; exists {
+		return nil, fmt.Errorf("container %q: %w", id, errdefs.ErrAlreadyExists)
+	}
+
+	if _, exists = ns.snapshots[id]; exists {
+		return nil, fmt.Errorf("snapshot %q: %w", id, errdefs.ErrAlreadyExists)
+	}
+
+	now := time.Now().UTC()
+	ns.snapshots[id] = snapshots.Info{
+		Kind:    snapshots.KindActive,
+		Name:    id,
+		Parent:  img.record.Target.Digest.String(),
+		Created: now,
+		Updated: now,
+	}
+
+	labels := make(map[string]string)
+
+	for k, v := range spec.Labels {
+		labels[k] = v
+	}
+
+	if img.remote.StopSignal != "" {
+		labels[containerd.StopSignalLabel] = img.remote.StopSignal
+	}
+
+	c := &memoryContainer{
+		backend: b,
+		ns:      ns,
+		ignored: img.remote.IgnoredSignals,
+		record: containers.Container{
+			ID:          id,
+			Labels:      labels,
+			Image:       img.record.Name,
+			SnapshotKey: id,
+			Snapshotter: memorySnapshotter,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		},
+		spec: spec,
+	}
+	ns.containers[id] = c
+	b.publish(ns, ContainerCreateTopic, Event{ContainerID: id, Image: img.record.Name})
+
+	return c, nil
+}
+
+// LoadContainer returns the stored container with the given ID.
+func (b *MemoryBackend) LoadContainer(ctx context.Context, id string) (RuntimeContainer, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ns, err := b.namespace(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c, exists := ns.containers[id]
+
+	if !exists {
+		return nil, fmt.Errorf("container %q: %w", id, errdefs.ErrNotFound)
+	}
+
+	return c, nil
+}
+
+// Containers returns the stored containers matching any of the given containerd filters.
+func (b *MemoryBackend) Containers(ctx context.Context, fs ...string) (cs []RuntimeContainer, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ns, err := b.namespace(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := filters.ParseAll(fs...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range ns.containers {
+		if filter.Match(adaptContainer(c.record)) {
+			cs = append(cs, c)
+		}
+	}
+
+	sort.Slice(cs, func(i, j int) bool { return cs[i].ID() < cs[j].ID() })
+
+	return cs, nil
+}
+
+// DeleteContainer removes the container with the given ID along with its snapshot.
+// Like containerd, it refuses to delete containers that still have a task.
+func (b *MemoryBackend) DeleteContainer(ctx context.Context, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ns, err := b.namespace(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	c, exists := ns.containers[id]
+
+	if !exists {
+		return fmt.Errorf("container %q: %w", id, errdefs.ErrNotFound)
+	}
+
+	if c.task != nil {
+		return fmt.Errorf("cannot delete running task %v: %w", id, errdefs.ErrFailedPrecondition)
+	}
+
+	delete(ns.snapshots, c.record.SnapshotKey)
+	delete(ns.containers, id)
+	b.publish(ns, ContainerDeleteTopic, Event{ContainerID: id})
+
+	return nil
+}
+
+// Snapshots returns the snapshots held in the namespace carried by ctx.
+func (b *MemoryBackend) Snapshots(ctx context.Context) (infos []snapshots.Info, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ns, err := b.namespace(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, info := range ns.snapshots {
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+
+	return infos, nil
+}
+
+// TaskIO returns the streams the given container's task was created with, so callers can simulate task output.
+func (b *MemoryBackend) TaskIO(ctx context.Context, containerID string) (TaskIO, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ns, err := b.namespace(ctx)
+
+	if err != nil {
+		return TaskIO{}, err
+	}
+
+	c, exists := ns.containers[containerID]
+
+	if !exists {
+		return TaskIO{}, fmt.Errorf("container %q: %w", containerID, errdefs.ErrNotFound)
+	}
+
+	if c.task == nil {
+		return TaskIO{}, fmt.Errorf("no running task found: %w", errdefs.ErrNotFound)
+	}
+
+	return c.task.io, nil
+}
+
+// ExitTask makes the given container's running task exit with the given status, as if its process ended on its own.
+func (b *MemoryBackend) ExitTask(ctx context.Context, containerID string, code uint32) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ns, err := b.namespace(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	c, exists := ns.containers[containerID]
+
+	if !exists {
+		return fmt.Errorf("container %q: %w", containerID, errdefs.ErrNotFound)
+	}
+
+	if c.task == nil {
+		return fmt.Errorf("no running task found: %w", errdefs.ErrNotFound)
+	}
+
+	if c.task.status != containerd.Running && c.task.status != containerd.Paused {
+		return fmt.Errorf("task %q is %s: %w", containerID, c.task.status, errdefs.ErrFailedPrecondition)
+	}
+
+	c.task.exit(code)
+
+	return nil
+}
+
+// Subscribe streams the events published in any namespace that match any of the given containerd filters.
+// Like containerd, it cuts off subscribers that fall more than followBuffer events behind.
+func (b *MemoryBackend) Subscribe(ctx context.Context, fs ...string) (<-chan Event, <-chan error) {
+	var (
+		events = make(chan Event, followBuffer)
+		errs   = make(chan error, 1)
+	)
+
+	filter, err := filters.ParseAll(fs...)
+
+	if err != nil {
+		errs <- err
+		close(errs)
+		return events, errs
+	}
+
+	sub := &memorySubscriber{filter: filter, events: events, errs: errs}
+	b.mu.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, subscribed := b.subscribers[sub]; subscribed {
+			delete(b.subscribers, sub)
+			close(errs)
+		}
+	}()
+
+	return events, errs
+}
+
+// publish stamps an event with its namespace, topic and time and hands it to the matching subscribers. Callers must hold b.mu.
+func (b *MemoryBackend) publish(ns *memoryNamespace, topic string, e Event) {
+	e.Timestamp = time.Now().UTC()
+	e.Namespace = ns.name
+	e.Topic = topic
+
+	for sub := range b.subscribers {
+		if !sub.filter.Match(adaptEvent(e)) {
+			continue
+		}
+
+		select {
+		case sub.events <- e:
+		default:
+			delete(b.subscribers, sub)
+			sub.errs <- fmt.Errorf("event subscriber fell behind: %w", errdefs.ErrUnavailable)
+			close(sub.errs)
+		}
+	}
+}
+
+type memorySubscriber struct {
+	filter filters.Filter
+	events chan<- Event
+	errs   chan<- error
+}
+
+// reportPull plays the progress of a pull of remote through progress.
+func reportPull(ref string, remote RemoteImage, progress func(PullProgress)) {
+	sizes := remote.Layers
+
+	if len(sizes) == 0 {
+		sizes = []int64{remote.Size}
+	}
+
+	layers := make([]LayerProgress, len(sizes))
+
+	for n, size := range sizes {
+		layers[n] = LayerProgress{
+			Digest:    digest.FromString(fmt.Sprintf("%s layer %d", ref, n)).String(),
+			MediaType: ocispec.MediaTypeImageLayerGzip,
+			Status:    LayerWaiting,
+			Total:     size,
+		}
+	}
+
+	report := func(status string) {
+		progress(PullProgress{Status: status, Layers: append([]LayerProgress(nil), layers...)})
+	}
+
+	report(PullDownloading)
+
+	for n := range layers {
+		layers[n].Status, layers[n].Offset = LayerDownloading, layers[n].Total/2
+		report(PullDownloading)
+		layers[n].Status, layers[n].Offset = LayerDone, layers[n].Total
+		report(PullDownloading)
+	}
+
+	report(PullUnpacking)
+}
+
+type memoryImage struct {
+	backend *MemoryBackend
+	record  images.Image
+	remote  RemoteImage
+	// present lists the platforms of remote whose content was pulled or imported.
+	present []ocispec.Platform
+}
+
+func (i *memoryImage) Name() string {
+	return i.record.Name
+}
+
+func (i *memoryImage) Target() ocispec.Descriptor {
+	i.backend.mu.Lock()
+	defer i.backend.mu.Unlock()
+
+	return i.record.Target
+}
+
+func (i *memoryImage) Labels() map[string]string {
+	i.backend.mu.Lock()
+	defer i.backend.mu.Unlock()
+
+	return i.record.Labels
+}
+
+func (i *memoryImage) CreatedAt() time.Time {
+	return i.record.CreatedAt
+}
+
+func (i *memoryImage) UpdatedAt() time.Time {
+	i.backend.mu.Lock()
+	defer i.backend.mu.Unlock()
+
+	return i.record.UpdatedAt
+}
+
+func (i *memoryImage) Size(ctx context.Context) (int64, error) {
+	i.backend.mu.Lock()
+	defer i.backend.mu.Unlock()
+
+	return i.remote.Size, nil
+}
+
+func (i *memoryImage) UnpackedSize(ctx context.Context) (int64, error) {
+	i.backend.mu.Lock()
+	defer i.backend.mu.Unlock()
+
+	return i.remote.UnpackedSize, nil
+}
+
+func (i *memoryImage) Platforms(ctx context.Context) ([]ocispec.Platform, error) {
+	i.backend.mu.Lock()
+	defer i.backend.mu.Unlock()
+
+	return i.remote.Platforms, nil
+}
+
+func (i *memoryImage) PresentPlatforms(ctx context.Context) ([]ocispec.Platform, error) {
+	i.backend.mu.Lock()
+	defer i.backend.mu.Unlock()
+
+	return append([]ocispec.Platform(nil), i.present...), nil
+}
+
+func (i *memoryImage) Config(ctx context.Context) (ImageConfig, error) {
+	i.backend.mu.Lock()
+	defer i.backend.mu.Unlock()
+
+	return i.remote.Config, nil
+}
+
+type memoryContainer struct {
+	backend *MemoryBackend
+	ns      *memoryNamespace
+	record  containers.Container
+	spec    ContainerSpec
+	ignored []syscall.Signal
+	task    *memoryTask
+	// joined is the pid of the process whose namespaces the container's tasks join, 0 if they get their own.
+	joined uint32
+}
+
+func (c *memoryContainer) ID() string {
+	return c.record.ID
+}
+
+func (c *memoryContainer) Image(ctx context.Context) (Image, error) {
+	c.backend.mu.Lock()
+	defer c.backend.mu.Unlock()
+
+	img, exists := c.ns.images[c.record.Image]
+
+	if !exists {
+		return nil, fmt.Errorf("image %q: %w", c.record.Image, errdefs.ErrNotFound)
+	}
+
+	return img, nil
+}
+
+func (c *memoryContainer) Spec(ctx context.Context) (ContainerSpec, error) {
+	c.backend.mu.Lock()
+	defer c.backend.mu.Unlock()
+
+	return c.spec, nil
+}
+
+func (c *memoryContainer) Labels(ctx context.Context) (map[string]string, error) {
+	c.backend.mu.Lock()
+	defer c.backend.mu.Unlock()
+
+	labels := make(map[string]string, len(c.record.Labels))
+
+	for k, v := range c.record.Labels {
+		labels[k] = v
+	}
+
+	return labels, nil
+}
+
+func (c *memoryContainer) SetLabels(ctx context.Context, labels map[string]string) error {
+	c.backend.mu.Lock()
+	defer c.backend.mu.Unlock()
+
+	// Like containerd, an empty value removes the label.
+	for k, v := range labels {
+
+		if v == "" {
+			delete(c.record.Labels, k)
+		} else {
+			c.record.Labels[k] = v
+		}
+	}
+
+	c.record.UpdatedAt = time.Now().UTC()
+	c.backend.publish(c.ns, ContainerUpdateTopic, Event{ContainerID: c.record.ID, Image: c.record.Image})
+
+	return nil
+}
+
+func (c *memoryContainer) UpdateResources(ctx context.Context, r Resources) error {
+	c.backend.mu.Lock()
+	defer c.backend.mu.Unlock()
+
+	c.spec.Resources = r
+	c.record.UpdatedAt = time.Now().UTC()
+	c.backend.publish(c.ns, ContainerUpdateTopic, Event{ContainerID: c.record.ID, Image: c.record.Image})
+
+	return nil
+}
+
+func (c *memoryContainer) JoinNamespaces(ctx context.Context, pid uint32) error {
+	c.backend.mu.Lock()
+	defer c.backend.mu.Unlock()
+
+	c.joined = pid
+
+	return nil
+}
+
+func (c *memoryContainer) Task(ctx context.Context, attach cio.Attach) (Task, error) {
+	return c.LoadTask(ctx)
+}
+
+func (c *memoryContainer) NewTask(ctx context.Context, io TaskIO) (RuntimeTask, error) {
+	c.backend.mu.Lock()
+	defer c.backend.mu.Unlock()
+
+	if _, exists := c.ns.containers[c.record.ID]; !exists {
+		return nil, fmt.Errorf("container %q: %w", c.record.ID, errdefs.ErrNotFound)
+	}
+
+	if c.task != nil {
+		return nil, fmt.Errorf("task %q: %w", c.record.ID, errdefs.ErrAlreadyExists)
+	}
+
+	// Namespaces only exist while a process is in them, like /proc/<pid>/ns on a real host.
+	if c.joined != 0 && !c.ns.running(c.joined) {
+		return nil, fmt.Errorf("namespaces of pid %d: %w", c.joined, errdefs.ErrNotFound)
+	}
+
+	if c.spec.Terminal {
+		io.Stderr = io.Stdout
+	}
+
+	c.backend.lastPid++
+	c.task = &memoryTask{
+		container: c,
+		pid:       c.backend.lastPid,
+		io:        io,
+		cgroup:    c.spec.Resources.linuxResources(),
+		status:    containerd.Created,
+		exited:    make(chan struct{}),
+		execs:     make(map[string]*memoryProcess),
+	}
+	c.backend.publish(c.ns, TaskCreateTopic, Event{ContainerID: c.record.ID, Pid: c.task.pid})
+
+	return c.task, nil
+}
+
+func (c *memoryContainer) AttachTask(ctx context.Context, io TaskIO) (RuntimeTask, error) {
+	c.backend.mu.Lock()
+	defer c.backend.mu.Unlock()
+
+	if c.task == nil {
+		return nil, fmt.Errorf("no running task found: %w", errdefs.ErrNotFound)
+	}
+
+	if c.spec.Terminal {
+		io.Stderr = io.Stdout
+	}
+
+	c.task.io = io
+
+	return c.task, nil
+}
+
+func (c *memoryContainer) StopSignal(ctx context.Context) (syscall.Signal, error) {
+	c.backend.mu.Lock()
+	defer c.backend.mu.Unlock()
+
+	return stopSignal(c.record.Labels)
+}
+
+func (c *memoryContainer) LoadTask(ctx context.Context) (RuntimeTask, error) {
+	c.backend.mu.Lock()
+	defer c.backend.mu.Unlock()
+
+	if c.task == nil {
+		return nil, fmt.Errorf("no running task found: %w", errdefs.ErrNotFound)
+	}
+
+	return c.task, nil
+}
+
+type memoryTask struct {
+	container *memoryContainer
+	pid       uint32
+	io        TaskIO
+	status    containerd.ProcessStatus
+	// cgroup holds the limits in force, which Update changes the way runc update does.
+	cgroup     *specs.LinuxResources
+	exitStatus uint32
+	exitedAt   time.Time
+	exited     chan struct{}
+	execs      map[string]*memoryProcess
+	console    ConsoleSize
+	// cpuTime is the time the task spent running before runningSince, when it last started or resumed.
+	cpuTime      time.Duration
+	runningSince time.Time
+}
+
+func (t *memoryTask) ID() string {
+	return t.container.record.ID
+}
+
+func (t *memoryTask) Pid() uint32 {
+	return t.pid
+}
+
+func (t *memoryTask) Status(ctx context.Context, attach cio.Attach) (Status, error) {
+	t.container.backend.mu.Lock()
+	defer t.container.backend.mu.Unlock()
+
+	return Status{Status: t.status, ExitStatus: t.exitStatus, ExitTime: t.exitedAt}, nil
+}
+
+func (t *memoryTask) Pids(ctx context.Context) ([]ProcessInfo, error) {
+	t.container.backend.mu.Lock()
+	defer t.container.backend.mu.Unlock()
+
+	if t.status == containerd.Stopped {
+		return []ProcessInfo{}, nil
+	}
+
+	pis := []ProcessInfo{{Pid: t.pid}}
+
+	for _, p := range t.execs {
+		if p.status == containerd.Running {
+			pis = append(pis, ProcessInfo{Pid: p.pid})
+		}
+	}
+
+	sort.Slice(pis, func(i, j int) bool { return pis[i].Pid < pis[j].Pid })
+
+	return pis, nil
+}
+
+func (t *memoryTask) Metrics(ctx context.Context) (Metrics, error) {
+	t.container.backend.mu.Lock()
+	defer t.container.backend.mu.Unlock()
+
+	if t.status == containerd.Stopped {
+		return Metrics{}, fmt.Errorf("process already finished: %w", errdefs.ErrNotFound)
+	}
+
+	now := time.Now().UTC()
+	cpuTime := t.cpuTime
+
+	if t.status == containerd.Running {
+		cpuTime += now.Sub(t.runningSince)
+	}
+
+	m := Metrics{
+		Timestamp:   now,
+		CPUUsage:    uint64(cpuTime),
+		CPUUser:     uint64(cpuTime),
+		PidsCurrent: 1,
+	}
+
+	for _, p := range t.execs {
+		if p.status == containerd.Running {
+			m.PidsCurrent++
+		}
+	}
+
+	if t.cgroup.Memory != nil && t.cgroup.Memory.Limit != nil && *t.cgroup.Memory.Limit > 0 {
+		m.MemoryLimit = uint64(*t.cgroup.Memory.Limit)
+	}
+
+	if t.cgroup.Pids != nil && t.cgroup.Pids.Limit > 0 {
+		m.PidsLimit = uint64(t.cgroup.Pids.Limit)
+	}
+
+	return m, nil
+}
+
+func (t *memoryTask) Start(ctx context.Context) error {
+	t.container.backend.mu.Lock()
+	defer t.container.backend.mu.Unlock()
+
+	if t.status != containerd.Created {
+		return fmt.Errorf("task %q is %s: %w", t.ID(), t.status, errdefs.ErrFailedPrecondition)
+	}
+
+	t.status = containerd.Running
+	t.runningSince = time.Now()
+	t.publish(TaskStartTopic, Event{Pid: t.pid})
+
+	return nil
+}
+
+func (t *memoryTask) Wait(ctx context.Context) (<-chan ExitStatus, error) {
+	es := make(chan ExitStatus, 1)
+
+	go func() {
+		defer close(es)
+
+		select {
+		case <-t.exited:
+			t.container.backend.mu.Lock()
+			es <- ExitStatus(*containerd.NewExitStatus(t.exitStatus, t.exitedAt, nil))
+			t.container.backend.mu.Unlock()
+		case <-ctx.Done():
+			es <- ExitStatus(*containerd.NewExitStatus(containerd.UnknownExitStatus, time.Time{}, ctx.Err()))
+		}
+	}()
+
+	return es, nil
+}
+
+func (t *memoryTask) Kill(ctx context.Context, signal syscall.Signal) error {
+	t.container.backend.mu.Lock()
+	defer t.container.backend.mu.Unlock()
+
+	if t.status == containerd.Stopped {
+		return fmt.Errorf("process already finished: %w", errdefs.ErrNotFound)
+	}
+
+	for _, ignored := range t.container.ignored {
+		if signal == ignored && signal != syscall.SIGKILL {
+			return nil
+		}
+	}
+
+	t.exit(128 + uint32(signal))
+
+	return nil
+}
+
+func (t *memoryTask) Pause(ctx context.Context) error {
+	t.container.backend.mu.Lock()
+	defer t.container.backend.mu.Unlock()
+
+	if t.status != containerd.Running {
+		return fmt.Errorf("cannot pause a %s task: %w", t.status, errdefs.ErrFailedPrecondition)
+	}
+
+	t.status = containerd.Paused
+	t.cpuTime += time.Since(t.runningSince)
+	t.publish(TaskPausedTopic, Event{})
+
+	return nil
+}
+
+func (t *memoryTask) Resume(ctx context.Context) error {
+	t.container.backend.mu.Lock()
+	defer t.container.backend.mu.Unlock()
+
+	if t.status != containerd.Paused {
+		return fmt.Errorf("cannot resume a %s task: %w", t.status, errdefs.ErrFailedPrecondition)
+	}
+
+	t.status = containerd.Running
+	t.runningSince = time.Now()
+	t.publish(TaskResumedTopic, Event{})
+
+	return nil
+}
+
+func (t *memoryTask) Update(ctx context.Context, r Resources) error {
+	t.container.backend.mu.Lock()
+	defer t.container.backend.mu.Unlock()
+
+	if t.status == containerd.Stopped {
+		return fmt.Errorf("cannot update a stopped task: %w", errdefs.ErrFailedPrecondition)
+	}
+
+	updateCgroup(t.cgroup, r.updateResources())
+
+	return nil
+}
+
+// updateCgroup applies the limits of update to cgroup like runc update does: limits that are nil in update are left as they are.
+func updateCgroup(cgroup, update *specs.LinuxResources) {
+	if update.CPU != nil {
+
+		if cgroup.CPU == nil {
+			cgroup.CPU = &specs.LinuxCPU{}
+		}
+
+		if update.CPU.Shares != nil {
+			cgroup.CPU.Shares = update.CPU.Shares
+		}
+
+		if update.CPU.Quota != nil {
+			cgroup.CPU.Quota = update.CPU.Quota
+		}
+
+		if update.CPU.Period != nil {
+			cgroup.CPU.Period = update.CPU.Period
+		}
+	}
+
+	if update.Memory != nil {
+
+		if cgroup.Memory == nil {
+			cgroup.Memory = &specs.LinuxMemory{}
+		}
+
+		if update.Memory.Limit != nil {
+			cgroup.Memory.Limit = update.Memory.Limit
+		}
+
+		if update.Memory.Reservation != nil {
+			cgroup.Memory.Reservation = update.Memory.Reservation
+		}
+
+		if update.Memory.Swap != nil {
+			cgroup.Memory.Swap = update.Memory.Swap
+		}
+	}
+
+	if update.Pids != nil && update.Pids.Limit != 0 {
+		cgroup.Pids = &specs.LinuxPids{Limit: update.Pids.Limit}
+	}
+}
+
+func (t *memoryTask) Resize(ctx context.Context, width, height uint32) error {
+	t.container.backend.mu.Lock()
+	defer t.container.backend.mu.Unlock()
+
+	if !t.container.spec.Terminal {
+		return fmt.Errorf("task %q has no terminal: %w", t.ID(), errdefs.ErrFailedPrecondition)
+	}
+
+	if t.status == containerd.Stopped {
+		return fmt.Errorf("process already finished: %w", errdefs.ErrNotFound)
+	}
+
+	t.console = ConsoleSize{Width: width, Height: height}
+
+	return nil
+}
+
+// Dial connects from the node's own network namespace, which simulated tasks share.
+func (t *memoryTask) Dial(ctx context.Context, network, address string) (net.Conn, error) {
+	t.container.backend.mu.Lock()
+	stopped := t.status == containerd.Stopped
+	t.container.backend.mu.Unlock()
+
+	if stopped {
+		return nil, fmt.Errorf("process already finished: %w", errdefs.ErrNotFound)
+	}
+
+	var dialer net.Dialer
+
+	return dialer.DialContext(ctx, network, address)
+}
+
+// exit moves the task to the stopped state and releases its waiters. Its exec'd processes die with it.
+// A task that never started is stopped without an exit event, as containerd does.
+// Callers must hold the backend lock.
+func (t *memoryTask) exit(code uint32) {
+	for _, p := range t.execs {
+		if p.status != containerd.Stopped {
+			p.exit(128 + uint32(syscall.SIGKILL))
+		}
+	}
+
+	if t.status == containerd.Running {
+		t.cpuTime += time.Since(t.runningSince)
+	}
+
+	started := t.status != containerd.Created
+	t.status = containerd.Stopped
+	t.exitStatus = code
+	t.exitedAt = time.Now().UTC()
+	close(t.exited)
+
+	if started {
+		t.publish(TaskExitTopic, Event{Pid: t.pid, ExitStatus: code, ExitedAt: t.exitedAt})
+	}
+}
+
+// publish publishes an event about the task. Callers must hold the backend lock.
+func (t *memoryTask) publish(topic string, e Event) {
+	e.ContainerID = t.container.record.ID
+	t.container.backend.publish(t.container.ns, topic, e)
+}
+
+func (t *memoryTask) Delete(ctx context.Context) (ExitStatus, error) {
+	t.container.backend.mu.Lock()
+	defer t.container.backend.mu.Unlock()
+
+	if t.status != containerd.Stopped && t.status != containerd.Created {
+		return ExitStatus{}, fmt.Errorf("task must be stopped before deletion: %s: %w", t.status, errdefs.ErrFailedPrecondition)
+	}
+
+	if t.status == containerd.Created {
+		t.exit(0)
+	}
+
+	if t.container.task == t {
+		t.container.task = nil
+	}
+
+	t.publish(TaskDeleteTopic, Event{Pid: t.pid, ExitStatus: t.exitStatus, ExitedAt: t.exitedAt})
+
+	return ExitStatus(*containerd.NewExitStatus(t.exitStatus, t.exitedAt, nil)), nil
+}
+
+func adaptImage(i images.Image) filters.Adaptor {
+	return filters.AdapterFunc(func(fieldpath []string) (string, bool) {
+		if len(fieldpath) == 0 {
+			return "", false
+		}
+
+		switch fieldpath[0] {
+		case "name":
+			return i.Name, len(i.Name) > 0
+		case "target":
+			if len(fieldpath) < 2 {
+				return "", false
+			}
+
+			switch fieldpath[1] {
+			case "digest":
+				return i.Target.Digest.String(), len(i.Target.Digest) > 0
+			case "mediatype":
+				return i.Target.MediaType, len(i.Target.MediaType) > 0
+			}
+		case "labels":
+			return adaptLabels(fieldpath[1:], i.Labels)
+		}
+
+		return "", false
+	})
+}
+
+func adaptContainer(c containers.Container) filters.Adaptor {
+	return filters.AdapterFunc(func(fieldpath []string) (string, bool) {
+		if len(fieldpath) == 0 {
+			return "", false
+		}
+
+		switch fieldpath[0] {
+		case "id":
+			return c.ID, len(c.ID) > 0
+		case "image":
+			return c.Image, len(c.Image) > 0
+		case "labels":
+			return adaptLabels(fieldpath[1:], c.Labels)
+		}
+
+		return "", false
+	})
+}
+
+func adaptLabels(fieldpath []string, labels map[string]string) (string, bool) {
+	if len(fieldpath) == 0 {
+		return "", false
+	}
+
+	value, exists := labels[strings.Join(fieldpath, ".")]
+
+	return value, exists
+}
+
+func (t *memoryTask) Exec(ctx context.Context, id string, spec ProcessSpec, io TaskIO) (RuntimeProcess, error) {
+	t.container.backend.mu.Lock()
+	defer t.container.backend.mu.Unlock()
+
+	if t.status != containerd.Running {
+		return nil, fmt.Errorf("cannot exec in a %s state: %w", t.status, errdefs.ErrFailedPrecondition)
+	}
+
+	if err := identifiers.Validate(id); err != nil {
+		return nil, fmt.Errorf("exec id %q: %w", id, err)
+	}
+
+	if _, exists := t.execs[id]; exists {
+		return nil, fmt.Errorf("id %s: %w", id, errdefs.ErrAlreadyExists)
+	}
+
+	if spec.Terminal {
+		io.Stderr = io.Stdout
+	}
+
+	p := &memoryProcess{
+		task:   t,
+		id:     id,
+		spec:   spec,
+		io:     io,
+		status: containerd.Created,
+		exited: make(chan struct{}),
+	}
+	t.execs[id] = p
+	t.publish(TaskExecAddedTopic, Event{ExecID: id})
+
+	return p, nil
+}
+
+type memoryProcess struct {
+	task       *memoryTask
+	id         string
+	pid        uint32
+	spec       ProcessSpec
+	io         TaskIO
+	status     containerd.ProcessStatus
+	exitStatus uint32
+	exitedAt   time.Time
+	exited     chan struct{}
+	console    ConsoleSize
+}
+
+func (p *memoryProcess) ID() string {
+	return p.id
+}
+
+func (p *memoryProcess) Pid() uint32 {
+	p.task.container.backend.mu.Lock()
+	defer p.task.container.backend.mu.Unlock()
+
+	return p.pid
+}
+
+// Start runs the simulated command in the background.
+func (p *memoryProcess) Start(ctx context.Context) error {
+	b := p.task.container.backend
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if p.status != containerd.Created {
+		return fmt.Errorf("process %q is %s: %w", p.id, p.status, errdefs.ErrFailedPrecondition)
+	}
+
+	run, found := memoryCommands[path.Base(p.spec.Args[0])]
+
+	if !found {
+		return fmt.Errorf("exec: %q: executable file not found in $PATH: %w", p.spec.Args[0], errdefs.ErrNotFound)
+	}
+
+	b.lastPid++
+	p.pid = b.lastPid
+	p.status = containerd.Running
+	p.task.publish(TaskExecStartedTopic, Event{ExecID: p.id, Pid: p.pid})
+
+	go func() {
+		code := run(p, p.spec.Args[1:])
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if p.status == containerd.Running {
+			p.exit(code)
+		}
+	}()
+
+	return nil
+}
+
+func (p *memoryProcess) Wait(ctx context.Context) (<-chan ExitStatus, error) {
+	es := make(chan ExitStatus, 1)
+
+	go func() {
+		defer close(es)
+
+		select {
+		case <-p.exited:
+			p.task.container.backend.mu.Lock()
+			es <- ExitStatus(*containerd.NewExitStatus(p.exitStatus, p.exitedAt, nil))
+			p.task.container.backend.mu.Unlock()
+		case <-ctx.Done():
+			es <- ExitStatus(*containerd.NewExitStatus(containerd.UnknownExitStatus, time.Time{}, ctx.Err()))
+		}
+	}()
+
+	return es, nil
+}
+
+func (p *memoryProcess) Kill(ctx context.Context, signal syscall.Signal) error {
+	p.task.container.backend.mu.Lock()
+	defer p.task.container.backend.mu.Unlock()
+
+	if p.status != containerd.Running {
+		return fmt.Errorf("process already finished: %w", errdefs.ErrNotFound)
+	}
+
+	p.exit(128 + uint32(signal))
+
+	return nil
+}
+
+func (p *memoryProcess) Resize(ctx context.Context, width, height uint32) error {
+	p.task.container.backend.mu.Lock()
+	defer p.task.container.backend.mu.Unlock()
+
+	if !p.spec.Terminal {
+		return fmt.Errorf("process %q has no terminal: %w", p.id, errdefs.ErrFailedPrecondition)
+	}
+
+	if p.status == containerd.Stopped {
+		return fmt.Errorf("process already finished: %w", errdefs.ErrNotFound)
+	}
+
+	p.console = ConsoleSize{Width: width, Height: height}
+
+	return nil
+}
+
+func (p *memoryProcess) consoleSize() ConsoleSize {
+	p.task.container.backend.mu.Lock()
+	defer p.task.container.backend.mu.Unlock()
+
+	return p.console
+}
+
+func (p *memoryProcess) Delete(ctx context.Context) (ExitStatus, error) {
+	p.task.container.backend.mu.Lock()
+	defer p.task.container.backend.mu.Unlock()
+
+	if p.status == containerd.Running {
+		return ExitStatus{}, fmt.Errorf("process must be stopped before deletion: %w", errdefs.ErrFailedPrecondition)
+	}
+
+	if p.status == containerd.Created {
+		p.exit(0)
+	}
+
+	delete(p.task.execs, p.id)
+
+	return ExitStatus(*containerd.NewExitStatus(p.exitStatus, p.exitedAt, nil)), nil
+}
+
+// exit moves the process to the stopped state and releases its waiters. Callers must hold the backend lock.
+func (p *memoryProcess) exit(code uint32) {
+	started := p.status != containerd.Created
+	p.status = containerd.Stopped
+	p.exitStatus = code
+	p.exitedAt = time.Now().UTC()
+	close(p.exited)
+
+	if started {
+		p.task.publish(TaskExitTopic, Event{ExecID: p.id, Pid: p.pid, ExitStatus: code, ExitedAt: p.exitedAt})
+	}
+}
+
+// memoryCommands simulates the few commands exec'd processes of a MemoryBackend can run. Each returns its exit code.
+var memoryCommands = map[string]func(p *memoryProcess, args []string) uint32{
+	"echo": func(p *memoryProcess, args []string) uint32 {
+		if p.io.Stdout != nil {
+			fmt.Fprintln(p.io.Stdout, strings.Join(args, " "))
+		}
+
+		return 0
+	},
+	"cat": func(p *memoryProcess, args []string) uint32 {
+		if p.io.Stdin != nil {
+			stdout := p.io.Stdout
+
+			if stdout == nil {
+				stdout = ioutil.Discard
+			}
+
+			io.Copy(stdout, p.io.Stdin)
+		}
+
+		return 0
+	},
+	// stty prints the terminal size as "rows columns" when it starts and after every line read from stdin, until stdin is closed.
+	"stty": func(p *memoryProcess, args []string) uint32 {
+		if !p.spec.Terminal {
+			if p.io.Stderr != nil {
+				fmt.Fprintln(p.io.Stderr, "stty: standard input: Inappropriate ioctl for device")
+			}
+
+			return 1
+		}
+
+		stdout := p.io.Stdout
+
+		if stdout == nil {
+			stdout = ioutil.Discard
+		}
+
+		size := p.consoleSize()
+		fmt.Fprintf(stdout, "%d %d\n", size.Height, size.Width)
+
+		if p.io.Stdin == nil {
+			return 0
+		}
+
+		for scanner := bufio.NewScanner(p.io.Stdin); scanner.Scan(); {
+			size = p.consoleSize()
+			fmt.Fprintf(stdout, "%d %d\n", size.Height, size.Width)
+		}
+
+		return 0
+	},
+	"true": func(p *memoryProcess, args []string) uint32 {
+		return 0
+	},
+	"false": func(p *memoryProcess, args []string) uint32 {
+		return 1
+	},
+}
@@ -0,0 +1,133 @@
+package node
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "github.com/containerd/cgroups/stats/v1"
+	v2 "github.com/containerd/cgroups/v2/stats"
+	"github.com/containerd/containerd/api/types"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/typeurl"
+)
+
+// unboundedLimit is the smallest value treated as "no limit". Both cgroup versions report a missing limit as a number close to the largest int64.
+const unboundedLimit = 1 << 62
+
+// Metrics is a snapshot of the resource usage of a task's cgroup, the same for cgroup v1 and v2 hosts.
+// Limits are 0 when the cgroup has none.
+type Metrics struct {
+	Timestamp time.Time `json:"timestamp"`
+	// CPUUsage is the CPU time consumed by the task's processes in nanoseconds. CPUUser and CPUSystem split it by mode.
+	CPUUsage  uint64 `json:"cpu_usage"`
+	CPUUser   uint64 `json:"cpu_user"`
+	CPUSystem uint64 `json:"cpu_system"`
+	// MemoryUsage is in bytes and includes MemoryCache, the page cache charged to the cgroup.
+	MemoryUsage uint64 `json:"memory_usage"`
+	MemoryLimit uint64 `json:"memory_limit"`
+	MemoryCache uint64 `json:"memory_cache"`
+	// BlkioRead and BlkioWrite are the bytes transferred to and from block devices, summed over all devices.
+	BlkioRead   uint64 `json:"blkio_read"`
+	BlkioWrite  uint64 `json:"blkio_write"`
+	PidsCurrent uint64 `json:"pids_current"`
+	PidsLimit   uint64 `json:"pids_limit"`
+}
+
+// decodeMetrics converts the cgroup stats containerd reports for a task into Metrics.
+func decodeMetrics(m *types.Metric) (Metrics, error) {
+	if m == nil || m.Data == nil {
+		return Metrics{}, fmt.Errorf("no metrics reported: %w", errdefs.ErrNotFound)
+	}
+
+	data, err := typeurl.UnmarshalAny(m.Data)
+
+	if err != nil {
+		return Metrics{}, err
+	}
+
+	switch stats := data.(type) {
+	case *v1.Metrics:
+		return metricsV1(m.Timestamp, stats), nil
+	case *v2.Metrics:
+		return metricsV2(m.Timestamp, stats), nil
+	default:
+		return Metrics{}, fmt.Errorf("unsupported metrics type %s: %w", m.Data.TypeUrl, errdefs.ErrNotImplemented)
+	}
+}
+
+func metricsV1(timestamp time.Time, stats *v1.Metrics) Metrics {
+	metrics := Metrics{Timestamp: timestamp}
+
+	if stats.CPU != nil && stats.CPU.Usage != nil {
+		metrics.CPUUsage = stats.CPU.Usage.Total
+		metrics.CPUUser = stats.CPU.Usage.User
+		metrics.CPUSystem = stats.CPU.Usage.Kernel
+	}
+
+	if stats.Memory != nil {
+		metrics.MemoryCache = stats.Memory.Cache
+
+		if stats.Memory.Usage != nil {
+			metrics.MemoryUsage = stats.Memory.Usage.Usage
+			metrics.MemoryLimit = limit(stats.Memory.Usage.Limit)
+		}
+	}
+
+	if stats.Blkio != nil {
+		for _, entry := range stats.Blkio.IoServiceBytesRecursive {
+			switch strings.ToLower(entry.Op) {
+			case "read":
+				metrics.BlkioRead += entry.Value
+			case "write":
+				metrics.BlkioWrite += entry.Value
+			}
+		}
+	}
+
+	if stats.Pids != nil {
+		metrics.PidsCurrent = stats.Pids.Current
+		metrics.PidsLimit = limit(stats.Pids.Limit)
+	}
+
+	return metrics
+}
+
+func metricsV2(timestamp time.Time, stats *v2.Metrics) Metrics {
+	metrics := Metrics{Timestamp: timestamp}
+
+	// cgroup v2 accounts CPU time in microseconds.
+	if stats.CPU != nil {
+		metrics.CPUUsage = stats.CPU.UsageUsec * uint64(time.Microsecond)
+		metrics.CPUUser = stats.CPU.UserUsec * uint64(time.Microsecond)
+		metrics.CPUSystem = stats.CPU.SystemUsec * uint64(time.Microsecond)
+	}
+
+	if stats.Memory != nil {
+		metrics.MemoryUsage = stats.Memory.Usage
+		metrics.MemoryLimit = limit(stats.Memory.UsageLimit)
+		metrics.MemoryCache = stats.Memory.File
+	}
+
+	if stats.Io != nil {
+		for _, entry := range stats.Io.Usage {
+			metrics.BlkioRead += entry.Rbytes
+			metrics.BlkioWrite += entry.Wbytes
+		}
+	}
+
+	if stats.Pids != nil {
+		metrics.PidsCurrent = stats.Pids.Current
+		metrics.PidsLimit = limit(stats.Pids.Limit)
+	}
+
+	return metrics
+}
+
+func limit(v uint64) uint64 {
+	if v >= unboundedLimit {
+		return 0
+	}
+
+	return v
+}
@@ -1,23 +1,81 @@
 /*
 Package node provides interfaces for containerd interaction and clamor-node business logic.
-Right now it's mostly just a wrapper around https://pkg.go.dev/github.com/containerd/containerd?tab=doc#Client.
+Node holds the business logic and delegates runtime work to a Backend: either containerd (see NewContainerdBackend)
+or an in-memory simulation (see NewMemoryBackend) for machines without a containerd daemon.
 TODO: Consider k8s container runtime interface support.
 */
 package node
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
 	"strings"
+	"sync"
 	"syscall"
-	"fmt"
+	"time"
+
 	"github.com/containerd/containerd"
-	"github.com/containerd/containerd/oci"
-	"github.com/containerd/containerd/cio"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/identifiers"
+	"github.com/containerd/containerd/namespaces"
+	"github.com/containerd/containerd/reference"
 )
 
 // Node implements the Service interfaces.
 type Node struct {
-	Ctr *containerd.Client
+	Backend Backend
+	Logs    *LogStore
+
+	streams *streamHub
+	events  *eventHub
+	pulls   *pullHub
+	ops     *operationHub
+	gc      *imageGC
+	restart *restartSupervisor
+	health  *healthMonitor
+	recon   *reconciler
+	// sandboxImage runs the sandbox container of pods that don't name another image.
+	sandboxImage string
+
+	// stop cancels the background loops started by NewNode, and loops tracks them until they return.
+	stop  context.CancelFunc
+	loops *sync.WaitGroup
+}
+
+// NodeOpt configures a Node created by NewNode.
+type NodeOpt func(n *Node)
+
+// WithImageGC makes the Node remove images under the given policy, every policy.Interval and on PruneImages.
+// Without it, PruneImages removes nothing.
+func WithImageGC(policy ImageGCPolicy) NodeOpt {
+	return func(n *Node) {
+		n.gc.policy = policy
+	}
+}
+
+// WithRestartBackoff sets the delay before the restart supervisor first restarts a task, and the most it grows to after repeated restarts.
+// Without it, DefaultRestartBackoff and DefaultMaxRestartBackoff apply.
+func WithRestartBackoff(initial, max time.Duration) NodeOpt {
+	return func(n *Node) {
+		n.restart.backoff, n.restart.maxBackoff = initial, max
+	}
+}
+
+// WithReconcileInterval sets how often applied workloads are checked for drift. Without it, DefaultReconcileInterval applies.
+func WithReconcileInterval(interval time.Duration) NodeOpt {
+	return func(n *Node) {
+		n.recon.interval = interval
+	}
+}
+
+// WithSandboxImage sets the image that runs the sandbox container of pods that don't name another one. Without it, DefaultSandboxImage applies.
+func WithSandboxImage(ref string) NodeOpt {
+	return func(n *Node) {
+		n.sandboxImage = ref
+	}
 }
 
 // Service provides core node methods.
@@ -25,22 +83,40 @@ type Service interface {
 	ImageService
 	ContainerService
 	TaskService
+	LogService
+	EventService
+	OperationService
+	WorkloadService
+	PodService
+
+	// Close stops the background work of the Service. Tasks it started keep running.
+	Close() (err error)
 }
 
 // ImageService provides methods to interact with containerd Image objects.
 type ImageService interface {
-	PullImage(ctx context.Context, name string) (image Image, err error)
+	PullImage(ctx context.Context, name string, platforms ...string) (image Image, err error)
 	GetImage(ctx context.Context, name string) (image Image, err error)
 	GetImages(ctx context.Context, filter string) (images []Image, err error)
 	DeleteImage(ctx context.Context, name string) (err error)
+	TagImage(ctx context.Context, source, target string) (image Image, err error)
+	UntagImage(ctx context.Context, name string) (err error)
+	GetPullJob(ctx context.Context, ref string) (job PullJob, err error)
+	GetPullJobs(ctx context.Context) (jobs []PullJob, err error)
+	FollowPullJob(ctx context.Context, ref string) (jobs <-chan PullJob, err error)
+	ImportImages(ctx context.Context, r io.Reader) (images []Image, err error)
+	ExportImages(ctx context.Context, w io.Writer, names ...string) (err error)
+	PruneImages(ctx context.Context, dryRun bool) (report ImagePruneReport, err error)
 }
 
 // ContainerService provides methods to interact with containerd Container objects.
 type ContainerService interface {
-	CreateContainer(ctx context.Context, imageName, id string) (container Container, err error)
+	CreateContainer(ctx context.Context, imageName, id string, spec ContainerSpec) (container Container, err error)
 	GetContainer(ctx context.Context, id string) (container Container, err error)
 	GetContainers(ctx context.Context, filter string) (container []Container, err error)
 	DeleteContainer(ctx context.Context, id string) (err error)
+	UpdateContainerResources(ctx context.Context, id string, resources Resources) (container Container, err error)
+	GetContainerHealth(ctx context.Context, id string) (health Health, err error)
 }
 
 // TaskService provides methods to interact with containerd Task objects.
@@ -49,15 +125,114 @@ type TaskService interface {
 	CreateTask(ctx context.Context, containerID string) (task Task, err error)
 	GetTask(ctx context.Context, containerID string) (task Task, err error)
 	GetTasks(ctx context.Context, filter string) (tasks []Task, err error)
-	KillTask(ctx context.Context, containerID string) (err error)
+	KillTask(ctx context.Context, containerID, signal string, timeout time.Duration) (err error)
 	DeleteTask(ctx context.Context, containerID string) (exitStatus ExitStatus, err error)
+	ExecTask(ctx context.Context, containerID string, spec ProcessSpec, io TaskIO) (exitStatus ExitStatus, err error)
+	AttachTask(ctx context.Context, containerID string, io TaskIO) (exitStatus ExitStatus, err error)
+	PauseTask(ctx context.Context, containerID string) (task Task, err error)
+	ResumeTask(ctx context.Context, containerID string) (task Task, err error)
+}
+
+// LogService provides methods to read the output of container tasks.
+type LogService interface {
+	GetLogs(ctx context.Context, containerID string, opts LogOptions) (records []LogRecord, err error)
+	FollowLogs(ctx context.Context, containerID string, opts LogOptions) (records <-chan LogRecord, err error)
+}
+
+// EventService provides methods to watch what happens to images, containers and tasks.
+type EventService interface {
+	Events(ctx context.Context, opts EventOptions) (events <-chan Event, err error)
+}
+
+// OperationService provides methods to run long Node calls in the background and keep track of them.
+type OperationService interface {
+	PullImageAsync(ctx context.Context, ref string, platforms ...string) (op Operation, err error)
+	KillTaskAsync(ctx context.Context, containerID, signal string, timeout time.Duration) (op Operation, err error)
+	GetOperation(ctx context.Context, id string) (op Operation, err error)
+	GetOperations(ctx context.Context) (ops []Operation, err error)
+	CancelOperation(ctx context.Context, id string) (op Operation, err error)
+}
+
+// WorkloadService provides methods to keep containers in the state declared by Workloads.
+type WorkloadService interface {
+	ApplyWorkload(ctx context.Context, w Workload) (status WorkloadStatus, err error)
+	GetWorkload(ctx context.Context, name string) (status WorkloadStatus, err error)
+	GetWorkloads(ctx context.Context) (statuses []WorkloadStatus, err error)
+	DeleteWorkload(ctx context.Context, name string) (err error)
 }
 
-// NewNode returns Node instances.
-func NewNode(ctr *containerd.Client) Service {
-	return &Node{
-		Ctr: ctr,
+// PodService provides methods to run groups of containers that share their network, IPC and PID namespaces.
+type PodService interface {
+	CreatePod(ctx context.Context, id string, spec PodSpec) (pod Pod, err error)
+	GetPod(ctx context.Context, id string) (pod Pod, err error)
+	GetPods(ctx context.Context) (pods []Pod, err error)
+	DeletePod(ctx context.Context, id string) (err error)
+}
+
+// exitLogGrace is how long FollowLogs and AttachTask keep streaming after a task exits. Output can still be in flight from the shim when the exit is reported.
+const exitLogGrace = 250 * time.Millisecond
+
+// NewNode returns Node instances backed by the given Backend, configured by the given NodeOpts. Task output is kept in the given LogStore.
+// Workloads applied before the node started are picked up again from the labels of their containers.
+func NewNode(backend Backend, logs *LogStore, opts ...NodeOpt) Service {
+	n := &Node{
+		Backend: backend,
+		Logs:    logs,
+		streams: newStreamHub(),
+		events:  newEventHub(backend),
+		pulls:   newPullHub(),
+		ops:     newOperationHub(),
+		gc:      &imageGC{backend: backend},
+
+		sandboxImage: DefaultSandboxImage,
+	}
+	n.health = newHealthMonitor(n)
+	n.recon = newReconciler(n)
+	n.restart = &restartSupervisor{node: n, backoff: DefaultRestartBackoff, maxBackoff: DefaultMaxRestartBackoff, pending: make(map[string]bool)}
+
+	for _, opt := range opts {
+		opt(n)
 	}
+
+	var (
+		ctx        context.Context
+		subscribed = make(chan struct{})
+	)
+
+	ctx, n.stop = context.WithCancel(context.Background())
+	n.loops = &sync.WaitGroup{}
+
+	if n.gc.policy.Interval > 0 {
+		n.loop(func() { n.gc.run(ctx) })
+	}
+
+	n.loop(func() { n.restart.run(ctx, subscribed) })
+	<-subscribed
+	n.loop(func() { n.health.scan(ctx) })
+	adopted := n.recon.adopt()
+	n.loop(func() { n.recon.run(ctx, adopted) })
+
+	return n
+}
+
+// loop runs f in the background until Close stops it.
+func (n Node) loop(f func()) {
+	n.loops.Add(1)
+
+	go func() {
+		defer n.loops.Done()
+		f()
+	}()
+}
+
+// Close stops the image collector, the restart supervisor, the health probes and the workload reconciler, and waits for them to return.
+// Tasks are left running, so a new Node over the same Backend picks them up again.
+func (n Node) Close() (err error) {
+	n.stop()
+	n.health.close()
+	n.loops.Wait()
+
+	return nil
 }
 
 // TaskStatus returns the given containerd.Task's process status as a string.
@@ -77,102 +252,204 @@ func TaskStatus(ctx context.Context, task Task) (status string) {
 
 // GetImage gets a containerd.Image instance by name.
 func (n Node) GetImage(ctx context.Context, name string) (i Image, err error) {
-	image, err := n.getImage(ctx, name)
-	return newImage(image), err
+	return n.getImage(ctx, name)
 }
 
 // CreateContainer creates a containerd.Container instance with the given id using the given image.
+// The given spec is applied on top of the image config. Its restart policy is stored as the container's RestartPolicyLabel.
 // It returns the created containerd.Container.
-func (n Node) CreateContainer(ctx context.Context, imageName, id string) (c Container, err error) {
+func (n Node) CreateContainer(ctx context.Context, imageName, id string, spec ContainerSpec) (c Container, err error) {
 	var (
-		container              containerd.Container
-		image                  containerd.Image
+		container              RuntimeContainer
+		image                  Image
 		getImageErr, createErr error
 	)
 
+	if specErr := spec.Validate(); specErr != nil {
+		return nil, fmt.Errorf("invalid spec for container %s: %w", id, specErr)
+	}
+
 	if image, getImageErr = n.getImage(ctx, imageName); getImageErr != nil {
 		return nil, fmt.Errorf("failed to get image %s for container %s: %w", imageName, id, getImageErr)
 	}
 
-	container, createErr = n.Ctr.NewContainer(
-		ctx,
-		id,
-		containerd.WithImage(image),
-		containerd.WithNewSnapshot(id, image),
-		containerd.WithNewSpec(oci.WithImageConfig(image)),
-	)
+	if spec.RestartPolicy != "" {
+		policy, _ := ParseRestartPolicy(spec.RestartPolicy)
+		labels := map[string]string{RestartPolicyLabel: policy.String()}
+
+		for k, v := range spec.Labels {
+			labels[k] = v
+		}
 
-	if createErr != nil {
+		spec.Labels = labels
+	}
+
+	if container, createErr = n.Backend.NewContainer(ctx, id, image, spec); createErr != nil {
 		return nil, fmt.Errorf("failed to create container %s: %w", id, createErr)
 	}
 
-	return newContainer(container), nil
+	return container, nil
 }
 
 // CreateTask starts a new task for the given container.
+// The task of a container with a Sandbox joins the namespaces of the sandbox's task, which has to be running.
+// The task's stdout and stderr are appended to the container's log file and copied to attached sessions, see AttachTask.
+// It only gets a stdin, fed by attached sessions, if the container's spec asks for one.
+// Starting the task of a supervised container resets its restart count and undoes a stop by hand, see KillTask.
 // It returns the created containerd.Task.
-// TODO: Figure out task I/O. Currently just using stdio.
 func (n Node) CreateTask(ctx context.Context, containerID string) (t Task, err error) {
+	return n.createTask(ctx, containerID, map[string]string{RestartCountLabel: "0", RestartStoppedLabel: "false"})
+}
+
+// createTask starts a new task for the given container, see CreateTask.
+// If the container is supervised, the given restart labels are set on it once the task is created, before it starts.
+func (n Node) createTask(ctx context.Context, containerID string, restartLabels map[string]string) (t Task, err error) {
 	var (
-		task                        containerd.Task
-		container, loadContainerErr = n.Ctr.LoadContainer(ctx, containerID)
-		newTaskErr                  error
+		task                                RuntimeTask
+		spec                                ContainerSpec
+		logIO, taskIO                       TaskIO
+		container, loadContainerErr         = n.getContainer(ctx, containerID)
+		specErr, openLogErr, openStreamsErr error
+		newTaskErr                          error
 	)
 
 	if loadContainerErr != nil {
 		return nil, fmt.Errorf("failed to load container %s: %w", containerID, loadContainerErr)
 	}
 
-	if task, newTaskErr = container.NewTask(ctx, cio.NewCreator(cio.WithStdio)); newTaskErr != nil {
+	if spec, specErr = container.Spec(ctx); specErr != nil {
+		return nil, fmt.Errorf("failed to get spec of container %s: %w", containerID, specErr)
+	}
+
+	if spec.Sandbox != "" {
+
+		if joinErr := n.joinSandbox(ctx, container, spec.Sandbox); joinErr != nil {
+			return nil, fmt.Errorf("failed to join sandbox %s of container %s: %w", spec.Sandbox, containerID, joinErr)
+		}
+	}
+
+	if logIO, openLogErr = n.Logs.Open(ctx, containerID); openLogErr != nil {
+		return nil, fmt.Errorf("failed to open logs for container %s: %w", containerID, openLogErr)
+	}
+
+	if taskIO, openStreamsErr = n.streams.open(ctx, containerID, logIO, spec.Stdin); openStreamsErr != nil {
+		n.Logs.Close(ctx, containerID)
+		return nil, fmt.Errorf("failed to open streams for container %s: %w", containerID, openStreamsErr)
+	}
+
+	if task, newTaskErr = container.NewTask(ctx, taskIO); newTaskErr != nil {
+		n.streams.close(ctx, containerID)
+		n.Logs.Close(ctx, containerID)
 		return nil, fmt.Errorf("failed to create task for container %s: %w", containerID, newTaskErr)
 	}
 
-	return newTask(task), nil
+	if labelErr := setRestartLabels(ctx, container, restartLabels); labelErr != nil {
+		task.Delete(detachedContext(ctx))
+		n.streams.close(ctx, containerID)
+		n.Logs.Close(ctx, containerID)
+		return nil, fmt.Errorf("failed to set restart state of container %s: %w", containerID, labelErr)
+	}
+
+	if startErr := task.Start(ctx); startErr != nil {
+		task.Delete(detachedContext(ctx))
+		n.streams.close(ctx, containerID)
+		n.Logs.Close(ctx, containerID)
+		return nil, fmt.Errorf("failed to start task for container %s: %w", containerID, startErr)
+	}
+
+	if spec.HealthCheck != nil {
+		n.health.start(ctx, containerID, *spec.HealthCheck)
+	}
+
+	return task, nil
 }
 
-// PullImage pulls the given image ref.
+// PullImage pulls the given image ref for the node's platform, or for the given platforms, e.g. linux/arm64/v8, or for AllPlatforms.
+// Images are only unpacked for the node's platform, the content of the others is stored to be exported to nodes that run them.
 // It returns the created containerd.Image.
-func (n Node) PullImage(ctx context.Context, ref string) (i Image, err error) {
-	var img containerd.Image
+func (n Node) PullImage(ctx context.Context, ref string, platforms ...string) (i Image, err error) {
+	opts, parseErr := parsePullPlatforms(platforms)
 
-	if img, err = n.pullImage(ctx, ref); err != nil {
-		return nil, err
+	if parseErr != nil {
+		return nil, fmt.Errorf("failed to pull image %s: %w", ref, parseErr)
 	}
 
-	return newImage(img), nil
+	return n.pullImage(ctx, ref, opts)
 }
 
 // GetImages returns a list of all containerd.Image instances known to the containerd daemon.
 func (n Node) GetImages(ctx context.Context, filter string) (images []Image, err error) {
-	imgs, getImagesErr := n.Ctr.ListImages(ctx, filter)
+	images, getImagesErr := n.Backend.ListImages(ctx, filter)
 
 	if getImagesErr != nil {
 		return nil, fmt.Errorf("failed to get images using filter %s: %w", filter, getImagesErr)
 	}
 
-	for _, i := range imgs {
-		images = append(images, newImage(i))
+	return images, nil
+}
+
+// ImportImages stores the images of the given OCI image layout or docker save tarball, so nodes that can't reach a registry can run them.
+// It returns the imported images.
+func (n Node) ImportImages(ctx context.Context, r io.Reader) (images []Image, err error) {
+	if images, err = n.Backend.Import(ctx, r); err != nil {
+		return nil, fmt.Errorf("failed to import images: %w", err)
 	}
 
 	return images, nil
 }
 
+// ExportImages writes the images with the given names to w as a single OCI image layout tarball, which ImportImages and docker load read back.
+// Nothing is written unless all the images exist.
+func (n Node) ExportImages(ctx context.Context, w io.Writer, names ...string) (err error) {
+	if len(names) == 0 {
+		return fmt.Errorf("no images to export: %w", errdefs.ErrInvalidArgument)
+	}
+
+	for _, name := range names {
+
+		if _, err = n.getImage(ctx, name); err != nil {
+			return err
+		}
+	}
+
+	if exportErr := n.Backend.Export(ctx, w, names...); exportErr != nil {
+		return fmt.Errorf("failed to export images %s: %w", strings.Join(names, ", "), exportErr)
+	}
+
+	return nil
+}
+
+// PruneImages removes the images of ctx's namespace that the node's image GC policy has no use for, and reports what it removed.
+// With dryRun set nothing is removed, the report says what would be.
+func (n Node) PruneImages(ctx context.Context, dryRun bool) (report ImagePruneReport, err error) {
+	namespace, err := namespaces.NamespaceRequired(ctx)
+
+	if err != nil {
+		return ImagePruneReport{}, fmt.Errorf("failed to prune images: %w", err)
+	}
+
+	if report, err = n.gc.collect(ctx, []string{namespace}, dryRun); err != nil {
+		return report, fmt.Errorf("failed to prune images: %w", err)
+	}
+
+	return report, nil
+}
+
 // GetContainer retrieves a containerd.Container instance by the given ID.
 func (n Node) GetContainer(ctx context.Context, containerID string) (c Container, err error) {
-	container, err := n.getContainer(ctx, containerID)
-	return newContainer(container), err
+	return n.getContainer(ctx, containerID)
 }
 
 // GetContainers returns a list of all containerd.Container instances known to the containerd daemon.
 func (n Node) GetContainers(ctx context.Context, filter string) (cs []Container, err error) {
-	containers, getContainersErr := n.Ctr.Containers(ctx, filter)
+	containers, getContainersErr := n.Backend.Containers(ctx, filter)
 
 	if getContainersErr != nil {
 		return nil, fmt.Errorf("failed to get containers using filter %s: %w", filter, getContainersErr)
 	}
 
 	for _, c := range containers {
-		cs = append(cs, newContainer(c))
+		cs = append(cs, c)
 	}
 
 	return cs, nil
@@ -181,82 +458,640 @@ func (n Node) GetContainers(ctx context.Context, filter string) (cs []Container,
 // GetTask retrieves a containerd.Task instance for the given container ID.
 // TODO: Handle containers that don't have tasks. The below won't differentiate between errors retrieving a container task and taskless containers.
 func (n Node) GetTask(ctx context.Context, containerID string) (t Task, err error) {
-	task, err := n.getTask(ctx, containerID)
-	return newTask(task), err
+	return n.getTask(ctx, containerID)
 }
 
 // GetTasks returns a list of all containerd.Task instances known to the containerd daemon.
 func (n Node) GetTasks(ctx context.Context, filter string) (tasks []Task, err error) {
-	containers, getContainersErr := n.Ctr.Containers(ctx, filter)
+	containers, getContainersErr := n.Backend.Containers(ctx, filter)
 
 	if getContainersErr != nil {
 		return nil, fmt.Errorf("failed to list containers using filter %s: %w", filter, getContainersErr)
 	}
 
 	for _, container := range containers {
-		task, taskErr := container.Task(ctx, nil)
+		task, taskErr := container.LoadTask(ctx)
 
 		if taskErr != nil {
 			return nil, fmt.Errorf("failed to build task list: %w", taskErr)
 		}
 
-		tasks = append(tasks, newTask(task))
+		tasks = append(tasks, task)
 	}
 
 	return tasks, nil
 }
 
-// KillTask sends a SIGKILL to the task associated with the given container.
-// TODO: Accept other process signals.
-func (n Node) KillTask(ctx context.Context, containerID string) (err error) {
+// KillTask signals the task associated with the given container. signal is a signal name or number, see ParseSignal.
+// An empty signal stops the task gracefully: it's sent the container's stop signal, then SIGKILL if it hasn't exited once timeout,
+// or DefaultStopTimeout when timeout is 0, has passed. An explicit signal escalates the same way when a timeout is given.
+// Paused tasks are resumed after the signal is sent, so they can handle it.
+// The container's stop signal and SIGKILL stop a supervised container by hand: its task isn't restarted until CreateTask.
+// KillTask returns once the task has exited, except for an explicit signal other than SIGKILL without a timeout, which is only delivered.
+// It gives up waiting when ctx is done.
+func (n Node) KillTask(ctx context.Context, containerID, signal string, timeout time.Duration) (err error) {
 	var (
-		task                             containerd.Task
-		es                               <-chan containerd.ExitStatus
+		container                        RuntimeContainer
+		task                             RuntimeTask
+		sig, stopSig                     syscall.Signal
+		es                               <-chan ExitStatus
 		getTaskErr, waitErr, killTaskErr error
 	)
 
-	if task, getTaskErr = n.getTask(ctx, containerID); getTaskErr != nil {
+	if timeout < 0 {
+		return fmt.Errorf("invalid timeout %s: %w", timeout, errdefs.ErrInvalidArgument)
+	}
+
+	if container, getTaskErr = n.getContainer(ctx, containerID); getTaskErr != nil {
 		return fmt.Errorf("failed to get container %s: %w", containerID, getTaskErr)
 	}
 
+	if stopSig, err = container.StopSignal(ctx); err != nil {
+		return fmt.Errorf("failed to get stop signal for container %s: %w", containerID, err)
+	}
+
+	if signal == "" {
+		sig = stopSig
+
+		if timeout == 0 {
+			timeout = DefaultStopTimeout
+		}
+	} else if sig, err = ParseSignal(signal); err != nil {
+		return fmt.Errorf("invalid signal for container %s: %w", containerID, err)
+	}
+
+	if task, getTaskErr = container.LoadTask(ctx); getTaskErr != nil {
+		return fmt.Errorf("failed to load task for container %s: %w", containerID, getTaskErr)
+	}
+
 	if es, waitErr = task.Wait(ctx); waitErr != nil {
 		return fmt.Errorf("failed to get task exit status channel: %w", waitErr)
 	}
 
-	if killTaskErr = task.Kill(ctx, syscall.SIGKILL); killTaskErr != nil {
-		return fmt.Errorf("failed to kill task for container %s: %w", containerID, killTaskErr)
+	// delivered is set once the signal reached the task.
+	var delivered bool
+
+	if sig == stopSig || sig == syscall.SIGKILL {
+		labels, labelsErr := container.Labels(ctx)
+
+		if labelsErr != nil {
+			return fmt.Errorf("failed to get labels of container %s: %w", containerID, labelsErr)
+		}
+
+		// The stop is recorded before the signal is sent, so the restart supervisor can't mistake the exit for a crash.
+		if labelErr := setRestartLabels(ctx, container, map[string]string{RestartStoppedLabel: "true"}); labelErr != nil {
+			return fmt.Errorf("failed to record stop of container %s: %w", containerID, labelErr)
+		}
+
+		// A task the signal doesn't reach, e.g. because it already exited, isn't stopped by hand, so a restart scheduled for it goes ahead.
+		defer func(previous string) {
+			if !delivered {
+				setRestartLabels(detachedContext(ctx), container, map[string]string{RestartStoppedLabel: previous})
+			}
+		}(labels[RestartStoppedLabel])
 	}
 
-	<-es
+	status, statusErr := task.Status(ctx, nil)
 
-	return nil
+	if killTaskErr = task.Kill(ctx, sig); killTaskErr != nil {
+		return fmt.Errorf("failed to send %s to task for container %s: %w", sig, containerID, killTaskErr)
+	}
+
+	delivered = true
+
+	// A frozen task can't act on the signal, so a paused task is thawed once the signal is pending.
+	if statusErr == nil && status.Status == containerd.Paused {
+
+		if resumeErr := task.Resume(ctx); resumeErr != nil && !errors.Is(resumeErr, errdefs.ErrNotFound) && !errors.Is(resumeErr, errdefs.ErrFailedPrecondition) {
+			return fmt.Errorf("failed to resume paused task for container %s: %w", containerID, resumeErr)
+		}
+	}
+
+	if sig != syscall.SIGKILL {
+
+		if timeout == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		select {
+		case <-es:
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("gave up waiting for task of container %s to exit: %w", containerID, ctx.Err())
+		case <-timer.C:
+		}
+
+		if killTaskErr = task.Kill(ctx, syscall.SIGKILL); killTaskErr != nil && !errors.Is(killTaskErr, errdefs.ErrNotFound) {
+			return fmt.Errorf("failed to kill task for container %s after %s: %w", containerID, timeout, killTaskErr)
+		}
+	}
+
+	select {
+	case <-es:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("gave up waiting for task of container %s to exit: %w", containerID, ctx.Err())
+	}
+}
+
+// UpdateContainerResources replaces the resource limits of the given container.
+// If the container has a live task, its cgroups are updated in place first, so a rejected update leaves the stored limits untouched.
+func (n Node) UpdateContainerResources(ctx context.Context, id string, resources Resources) (c Container, err error) {
+	var (
+		container       RuntimeContainer
+		task            RuntimeTask
+		getContainerErr error
+	)
+
+	if validateErr := resources.Validate(); validateErr != nil {
+		return nil, fmt.Errorf("invalid resources for container %s: %w", id, validateErr)
+	}
+
+	if container, getContainerErr = n.getContainer(ctx, id); getContainerErr != nil {
+		return nil, fmt.Errorf("failed to get container %s: %w", id, getContainerErr)
+	}
+
+	if task, err = container.LoadTask(ctx); err == nil {
+		if status, statusErr := task.Status(ctx, nil); statusErr == nil && status.Status != containerd.Stopped {
+			if updateErr := task.Update(ctx, resources); updateErr != nil {
+				return nil, fmt.Errorf("failed to update task resources for container %s: %w", id, updateErr)
+			}
+		}
+	} else if !errors.Is(err, errdefs.ErrNotFound) {
+		return nil, fmt.Errorf("failed to load task for container %s: %w", id, err)
+	}
+
+	if updateErr := container.UpdateResources(ctx, resources); updateErr != nil {
+		return nil, fmt.Errorf("failed to update resources for container %s: %w", id, updateErr)
+	}
+
+	return container, nil
+}
+
+// GetContainerHealth returns the health of the given container's running task, as the probes of the container's HealthCheck found it.
+// Containers without a HealthCheck, or without a running task, report an empty status.
+func (n Node) GetContainerHealth(ctx context.Context, id string) (health Health, err error) {
+	if _, getContainerErr := n.getContainer(ctx, id); getContainerErr != nil {
+		return Health{}, fmt.Errorf("failed to get container %s: %w", id, getContainerErr)
+	}
+
+	return n.health.get(ctx, id), nil
+}
+
+// ExecTask runs an additional process described by spec inside the given container's running task, connected to the given streams.
+// It returns the process's exit status once it has exited. When ctx is done first, the process is killed and ctx's error is returned.
+func (n Node) ExecTask(ctx context.Context, containerID string, spec ProcessSpec, io TaskIO) (exitStatus ExitStatus, err error) {
+	var (
+		task                                   RuntimeTask
+		process                                RuntimeProcess
+		es                                     <-chan ExitStatus
+		getTaskErr, execErr, waitErr, startErr error
+		cleanupCtx                             = detachedContext(ctx)
+	)
+
+	if validateErr := spec.Validate(); validateErr != nil {
+		return ExitStatus{}, fmt.Errorf("invalid process spec for container %s: %w", containerID, validateErr)
+	}
+
+	if task, getTaskErr = n.getTask(ctx, containerID); getTaskErr != nil {
+		return ExitStatus{}, fmt.Errorf("failed to get task for container %s: %w", containerID, getTaskErr)
+	}
+
+	if process, execErr = task.Exec(ctx, newExecID(), spec, io); execErr != nil {
+		return ExitStatus{}, fmt.Errorf("failed to exec in task of container %s: %w", containerID, execErr)
+	}
+
+	defer func() {
+		if deleted, deleteErr := process.Delete(cleanupCtx); deleteErr == nil && err == nil {
+			exitStatus = deleted
+		} else if err == nil {
+			err = fmt.Errorf("failed to delete exec process %s of container %s: %w", process.ID(), containerID, deleteErr)
+		}
+	}()
+
+	// The exit status channel is set up before the process starts, so a quick exit isn't missed.
+	if es, waitErr = process.Wait(cleanupCtx); waitErr != nil {
+		return ExitStatus{}, fmt.Errorf("failed to wait for exec process of container %s: %w", containerID, waitErr)
+	}
+
+	if startErr = process.Start(ctx); startErr != nil {
+		return ExitStatus{}, fmt.Errorf("failed to start exec process in container %s: %w", containerID, startErr)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	applyResizes(ctx, io.Resize, process.Resize, done)
+
+	select {
+	case exitStatus = <-es:
+		return exitStatus, nil
+	case <-ctx.Done():
+		process.Kill(cleanupCtx, syscall.SIGKILL)
+		<-es
+		return ExitStatus{}, fmt.Errorf("exec process of container %s was killed: %w", containerID, ctx.Err())
+	}
+}
+
+// AttachTask connects io to the stdio of the given container's running task: output the task writes from now on is copied to io's writers,
+// and io.Stdin is forwarded to the task if it was created with stdin open, discarded otherwise. Sizes received on io.Resize are applied to the task's terminal.
+// It returns the task's exit status once it has exited. When ctx is done first, io is detached, the task keeps running and ctx's error is returned.
+// Tasks created before a restart of the node are reconnected to their logs and sessions through the runtime on their first attach.
+func (n Node) AttachTask(ctx context.Context, containerID string, io TaskIO) (exitStatus ExitStatus, err error) {
+	var (
+		container                                RuntimeContainer
+		task                                     RuntimeTask
+		streams                                  *taskStreams
+		es                                       <-chan ExitStatus
+		getContainerErr, loadTaskErr, streamsErr error
+		waitErr                                  error
+		waitCtx, cancelWait                      = context.WithCancel(detachedContext(ctx))
+	)
+
+	defer cancelWait()
+
+	if container, getContainerErr = n.getContainer(ctx, containerID); getContainerErr != nil {
+		return ExitStatus{}, fmt.Errorf("failed to get container %s: %w", containerID, getContainerErr)
+	}
+
+	if task, loadTaskErr = container.LoadTask(ctx); loadTaskErr != nil {
+		return ExitStatus{}, fmt.Errorf("failed to load task for container %s: %w", containerID, loadTaskErr)
+	}
+
+	streams, streamsErr = n.streams.get(ctx, containerID, func() (*taskStreams, error) {
+		return n.reconnectTask(ctx, container)
+	})
+
+	if streamsErr != nil {
+		return ExitStatus{}, fmt.Errorf("failed to reconnect to task of container %s: %w", containerID, streamsErr)
+	}
+
+	if es, waitErr = task.Wait(waitCtx); waitErr != nil {
+		return ExitStatus{}, fmt.Errorf("failed to wait for task of container %s: %w", containerID, waitErr)
+	}
+
+	session := streams.attach()
+	written := make(chan struct{})
+
+	go func() {
+		defer close(written)
+
+		for chunk := range session.chunks {
+			w := io.Stdout
+
+			if chunk.stream == StderrStream {
+				w = io.Stderr
+			}
+
+			if w != nil {
+				w.Write(chunk.data)
+			}
+		}
+	}()
+
+	if io.Stdin != nil {
+		go copyStdin(streams.stdin, io.Stdin)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	applyResizes(ctx, io.Resize, task.Resize, done)
+
+	select {
+	case exitStatus = <-es:
+		// Output written just before the exit can still be on its way from the runtime.
+		select {
+		case <-time.After(exitLogGrace):
+		case <-ctx.Done():
+		}
+
+		streams.detach(session)
+		<-written
+
+		return exitStatus, nil
+	case <-ctx.Done():
+		streams.detach(session)
+		<-written
+
+		return ExitStatus{}, fmt.Errorf("detached from task of container %s: %w", containerID, ctx.Err())
+	case <-written:
+		return ExitStatus{}, fmt.Errorf("session attached to task of container %s was cut off from its output", containerID)
+	}
+}
+
+// reconnectTask connects the stdio of a task the node has no streams for, e.g. one created before a restart, to its logs and a fresh set of streams.
+func (n Node) reconnectTask(ctx context.Context, container RuntimeContainer) (streams *taskStreams, err error) {
+	var (
+		spec             ContainerSpec
+		logIO, taskIO    TaskIO
+		specErr, openErr error
+	)
+
+	if spec, specErr = container.Spec(ctx); specErr != nil {
+		return nil, specErr
+	}
+
+	if logIO, openErr = n.Logs.Open(ctx, container.ID()); openErr != nil {
+		return nil, openErr
+	}
+
+	streams, taskIO = newTaskStreams(logIO, spec.Stdin)
+
+	if _, err = container.AttachTask(ctx, taskIO); err != nil {
+		streams.close()
+		n.Logs.Close(ctx, container.ID())
+		return nil, err
+	}
+
+	return streams, nil
+}
+
+// GetLogs returns the recorded output of the given container's tasks, oldest first.
+func (n Node) GetLogs(ctx context.Context, containerID string, opts LogOptions) (records []LogRecord, err error) {
+	var readErr error
+
+	if _, getContainerErr := n.getContainer(ctx, containerID); getContainerErr != nil {
+		return nil, fmt.Errorf("failed to get container %s: %w", containerID, getContainerErr)
+	}
+
+	if records, readErr = n.Logs.Read(ctx, containerID, opts); readErr != nil {
+		return nil, fmt.Errorf("failed to read logs for container %s: %w", containerID, readErr)
+	}
+
+	return records, nil
+}
+
+// FollowLogs streams the given container's recorded output, then its new output as the task writes it.
+// The channel is closed once the task has exited, or right after the recorded output if there's no live task, or when ctx is done.
+func (n Node) FollowLogs(ctx context.Context, containerID string, opts LogOptions) (records <-chan LogRecord, err error) {
+	var (
+		container                  RuntimeContainer
+		task                       RuntimeTask
+		exited                     <-chan ExitStatus
+		getContainerErr, followErr error
+		until                      = make(chan struct{})
+	)
+
+	if container, getContainerErr = n.getContainer(ctx, containerID); getContainerErr != nil {
+		return nil, fmt.Errorf("failed to get container %s: %w", containerID, getContainerErr)
+	}
+
+	if task, err = container.LoadTask(ctx); err == nil {
+		if status, statusErr := task.Status(ctx, nil); statusErr == nil && status.Status != containerd.Stopped {
+			if exited, err = task.Wait(ctx); err != nil {
+				return nil, fmt.Errorf("failed to wait for task of container %s: %w", containerID, err)
+			}
+		}
+	} else if !errors.Is(err, errdefs.ErrNotFound) {
+		return nil, fmt.Errorf("failed to load task for container %s: %w", containerID, err)
+	}
+
+	if exited == nil {
+		close(until)
+	} else {
+		go func() {
+			defer close(until)
+
+			select {
+			case <-exited:
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case <-time.After(exitLogGrace):
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	if records, followErr = n.Logs.Follow(ctx, containerID, opts, until); followErr != nil {
+		return nil, fmt.Errorf("failed to follow logs for container %s: %w", containerID, followErr)
+	}
+
+	return records, nil
+}
+
+// Events streams the runtime events of ctx's namespace selected by opts until ctx is done.
+// The Node keeps the recent events of every namespace it has streamed events from, see EventOptions.Since.
+// The channel is also closed if the caller falls too far behind. It can resubscribe from the Seq of the last event it got.
+func (n Node) Events(ctx context.Context, opts EventOptions) (events <-chan Event, err error) {
+	if events, err = n.events.subscribe(ctx, opts); err != nil {
+		return nil, fmt.Errorf("failed to subscribe to events: %w", err)
+	}
+
+	return events, nil
+}
+
+// GetPullJob returns the running or most recent pull job of the given image ref.
+func (n Node) GetPullJob(ctx context.Context, ref string) (job PullJob, err error) {
+	job, getJobErr := n.pulls.get(ctx, ref)
+
+	if getJobErr == nil {
+		return job, nil
+	} else if errors.Is(getJobErr, errdefs.ErrNotFound) {
+		return PullJob{}, ErrNotFound{name: ref, inner: getJobErr}
+	} else {
+		return PullJob{}, fmt.Errorf("failed to get pull job %s: %w", ref, getJobErr)
+	}
+}
+
+// GetPullJobs returns the running and recently finished pull jobs, oldest first.
+func (n Node) GetPullJobs(ctx context.Context) (jobs []PullJob, err error) {
+	if jobs, err = n.pulls.list(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get pull jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// FollowPullJob streams the progress of the running pull of the given image ref, or of the next one to start when none is running.
+// The stream ends with the job's final state, or when ctx is done.
+func (n Node) FollowPullJob(ctx context.Context, ref string) (jobs <-chan PullJob, err error) {
+	if jobs, err = n.pulls.follow(ctx, ref); err != nil {
+		return nil, fmt.Errorf("failed to follow pull job %s: %w", ref, err)
+	}
+
+	return jobs, nil
+}
+
+// PullImageAsync starts pulling the given image ref for the given platforms in the background, see PullImage.
+// The operation's result is the pulled Image.
+func (n Node) PullImageAsync(ctx context.Context, ref string, platforms ...string) (op Operation, err error) {
+	opts, parseErr := parsePullPlatforms(platforms)
+
+	if parseErr != nil {
+		return Operation{}, fmt.Errorf("failed to start pulling image %s: %w", ref, parseErr)
+	}
+
+	op, err = n.ops.start(ctx, PullImageOperation, ref, func(ctx context.Context) (interface{}, error) {
+		return n.pullImage(ctx, ref, opts)
+	})
+
+	if err != nil {
+		return Operation{}, fmt.Errorf("failed to start pulling image %s: %w", ref, err)
+	}
+
+	return op, nil
+}
+
+// KillTaskAsync starts killing the task of the given container in the background, see KillTask.
+func (n Node) KillTaskAsync(ctx context.Context, containerID, signal string, timeout time.Duration) (op Operation, err error) {
+	op, err = n.ops.start(ctx, KillTaskOperation, containerID, func(ctx context.Context) (interface{}, error) {
+		return nil, n.KillTask(ctx, containerID, signal, timeout)
+	})
+
+	if err != nil {
+		return Operation{}, fmt.Errorf("failed to start killing task for container %s: %w", containerID, err)
+	}
+
+	return op, nil
+}
+
+// GetOperation returns the operation with the given ID.
+func (n Node) GetOperation(ctx context.Context, id string) (op Operation, err error) {
+	op, getOpErr := n.ops.get(ctx, id)
+
+	if getOpErr == nil {
+		return op, nil
+	} else if errors.Is(getOpErr, errdefs.ErrNotFound) {
+		return Operation{}, ErrNotFound{name: id, inner: getOpErr}
+	} else {
+		return Operation{}, fmt.Errorf("failed to get operation %s: %w", id, getOpErr)
+	}
+}
+
+// GetOperations returns the running and recently finished operations, oldest first.
+func (n Node) GetOperations(ctx context.Context) (ops []Operation, err error) {
+	if ops, err = n.ops.list(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get operations: %w", err)
+	}
+
+	return ops, nil
+}
+
+// CancelOperation cancels the running operation with the given ID and returns it once it has stopped. Finished operations are returned as they are.
+func (n Node) CancelOperation(ctx context.Context, id string) (op Operation, err error) {
+	op, cancelErr := n.ops.cancel(ctx, id)
+
+	if cancelErr == nil {
+		return op, nil
+	} else if errors.Is(cancelErr, errdefs.ErrNotFound) {
+		return Operation{}, ErrNotFound{name: id, inner: cancelErr}
+	} else {
+		return Operation{}, fmt.Errorf("failed to cancel operation %s: %w", id, cancelErr)
+	}
 }
 
 // DeleteImage deletes the given image from the containerd image store.
 func (n Node) DeleteImage(ctx context.Context, name string) (err error) {
 
-	if deleteImageErr := n.Ctr.ImageService().Delete(ctx, name); deleteImageErr != nil {
+	if deleteImageErr := n.Backend.DeleteImage(ctx, name); deleteImageErr != nil {
 		return fmt.Errorf("failed to delete image %s: %w", name, deleteImageErr)
 	}
 
 	return nil
 }
 
-// DeleteContainer deletes the given image from the containerd container store.
+// TagImage gives the image named source the additional name target, e.g. to promote a build by tagging it as the release.
+// An image already named target is retagged: it's pointed at source's content. It returns the image named target.
+func (n Node) TagImage(ctx context.Context, source, target string) (image Image, err error) {
+	if _, parseErr := reference.Parse(target); parseErr != nil {
+		return nil, fmt.Errorf("invalid image name %s: %v: %w", target, parseErr, errdefs.ErrInvalidArgument)
+	}
+
+	if _, err = n.getImage(ctx, source); err != nil {
+		return nil, err
+	}
+
+	if image, err = n.Backend.TagImage(ctx, source, target); err != nil {
+		return nil, fmt.Errorf("failed to tag image %s as %s: %w", source, target, err)
+	}
+
+	return image, nil
+}
+
+// UntagImage removes the name of an image that goes by other names too. Its content stays around under the others.
+// Removing the last name of an image would delete it, that's what DeleteImage is for.
+func (n Node) UntagImage(ctx context.Context, name string) (err error) {
+	image, err := n.getImage(ctx, name)
+
+	if err != nil {
+		return err
+	}
+
+	tags, listErr := n.Backend.ListImages(ctx, "target.digest=="+image.Target().Digest.String())
+
+	if listErr != nil {
+		return fmt.Errorf("failed to get tags of image %s: %w", name, listErr)
+	}
+
+	if len(tags) < 2 {
+		return fmt.Errorf("image %s has no other name, delete it instead: %w", name, errdefs.ErrFailedPrecondition)
+	}
+
+	if deleteImageErr := n.Backend.DeleteImage(ctx, name); deleteImageErr != nil {
+		return fmt.Errorf("failed to untag image %s: %w", name, deleteImageErr)
+	}
+
+	return nil
+}
+
+// DeleteContainer deletes the given container from the containerd container store, along with its snapshot and logs.
 func (n Node) DeleteContainer(ctx context.Context, id string) (err error) {
 
-	if deleteContainerErr := n.Ctr.ContainerService().Delete(ctx, id); deleteContainerErr != nil {
+	if deleteContainerErr := n.Backend.DeleteContainer(ctx, id); deleteContainerErr != nil {
 		return fmt.Errorf("failed to delete container %s: %w", id, deleteContainerErr)
 	}
 
+	n.streams.close(ctx, id)
+
+	if removeLogsErr := n.Logs.Remove(ctx, id); removeLogsErr != nil {
+		return fmt.Errorf("failed to remove logs for container %s: %w", id, removeLogsErr)
+	}
+
 	return nil
 }
 
+// PauseTask freezes the given container's running task. Its processes keep their memory and open files but get no CPU time until ResumeTask.
+// It returns the paused task, whose status is then "paused".
+func (n Node) PauseTask(ctx context.Context, containerID string) (t Task, err error) {
+	task, getTaskErr := n.getTask(ctx, containerID)
+
+	if getTaskErr != nil {
+		return nil, fmt.Errorf("failed to get task for container %s: %w", containerID, getTaskErr)
+	}
+
+	if pauseErr := task.Pause(ctx); pauseErr != nil {
+		return nil, fmt.Errorf("failed to pause task for container %s: %w", containerID, pauseErr)
+	}
+
+	return task, nil
+}
+
+// ResumeTask thaws the given container's task after PauseTask.
+// It returns the resumed task.
+func (n Node) ResumeTask(ctx context.Context, containerID string) (t Task, err error) {
+	task, getTaskErr := n.getTask(ctx, containerID)
+
+	if getTaskErr != nil {
+		return nil, fmt.Errorf("failed to get task for container %s: %w", containerID, getTaskErr)
+	}
+
+	if resumeErr := task.Resume(ctx); resumeErr != nil {
+		return nil, fmt.Errorf("failed to resume task for container %s: %w", containerID, resumeErr)
+	}
+
+	return task, nil
+}
+
 // DeleteTask deletes resources associated with the given container's task.
 func (n Node) DeleteTask(ctx context.Context, containerID string) (exitStatus ExitStatus, err error) {
 	var (
-		task                      containerd.Task
-		taskExitStatus            *containerd.ExitStatus
+		task                      RuntimeTask
+		taskExitStatus            ExitStatus
 		getTaskErr, deleteTaskErr error
 	)
 
@@ -268,53 +1103,327 @@ func (n Node) DeleteTask(ctx context.Context, containerID string) (exitStatus Ex
 		return ExitStatus{}, fmt.Errorf("failed to delete task for container %s: %w", containerID, deleteTaskErr)
 	}
 
-	return ExitStatus(*taskExitStatus), nil
+	n.streams.close(ctx, containerID)
+
+	if closeLogErr := n.Logs.Close(ctx, containerID); closeLogErr != nil {
+		return taskExitStatus, fmt.Errorf("failed to close logs for container %s: %w", containerID, closeLogErr)
+	}
+
+	return taskExitStatus, nil
 }
 
-func (n Node) getImage(ctx context.Context, name string) (i containerd.Image, err error) {
-	image, err := n.Ctr.GetImage(ctx, name)
+// ApplyWorkload makes the given workload the desired state of its container, replacing the workload applied before under its name, and reconciles it.
+// From then on the workload is reconciled every reconcile interval, see WithReconcileInterval, until DeleteWorkload.
+// It only fails when the workload is malformed: a reconcile that fails is reported in the returned status and retried.
+func (n Node) ApplyWorkload(ctx context.Context, w Workload) (status WorkloadStatus, err error) {
+	if status, err = n.recon.apply(ctx, w); err != nil {
+		return WorkloadStatus{}, fmt.Errorf("failed to apply workload %s: %w", w.Name, err)
+	}
+
+	return status, nil
+}
+
+// GetWorkload returns the given workload's status as its last reconcile left it.
+func (n Node) GetWorkload(ctx context.Context, name string) (status WorkloadStatus, err error) {
+	status, getErr := n.recon.get(ctx, name)
+
+	if getErr == nil {
+		return status, nil
+	} else if errors.Is(getErr, errdefs.ErrNotFound) {
+		return WorkloadStatus{}, ErrNotFound{name: name, inner: getErr}
+	} else {
+		return WorkloadStatus{}, fmt.Errorf("failed to get workload %s: %w", name, getErr)
+	}
+}
+
+// GetWorkloads returns the statuses of the workloads applied in ctx's namespace, ordered by name.
+func (n Node) GetWorkloads(ctx context.Context) (statuses []WorkloadStatus, err error) {
+	if statuses, err = n.recon.list(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get workloads: %w", err)
+	}
+
+	return statuses, nil
+}
+
+// DeleteWorkload stops reconciling the given workload, and stops and deletes its task and container.
+func (n Node) DeleteWorkload(ctx context.Context, name string) (err error) {
+	deleteErr := n.recon.delete(ctx, name)
+
+	if deleteErr == nil {
+		return nil
+	} else if errors.Is(deleteErr, errdefs.ErrNotFound) {
+		return ErrNotFound{name: name, inner: deleteErr}
+	} else {
+		return fmt.Errorf("failed to delete workload %s: %w", name, deleteErr)
+	}
+}
+
+// CreatePod creates the sandbox container of a pod with the given ID and starts its task, then does the same for each of the pod's members.
+// The members' tasks join the network, IPC and PID namespaces of the sandbox's task.
+// The sandbox container has the pod's ID, members have PodContainerID of theirs. Members' images have to be present, see PullImage.
+// If any container or task can't be created, the ones created before it are removed again.
+func (n Node) CreatePod(ctx context.Context, id string, spec PodSpec) (pod Pod, err error) {
+	var created []string
+
+	if validateErr := identifiers.Validate(id); validateErr != nil {
+		return Pod{}, fmt.Errorf("invalid pod ID %s: %w", id, validateErr)
+	}
+
+	if validateErr := spec.Validate(); validateErr != nil {
+		return Pod{}, fmt.Errorf("invalid spec for pod %s: %w", id, validateErr)
+	}
+
+	if spec.SandboxImage == "" {
+		spec.SandboxImage = n.sandboxImage
+	}
+
+	if _, getImageErr := n.Backend.GetImage(ctx, spec.SandboxImage); errors.Is(getImageErr, errdefs.ErrNotFound) {
+
+		if _, pullErr := n.PullImage(ctx, spec.SandboxImage); pullErr != nil {
+			return Pod{}, fmt.Errorf("failed to pull sandbox image for pod %s: %w", id, pullErr)
+		}
+	} else if getImageErr != nil {
+		return Pod{}, fmt.Errorf("failed to get sandbox image for pod %s: %w", id, getImageErr)
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		for i := len(created) - 1; i >= 0; i-- {
+			n.removeContainer(detachedContext(ctx), created[i])
+		}
+	}()
+
+	if _, err = n.CreateContainer(ctx, spec.SandboxImage, id, ContainerSpec{Labels: map[string]string{PodLabel: id}}); err != nil {
+		return Pod{}, fmt.Errorf("failed to create sandbox of pod %s: %w", id, err)
+	}
+
+	created = append(created, id)
+
+	if _, err = n.CreateTask(ctx, id); err != nil {
+		return Pod{}, fmt.Errorf("failed to start sandbox of pod %s: %w", id, err)
+	}
+
+	for _, m := range spec.Members {
+		containerID := PodContainerID(id, m.Name)
+		memberSpec := m.Spec
+		memberSpec.Sandbox = id
+		memberSpec.Labels = make(map[string]string)
+
+		for k, v := range m.Spec.Labels {
+			memberSpec.Labels[k] = v
+		}
+
+		memberSpec.Labels[PodLabel], memberSpec.Labels[PodMemberLabel] = id, m.Name
+
+		if _, err = n.CreateContainer(ctx, m.Image, containerID, memberSpec); err != nil {
+			return Pod{}, fmt.Errorf("failed to create member %s of pod %s: %w", m.Name, id, err)
+		}
+
+		created = append(created, containerID)
+
+		if _, err = n.CreateTask(ctx, containerID); err != nil {
+			return Pod{}, fmt.Errorf("failed to start member %s of pod %s: %w", m.Name, id, err)
+		}
+	}
+
+	if pod, err = n.GetPod(ctx, id); err != nil {
+		return Pod{}, err
+	}
+
+	return pod, nil
+}
+
+// GetPod returns the pod with the given ID, along with its aggregate status.
+func (n Node) GetPod(ctx context.Context, id string) (pod Pod, err error) {
+	sandbox, getErr := n.getContainer(ctx, id)
+
+	if getErr != nil {
+		return Pod{}, fmt.Errorf("failed to get sandbox of pod %s: %w", id, getErr)
+	}
+
+	labels, labelsErr := sandbox.Labels(ctx)
+
+	if labelsErr != nil {
+		return Pod{}, fmt.Errorf("failed to get labels of container %s: %w", id, labelsErr)
+	}
+
+	if _, isMember := labels[PodMemberLabel]; isMember || labels[PodLabel] != id {
+		return Pod{}, ErrNotFound{name: id, inner: fmt.Errorf("pod %q: %w", id, errdefs.ErrNotFound)}
+	}
+
+	cs, listErr := n.Backend.Containers(ctx, `labels."`+PodLabel+`"=="`+id+`",labels."`+PodMemberLabel+`"`)
+
+	if listErr != nil {
+		return Pod{}, fmt.Errorf("failed to get members of pod %s: %w", id, listErr)
+	}
+
+	var members []Container
+
+	for _, c := range cs {
+		members = append(members, c)
+	}
+
+	return newPod(ctx, id, sandbox, members), nil
+}
+
+// GetPods returns the pods in ctx's namespace, ordered by ID.
+func (n Node) GetPods(ctx context.Context) (pods []Pod, err error) {
+	cs, listErr := n.Backend.Containers(ctx, `labels."`+PodLabel+`"`)
+
+	if listErr != nil {
+		return nil, fmt.Errorf("failed to get pod containers: %w", listErr)
+	}
+
+	var (
+		sandboxes = make(map[string]Container)
+		members   = make(map[string][]Container)
+	)
+
+	for _, c := range cs {
+		labels, labelsErr := c.Labels(ctx)
+
+		if labelsErr != nil {
+			return nil, fmt.Errorf("failed to get labels of container %s: %w", c.ID(), labelsErr)
+		}
+
+		if _, isMember := labels[PodMemberLabel]; isMember {
+			members[labels[PodLabel]] = append(members[labels[PodLabel]], c)
+		} else if labels[PodLabel] == c.ID() {
+			sandboxes[c.ID()] = c
+		}
+	}
+
+	for id, sandbox := range sandboxes {
+		pods = append(pods, newPod(ctx, id, sandbox, members[id]))
+	}
+
+	sort.Slice(pods, func(i, j int) bool { return pods[i].ID < pods[j].ID })
+
+	return pods, nil
+}
+
+// DeletePod stops and deletes the tasks and containers of the given pod's members, and then its sandbox's.
+func (n Node) DeletePod(ctx context.Context, id string) (err error) {
+	pod, getErr := n.GetPod(ctx, id)
+
+	if getErr != nil {
+		return getErr
+	}
+
+	for _, m := range pod.Members {
+
+		if removeErr := n.removeContainer(ctx, m.ID()); removeErr != nil {
+			return fmt.Errorf("failed to delete member %s of pod %s: %w", m.ID(), id, removeErr)
+		}
+	}
+
+	if removeErr := n.removeContainer(ctx, id); removeErr != nil {
+		return fmt.Errorf("failed to delete sandbox of pod %s: %w", id, removeErr)
+	}
+
+	return nil
+}
+
+// joinSandbox makes the given container's next task join the namespaces of the given sandbox container's task.
+func (n Node) joinSandbox(ctx context.Context, container RuntimeContainer, sandboxID string) error {
+	sandbox, getErr := n.getTask(ctx, sandboxID)
+
+	if getErr != nil {
+		return getErr
+	}
+
+	status, statusErr := sandbox.Status(ctx, nil)
+
+	if statusErr != nil {
+		return statusErr
+	}
+
+	if status.Status != containerd.Running && status.Status != containerd.Paused {
+		return fmt.Errorf("sandbox task is %s: %w", status.Status, errdefs.ErrFailedPrecondition)
+	}
+
+	return container.JoinNamespaces(ctx, sandbox.Pid())
+}
+
+// removeContainer stops and deletes the given container's task, if it has one, and then the container.
+func (n Node) removeContainer(ctx context.Context, id string) error {
+	task, err := n.getTask(ctx, id)
+
+	if err == nil {
+		status, statusErr := task.Status(ctx, nil)
+
+		if statusErr == nil && status.Status != containerd.Stopped && status.Status != containerd.Created {
+
+			if err = n.KillTask(ctx, id, "", 0); err != nil {
+				return err
+			}
+		}
+
+		if _, err = n.DeleteTask(ctx, id); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, errdefs.ErrNotFound) {
+		return err
+	}
+
+	return n.DeleteContainer(ctx, id)
+}
+
+func (n Node) getImage(ctx context.Context, name string) (i Image, err error) {
+	image, err := n.Backend.GetImage(ctx, name)
 
 	if err == nil {
 		return image, nil
-	} else if err.Error() == "image \""+name+"\": not found" {
+	} else if errors.Is(err, errdefs.ErrNotFound) {
 		return nil, ErrNotFound{name: name, inner: err}
 	} else {
 		return nil, fmt.Errorf("failed to get image %s: %w", name, err)
 	}
 }
 
-func (n Node) pullImage(ctx context.Context, ref string) (image containerd.Image, err error) {
-	image, pullImageErr := n.Ctr.Pull(ctx, ref, containerd.WithPullUnpack)
+func (n Node) pullImage(ctx context.Context, ref string, opts PullOptions) (image Image, err error) {
+	tracker, startErr := n.pulls.start(ctx, ref)
+
+	if startErr != nil {
+		return nil, fmt.Errorf("failed to pull image %s: %w", ref, startErr)
+	}
+
+	opts.Progress = tracker.update
+	image, pullImageErr := n.Backend.Pull(ctx, ref, opts)
+	tracker.finish(pullImageErr)
 
 	if pullImageErr == nil {
 		return image, nil
-	} else if pullImageErr.Error() == "failed to resolve reference \""+ref+"\": object required" {
+	} else if errors.Is(pullImageErr, errdefs.ErrNotFound) {
 		return nil, ErrNotFound{name: ref, inner: pullImageErr}
 	} else {
 		return nil, fmt.Errorf("failed to pull image %s: %w", ref, pullImageErr)
 	}
 }
 
-func (n Node) getContainer(ctx context.Context, containerID string) (c containerd.Container, err error) {
-	container, loadContainerErr := n.Ctr.LoadContainer(ctx, containerID)
+func (n Node) getContainer(ctx context.Context, containerID string) (c RuntimeContainer, err error) {
+	container, loadContainerErr := n.Backend.LoadContainer(ctx, containerID)
 
 	if loadContainerErr == nil {
 		return container, nil
-	} else if strings.HasSuffix(loadContainerErr.Error(), "not found") {
+	} else if errors.Is(loadContainerErr, errdefs.ErrNotFound) {
 		return nil, ErrNotFound{name: containerID, inner: loadContainerErr}
 	} else {
 		return nil, fmt.Errorf("failed to load container %s: %w", containerID, loadContainerErr)
 	}
 }
 
-func (n Node) getTask(ctx context.Context, containerID string) (task containerd.Task, err error) {
+func (n Node) getTask(ctx context.Context, containerID string) (task RuntimeTask, err error) {
 	container, getContainerErr := n.getContainer(ctx, containerID)
 
 	if getContainerErr != nil {
 		return nil, fmt.Errorf("failed to get container for task %s: %w", containerID, getContainerErr)
 	}
 
-	task, taskErr := container.Task(ctx, nil)
+	task, taskErr := container.LoadTask(ctx)
 
 	if taskErr != nil {
 		return nil, fmt.Errorf("failed to load task for container %s: %w", containerID, taskErr)
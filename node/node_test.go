@@ -1,26 +1,72 @@
 package node_test
 
 import (
+	"archive/tar"
+	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
 	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"testing"
+	"time"
 
-	"github.com/containerd/containerd"
-	"github.com/containerd/containerd/cio"
+	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/namespaces"
-	"github.com/containerd/containerd/oci"
+	"github.com/containerd/containerd/platforms"
+	"github.com/containerd/containerd/remotes/docker"
 	"github.com/mokrz/clamor/node"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 var (
-	containerdSock  = "/run/containerd/containerd.sock"
 	weirdString     = "@#%4$1^'`_|+%20"
 	testImage       = "docker.io/library/hello-world:latest"
 	testNamespace   = "clamor-testing"
 	testContainerID = "clamor-testing"
-	ctr             *containerd.Client
+	// stopSignalImage is configured with a stop signal other than SIGTERM.
+	stopSignalImage = "docker.io/library/nginx:latest"
+	// stubbornImage runs processes that ignore SIGTERM.
+	stubbornImage = "docker.io/library/stubborn:latest"
+	// multiPlatformImage has content for several platforms, and sets sizes, labels and a config.
+	multiPlatformImage       = "docker.io/library/busybox:latest"
+	multiPlatformImageConfig = node.ImageConfig{
+		Entrypoint:   []string{"/bin/sh"},
+		Cmd:          []string{"-c", "httpd -f"},
+		Env:          []string{"PATH=/bin"},
+		ExposedPorts: []string{"80/tcp", "443/tcp"},
+		User:         "www-data",
+	}
+	multiPlatforms = []ocispec.Platform{{OS: "linux", Architecture: "amd64"}, {OS: "linux", Architecture: "arm64", Variant: "v8"}}
+	testSpec       = node.ContainerSpec{
+		Command:    []string{"/bin/sh", "-c"},
+		Args:       []string{"echo hello"},
+		Env:        []string{"GREETING=hello"},
+		WorkingDir: "/tmp",
+		User:       "1000:1000",
+		Hostname:   "clamor",
+		Labels:     map[string]string{"io.clamor.test": "true"},
+	}
 )
 
 func TestPullImage(t *testing.T) {
@@ -42,14 +88,10 @@ func TestPullImage(t *testing.T) {
 		{name: "valid namespace valid image name", args: testArguments{namespace: testNamespace, imageName: testImage}, wantErr: false},
 	}
 
-	ctrd, ctrdErr := newCtrd(containerdSock)
-	defer ctrd.client.Close()
-
-	if ctrdErr != nil {
-		t.Errorf("failed to create containerd client with error: %s", ctrdErr.Error())
-	}
-
-	node := node.NewNode(ctrd.client)
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	node := node.NewNode(ctrd.backend, ctrd.logs)
+	defer node.Close()
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -58,13 +100,169 @@ func TestPullImage(t *testing.T) {
 
 			if i != nil {
 				ctrd.deleteImage(ctx, i.Name())
-			} else if !test.wantErr {
+			}
+
+			if err != nil && !test.wantErr {
 				t.Errorf("node.PullImage failed with error: %s", err.Error())
+			} else if err == nil && test.wantErr {
+				t.Errorf("node.PullImage succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestPullImagePlatforms(t *testing.T) {
+	type test struct {
+		name      string
+		platforms []string
+		// pulled lists the platforms of an earlier pull of the image.
+		pulled      []string
+		wantPresent []ocispec.Platform
+		wantErr     error
+	}
+
+	arm64 := ocispec.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}
+	tests := []test{
+		{name: "node's platform", wantPresent: []ocispec.Platform{platforms.DefaultSpec()}},
+		{name: "other platform", platforms: []string{"linux/arm64"}, wantPresent: []ocispec.Platform{arm64}},
+		{name: "all platforms", platforms: []string{node.AllPlatforms}, wantPresent: multiPlatforms},
+		{name: "adds to earlier pull", platforms: []string{"linux/arm64/v8"}, pulled: []string{"linux/amd64"}, wantPresent: multiPlatforms},
+		{name: "missing platform", platforms: []string{"linux/amd64", "linux/s390x"}, wantErr: errdefs.ErrNotFound},
+		{name: "invalid platform", platforms: []string{weirdString}, wantErr: errdefs.ErrInvalidArgument},
+	}
+
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	n := node.NewNode(ctrd.backend, ctrd.logs)
+	defer n.Close()
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := namespaces.WithNamespace(context.TODO(), testNamespace+strconv.Itoa(i))
+
+			if test.pulled != nil {
+
+				if _, err := n.PullImage(ctx, multiPlatformImage, test.pulled...); err != nil {
+					t.Fatalf("node.PullImage failed with error: %s", err.Error())
+				}
+			}
+
+			img, err := n.PullImage(ctx, multiPlatformImage, test.platforms...)
+
+			if test.wantErr != nil {
+
+				if !errors.Is(err, test.wantErr) {
+					t.Errorf("node.PullImage returned %v, want %s", err, test.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("node.PullImage failed with error: %s", err.Error())
+			}
+
+			present, _ := img.PresentPlatforms(ctx)
+			sort.Slice(present, func(i, j int) bool {
+				return platforms.Format(present[i]) < platforms.Format(present[j])
+			})
+
+			if !reflect.DeepEqual(present, test.wantPresent) {
+				t.Errorf("node.PullImage pulled %v, want %v", present, test.wantPresent)
+			}
+
+			if ps, _ := img.Platforms(ctx); !reflect.DeepEqual(ps, multiPlatforms) {
+				t.Errorf("image platforms are %v, want %v", ps, multiPlatforms)
 			}
 		})
 	}
 }
 
+func TestPullJobs(t *testing.T) {
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	svc := node.NewNode(ctrd.backend, ctrd.logs)
+	defer svc.Close()
+	ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
+
+	var reports []node.PullProgress
+
+	if _, pullErr := ctrd.backend.Pull(ctx, multiPlatformImage, node.PullOptions{Progress: func(p node.PullProgress) { reports = append(reports, p) }}); pullErr != nil {
+		t.Fatalf("failed to pull image with error: %s", pullErr.Error())
+	}
+
+	var layerStatuses []string
+
+	for _, p := range reports {
+		layerStatuses = append(layerStatuses, p.Status+"/"+p.Layers[0].Status)
+	}
+
+	wantLayerStatuses := []string{"downloading/waiting", "downloading/downloading", "downloading/done", "unpacking/done"}
+
+	if !reflect.DeepEqual(layerStatuses, wantLayerStatuses) {
+		t.Errorf("pull reported %v, want %v", layerStatuses, wantLayerStatuses)
+	}
+
+	if _, getErr := svc.GetPullJob(ctx, testImage); !errors.As(getErr, &node.ErrNotFound{}) {
+		t.Errorf("node.GetPullJob of an image that was never pulled returned %v, want node.ErrNotFound", getErr)
+	}
+
+	followCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	jobs, followErr := svc.FollowPullJob(followCtx, testImage)
+
+	if followErr != nil {
+		t.Fatalf("node.FollowPullJob failed with error: %s", followErr.Error())
+	}
+
+	if _, pullErr := svc.PullImage(ctx, testImage); pullErr != nil {
+		t.Fatalf("failed to pull image with error: %s", pullErr.Error())
+	}
+
+	var last node.PullJob
+
+	for job := range jobs {
+		last = job
+	}
+
+	if last.Status != node.PullDone || last.FinishedAt.IsZero() || last.Offset != last.Total || len(last.Layers) != 1 {
+		t.Errorf("node.FollowPullJob ended with %+v, want a done job", last)
+	}
+
+	if job, getErr := svc.GetPullJob(ctx, testImage); getErr != nil || !reflect.DeepEqual(job, last) {
+		t.Errorf("node.GetPullJob returned %+v, %v, want %+v", job, getErr, last)
+	}
+
+	missing := "docker.io/library/missing:latest"
+
+	if _, pullErr := svc.PullImage(ctx, missing); pullErr == nil {
+		t.Fatalf("node.PullImage of a missing image succeeded, want error")
+	}
+
+	if job, getErr := svc.GetPullJob(ctx, missing); getErr != nil || job.Status != node.PullFailed || job.Error == "" {
+		t.Errorf("node.GetPullJob of a failed pull returned %+v, %v, want a failed job", job, getErr)
+	}
+
+	if _, getErr := svc.GetPullJobs(namespaces.WithNamespace(context.TODO(), "")); getErr == nil {
+		t.Errorf("node.GetPullJobs without a namespace succeeded, want error")
+	}
+
+	// Only the most recent finished jobs are kept.
+	for n := 0; n < 100; n++ {
+		svc.PullImage(ctx, fmt.Sprintf("docker.io/library/missing-%d:latest", n))
+	}
+
+	all, listErr := svc.GetPullJobs(ctx)
+
+	if listErr != nil {
+		t.Fatalf("node.GetPullJobs failed with error: %s", listErr.Error())
+	}
+
+	if len(all) != 64 || all[len(all)-1].Ref != "docker.io/library/missing-99:latest" {
+		t.Errorf("node.GetPullJobs returned %d jobs, the last of which is %s, want the 64 most recent", len(all), all[len(all)-1].Ref)
+	}
+}
+
 func TestGetImage(t *testing.T) {
 	type testArguments struct {
 		namespace, imageName string
@@ -84,14 +282,10 @@ func TestGetImage(t *testing.T) {
 		{name: "valid namespace valid image name", args: testArguments{namespace: testNamespace, imageName: testImage}, wantErr: false},
 	}
 
-	ctrd, ctrdErr := newCtrd(containerdSock)
-	defer ctrd.client.Close()
-
-	if ctrdErr != nil {
-		t.Errorf("failed to create containerd client with error: %s", ctrdErr.Error())
-	}
-
-	node := node.NewNode(ctrd.client)
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	node := node.NewNode(ctrd.backend, ctrd.logs)
+	defer node.Close()
 	ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
 
 	_, pullErr := ctrd.pullImage(ctx, testImage)
@@ -108,181 +302,2658 @@ func TestGetImage(t *testing.T) {
 
 			if err != nil && !test.wantErr {
 				t.Errorf("node.GetImage failed with error: %s", err.Error())
+			} else if err == nil && test.wantErr {
+				t.Errorf("node.GetImage succeeded, want error")
 			}
 		})
 	}
 }
 
-func TestCreateContainer(t *testing.T) {
-	type testArguments struct {
-		namespace, image, id string
+func TestImageMetadata(t *testing.T) {
+	type test struct {
+		name          string
+		image         string
+		wantMediaType string
+		wantLabels    map[string]string
+		wantSizes     [2]int64
+		wantPlatforms []ocispec.Platform
+		wantConfig    node.ImageConfig
+	}
+
+	tests := []test{
+		{
+			name:          "single platform",
+			image:         testImage,
+			wantMediaType: ocispec.MediaTypeImageManifest,
+			wantPlatforms: []ocispec.Platform{platforms.DefaultSpec()},
+		},
+		{
+			name:          "multi platform",
+			image:         multiPlatformImage,
+			wantMediaType: ocispec.MediaTypeImageIndex,
+			wantLabels:    map[string]string{"io.clamor.test": "true"},
+			wantSizes:     [2]int64{1 << 20, 3 << 20},
+			wantPlatforms: multiPlatforms,
+			wantConfig:    multiPlatformImageConfig,
+		},
+	}
+
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	svc := node.NewNode(ctrd.backend, ctrd.logs)
+	defer svc.Close()
+	ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			before := time.Now()
+
+			if _, pullErr := ctrd.pullImage(ctx, test.image); pullErr != nil {
+				t.Fatalf("failed to pull image with error: %s", pullErr)
+			}
+
+			defer ctrd.deleteImage(ctx, test.image)
+
+			img, getErr := svc.GetImage(ctx, test.image)
+
+			if getErr != nil {
+				t.Fatalf("node.GetImage failed with error: %s", getErr.Error())
+			}
+
+			if target := img.Target(); target.MediaType != test.wantMediaType || target.Digest == "" {
+				t.Errorf("image target is %+v, want media type %s and a digest", target, test.wantMediaType)
+			}
+
+			if img.CreatedAt().Before(before) || img.UpdatedAt().Before(img.CreatedAt()) {
+				t.Errorf("image was created at %s and updated at %s, want both after %s", img.CreatedAt(), img.UpdatedAt(), before)
+			}
+
+			if !reflect.DeepEqual(img.Labels(), test.wantLabels) {
+				t.Errorf("image labels are %v, want %v", img.Labels(), test.wantLabels)
+			}
+
+			size, sizeErr := img.Size(ctx)
+			unpackedSize, unpackedSizeErr := img.UnpackedSize(ctx)
+
+			if sizeErr != nil || unpackedSizeErr != nil {
+				t.Fatalf("image sizes failed with errors: %v, %v", sizeErr, unpackedSizeErr)
+			} else if got := [2]int64{size, unpackedSize}; got != test.wantSizes {
+				t.Errorf("image sizes are %v, want %v", got, test.wantSizes)
+			}
+
+			ps, platformsErr := img.Platforms(ctx)
+
+			if platformsErr != nil {
+				t.Fatalf("image platforms failed with error: %s", platformsErr.Error())
+			} else if !reflect.DeepEqual(ps, test.wantPlatforms) {
+				t.Errorf("image platforms are %v, want %v", ps, test.wantPlatforms)
+			}
+
+			config, configErr := img.Config(ctx)
+
+			if configErr != nil {
+				t.Fatalf("image config failed with error: %s", configErr.Error())
+			} else if !reflect.DeepEqual(config, test.wantConfig) {
+				t.Errorf("image config is %+v, want %+v", config, test.wantConfig)
+			}
+		})
+	}
+}
+
+func TestImportExportImages(t *testing.T) {
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	svc := node.NewNode(ctrd.backend, ctrd.logs)
+	defer svc.Close()
+	ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
+	otherCtx := namespaces.WithNamespace(context.TODO(), "clamor-testing-import")
+
+	for _, ref := range []string{testImage, multiPlatformImage} {
+
+		if _, pullErr := ctrd.pullImage(ctx, ref); pullErr != nil {
+			t.Fatalf("failed to pull seed image with error: %s", pullErr.Error())
+		}
+	}
+
+	var archive bytes.Buffer
+
+	if exportErr := svc.ExportImages(ctx, &archive, testImage, multiPlatformImage); exportErr != nil {
+		t.Fatalf("node.ExportImages failed with error: %s", exportErr.Error())
+	}
+
+	imported, importErr := svc.ImportImages(otherCtx, &archive)
+
+	if importErr != nil {
+		t.Fatalf("node.ImportImages failed with error: %s", importErr.Error())
+	}
+
+	if len(imported) != 2 || imported[0].Name() != testImage || imported[1].Name() != multiPlatformImage {
+		t.Fatalf("node.ImportImages returned %d images, want %s and %s", len(imported), testImage, multiPlatformImage)
+	}
+
+	original, _ := svc.GetImage(ctx, multiPlatformImage)
+	config, _ := imported[1].Config(otherCtx)
+	importedPlatforms, _ := imported[1].Platforms(otherCtx)
+
+	if imported[1].Target().Digest != original.Target().Digest || !reflect.DeepEqual(config, multiPlatformImageConfig) || !reflect.DeepEqual(importedPlatforms, multiPlatforms) {
+		t.Errorf("node.ImportImages returned %s with target %v, config %+v and platforms %v, want them as exported", multiPlatformImage, imported[1].Target(), config, importedPlatforms)
 	}
 
+	if _, getErr := svc.GetImage(otherCtx, testImage); getErr != nil {
+		t.Errorf("node.GetImage of an imported image failed with error: %s", getErr.Error())
+	}
+
+	// Images pulled for another platform than the node's export that platform's content.
+	arm64 := ocispec.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}
+	armCtx := namespaces.WithNamespace(context.TODO(), "clamor-testing-arm64")
+
+	if _, pullErr := svc.PullImage(armCtx, multiPlatformImage, "linux/arm64"); pullErr != nil {
+		t.Fatalf("node.PullImage failed with error: %s", pullErr.Error())
+	}
+
+	var armArchive bytes.Buffer
+
+	if exportErr := svc.ExportImages(armCtx, &armArchive, multiPlatformImage); exportErr != nil {
+		t.Fatalf("node.ExportImages of an image pulled for %s failed with error: %s", platforms.Format(arm64), exportErr.Error())
+	}
+
+	armCtx = namespaces.WithNamespace(context.TODO(), "clamor-testing-arm64-import")
+
+	if imported, importErr = svc.ImportImages(armCtx, &armArchive); importErr != nil {
+		t.Fatalf("node.ImportImages failed with error: %s", importErr.Error())
+	}
+
+	present, _ := imported[0].PresentPlatforms(armCtx)
+	importedPlatforms, _ = imported[0].Platforms(armCtx)
+
+	if !reflect.DeepEqual(present, []ocispec.Platform{arm64}) || !reflect.DeepEqual(importedPlatforms, multiPlatforms) {
+		t.Errorf("node.ImportImages returned platforms %v with %v present, want %v with %v present", importedPlatforms, present, multiPlatforms, []ocispec.Platform{arm64})
+	}
+
+	// docker save tarballs name their images after their repo tags, in docker's short form.
+	var saved bytes.Buffer
+	tw := tar.NewWriter(&saved)
+	files := []struct {
+		name, content string
+	}{
+		{name: "manifest.json", content: `[{"Config":"config.json","RepoTags":["alpine:3.12"],"Layers":["layer/layer.tar"]}]`},
+		{name: "config.json", content: `{}`},
+		{name: "layer/layer.tar", content: strings.Repeat("x", 100)},
+	}
+
+	for _, f := range files {
+		tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: f.name, Mode: 0644, Size: int64(len(f.content))})
+		tw.Write([]byte(f.content))
+	}
+
+	tw.Close()
+
+	if imported, importErr = svc.ImportImages(otherCtx, &saved); importErr != nil {
+		t.Fatalf("node.ImportImages of a docker save tarball failed with error: %s", importErr.Error())
+	}
+
+	if size, _ := imported[0].Size(otherCtx); len(imported) != 1 || imported[0].Name() != "docker.io/library/alpine:3.12" || size != 100 {
+		t.Errorf("node.ImportImages of a docker save tarball returned %d images, the first named %s, want docker.io/library/alpine:3.12", len(imported), imported[0].Name())
+	}
+
+	if _, importErr = svc.ImportImages(otherCtx, strings.NewReader(weirdString)); !errors.Is(importErr, errdefs.ErrInvalidArgument) {
+		t.Errorf("node.ImportImages of a weird archive returned %v, want errdefs.ErrInvalidArgument", importErr)
+	}
+
+	if exportErr := svc.ExportImages(ctx, &archive, testImage, "docker.io/library/missing:latest"); !errors.As(exportErr, &node.ErrNotFound{}) || archive.Len() != 0 {
+		t.Errorf("node.ExportImages of a missing image returned %v after writing %d bytes, want node.ErrNotFound and nothing written", exportErr, archive.Len())
+	}
+
+	if exportErr := svc.ExportImages(ctx, &archive); exportErr == nil {
+		t.Errorf("node.ExportImages of no images succeeded, want error")
+	}
+}
+
+func TestPruneImages(t *testing.T) {
 	type test struct {
-		name             string
-		args             testArguments
-		wantErr, wantImg bool
+		name       string
+		policy     node.ImageGCPolicy
+		wantPruned []string
+	}
+
+	var (
+		app     = "docker.io/library/app"
+		db      = "docker.io/library/db:v1"
+		remotes []node.RemoteImage
+	)
+
+	// Each image takes 50 of the disk's 1000 bytes.
+	for _, ref := range []string{app + ":v1", app + ":v2", app + ":v3", db} {
+		remotes = append(remotes, node.RemoteImage{Ref: ref, Size: 10, UnpackedSize: 40})
 	}
 
 	tests := []test{
-		{name: "empty namespace", args: testArguments{namespace: "", image: testImage, id: testContainerID}, wantErr: true},
-		{name: "weird namespace", args: testArguments{namespace: weirdString, image: testImage, id: testContainerID}, wantErr: true},
-		{name: "empty image name", args: testArguments{namespace: testNamespace, image: "", id: ""}, wantErr: true},
-		{name: "weird image name", args: testArguments{namespace: testNamespace, image: weirdString, id: weirdString}, wantErr: true},
-		{name: "empty container ID", args: testArguments{namespace: testNamespace, image: testImage, id: ""}, wantErr: true},
-		{name: "weird container ID", args: testArguments{namespace: testNamespace, image: testImage, id: weirdString}, wantErr: true},
-		{name: "valid namespace valid image valid container ID", args: testArguments{namespace: testNamespace, image: testImage, id: testContainerID}, wantErr: false},
+		{name: "no policy"},
+		{name: "keep last", policy: node.ImageGCPolicy{KeepLast: 1}, wantPruned: []string{app + ":v2/keep_last"}},
+		{name: "max age", policy: node.ImageGCPolicy{MaxAge: node.Duration(10 * time.Millisecond)}, wantPruned: []string{db + "/max_age", app + ":v3/max_age", app + ":v2/max_age"}},
+		{name: "watermarks", policy: node.ImageGCPolicy{HighWatermark: 20, LowWatermark: 10}, wantPruned: []string{app + ":v2/disk_usage", app + ":v3/disk_usage"}},
+		{name: "watermarks after keep last", policy: node.ImageGCPolicy{KeepLast: 1, HighWatermark: 20, LowWatermark: 10}, wantPruned: []string{app + ":v2/keep_last", app + ":v3/disk_usage"}},
+		{name: "under high watermark", policy: node.ImageGCPolicy{HighWatermark: 30, LowWatermark: 10}},
 	}
 
-	ctrd, ctrdErr := newCtrd(containerdSock)
-	defer ctrd.client.Close()
+	for _, test := range tests {
 
-	if ctrdErr != nil {
-		t.Errorf("failed to create containerd client with error: %s", ctrdErr.Error())
-	}
+		t.Run(test.name, func(t *testing.T) {
+			logDir, _ := ioutil.TempDir("", "clamor-logs")
+			defer os.RemoveAll(logDir)
+			backend := node.NewMemoryBackend(remotes...)
+			backend.DiskCapacity = 1000
+			svc := node.NewNode(backend, node.NewLogStore(logDir), node.WithImageGC(test.policy))
+			defer svc.Close()
+			ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
 
-	node := node.NewNode(ctrd.client)
+			for _, remote := range remotes {
+
+				if _, pullErr := svc.PullImage(ctx, remote.Ref); pullErr != nil {
+					t.Fatalf("failed to pull seed image with error: %s", pullErr.Error())
+				}
+
+				time.Sleep(time.Millisecond)
+			}
+
+			// The oldest image is kept by its container, whatever the policy says.
+			if _, createErr := svc.CreateContainer(ctx, app+":v1", testContainerID, node.ContainerSpec{}); createErr != nil {
+				t.Fatalf("failed to create seed container with error: %s", createErr.Error())
+			}
+
+			time.Sleep(20 * time.Millisecond)
+
+			for _, dryRun := range []bool{true, false} {
+				report, pruneErr := svc.PruneImages(ctx, dryRun)
+
+				if pruneErr != nil {
+					t.Fatalf("node.PruneImages failed with error: %s", pruneErr.Error())
+				}
+
+				var pruned []string
+
+				for _, img := range report.Images {
+					pruned = append(pruned, img.Name+"/"+img.Reason)
+				}
+
+				if !reflect.DeepEqual(pruned, test.wantPruned) || report.Freed != int64(50*len(pruned)) || report.DryRun != dryRun || report.DiskUsage.Used != 200 {
+					t.Errorf("node.PruneImages with dry run %t returned %+v, want %v pruned out of 200 bytes used", dryRun, report, test.wantPruned)
+				}
+			}
+
+			images, _ := svc.GetImages(ctx, "")
+
+			if len(images) != len(remotes)-len(test.wantPruned) {
+				t.Errorf("node.PruneImages left %d images, want %d", len(images), len(remotes)-len(test.wantPruned))
+			}
+		})
+	}
 
+	// With an interval, the collector runs in the background.
+	logDir, _ := ioutil.TempDir("", "clamor-logs")
+	defer os.RemoveAll(logDir)
+	svc := node.NewNode(node.NewMemoryBackend(remotes...), node.NewLogStore(logDir), node.WithImageGC(node.ImageGCPolicy{Interval: node.Duration(10 * time.Millisecond), KeepLast: 1}))
+	defer svc.Close()
 	ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
 
-	_, pullErr := ctrd.pullImage(ctx, testImage)
+	for _, remote := range remotes[:3] {
+		svc.PullImage(ctx, remote.Ref)
+		time.Sleep(time.Millisecond)
+	}
 
-	if pullErr != nil {
-		t.Errorf("failed to pull seed image with error: %s", pullErr.Error())
+	deadline := time.Now().Add(2 * time.Second)
+
+	for images, _ := svc.GetImages(ctx, ""); len(images) != 1; images, _ = svc.GetImages(ctx, "") {
+
+		if time.Now().After(deadline) {
+			t.Fatalf("background image gc left %d images, want 1", len(images))
+		}
+
+		time.Sleep(10 * time.Millisecond)
 	}
 
-	defer ctrd.deleteImage(ctx, testImage)
+	var policy node.ImageGCPolicy
+
+	if decodeErr := json.Unmarshal([]byte(`{"interval": "1h", "keep_last": 3, "max_age": "720h", "high_watermark": 85, "low_watermark": 70}`), &policy); decodeErr != nil || policy.Validate() != nil {
+		t.Errorf("failed to decode image gc policy with error: %v", decodeErr)
+	}
+
+	if time.Duration(policy.MaxAge) != 720*time.Hour {
+		t.Errorf("decoded max age %s, want 720h", time.Duration(policy.MaxAge))
+	}
+
+	if invalidErr := (node.ImageGCPolicy{HighWatermark: 70, LowWatermark: 85}).Validate(); invalidErr == nil {
+		t.Errorf("image gc policy with a low watermark above its high watermark is valid, want error")
+	}
+}
+
+func TestRegistryCredentials(t *testing.T) {
+	type test struct {
+		name                     string
+		host                     string
+		wantUsername, wantSecret string
+		wantErr                  bool
+	}
+
+	configDir, tempDirErr := ioutil.TempDir("", "clamor-docker-config")
+
+	if tempDirErr != nil {
+		t.Fatalf("failed to create docker config directory with error: %s", tempDirErr.Error())
+	}
+
+	defer os.RemoveAll(configDir)
+
+	dockerConfig := filepath.Join(configDir, "config.json")
+	brokenConfig := filepath.Join(configDir, "broken.json")
+	writeErr := ioutil.WriteFile(dockerConfig, []byte(`{"auths": {
+		"https://index.docker.io/v1/": {"auth": "`+base64.StdEncoding.EncodeToString([]byte("hub-user:hub:password"))+`"},
+		"registry.example.com:5000": {"identitytoken": "identity"},
+		"plain.example.com": {"username": "plain-user", "password": "plain-password"}
+	}}`), 0600)
+
+	if writeErr == nil {
+		writeErr = ioutil.WriteFile(brokenConfig, []byte(`{"auths": {"broken.example.com": {"auth": "not base64"}}}`), 0600)
+	}
+
+	if writeErr != nil {
+		t.Fatalf("failed to write docker config with error: %s", writeErr.Error())
+	}
+
+	registries := node.Registries{
+		"basic.example.com":         {Username: "user", Password: "s3cret"},
+		"token.example.com":         {Token: "t0ken"},
+		"docker.io":                 {DockerConfig: dockerConfig},
+		"registry.example.com:5000": {DockerConfig: dockerConfig},
+		"plain.example.com":         {DockerConfig: dockerConfig},
+		"missing.example.com":       {DockerConfig: dockerConfig},
+		"gone.example.com":          {DockerConfig: filepath.Join(configDir, "gone.json")},
+		"broken.example.com":        {DockerConfig: brokenConfig},
+	}
+
+	tests := []test{
+		{name: "basic auth", host: "basic.example.com", wantUsername: "user", wantSecret: "s3cret"},
+		{name: "token", host: "token.example.com", wantSecret: "t0ken"},
+		{name: "docker hub from docker config", host: "registry-1.docker.io", wantUsername: "hub-user", wantSecret: "hub:password"},
+		{name: "identity token from docker config", host: "registry.example.com:5000", wantSecret: "identity"},
+		{name: "username and password from docker config", host: "plain.example.com", wantUsername: "plain-user", wantSecret: "plain-password"},
+		{name: "host missing from docker config", host: "missing.example.com"},
+		{name: "unknown host", host: "quay.io"},
+		{name: "missing docker config", host: "gone.example.com", wantErr: true},
+		{name: "broken docker config", host: "broken.example.com", wantErr: true},
+	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			ctx := namespaces.WithNamespace(context.TODO(), test.args.namespace)
-
-			_, err := node.CreateContainer(ctx, test.args.image, test.args.id)
+			username, secret, err := registries.Credentials(test.host)
 
 			if err != nil && !test.wantErr {
-				t.Errorf("node.CreateContainer failed with error: %s", err.Error())
+				t.Fatalf("Registries.Credentials failed with error: %s", err.Error())
+			} else if err == nil && test.wantErr {
+				t.Fatalf("Registries.Credentials succeeded, want error")
 			}
 
-			ctrd.deleteContainer(ctx, test.args.id)
+			if username != test.wantUsername || secret != test.wantSecret {
+				t.Errorf("Registries.Credentials returned %q, %q, want %q, %q", username, secret, test.wantUsername, test.wantSecret)
+			}
 		})
 	}
+
+	cfg := node.Config{Registries: registries}
+
+	for _, formatted := range []string{fmt.Sprintf("%v", cfg), fmt.Sprintf("%+v", cfg), fmt.Sprintf("%#v", cfg.Registries)} {
+
+		if strings.Contains(formatted, "s3cret") || strings.Contains(formatted, "t0ken") || !strings.Contains(formatted, "[redacted]") {
+			t.Errorf("formatted config %s gives credentials away", formatted)
+		}
+	}
+}
+
+// registryStandIn serves the manifest and layer of a single image, docker.io/library/app:latest, the way a registry does.
+// It records the paths it's asked for.
+type registryStandIn struct {
+	manifest, layer []byte
+	// token makes the registry challenge requests for a bearer token, which its token service hands out to anyone.
+	token    string
+	mu       sync.Mutex
+	requests []string
+}
+
+func (r *registryStandIn) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if r.token != "" {
+
+		if req.URL.Path == "/token" {
+			json.NewEncoder(w).Encode(map[string]string{"token": r.token})
+			return
+		}
+
+		if req.Header.Get("Authorization") != "Bearer "+r.token {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="http://%s/token",service="registry",scope="repository:library/app:pull"`, req.Host))
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	r.mu.Lock()
+	r.requests = append(r.requests, req.Method+" "+req.URL.Path)
+	r.mu.Unlock()
+
+	var body []byte
+
+	switch req.URL.Path {
+	case "/v2/":
+	case "/v2/library/app/manifests/latest", "/v2/library/app/manifests/" + digest.FromBytes(r.manifest).String():
+		body = r.manifest
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+		w.Header().Set("Docker-Content-Digest", digest.FromBytes(r.manifest).String())
+	case "/v2/library/app/blobs/" + digest.FromBytes(r.layer).String():
+		body = r.layer
+	default:
+		http.NotFound(w, req)
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+
+	if req.Method != http.MethodHead {
+		w.Write(body)
+	}
+}
+
+func (r *registryStandIn) served() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.requests...)
+}
+
+func TestRegistryHosts(t *testing.T) {
+	type test struct {
+		name       string
+		registries func(upstream, mirror, broken string) node.Registries
+		// clientAuth makes the upstream registry ask for a client certificate.
+		clientAuth bool
+		// mirrorToken makes the mirror ask for a bearer token, see registryStandIn.
+		mirrorToken string
+		// wantServedBy is which of the upstream registry and the mirror serve the image.
+		wantServedBy string
+		wantErr      bool
+	}
+
+	layer := []byte("layer")
+	manifest, _ := json.Marshal(ocispec.Manifest{
+		Config: ocispec.Descriptor{MediaType: ocispec.MediaTypeImageConfig, Digest: digest.FromString("{}"), Size: 2},
+		Layers: []ocispec.Descriptor{{MediaType: ocispec.MediaTypeImageLayer, Digest: digest.FromBytes(layer), Size: int64(len(layer))}},
+	})
+
+	certDir, tempDirErr := ioutil.TempDir("", "clamor-registry-certs")
+
+	if tempDirErr != nil {
+		t.Fatalf("failed to create certificate directory with error: %s", tempDirErr.Error())
+	}
+
+	defer os.RemoveAll(certDir)
+
+	tests := []test{
+		{
+			name: "upstream with custom CA",
+			registries: func(upstream, mirror, broken string) node.Registries {
+				return node.Registries{upstream: {CAFile: filepath.Join(certDir, "ca.pem")}}
+			},
+			wantServedBy: "upstream",
+		},
+		{
+			name: "insecure upstream",
+			registries: func(upstream, mirror, broken string) node.Registries {
+				return node.Registries{upstream: {InsecureSkipVerify: true}}
+			},
+			wantServedBy: "upstream",
+		},
+		{
+			name: "mirror",
+			registries: func(upstream, mirror, broken string) node.Registries {
+				return node.Registries{upstream: {Mirrors: []string{mirror}, InsecureSkipVerify: true}}
+			},
+			wantServedBy: "mirror",
+		},
+		{
+			name: "broken mirror falls back",
+			registries: func(upstream, mirror, broken string) node.Registries {
+				return node.Registries{upstream: {Mirrors: []string{"http://" + broken, mirror}, InsecureSkipVerify: true}}
+			},
+			wantServedBy: "mirror",
+		},
+		{
+			name: "mirror denies access falls back",
+			registries: func(upstream, mirror, broken string) node.Registries {
+				return node.Registries{upstream: {Mirrors: []string{"http://" + broken + "/denied", "http://" + broken + "/forbidden", mirror}, InsecureSkipVerify: true}}
+			},
+			wantServedBy: "mirror",
+		},
+		{
+			name: "anonymous token mirror",
+			registries: func(upstream, mirror, broken string) node.Registries {
+				return node.Registries{upstream: {Mirrors: []string{mirror}, InsecureSkipVerify: true}}
+			},
+			mirrorToken:  "anonymous",
+			wantServedBy: "mirror",
+		},
+		{
+			name: "mirror without the image falls back",
+			registries: func(upstream, mirror, broken string) node.Registries {
+				return node.Registries{upstream: {Mirrors: []string{"http://127.0.0.1:1", upstream + "/empty"}, InsecureSkipVerify: true}}
+			},
+			wantServedBy: "upstream",
+		},
+		{
+			name: "mirror without TLS falls back",
+			registries: func(upstream, mirror, broken string) node.Registries {
+				return node.Registries{upstream: {CAFile: filepath.Join(certDir, "ca.pem"), Mirrors: []string{"https://" + mirror}}}
+			},
+			wantServedBy: "upstream",
+		},
+		{
+			name: "client certificate",
+			registries: func(upstream, mirror, broken string) node.Registries {
+				return node.Registries{upstream: {CAFile: filepath.Join(certDir, "ca.pem"), CertFile: filepath.Join(certDir, "cert.pem"), KeyFile: filepath.Join(certDir, "key.pem")}}
+			},
+			clientAuth:   true,
+			wantServedBy: "upstream",
+		},
+		{
+			name: "missing client certificate",
+			registries: func(upstream, mirror, broken string) node.Registries {
+				return node.Registries{upstream: {CAFile: filepath.Join(certDir, "ca.pem")}}
+			},
+			clientAuth: true,
+			wantErr:    true,
+		},
+		{
+			name: "missing CA bundle",
+			registries: func(upstream, mirror, broken string) node.Registries {
+				return node.Registries{upstream: {CAFile: filepath.Join(certDir, "gone.pem")}}
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			upstream := &registryStandIn{manifest: manifest, layer: layer}
+			mirror := &registryStandIn{manifest: manifest, layer: layer, token: test.mirrorToken}
+			upstreamServer := httptest.NewUnstartedServer(upstream)
+
+			if test.clientAuth {
+				upstreamServer.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+				upstreamServer.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+			}
+
+			upstreamServer.StartTLS()
+			defer upstreamServer.Close()
+			mirrorServer := httptest.NewServer(mirror)
+			defer mirrorServer.Close()
+			brokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				switch {
+				case strings.HasPrefix(req.URL.Path, "/denied/"):
+					w.Header().Set("WWW-Authenticate", `Basic realm="mirror"`)
+					http.Error(w, "denied", http.StatusUnauthorized)
+				case strings.HasPrefix(req.URL.Path, "/forbidden/"):
+					http.Error(w, "forbidden", http.StatusForbidden)
+				default:
+					http.Error(w, "broken", http.StatusBadGateway)
+				}
+			}))
+			defer brokenServer.Close()
+
+			// The stand-in's self-signed certificate is its own CA, and makes do as a client certificate.
+			cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: upstreamServer.Certificate().Raw})
+			key, marshalErr := x509.MarshalPKCS8PrivateKey(upstreamServer.TLS.Certificates[0].PrivateKey)
+
+			if marshalErr != nil {
+				t.Fatalf("failed to encode private key with error: %s", marshalErr.Error())
+			}
+
+			for file, content := range map[string][]byte{"ca.pem": cert, "cert.pem": cert, "key.pem": pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key})} {
+
+				if writeErr := ioutil.WriteFile(filepath.Join(certDir, file), content, 0600); writeErr != nil {
+					t.Fatalf("failed to write %s with error: %s", file, writeErr.Error())
+				}
+			}
+
+			upstreamHost := strings.TrimPrefix(upstreamServer.URL, "https://")
+			registries := test.registries(upstreamHost, strings.TrimPrefix(mirrorServer.URL, "http://"), strings.TrimPrefix(brokenServer.URL, "http://"))
+			resolver := docker.NewResolver(docker.ResolverOptions{Hosts: registries.Hosts})
+			ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
+
+			name, desc, err := resolver.Resolve(ctx, upstreamHost+"/library/app:latest")
+
+			if err != nil && !test.wantErr {
+				t.Fatalf("resolve failed with error: %s", err.Error())
+			} else if err == nil && test.wantErr {
+				t.Fatalf("resolve succeeded, want error")
+			}
+
+			if test.wantErr {
+				return
+			}
+
+			if desc.Digest != digest.FromBytes(manifest) {
+				t.Errorf("resolve returned %s, want %s", desc.Digest, digest.FromBytes(manifest))
+			}
+
+			fetcher, _ := resolver.Fetcher(ctx, name)
+			rc, fetchErr := fetcher.Fetch(ctx, ocispec.Descriptor{MediaType: ocispec.MediaTypeImageLayer, Digest: digest.FromBytes(layer), Size: int64(len(layer))})
+
+			if fetchErr != nil {
+				t.Fatalf("fetch failed with error: %s", fetchErr.Error())
+			}
+
+			fetched, _ := ioutil.ReadAll(rc)
+			rc.Close()
+
+			if !bytes.Equal(fetched, layer) {
+				t.Errorf("fetch returned %q, want %q", fetched, layer)
+			}
+
+			servedBy := map[string][]string{"upstream": upstream.served(), "mirror": mirror.served()}
+			blob := "GET /v2/library/app/blobs/" + digest.FromBytes(layer).String()
+
+			for server, requests := range servedBy {
+
+				if served := strings.Contains(strings.Join(requests, "\n"), blob); served != (server == test.wantServedBy) {
+					t.Errorf("%s registry got requests %v, want the layer served by the %s", server, requests, test.wantServedBy)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateContainer(t *testing.T) {
+	type testArguments struct {
+		namespace, image, id string
+		spec                 node.ContainerSpec
+	}
+
+	type test struct {
+		name             string
+		args             testArguments
+		wantErr, wantImg bool
+	}
+
+	tests := []test{
+		{name: "empty namespace", args: testArguments{namespace: "", image: testImage, id: testContainerID}, wantErr: true},
+		{name: "weird namespace", args: testArguments{namespace: weirdString, image: testImage, id: testContainerID}, wantErr: true},
+		{name: "empty image name", args: testArguments{namespace: testNamespace, image: "", id: ""}, wantErr: true},
+		{name: "weird image name", args: testArguments{namespace: testNamespace, image: weirdString, id: weirdString}, wantErr: true},
+		{name: "empty container ID", args: testArguments{namespace: testNamespace, image: testImage, id: ""}, wantErr: true},
+		{name: "weird container ID", args: testArguments{namespace: testNamespace, image: testImage, id: weirdString}, wantErr: true},
+		{name: "malformed env", args: testArguments{namespace: testNamespace, image: testImage, id: testContainerID, spec: node.ContainerSpec{Env: []string{"=oops"}}}, wantErr: true},
+		{name: "valid namespace valid image valid container ID", args: testArguments{namespace: testNamespace, image: testImage, id: testContainerID}, wantErr: false},
+		{name: "valid spec", args: testArguments{namespace: testNamespace, image: testImage, id: testContainerID, spec: testSpec}, wantErr: false},
+		{name: "invalid resources", args: testArguments{namespace: testNamespace, image: testImage, id: testContainerID, spec: node.ContainerSpec{Resources: node.Resources{MemoryLimit: 1 << 20, MemoryReservation: 1 << 30}}}, wantErr: true},
+	}
+
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	node := node.NewNode(ctrd.backend, ctrd.logs)
+	defer node.Close()
+
+	ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
+
+	_, pullErr := ctrd.pullImage(ctx, testImage)
+
+	if pullErr != nil {
+		t.Errorf("failed to pull seed image with error: %s", pullErr.Error())
+	}
+
+	defer ctrd.deleteImage(ctx, testImage)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := namespaces.WithNamespace(context.TODO(), test.args.namespace)
+
+			c, err := node.CreateContainer(ctx, test.args.image, test.args.id, test.args.spec)
+
+			if c != nil {
+				if spec, _ := c.Spec(ctx); !reflect.DeepEqual(spec, test.args.spec) {
+					t.Errorf("node.CreateContainer stored spec %+v, want %+v", spec, test.args.spec)
+				}
+			}
+
+			if err != nil && !test.wantErr {
+				t.Errorf("node.CreateContainer failed with error: %s", err.Error())
+			} else if err == nil && test.wantErr {
+				t.Errorf("node.CreateContainer succeeded, want error")
+			}
+
+			ctrd.deleteContainer(ctx, test.args.id)
+		})
+	}
+}
+
+func TestUpdateContainerResources(t *testing.T) {
+	type testArguments struct {
+		namespace, id string
+		resources     node.Resources
+		withTask      bool
+	}
+
+	type test struct {
+		name    string
+		args    testArguments
+		wantErr bool
+	}
+
+	limits := node.Resources{CPUShares: 512, CPUQuota: 50000, MemoryLimit: 1 << 30, MemorySwap: 1 << 31, PidsLimit: 64}
+	// updates set limits on a live task and clear them again.
+	updates := []node.Resources{limits, {}}
+	tests := []test{
+		{name: "empty namespace", args: testArguments{namespace: "", id: testContainerID, resources: limits}, wantErr: true},
+		{name: "weird container ID", args: testArguments{namespace: testNamespace, id: weirdString, resources: limits}, wantErr: true},
+		{name: "tiny cpu quota", args: testArguments{namespace: testNamespace, id: testContainerID, resources: node.Resources{CPUQuota: 10}}, wantErr: true},
+		{name: "cpu period out of range", args: testArguments{namespace: testNamespace, id: testContainerID, resources: node.Resources{CPUPeriod: 10}}, wantErr: true},
+		{name: "swap without memory limit", args: testArguments{namespace: testNamespace, id: testContainerID, resources: node.Resources{MemorySwap: 1 << 30}}, wantErr: true},
+		{name: "swap below memory limit", args: testArguments{namespace: testNamespace, id: testContainerID, resources: node.Resources{MemoryLimit: 1 << 30, MemorySwap: 1 << 20}}, wantErr: true},
+		{name: "negative pids limit", args: testArguments{namespace: testNamespace, id: testContainerID, resources: node.Resources{PidsLimit: -2}}, wantErr: true},
+		{name: "valid limits without task", args: testArguments{namespace: testNamespace, id: testContainerID, resources: limits}, wantErr: false},
+		{name: "valid limits with task", args: testArguments{namespace: testNamespace, id: testContainerID, resources: limits, withTask: true}, wantErr: false},
+		{name: "cleared limits", args: testArguments{namespace: testNamespace, id: testContainerID}, wantErr: false},
+	}
+
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	node := node.NewNode(ctrd.backend, ctrd.logs)
+	defer node.Close()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
+
+			if _, createErr := ctrd.createContainer(ctx, testImage, testContainerID); createErr != nil {
+				t.Fatalf("failed to create seed container with error: %s", createErr.Error())
+			}
+
+			defer ctrd.deleteContainer(ctx, testContainerID)
+
+			if test.args.withTask {
+
+				if _, createTaskErr := ctrd.createTask(ctx, testContainerID); createTaskErr != nil {
+					t.Fatalf("failed to create seed task with error: %s", createTaskErr.Error())
+				}
+
+				defer ctrd.deleteTask(ctx, testContainerID)
+			}
+
+			ctx = namespaces.WithNamespace(context.TODO(), test.args.namespace)
+			c, err := node.UpdateContainerResources(ctx, test.args.id, test.args.resources)
+
+			if err != nil && !test.wantErr {
+				t.Errorf("node.UpdateContainerResources failed with error: %s", err.Error())
+			} else if err == nil && test.wantErr {
+				t.Errorf("node.UpdateContainerResources succeeded, want error")
+			}
+
+			if c != nil {
+				if spec, _ := c.Spec(ctx); spec.Resources != test.args.resources {
+					t.Errorf("node.UpdateContainerResources stored resources %+v, want %+v", spec.Resources, test.args.resources)
+				}
+			}
+		})
+	}
+
+	t.Run("limits cleared on a live task", func(t *testing.T) {
+		ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
+
+		if _, createErr := ctrd.createContainer(ctx, testImage, testContainerID); createErr != nil {
+			t.Fatalf("failed to create seed container with error: %s", createErr.Error())
+		}
+
+		defer ctrd.deleteContainer(ctx, testContainerID)
+		task, createTaskErr := ctrd.createTask(ctx, testContainerID)
+
+		if createTaskErr != nil {
+			t.Fatalf("failed to create seed task with error: %s", createTaskErr.Error())
+		}
+
+		defer ctrd.deleteTask(ctx, testContainerID)
+
+		for _, resources := range updates {
+
+			if _, updateErr := node.UpdateContainerResources(ctx, testContainerID, resources); updateErr != nil {
+				t.Fatalf("node.UpdateContainerResources failed with error: %s", updateErr.Error())
+			}
+
+			metrics, metricsErr := task.Metrics(ctx)
+
+			if metricsErr != nil {
+				t.Fatalf("task.Metrics failed with error: %s", metricsErr.Error())
+			}
+
+			if metrics.MemoryLimit != uint64(resources.MemoryLimit) || metrics.PidsLimit != uint64(resources.PidsLimit) {
+				t.Errorf("task enforces memory limit %d and pids limit %d, want %d and %d", metrics.MemoryLimit, metrics.PidsLimit, resources.MemoryLimit, resources.PidsLimit)
+			}
+		}
+	})
+}
+
+func TestCreateTask(t *testing.T) {
+	type testArguments struct {
+		namespace, containerID string
+	}
+
+	type test struct {
+		name             string
+		args             testArguments
+		wantErr, wantImg bool
+	}
+
+	tests := []test{
+		{name: "empty namespace", args: testArguments{namespace: "", containerID: testContainerID}, wantErr: true},
+		{name: "weird namespace", args: testArguments{namespace: weirdString, containerID: testContainerID}, wantErr: true},
+		{name: "empty container ID", args: testArguments{namespace: testNamespace, containerID: ""}, wantErr: true},
+		{name: "weird container ID", args: testArguments{namespace: testNamespace, containerID: weirdString}, wantErr: true},
+		{name: "valid namespace valid container ID", args: testArguments{namespace: testNamespace, containerID: testContainerID}, wantErr: false},
+	}
+
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	node := node.NewNode(ctrd.backend, ctrd.logs)
+	defer node.Close()
+
+	ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
+
+	_, pullErr := ctrd.pullImage(ctx, testImage)
+
+	if pullErr != nil {
+		t.Errorf("failed to pull seed image with error: %s", pullErr.Error())
+	}
+
+	defer ctrd.deleteImage(ctx, testImage)
+
+	_, createContainerErr := ctrd.createContainer(namespaces.WithNamespace(context.TODO(), testNamespace), testImage, testContainerID)
+
+	if createContainerErr != nil {
+		t.Errorf("failed to create seed container with error: %s", createContainerErr.Error())
+	}
+
+	defer ctrd.deleteContainer(ctx, testContainerID)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := namespaces.WithNamespace(context.TODO(), test.args.namespace)
+
+			if tsk, err := node.CreateTask(ctx, test.args.containerID); err != nil && !test.wantErr {
+				t.Errorf("node.CreateTask failed with error: %s", err.Error())
+			} else if tsk != nil {
+				ctrd.killTask(ctx, tsk.ID(), syscall.SIGKILL)
+				ctrd.deleteTask(ctx, tsk.ID())
+			}
+		})
+	}
+}
+
+func TestKillTask(t *testing.T) {
+	type testArguments struct {
+		namespace, containerID, signal string
+		timeout                        time.Duration
+	}
+
+	type test struct {
+		name             string
+		args             testArguments
+		wantErr, wantImg bool
+	}
+
+	tests := []test{
+		{name: "empty namespace", args: testArguments{namespace: "", containerID: testContainerID}, wantErr: true},
+		{name: "weird namespace", args: testArguments{namespace: weirdString, containerID: testContainerID}, wantErr: true},
+		{name: "empty container ID", args: testArguments{namespace: testNamespace, containerID: ""}, wantErr: true},
+		{name: "weird container ID", args: testArguments{namespace: testNamespace, containerID: weirdString}, wantErr: true},
+		{name: "valid namespace valid container ID", args: testArguments{namespace: testNamespace, containerID: testContainerID}, wantErr: false},
+		{name: "unknown signal", args: testArguments{namespace: testNamespace, containerID: testContainerID, signal: "SIGNOPE"}, wantErr: true},
+		{name: "negative timeout", args: testArguments{namespace: testNamespace, containerID: testContainerID, timeout: -time.Second}, wantErr: true},
+		{name: "signal name", args: testArguments{namespace: testNamespace, containerID: testContainerID, signal: "SIGKILL"}, wantErr: false},
+		{name: "short signal name", args: testArguments{namespace: testNamespace, containerID: testContainerID, signal: "int", timeout: time.Second}, wantErr: false},
+		{name: "signal number", args: testArguments{namespace: testNamespace, containerID: testContainerID, signal: "15"}, wantErr: false},
+	}
+
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	node := node.NewNode(ctrd.backend, ctrd.logs)
+	defer node.Close()
+
+	ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
+
+	_, pullErr := ctrd.pullImage(ctx, testImage)
+
+	if pullErr != nil {
+		t.Errorf("failed to pull seed image with error: %s", pullErr.Error())
+	}
+
+	defer ctrd.deleteImage(ctx, testImage)
+
+	_, createContainerErr := ctrd.createContainer(namespaces.WithNamespace(context.TODO(), testNamespace), testImage, testContainerID)
+
+	if createContainerErr != nil {
+		t.Errorf("failed to create seed container with error: %s", createContainerErr.Error())
+	}
+
+	defer ctrd.deleteContainer(ctx, testContainerID)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrd.createTask(ctx, test.args.containerID)
+			err := node.KillTask(namespaces.WithNamespace(context.TODO(), test.args.namespace), test.args.containerID, test.args.signal, test.args.timeout)
+
+			if err == nil {
+				ctrd.deleteTask(ctx, test.args.containerID)
+			} else if !test.wantErr {
+				t.Errorf("node.KillTask failed with error: %s", err.Error())
+			} else {
+				ctrd.killTask(ctx, test.args.containerID, syscall.SIGKILL)
+				ctrd.deleteTask(ctx, test.args.containerID)
+			}
+		})
+	}
+}
+
+func TestKillTaskGracefulStop(t *testing.T) {
+	type testArguments struct {
+		image, signal string
+		timeout       time.Duration
+		ctxTimeout    time.Duration
+	}
+
+	type test struct {
+		name         string
+		args         testArguments
+		wantExitCode uint32
+		wantErr      bool
+	}
+
+	tests := []test{
+		{name: "default stop signal", args: testArguments{image: testImage}, wantExitCode: 128 + uint32(syscall.SIGTERM)},
+		{name: "image stop signal", args: testArguments{image: stopSignalImage}, wantExitCode: 128 + uint32(syscall.SIGQUIT)},
+		{name: "escalate ignored stop signal", args: testArguments{image: stubbornImage, timeout: 50 * time.Millisecond}, wantExitCode: 128 + uint32(syscall.SIGKILL)},
+		{name: "escalate ignored explicit signal", args: testArguments{image: stubbornImage, signal: "TERM", timeout: 50 * time.Millisecond}, wantExitCode: 128 + uint32(syscall.SIGKILL)},
+		{name: "explicit signal without timeout", args: testArguments{image: stubbornImage, signal: "TERM"}},
+		{name: "context cancelled", args: testArguments{image: stubbornImage, timeout: time.Minute, ctxTimeout: 50 * time.Millisecond}, wantErr: true},
+	}
+
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	svc := node.NewNode(ctrd.backend, ctrd.logs)
+	defer svc.Close()
+	ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, createErr := ctrd.createContainer(ctx, test.args.image, testContainerID); createErr != nil {
+				t.Fatalf("failed to create seed container with error: %s", createErr.Error())
+			}
+
+			defer ctrd.deleteContainer(ctx, testContainerID)
+
+			if _, createTaskErr := ctrd.createTask(ctx, testContainerID); createTaskErr != nil {
+				t.Fatalf("failed to create seed task with error: %s", createTaskErr.Error())
+			}
+
+			killCtx := ctx
+
+			if test.args.ctxTimeout > 0 {
+				var cancel context.CancelFunc
+				killCtx, cancel = context.WithTimeout(ctx, test.args.ctxTimeout)
+				defer cancel()
+			}
+
+			err := svc.KillTask(killCtx, testContainerID, test.args.signal, test.args.timeout)
+
+			if err != nil && !test.wantErr {
+				t.Errorf("node.KillTask failed with error: %s", err.Error())
+			} else if err == nil && test.wantErr {
+				t.Errorf("node.KillTask succeeded, want error")
+			}
+
+			if test.wantErr || test.wantExitCode == 0 {
+				if task, _ := ctrd.getTask(ctx, testContainerID); node.TaskStatus(ctx, task) != "running" && node.TaskStatus(ctx, task) != "created" {
+					t.Errorf("node.KillTask stopped the task, want it left running")
+				}
+
+				ctrd.killTask(ctx, testContainerID, syscall.SIGKILL)
+				ctrd.deleteTask(ctx, testContainerID)
+				return
+			}
+
+			exitStatus, deleteErr := svc.DeleteTask(ctx, testContainerID)
+
+			if deleteErr != nil {
+				t.Fatalf("node.DeleteTask failed with error: %s", deleteErr.Error())
+			}
+
+			if code := exitStatus.ExitCode(); code != test.wantExitCode {
+				t.Errorf("task exited with %d, want %d", code, test.wantExitCode)
+			}
+		})
+	}
+}
+
+func TestRestartPolicies(t *testing.T) {
+	type test struct {
+		name   string
+		policy string
+		// exits are the statuses the task exits with, one after the other, each once it's restarted from the previous one.
+		exits       []uint32
+		wantCount   int
+		wantRunning bool
+	}
+
+	tests := []test{
+		{name: "no", policy: node.RestartNo, exits: []uint32{1}},
+		{name: "on-failure success", policy: node.RestartOnFailure, exits: []uint32{0}},
+		{name: "on-failure", policy: node.RestartOnFailure, exits: []uint32{1, 2, 3}, wantCount: 3, wantRunning: true},
+		{name: "on-failure out of retries", policy: "on-failure:2", exits: []uint32{1, 1, 1}, wantCount: 2},
+		{name: "always", policy: node.RestartAlways, exits: []uint32{0, 1}, wantCount: 2, wantRunning: true},
+		{name: "unless-stopped", policy: node.RestartUnlessStopped, exits: []uint32{0}, wantCount: 1, wantRunning: true},
+	}
+
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	svc := node.NewNode(ctrd.backend, ctrd.logs, node.WithRestartBackoff(time.Millisecond, 10*time.Millisecond))
+	defer svc.Close()
+	ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
+
+	if _, pullErr := ctrd.pullImage(ctx, testImage); pullErr != nil {
+		t.Fatalf("failed to pull seed image with error: %s", pullErr.Error())
+	}
+
+	// startSupervised creates a container with the given restart policy and starts its task.
+	startSupervised := func(t *testing.T, policy string) {
+		if _, createErr := svc.CreateContainer(ctx, testImage, testContainerID, node.ContainerSpec{RestartPolicy: policy}); createErr != nil {
+			t.Fatalf("node.CreateContainer failed with error: %s", createErr.Error())
+		}
+
+		if _, createTaskErr := svc.CreateTask(ctx, testContainerID); createTaskErr != nil {
+			t.Fatalf("node.CreateTask failed with error: %s", createTaskErr.Error())
+		}
+	}
+
+	// cleanup removes the container, once the supervisor is done restarting its task.
+	cleanup := func() {
+		svc.KillTask(ctx, testContainerID, "KILL", 0)
+		svc.DeleteTask(ctx, testContainerID)
+		ctrd.deleteContainer(ctx, testContainerID)
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			startSupervised(t, test.policy)
+			defer cleanup()
+
+			for _, code := range test.exits {
+				if exitErr := ctrd.backend.ExitTask(ctx, testContainerID, code); exitErr != nil {
+					t.Fatalf("failed to exit task with error: %s", exitErr.Error())
+				}
+
+				waitRunning(ctx, ctrd, testContainerID, 200*time.Millisecond)
+			}
+
+			status := restartStatus(ctx, t, svc, testContainerID)
+
+			if status.Count != test.wantCount {
+				t.Errorf("task was restarted %d times, want %d", status.Count, test.wantCount)
+			}
+
+			if last := test.exits[len(test.exits)-1]; status.LastExitStatus != last || status.LastExitAt.IsZero() {
+				t.Errorf("last exit is %d at %s, want %d", status.LastExitStatus, status.LastExitAt, last)
+			}
+
+			if task, _ := ctrd.getTask(ctx, testContainerID); (node.TaskStatus(ctx, task) == "running") != test.wantRunning {
+				t.Errorf("task is %q, want it running: %t", node.TaskStatus(ctx, task), test.wantRunning)
+			}
+		})
+	}
+
+	t.Run("stopped by hand", func(t *testing.T) {
+		for _, policy := range []string{node.RestartAlways, node.RestartUnlessStopped} {
+			startSupervised(t, policy)
+
+			if killErr := svc.KillTask(ctx, testContainerID, "", 0); killErr != nil {
+				t.Fatalf("node.KillTask failed with error: %s", killErr.Error())
+			}
+
+			waitRunning(ctx, ctrd, testContainerID, 100*time.Millisecond)
+
+			if status := restartStatus(ctx, t, svc, testContainerID); !status.Stopped || status.Count != 0 {
+				t.Errorf("%s task was restarted %d times after it was stopped by hand, want it left stopped", policy, status.Count)
+			}
+
+			// A new Node on the same backend stands in for a node restart.
+			restarted := node.NewNode(ctrd.backend, ctrd.logs, node.WithRestartBackoff(time.Millisecond, 10*time.Millisecond))
+			running := waitRunning(ctx, ctrd, testContainerID, 200*time.Millisecond)
+			restarted.Close()
+
+			if wantRunning := policy == node.RestartAlways; running != wantRunning {
+				t.Errorf("%s task is running after a node restart: %t, want %t", policy, running, wantRunning)
+			}
+
+			cleanup()
+		}
+	})
+
+	t.Run("kill after exit", func(t *testing.T) {
+		ctrd := newCtrd(t)
+		defer ctrd.cleanup()
+		// The backoff leaves time to kill the exited task before its restart is due.
+		svc := node.NewNode(ctrd.backend, ctrd.logs, node.WithRestartBackoff(50*time.Millisecond, 50*time.Millisecond))
+		defer svc.Close()
+
+		if _, pullErr := ctrd.pullImage(ctx, testImage); pullErr != nil {
+			t.Fatalf("failed to pull seed image with error: %s", pullErr.Error())
+		}
+
+		if _, createErr := svc.CreateContainer(ctx, testImage, testContainerID, node.ContainerSpec{RestartPolicy: node.RestartAlways}); createErr != nil {
+			t.Fatalf("node.CreateContainer failed with error: %s", createErr.Error())
+		}
+
+		if _, createTaskErr := svc.CreateTask(ctx, testContainerID); createTaskErr != nil {
+			t.Fatalf("node.CreateTask failed with error: %s", createTaskErr.Error())
+		}
+
+		if exitErr := ctrd.backend.ExitTask(ctx, testContainerID, 1); exitErr != nil {
+			t.Fatalf("failed to exit task with error: %s", exitErr.Error())
+		}
+
+		if killErr := svc.KillTask(ctx, testContainerID, "KILL", 0); killErr == nil {
+			t.Fatalf("node.KillTask succeeded on an exited task, want error")
+		}
+
+		if !waitRunning(ctx, ctrd, testContainerID, 500*time.Millisecond) {
+			t.Fatalf("task wasn't restarted after a kill that didn't reach it")
+		}
+
+		if status := restartStatus(ctx, t, svc, testContainerID); status.Stopped || status.Count != 1 {
+			t.Errorf("restart status is %+v, want one restart and not stopped", status)
+		}
+	})
+
+	t.Run("closed node", func(t *testing.T) {
+		ctrd := newCtrd(t)
+		defer ctrd.cleanup()
+		svc := node.NewNode(ctrd.backend, ctrd.logs, node.WithRestartBackoff(time.Millisecond, time.Millisecond))
+
+		if _, pullErr := ctrd.pullImage(ctx, testImage); pullErr != nil {
+			t.Fatalf("failed to pull seed image with error: %s", pullErr.Error())
+		}
+
+		if _, createErr := svc.CreateContainer(ctx, testImage, testContainerID, node.ContainerSpec{RestartPolicy: node.RestartAlways}); createErr != nil {
+			t.Fatalf("node.CreateContainer failed with error: %s", createErr.Error())
+		}
+
+		if _, createTaskErr := svc.CreateTask(ctx, testContainerID); createTaskErr != nil {
+			t.Fatalf("node.CreateTask failed with error: %s", createTaskErr.Error())
+		}
+
+		if closeErr := svc.Close(); closeErr != nil {
+			t.Fatalf("node.Close failed with error: %s", closeErr.Error())
+		}
+
+		if exitErr := ctrd.backend.ExitTask(ctx, testContainerID, 1); exitErr != nil {
+			t.Fatalf("failed to exit task with error: %s", exitErr.Error())
+		}
+
+		if waitRunning(ctx, ctrd, testContainerID, 100*time.Millisecond) {
+			t.Errorf("task was restarted by a closed node")
+		}
+	})
+
+	t.Run("started by hand", func(t *testing.T) {
+		startSupervised(t, node.RestartOnFailure)
+		defer cleanup()
+
+		if exitErr := ctrd.backend.ExitTask(ctx, testContainerID, 1); exitErr != nil {
+			t.Fatalf("failed to exit task with error: %s", exitErr.Error())
+		}
+
+		if !waitRunning(ctx, ctrd, testContainerID, 200*time.Millisecond) {
+			t.Fatalf("task wasn't restarted")
+		}
+
+		if killErr := svc.KillTask(ctx, testContainerID, "KILL", 0); killErr != nil {
+			t.Fatalf("node.KillTask failed with error: %s", killErr.Error())
+		}
+
+		if _, deleteErr := svc.DeleteTask(ctx, testContainerID); deleteErr != nil {
+			t.Fatalf("node.DeleteTask failed with error: %s", deleteErr.Error())
+		}
+
+		if _, createTaskErr := svc.CreateTask(ctx, testContainerID); createTaskErr != nil {
+			t.Fatalf("node.CreateTask failed with error: %s", createTaskErr.Error())
+		}
+
+		if status := restartStatus(ctx, t, svc, testContainerID); status.Stopped || status.Count != 0 {
+			t.Errorf("restart status is %+v after a start by hand, want it reset", status)
+		}
+	})
+}
+
+// waitRunning waits for the given container's task to run, and reports whether it did before timeout.
+func waitRunning(ctx context.Context, ctrd *ctrd, containerID string, timeout time.Duration) bool {
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if task, _ := ctrd.getTask(ctx, containerID); node.TaskStatus(ctx, task) == "running" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// restartStatus reads the restart status off the given container's labels.
+func restartStatus(ctx context.Context, t *testing.T, svc node.Service, containerID string) node.RestartStatus {
+	container, getErr := svc.GetContainer(ctx, containerID)
+
+	if getErr != nil {
+		t.Fatalf("node.GetContainer failed with error: %s", getErr.Error())
+	}
+
+	labels, labelsErr := container.Labels(ctx)
+
+	if labelsErr != nil {
+		t.Fatalf("failed to get container labels with error: %s", labelsErr.Error())
+	}
+
+	status, supervised, statusErr := node.ParseRestartStatus(labels)
+
+	if !supervised || statusErr != nil {
+		t.Fatalf("container isn't supervised: %v", statusErr)
+	}
+
+	return status
+}
+
+func TestHealthChecks(t *testing.T) {
+	var healthy int32 = 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path == "/slow":
+			time.Sleep(100 * time.Millisecond)
+		case atomic.LoadInt32(&healthy) == 0:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	closed, listenErr := net.Listen("tcp", "127.0.0.1:0")
+
+	if listenErr != nil {
+		t.Fatalf("failed to listen with error: %s", listenErr.Error())
+	}
+
+	closed.Close()
+
+	type test struct {
+		name       string
+		check      node.HealthCheck
+		wantStatus string
+		wantOutput string
+		wantErr    bool
+	}
+
+	fast := func(check node.HealthCheck) node.HealthCheck {
+		check.Interval, check.Timeout, check.Retries = node.Duration(5*time.Millisecond), node.Duration(50*time.Millisecond), 2
+		return check
+	}
+
+	tests := []test{
+		{name: "exec passes", check: fast(node.HealthCheck{Exec: []string{"true"}}), wantStatus: node.HealthHealthy},
+		{name: "exec output", check: fast(node.HealthCheck{Exec: []string{"echo", "ok"}}), wantStatus: node.HealthHealthy, wantOutput: "ok\n"},
+		{name: "exec fails", check: fast(node.HealthCheck{Exec: []string{"false"}}), wantStatus: node.HealthUnhealthy, wantOutput: "exited with 1"},
+		{name: "http passes", check: fast(node.HealthCheck{HTTPGet: server.URL + "/healthz"}), wantStatus: node.HealthHealthy, wantOutput: "200 OK"},
+		{name: "http times out", check: fast(node.HealthCheck{HTTPGet: server.URL + "/slow"}), wantStatus: node.HealthUnhealthy},
+		{name: "tcp passes", check: fast(node.HealthCheck{TCPSocket: server.Listener.Addr().String()}), wantStatus: node.HealthHealthy},
+		{name: "tcp refused", check: fast(node.HealthCheck{TCPSocket: closed.Addr().String()}), wantStatus: node.HealthUnhealthy},
+		{
+			name:       "failing in start period",
+			check:      node.HealthCheck{Exec: []string{"false"}, Interval: node.Duration(5 * time.Millisecond), Retries: 1, StartPeriod: node.Duration(time.Hour)},
+			wantStatus: node.HealthStarting,
+		},
+		{name: "no probe", check: node.HealthCheck{Interval: node.Duration(time.Second)}, wantErr: true},
+		{name: "two probes", check: node.HealthCheck{Exec: []string{"true"}, TCPSocket: "127.0.0.1:80"}, wantErr: true},
+		{name: "weird url", check: node.HealthCheck{HTTPGet: weirdString}, wantErr: true},
+		{name: "weird address", check: node.HealthCheck{TCPSocket: weirdString}, wantErr: true},
+		{name: "negative retries", check: node.HealthCheck{Exec: []string{"true"}, Retries: -1}, wantErr: true},
+	}
+
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	svc := node.NewNode(ctrd.backend, ctrd.logs)
+	defer svc.Close()
+	ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
+
+	if _, pullErr := ctrd.pullImage(ctx, testImage); pullErr != nil {
+		t.Fatalf("failed to pull seed image with error: %s", pullErr.Error())
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			check := test.check
+			_, createErr := svc.CreateContainer(ctx, testImage, testContainerID, node.ContainerSpec{HealthCheck: &check})
+
+			if createErr != nil && !test.wantErr {
+				t.Fatalf("node.CreateContainer failed with error: %s", createErr.Error())
+			} else if createErr == nil && test.wantErr {
+				ctrd.deleteContainer(ctx, testContainerID)
+				t.Fatalf("node.CreateContainer succeeded, want error")
+			} else if test.wantErr {
+
+				if !errors.Is(createErr, errdefs.ErrInvalidArgument) {
+					t.Errorf("node.CreateContainer failed with %s, want an invalid argument error", createErr.Error())
+				}
+
+				return
+			}
+
+			defer ctrd.deleteContainer(ctx, testContainerID)
+
+			if _, createTaskErr := svc.CreateTask(ctx, testContainerID); createTaskErr != nil {
+				t.Fatalf("node.CreateTask failed with error: %s", createTaskErr.Error())
+			}
+
+			health := waitHealth(ctx, svc, testContainerID, func(h node.Health) bool {
+				return h.Status == test.wantStatus && len(h.Log) >= 2
+			})
+
+			if health.Status != test.wantStatus {
+				t.Errorf("task is %q, want %q", health.Status, test.wantStatus)
+			}
+
+			if len(health.Log) == 0 || len(health.Log) > 5 {
+				t.Fatalf("health log holds %d probes, want 1 to 5", len(health.Log))
+			}
+
+			last := health.Log[len(health.Log)-1]
+
+			if last.Passed != (test.wantStatus == node.HealthHealthy) || last.End.Before(last.Start) {
+				t.Errorf("last probe is %+v, want it to pass: %t", last, test.wantStatus == node.HealthHealthy)
+			}
+
+			if test.wantOutput != "" && last.Output != test.wantOutput {
+				t.Errorf("last probe printed %q, want %q", last.Output, test.wantOutput)
+			}
+
+			if test.wantStatus == node.HealthUnhealthy && health.FailingStreak < 2 {
+				t.Errorf("task is unhealthy after %d failed probes, want at least 2", health.FailingStreak)
+			}
+
+			if killErr := svc.KillTask(ctx, testContainerID, "KILL", 0); killErr != nil {
+				t.Fatalf("node.KillTask failed with error: %s", killErr.Error())
+			}
+
+			if health = waitHealth(ctx, svc, testContainerID, func(h node.Health) bool { return h.Status == "" }); health.Status != "" {
+				t.Errorf("stopped task is %q, want no health status", health.Status)
+			}
+
+			svc.DeleteTask(ctx, testContainerID)
+		})
+	}
+
+	t.Run("recovers", func(t *testing.T) {
+		check := node.HealthCheck{HTTPGet: server.URL, Interval: node.Duration(5 * time.Millisecond), Retries: 1}
+
+		if _, createErr := svc.CreateContainer(ctx, testImage, testContainerID, node.ContainerSpec{HealthCheck: &check}); createErr != nil {
+			t.Fatalf("node.CreateContainer failed with error: %s", createErr.Error())
+		}
+
+		defer ctrd.deleteContainer(ctx, testContainerID)
+		defer svc.DeleteTask(ctx, testContainerID)
+		defer svc.KillTask(ctx, testContainerID, "KILL", 0)
+
+		if _, createTaskErr := svc.CreateTask(ctx, testContainerID); createTaskErr != nil {
+			t.Fatalf("node.CreateTask failed with error: %s", createTaskErr.Error())
+		}
+
+		for _, want := range []string{node.HealthHealthy, node.HealthUnhealthy, node.HealthHealthy} {
+			if want == node.HealthHealthy {
+				atomic.StoreInt32(&healthy, 1)
+			} else {
+				atomic.StoreInt32(&healthy, 0)
+			}
+
+			if health := waitHealth(ctx, svc, testContainerID, func(h node.Health) bool { return h.Status == want }); health.Status != want {
+				t.Fatalf("task is %q, want %q", health.Status, want)
+			}
+		}
+	})
+
+	if _, healthErr := svc.GetContainerHealth(ctx, weirdString); healthErr == nil {
+		t.Errorf("node.GetContainerHealth of a missing container succeeded, want error")
+	}
+}
+
+// waitHealth polls the health of the given container until done accepts it, for a second at most, and returns the last health it got.
+func waitHealth(ctx context.Context, svc node.Service, containerID string, done func(node.Health) bool) (health node.Health) {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if health, _ = svc.GetContainerHealth(ctx, containerID); done(health) {
+			return health
+		}
+	}
+
+	return health
+}
+
+func TestApplyWorkload(t *testing.T) {
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	svc := node.NewNode(ctrd.backend, ctrd.logs, node.WithReconcileInterval(time.Hour))
+	defer svc.Close()
+	ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
+	web := node.Workload{Name: testContainerID, Image: testImage, Spec: node.ContainerSpec{Env: []string{"PORT=80"}}}
+	changed := web
+	changed.Spec = node.ContainerSpec{Env: []string{"PORT=8080"}}
+	stopped := changed
+	stopped.State = node.WorkloadStopped
+
+	type test struct {
+		name string
+		// drift changes the runtime behind the node's back before the workload is applied.
+		drift      func(t *testing.T)
+		workload   node.Workload
+		wantDrift  []string
+		wantStatus string
+	}
+
+	tests := []test{
+		{name: "first apply", workload: web, wantDrift: []string{"image missing", "container missing", "task missing"}, wantStatus: "running"},
+		{name: "in sync", workload: web, wantStatus: "running"},
+		{
+			name: "task exited",
+			drift: func(t *testing.T) {
+				if killErr := ctrd.killTask(ctx, testContainerID, syscall.SIGKILL); killErr != nil {
+					t.Fatalf("failed to kill task with error: %s", killErr.Error())
+				}
+			},
+			workload:   web,
+			wantDrift:  []string{"task exited"},
+			wantStatus: "running",
+		},
+		{
+			name: "task paused",
+			drift: func(t *testing.T) {
+				if _, pauseErr := svc.PauseTask(ctx, testContainerID); pauseErr != nil {
+					t.Fatalf("node.PauseTask failed with error: %s", pauseErr.Error())
+				}
+			},
+			workload:   web,
+			wantDrift:  []string{"task paused"},
+			wantStatus: "running",
+		},
+		{
+			name: "container deleted",
+			drift: func(t *testing.T) {
+				ctrd.killTask(ctx, testContainerID, syscall.SIGKILL)
+				ctrd.deleteTask(ctx, testContainerID)
+
+				if deleteErr := ctrd.deleteContainer(ctx, testContainerID); deleteErr != nil {
+					t.Fatalf("failed to delete container with error: %s", deleteErr.Error())
+				}
+			},
+			workload:   web,
+			wantDrift:  []string{"container missing", "task missing"},
+			wantStatus: "running",
+		},
+		{name: "spec changed", workload: changed, wantDrift: []string{"container outdated", "task missing"}, wantStatus: "running"},
+		{name: "stopped", workload: stopped, wantDrift: []string{"task running"}},
+		{name: "stays stopped", workload: stopped},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.drift != nil {
+				test.drift(t)
+			}
+
+			status, applyErr := svc.ApplyWorkload(ctx, test.workload)
+
+			if applyErr != nil {
+				t.Fatalf("node.ApplyWorkload failed with error: %s", applyErr.Error())
+			}
+
+			if !status.Synced || status.Error != "" {
+				t.Errorf("workload isn't synced: %s", status.Error)
+			}
+
+			if !reflect.DeepEqual(status.Drift, test.wantDrift) {
+				t.Errorf("reconcile found drift %q, want %q", status.Drift, test.wantDrift)
+			}
+
+			if status.TaskStatus != test.wantStatus {
+				t.Errorf("task is %q, want %q", status.TaskStatus, test.wantStatus)
+			}
+
+			if got, _ := svc.GetWorkload(ctx, testContainerID); !reflect.DeepEqual(got, status) {
+				t.Errorf("node.GetWorkload returned %+v, want %+v", got, status)
+			}
+		})
+	}
+
+	t.Run("spec applied", func(t *testing.T) {
+		container, getErr := svc.GetContainer(ctx, testContainerID)
+
+		if getErr != nil {
+			t.Fatalf("node.GetContainer failed with error: %s", getErr.Error())
+		}
+
+		if spec, _ := container.Spec(ctx); !reflect.DeepEqual(spec.Env, changed.Spec.Env) {
+			t.Errorf("container env is %q, want %q", spec.Env, changed.Spec.Env)
+		}
+	})
+
+	t.Run("adopted after node restart", func(t *testing.T) {
+		// A new Node on the same backend stands in for a node restart.
+		restarted := node.NewNode(ctrd.backend, ctrd.logs, node.WithReconcileInterval(time.Hour))
+		defer restarted.Close()
+		statuses, getErr := restarted.GetWorkloads(ctx)
+
+		if getErr != nil {
+			t.Fatalf("node.GetWorkloads failed with error: %s", getErr.Error())
+		}
+
+		if len(statuses) != 1 || statuses[0].Workload.Name != testContainerID || statuses[0].Workload.State != node.WorkloadStopped {
+			t.Fatalf("restarted node has workloads %+v, want the stopped %s", statuses, testContainerID)
+		}
+
+		if status, _ := restarted.ApplyWorkload(ctx, stopped); !status.Synced || len(status.Drift) > 0 {
+			t.Errorf("reapplying the adopted workload found drift %q, error %q", status.Drift, status.Error)
+		}
+	})
+
+	t.Run("unmanaged container", func(t *testing.T) {
+		if _, createErr := ctrd.createContainer(ctx, testImage, "unmanaged"); createErr != nil {
+			t.Fatalf("failed to create container with error: %s", createErr.Error())
+		}
+
+		defer ctrd.deleteContainer(ctx, "unmanaged")
+		status, applyErr := svc.ApplyWorkload(ctx, node.Workload{Name: "unmanaged", Image: testImage})
+
+		if applyErr != nil {
+			t.Fatalf("node.ApplyWorkload failed with error: %s", applyErr.Error())
+		}
+
+		if status.Synced || status.Error == "" {
+			t.Errorf("workload is synced, want it to fail on the container it doesn't manage")
+		}
+
+		if deleteErr := svc.DeleteWorkload(ctx, "unmanaged"); deleteErr != nil {
+			t.Fatalf("node.DeleteWorkload failed with error: %s", deleteErr.Error())
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, w := range []node.Workload{
+			{Name: weirdString, Image: testImage},
+			{Name: "invalid", Image: weirdString},
+			{Name: "invalid", Image: testImage, State: "paused"},
+			{Name: "invalid", Image: testImage, Spec: node.ContainerSpec{Env: []string{"PORT"}}},
+		} {
+			if _, applyErr := svc.ApplyWorkload(ctx, w); !errors.Is(applyErr, errdefs.ErrInvalidArgument) {
+				t.Errorf("node.ApplyWorkload(%+v) returned %v, want invalid argument", w, applyErr)
+			}
+		}
+	})
+
+	t.Run("reconciled in the background", func(t *testing.T) {
+		looping := node.NewNode(ctrd.backend, ctrd.logs, node.WithReconcileInterval(10*time.Millisecond))
+		defer looping.Close()
+
+		if _, applyErr := looping.ApplyWorkload(ctx, changed); applyErr != nil {
+			t.Fatalf("node.ApplyWorkload failed with error: %s", applyErr.Error())
+		}
+
+		if killErr := ctrd.killTask(ctx, testContainerID, syscall.SIGKILL); killErr != nil {
+			t.Fatalf("failed to kill task with error: %s", killErr.Error())
+		}
+
+		if !waitRunning(ctx, ctrd, testContainerID, time.Second) {
+			t.Errorf("task wasn't started again")
+		}
+
+		if deleteErr := looping.DeleteWorkload(ctx, testContainerID); deleteErr != nil {
+			t.Fatalf("node.DeleteWorkload failed with error: %s", deleteErr.Error())
+		}
+	})
+
+	t.Run("slow pull", func(t *testing.T) {
+		ctrd := newCtrd(t)
+		defer ctrd.cleanup()
+		backend := &stalledPullBackend{Backend: ctrd.backend, ref: stopSignalImage, pulling: make(chan struct{}), release: make(chan struct{})}
+		svc := node.NewNode(backend, ctrd.logs, node.WithReconcileInterval(time.Hour))
+		defer svc.Close()
+		slowApplied := make(chan struct{})
+
+		go func() {
+			defer close(slowApplied)
+			svc.ApplyWorkload(ctx, node.Workload{Name: "slow", Image: stopSignalImage})
+		}()
+
+		<-backend.pulling
+		applied := make(chan error, 1)
+
+		go func() {
+			_, applyErr := svc.ApplyWorkload(ctx, web)
+			applied <- applyErr
+		}()
+
+		select {
+		case applyErr := <-applied:
+
+			if applyErr != nil {
+				t.Errorf("node.ApplyWorkload failed with error: %s", applyErr.Error())
+			}
+		case <-time.After(time.Second):
+			t.Errorf("workload wasn't applied while another workload's image was being pulled")
+		}
+
+		close(backend.release)
+		<-slowApplied
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		if deleteErr := svc.DeleteWorkload(ctx, testContainerID); deleteErr != nil {
+			t.Fatalf("node.DeleteWorkload failed with error: %s", deleteErr.Error())
+		}
+
+		if _, getErr := svc.GetContainer(ctx, testContainerID); !errors.Is(getErr, errdefs.ErrNotFound) {
+			t.Errorf("node.GetContainer returned %v after the workload was deleted, want not found", getErr)
+		}
+
+		if deleteErr := svc.DeleteWorkload(ctx, testContainerID); !errors.Is(deleteErr, errdefs.ErrNotFound) {
+			t.Errorf("node.DeleteWorkload returned %v for a deleted workload, want not found", deleteErr)
+		}
+	})
+}
+
+// stalledPullBackend holds pulls of ref until release is closed. pulling is closed once such a pull starts.
+type stalledPullBackend struct {
+	node.Backend
+	ref              string
+	pulling, release chan struct{}
+}
+
+func (b *stalledPullBackend) Pull(ctx context.Context, ref string, opts node.PullOptions) (node.Image, error) {
+	if ref == b.ref {
+		close(b.pulling)
+		<-b.release
+	}
+
+	return b.Backend.Pull(ctx, ref, opts)
+}
+
+func TestLoadManifest(t *testing.T) {
+	dir, tempDirErr := ioutil.TempDir("", "clamor-manifests")
+
+	if tempDirErr != nil {
+		t.Fatalf("failed to create manifest directory with error: %s", tempDirErr.Error())
+	}
+
+	defer os.RemoveAll(dir)
+
+	type test struct {
+		name     string
+		manifest string
+		want     node.Manifest
+		wantErr  bool
+	}
+
+	tests := []test{
+		{
+			name:     "default namespace",
+			manifest: `{"workloads": [{"name": "web", "image": "` + testImage + `", "spec": {"env": ["PORT=80"], "restart_policy": "always"}}]}`,
+			want: node.Manifest{Namespace: namespaces.Default, Workloads: []node.Workload{
+				{Name: "web", Image: testImage, Spec: node.ContainerSpec{Env: []string{"PORT=80"}, RestartPolicy: node.RestartAlways}},
+			}},
+		},
+		{
+			name:     "namespace",
+			manifest: `{"namespace": "` + testNamespace + `", "workloads": [{"name": "batch", "image": "` + testImage + `", "state": "stopped"}]}`,
+			want:     node.Manifest{Namespace: testNamespace, Workloads: []node.Workload{{Name: "batch", Image: testImage, State: node.WorkloadStopped}}},
+		},
+		{name: "malformed", manifest: `{"workloads": [`, wantErr: true},
+		{name: "invalid workload", manifest: `{"workloads": [{"name": "web", "image": "` + weirdString + `"}]}`, wantErr: true},
+		{name: "invalid namespace", manifest: `{"namespace": "` + weirdString + `"}`, wantErr: true},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, strconv.Itoa(i)+".json")
+
+			if writeErr := ioutil.WriteFile(path, []byte(test.manifest), 0644); writeErr != nil {
+				t.Fatalf("failed to write manifest with error: %s", writeErr.Error())
+			}
+
+			manifest, loadErr := node.LoadManifest(path)
+
+			if loadErr != nil && !test.wantErr {
+				t.Fatalf("node.LoadManifest failed with error: %s", loadErr.Error())
+			} else if loadErr == nil && test.wantErr {
+				t.Fatalf("node.LoadManifest succeeded, want error")
+			}
+
+			if !test.wantErr && !reflect.DeepEqual(manifest, test.want) {
+				t.Errorf("node.LoadManifest returned %+v, want %+v", manifest, test.want)
+			}
+		})
+	}
+
+	if _, loadErr := node.LoadManifest(filepath.Join(dir, "missing.json")); loadErr == nil {
+		t.Errorf("node.LoadManifest succeeded for a missing file, want error")
+	}
+}
+
+// workloadRecorder is a node.WorkloadService that records the workloads applied and deleted through it.
+type workloadRecorder struct {
+	applied, deleted []string
+	// rejected names the workloads ApplyWorkload fails for.
+	rejected map[string]bool
+}
+
+func (r *workloadRecorder) ApplyWorkload(ctx context.Context, w node.Workload) (status node.WorkloadStatus, err error) {
+	if r.rejected[w.Name] {
+		return node.WorkloadStatus{}, errdefs.ErrUnavailable
+	}
+
+	ns, _ := namespaces.Namespace(ctx)
+	r.applied = append(r.applied, ns+"/"+w.Name)
+
+	return node.WorkloadStatus{Workload: w, Synced: true}, nil
+}
+
+func (r *workloadRecorder) GetWorkload(ctx context.Context, name string) (status node.WorkloadStatus, err error) {
+	return node.WorkloadStatus{}, errdefs.ErrNotFound
+}
+
+func (r *workloadRecorder) GetWorkloads(ctx context.Context) (statuses []node.WorkloadStatus, err error) {
+	return nil, nil
+}
+
+func (r *workloadRecorder) DeleteWorkload(ctx context.Context, name string) (err error) {
+	ns, _ := namespaces.Namespace(ctx)
+	r.deleted = append(r.deleted, ns+"/"+name)
+
+	return nil
+}
+
+func TestManifestWatcher(t *testing.T) {
+	dir, tempDirErr := ioutil.TempDir("", "clamor-manifests")
+
+	if tempDirErr != nil {
+		t.Fatalf("failed to create manifest directory with error: %s", tempDirErr.Error())
+	}
+
+	defer os.RemoveAll(dir)
+
+	svc := &workloadRecorder{rejected: map[string]bool{"rejected": true}}
+	watcher := node.NewManifestWatcher(svc, dir, time.Hour)
+
+	write := func(name, manifest string) func(t *testing.T) {
+		return func(t *testing.T) {
+			if writeErr := ioutil.WriteFile(filepath.Join(dir, name), []byte(manifest), 0644); writeErr != nil {
+				t.Fatalf("failed to write manifest with error: %s", writeErr.Error())
+			}
+		}
+	}
+
+	remove := func(name string) func(t *testing.T) {
+		return func(t *testing.T) {
+			if removeErr := os.Remove(filepath.Join(dir, name)); removeErr != nil {
+				t.Fatalf("failed to remove manifest with error: %s", removeErr.Error())
+			}
+		}
+	}
+
+	type test struct {
+		name string
+		// change edits the directory before it's synced.
+		change                   func(t *testing.T)
+		wantApplied, wantDeleted []string
+		wantErr                  bool
+	}
+
+	tests := []test{
+		{name: "empty"},
+		{
+			name:        "added",
+			change:      write("base.json", `{"workloads": [{"name": "shipper", "image": "`+testImage+`"}, {"name": "agent", "image": "`+testImage+`"}]}`),
+			wantApplied: []string{"default/shipper", "default/agent"},
+		},
+		{name: "unchanged"},
+		{
+			name:        "changed",
+			change:      write("base.json", `{"workloads": [{"name": "shipper", "image": "`+testImage+`", "spec": {"env": ["LEVEL=debug"]}}]}`),
+			wantApplied: []string{"default/shipper"},
+			wantDeleted: []string{"default/agent"},
+		},
+		{
+			name: "other namespace",
+			change: func(t *testing.T) {
+				write("monitoring.json", `{"namespace": "`+testNamespace+`", "workloads": [{"name": "agent", "image": "`+testImage+`"}]}`)(t)
+				write(".hidden.json", `{"workloads": [{"name": "hidden", "image": "`+testImage+`"}]}`)(t)
+				write("notes.txt", `{"workloads": [{"name": "notes", "image": "`+testImage+`"}]}`)(t)
+			},
+			wantApplied: []string{testNamespace + "/agent"},
+		},
+		{name: "malformed", change: write("broken.json", `{"workloads": [`), wantErr: true},
+		{
+			name:        "fixed",
+			change:      write("broken.json", `{"workloads": [{"name": "fixed", "image": "`+testImage+`"}]}`),
+			wantApplied: []string{"default/fixed"},
+		},
+		{name: "invalid change keeps workloads", change: write("broken.json", `{"workloads": [{"name": "fixed", "image": "`+weirdString+`"}]}`), wantErr: true},
+		{name: "rejected", change: write("rejected.json", `{"workloads": [{"name": "rejected", "image": "`+testImage+`"}]}`), wantErr: true},
+		{name: "rejected again", wantErr: true},
+		{
+			name:        "partly rejected",
+			change:      write("partial.json", `{"workloads": [{"name": "partial", "image": "`+testImage+`"}, {"name": "rejected", "image": "`+testImage+`"}]}`),
+			wantApplied: []string{"default/partial"},
+			wantErr:     true,
+		},
+		{name: "partly rejected removed", change: remove("partial.json"), wantDeleted: []string{"default/partial"}, wantErr: true},
+		{name: "removed", change: remove("base.json"), wantDeleted: []string{"default/shipper"}, wantErr: true},
+		{
+			name: "all removed",
+			change: func(t *testing.T) {
+				remove("monitoring.json")(t)
+				remove("broken.json")(t)
+				remove("rejected.json")(t)
+			},
+			wantDeleted: []string{testNamespace + "/agent", "default/fixed"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			svc.applied, svc.deleted = nil, nil
+
+			if test.change != nil {
+				test.change(t)
+			}
+
+			syncErr := watcher.Sync()
+
+			if syncErr != nil && !test.wantErr {
+				t.Fatalf("ManifestWatcher.Sync failed with error: %s", syncErr.Error())
+			} else if syncErr == nil && test.wantErr {
+				t.Fatalf("ManifestWatcher.Sync succeeded, want error")
+			}
+
+			sort.Strings(svc.deleted)
+
+			if !reflect.DeepEqual(svc.applied, test.wantApplied) {
+				t.Errorf("ManifestWatcher.Sync applied %q, want %q", svc.applied, test.wantApplied)
+			}
+
+			if !reflect.DeepEqual(svc.deleted, test.wantDeleted) {
+				t.Errorf("ManifestWatcher.Sync deleted %q, want %q", svc.deleted, test.wantDeleted)
+			}
+		})
+	}
+
+	if syncErr := node.NewManifestWatcher(svc, filepath.Join(dir, "missing"), 0).Sync(); syncErr == nil {
+		t.Errorf("ManifestWatcher.Sync succeeded for a missing directory, want error")
+	}
+}
+
+func TestPods(t *testing.T) {
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	svc := node.NewNode(ctrd.backend, ctrd.logs)
+	defer svc.Close()
+	ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
+	podID := "web"
+	spec := node.PodSpec{
+		SandboxImage: testImage,
+		Members: []node.PodMember{
+			{Name: "sidecar", Image: testImage},
+			{Name: "app", Image: stopSignalImage, Spec: node.ContainerSpec{Env: []string{"PORT=80"}, Labels: map[string]string{node.PodLabel: "other"}}},
+		},
+	}
+
+	if _, pullErr := ctrd.pullImage(ctx, stopSignalImage); pullErr != nil {
+		t.Fatalf("failed to pull image with error: %s", pullErr.Error())
+	}
+
+	t.Run("create", func(t *testing.T) {
+		pod, createErr := svc.CreatePod(ctx, podID, spec)
+
+		if createErr != nil {
+			t.Fatalf("node.CreatePod failed with error: %s", createErr.Error())
+		}
+
+		if pod.ID != podID || pod.Sandbox.ID() != podID || pod.Status != node.PodRunning {
+			t.Fatalf("node.CreatePod returned pod %s with sandbox %s and status %s, want %s with its own sandbox and running", pod.ID, pod.Sandbox.ID(), pod.Status, podID)
+		}
+
+		wantMembers := []string{node.PodContainerID(podID, "app"), node.PodContainerID(podID, "sidecar")}
+
+		if len(pod.Members) != len(wantMembers) {
+			t.Fatalf("pod has %d members, want %d", len(pod.Members), len(wantMembers))
+		}
+
+		for i, m := range pod.Members {
+
+			if m.ID() != wantMembers[i] {
+				t.Errorf("member %d is %s, want %s", i, m.ID(), wantMembers[i])
+			}
+
+			memberSpec, _ := m.Spec(ctx)
+			labels, _ := m.Labels(ctx)
+
+			if memberSpec.Sandbox != podID || labels[node.PodLabel] != podID || labels[node.PodMemberLabel] == "" {
+				t.Errorf("member %s has sandbox %q and labels %v, want it tied to pod %s", m.ID(), memberSpec.Sandbox, labels, podID)
+			}
+
+			if task, taskErr := m.Task(ctx, nil); taskErr != nil || node.TaskStatus(ctx, task) != "running" {
+				t.Errorf("task of member %s isn't running", m.ID())
+			}
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		pods, getErr := svc.GetPods(ctx)
+
+		if getErr != nil {
+			t.Fatalf("node.GetPods failed with error: %s", getErr.Error())
+		}
+
+		if len(pods) != 1 || pods[0].ID != podID || len(pods[0].Members) != 2 {
+			t.Errorf("node.GetPods returned %+v, want pod %s with 2 members", pods, podID)
+		}
+	})
+
+	t.Run("member exited", func(t *testing.T) {
+		member := node.PodContainerID(podID, "sidecar")
+
+		if killErr := ctrd.killTask(ctx, member, syscall.SIGKILL); killErr != nil {
+			t.Fatalf("failed to kill task with error: %s", killErr.Error())
+		}
+
+		if pod, _ := svc.GetPod(ctx, podID); pod.Status != node.PodDegraded {
+			t.Errorf("pod is %s, want %s", pod.Status, node.PodDegraded)
+		}
+
+		ctrd.deleteTask(ctx, member)
+
+		if _, createErr := svc.CreateTask(ctx, member); createErr != nil {
+			t.Fatalf("node.CreateTask failed with error: %s", createErr.Error())
+		}
+
+		if pod, _ := svc.GetPod(ctx, podID); pod.Status != node.PodRunning {
+			t.Errorf("pod is %s after the member was started again, want %s", pod.Status, node.PodRunning)
+		}
+	})
+
+	t.Run("sandbox exited", func(t *testing.T) {
+		member := node.PodContainerID(podID, "sidecar")
+
+		if killErr := ctrd.killTask(ctx, podID, syscall.SIGKILL); killErr != nil {
+			t.Fatalf("failed to kill task with error: %s", killErr.Error())
+		}
+
+		ctrd.killTask(ctx, member, syscall.SIGKILL)
+		ctrd.deleteTask(ctx, member)
+
+		if _, createErr := svc.CreateTask(ctx, member); !errors.Is(createErr, errdefs.ErrFailedPrecondition) {
+			t.Errorf("node.CreateTask returned %v while the sandbox is stopped, want failed precondition", createErr)
+		}
+	})
+
+	t.Run("not a pod", func(t *testing.T) {
+		if _, getErr := svc.GetPod(ctx, node.PodContainerID(podID, "app")); !errors.Is(getErr, errdefs.ErrNotFound) {
+			t.Errorf("node.GetPod returned %v for a member, want not found", getErr)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		if deleteErr := svc.DeletePod(ctx, podID); deleteErr != nil {
+			t.Fatalf("node.DeletePod failed with error: %s", deleteErr.Error())
+		}
+
+		for _, id := range []string{podID, node.PodContainerID(podID, "app"), node.PodContainerID(podID, "sidecar")} {
+
+			if _, getErr := svc.GetContainer(ctx, id); !errors.Is(getErr, errdefs.ErrNotFound) {
+				t.Errorf("node.GetContainer returned %v for %s after the pod was deleted, want not found", getErr, id)
+			}
+		}
+
+		if deleteErr := svc.DeletePod(ctx, podID); !errors.Is(deleteErr, errdefs.ErrNotFound) {
+			t.Errorf("node.DeletePod returned %v for a deleted pod, want not found", deleteErr)
+		}
+	})
+
+	t.Run("rolled back", func(t *testing.T) {
+		missing := node.PodSpec{SandboxImage: testImage, Members: []node.PodMember{{Name: "app", Image: "docker.io/library/missing:latest"}}}
+
+		if _, createErr := svc.CreatePod(ctx, podID, missing); createErr == nil {
+			t.Fatalf("node.CreatePod succeeded with a missing member image, want error")
+		}
+
+		if _, getErr := svc.GetContainer(ctx, podID); !errors.Is(getErr, errdefs.ErrNotFound) {
+			t.Errorf("node.GetContainer returned %v for the sandbox of a failed pod, want not found", getErr)
+		}
+	})
+
+	t.Run("configured sandbox image", func(t *testing.T) {
+		configured := node.NewNode(ctrd.backend, ctrd.logs, node.WithSandboxImage(stopSignalImage))
+		defer configured.Close()
+		pod, createErr := configured.CreatePod(ctx, "configured", node.PodSpec{Members: []node.PodMember{{Name: "app", Image: testImage}}})
+
+		if createErr != nil {
+			t.Fatalf("node.CreatePod failed with error: %s", createErr.Error())
+		}
+
+		defer configured.DeletePod(ctx, "configured")
+
+		image, imageErr := pod.Sandbox.Image(ctx)
+
+		if imageErr != nil {
+			t.Fatalf("failed to get sandbox image with error: %s", imageErr.Error())
+		}
+
+		if image.Name() != stopSignalImage {
+			t.Errorf("sandbox runs image %s, want %s", image.Name(), stopSignalImage)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		member := node.PodMember{Name: "app", Image: testImage}
+
+		for _, s := range []node.PodSpec{
+			{},
+			{SandboxImage: weirdString, Members: []node.PodMember{member}},
+			{Members: []node.PodMember{{Name: weirdString, Image: testImage}}},
+			{Members: []node.PodMember{member, member}},
+			{Members: []node.PodMember{{Name: "app", Image: testImage, Spec: node.ContainerSpec{Sandbox: podID}}}},
+			{Members: []node.PodMember{{Name: "app", Image: testImage, Spec: node.ContainerSpec{Env: []string{"PORT"}}}}},
+		} {
+			if _, createErr := svc.CreatePod(ctx, podID, s); !errors.Is(createErr, errdefs.ErrInvalidArgument) {
+				t.Errorf("node.CreatePod(%+v) returned %v, want invalid argument", s, createErr)
+			}
+		}
+
+		if _, createErr := svc.CreatePod(ctx, weirdString, node.PodSpec{Members: []node.PodMember{member}}); !errors.Is(createErr, errdefs.ErrInvalidArgument) {
+			t.Errorf("node.CreatePod returned %v for a malformed ID, want invalid argument", createErr)
+		}
+	})
+}
+
+func TestOperations(t *testing.T) {
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	svc := node.NewNode(ctrd.backend, ctrd.logs)
+	defer svc.Close()
+	ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
+
+	// wait polls the operation with the given ID until it's over.
+	wait := func(id string) node.Operation {
+		deadline := time.Now().Add(5 * time.Second)
+
+		for {
+			op, getErr := svc.GetOperation(ctx, id)
+
+			if getErr != nil {
+				t.Fatalf("node.GetOperation failed with error: %s", getErr.Error())
+			}
+
+			if op.Finished() || time.Now().After(deadline) {
+				return op
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	pull, pullErr := svc.PullImageAsync(ctx, testImage)
+
+	if pullErr != nil {
+		t.Fatalf("node.PullImageAsync failed with error: %s", pullErr.Error())
+	}
+
+	if pulled := wait(pull.ID); pulled.Status != node.OperationSucceeded || pulled.FinishedAt.IsZero() {
+		t.Errorf("node.PullImageAsync ended with %+v, want it succeeded", pulled)
+	} else if image, isImage := pulled.Result.(node.Image); !isImage || image.Name() != testImage {
+		t.Errorf("node.PullImageAsync resulted in %v, want image %s", pulled.Result, testImage)
+	}
+
+	missing, pullErr := svc.PullImageAsync(ctx, "docker.io/library/missing:latest")
+
+	if pullErr != nil {
+		t.Fatalf("node.PullImageAsync failed with error: %s", pullErr.Error())
+	}
+
+	if failed := wait(missing.ID); failed.Status != node.OperationFailed || failed.Error == "" {
+		t.Errorf("node.PullImageAsync of a missing image ended with %+v, want it failed", failed)
+	}
+
+	if _, createErr := ctrd.createContainer(ctx, stubbornImage, testContainerID); createErr != nil {
+		t.Fatalf("failed to create seed container with error: %s", createErr.Error())
+	}
+
+	defer ctrd.deleteContainer(ctx, testContainerID)
+
+	if _, createTaskErr := ctrd.createTask(ctx, testContainerID); createTaskErr != nil {
+		t.Fatalf("failed to create seed task with error: %s", createTaskErr.Error())
+	}
+
+	defer ctrd.deleteTask(ctx, testContainerID)
+	defer ctrd.killTask(ctx, testContainerID, syscall.SIGKILL)
+
+	// The stubborn task outlasts the kill's timeout, so the operation runs until it's cancelled.
+	kill, killErr := svc.KillTaskAsync(ctx, testContainerID, "", time.Minute)
+
+	if killErr != nil {
+		t.Fatalf("node.KillTaskAsync failed with error: %s", killErr.Error())
+	}
+
+	if running, getErr := svc.GetOperation(ctx, kill.ID); getErr != nil || running.Status != node.OperationRunning {
+		t.Errorf("node.GetOperation of a stubborn kill returned %+v, %v, want it running", running, getErr)
+	}
+
+	cancelled, cancelErr := svc.CancelOperation(ctx, kill.ID)
+
+	if cancelErr != nil {
+		t.Fatalf("node.CancelOperation failed with error: %s", cancelErr.Error())
+	}
+
+	if cancelled.Status != node.OperationCancelled || cancelled.FinishedAt.IsZero() {
+		t.Errorf("node.CancelOperation returned %+v, want it cancelled", cancelled)
+	}
+
+	if task, _ := ctrd.getTask(ctx, testContainerID); node.TaskStatus(ctx, task) != "running" && node.TaskStatus(ctx, task) != "created" {
+		t.Errorf("cancelled kill stopped the task, want it left running")
+	}
+
+	ops, listErr := svc.GetOperations(ctx)
+
+	if listErr != nil {
+		t.Fatalf("node.GetOperations failed with error: %s", listErr.Error())
+	}
+
+	var ids []string
+
+	for _, op := range ops {
+		ids = append(ids, op.ID)
+	}
+
+	if wantIDs := []string{pull.ID, missing.ID, kill.ID}; !reflect.DeepEqual(ids, wantIDs) {
+		t.Errorf("node.GetOperations returned %v, want %v", ids, wantIDs)
+	}
+
+	if _, getErr := svc.GetOperation(ctx, "op-unknown"); !errors.As(getErr, &node.ErrNotFound{}) {
+		t.Errorf("node.GetOperation of an unknown ID returned %v, want node.ErrNotFound", getErr)
+	}
+
+	if _, getErr := svc.GetOperation(namespaces.WithNamespace(context.TODO(), "other"), pull.ID); !errors.As(getErr, &node.ErrNotFound{}) {
+		t.Errorf("node.GetOperation from another namespace returned %v, want node.ErrNotFound", getErr)
+	}
+
+	if _, startErr := svc.PullImageAsync(namespaces.WithNamespace(context.TODO(), ""), testImage); startErr == nil {
+		t.Errorf("node.PullImageAsync without a namespace succeeded, want error")
+	}
+}
+
+func TestExecTask(t *testing.T) {
+	type testArguments struct {
+		namespace, containerID string
+		spec                   node.ProcessSpec
+		stdin                  string
+	}
+
+	type test struct {
+		name         string
+		args         testArguments
+		wantStdout   string
+		wantExitCode uint32
+		wantErr      bool
+	}
+
+	tests := []test{
+		{name: "empty namespace", args: testArguments{namespace: "", containerID: testContainerID, spec: node.ProcessSpec{Args: []string{"true"}}}, wantErr: true},
+		{name: "weird container ID", args: testArguments{namespace: testNamespace, containerID: weirdString, spec: node.ProcessSpec{Args: []string{"true"}}}, wantErr: true},
+		{name: "missing args", args: testArguments{namespace: testNamespace, containerID: testContainerID}, wantErr: true},
+		{name: "weird env", args: testArguments{namespace: testNamespace, containerID: testContainerID, spec: node.ProcessSpec{Args: []string{"true"}, Env: []string{weirdString}}}, wantErr: true},
+		{name: "weird user", args: testArguments{namespace: testNamespace, containerID: testContainerID, spec: node.ProcessSpec{Args: []string{"true"}, User: "nobody:nogroup:extra"}}, wantErr: true},
+		{name: "named user", args: testArguments{namespace: testNamespace, containerID: testContainerID, spec: node.ProcessSpec{Args: []string{"true"}, User: "nobody"}}},
+		{name: "unknown command", args: testArguments{namespace: testNamespace, containerID: testContainerID, spec: node.ProcessSpec{Args: []string{"/bin/nope"}}}, wantErr: true},
+		{name: "not running", args: testArguments{namespace: testNamespace, containerID: "created", spec: node.ProcessSpec{Args: []string{"true"}}}, wantErr: true},
+		{name: "echo", args: testArguments{namespace: testNamespace, containerID: testContainerID, spec: node.ProcessSpec{Args: []string{"/bin/echo", "hello", "world"}}}, wantStdout: "hello world\n"},
+		{name: "cat", args: testArguments{namespace: testNamespace, containerID: testContainerID, spec: node.ProcessSpec{Args: []string{"cat"}, User: "1000:1000"}, stdin: "meow"}, wantStdout: "meow"},
+		{name: "false", args: testArguments{namespace: testNamespace, containerID: testContainerID, spec: node.ProcessSpec{Args: []string{"false"}}}, wantExitCode: 1},
+	}
+
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	svc := node.NewNode(ctrd.backend, ctrd.logs)
+	defer svc.Close()
+	ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
+
+	for _, id := range []string{testContainerID, "created"} {
+
+		if _, createErr := ctrd.createContainer(ctx, testImage, id); createErr != nil {
+			t.Fatalf("failed to create seed container with error: %s", createErr.Error())
+		}
+
+		defer ctrd.deleteContainer(ctx, id)
+	}
+
+	if _, createTaskErr := svc.CreateTask(ctx, testContainerID); createTaskErr != nil {
+		t.Fatalf("node.CreateTask failed with error: %s", createTaskErr.Error())
+	}
+
+	if _, createTaskErr := ctrd.createTask(ctx, "created"); createTaskErr != nil {
+		t.Fatalf("failed to create seed task with error: %s", createTaskErr.Error())
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			ctx := namespaces.WithNamespace(context.TODO(), test.args.namespace)
+			exitStatus, err := svc.ExecTask(ctx, test.args.containerID, test.args.spec, node.TaskIO{Stdin: strings.NewReader(test.args.stdin), Stdout: &stdout})
+
+			if err != nil && !test.wantErr {
+				t.Fatalf("node.ExecTask failed with error: %s", err.Error())
+			} else if err == nil && test.wantErr {
+				t.Fatalf("node.ExecTask succeeded, want error")
+			}
+
+			if test.wantErr {
+				return
+			}
+
+			if exitStatus.ExitCode() != test.wantExitCode {
+				t.Errorf("node.ExecTask exited with code %d, want %d", exitStatus.ExitCode(), test.wantExitCode)
+			}
+
+			if stdout.String() != test.wantStdout {
+				t.Errorf("node.ExecTask wrote %q, want %q", stdout.String(), test.wantStdout)
+			}
+		})
+	}
+
+	t.Run("cancel", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		stdin, stdinWriter := io.Pipe()
+		defer stdinWriter.Close()
+
+		if _, err := svc.ExecTask(ctx, testContainerID, node.ProcessSpec{Args: []string{"cat"}}, node.TaskIO{Stdin: stdin}); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("node.ExecTask returned %v, want %s", err, context.DeadlineExceeded)
+		}
+
+		task, _ := ctrd.getTask(ctx, testContainerID)
+
+		if pids, _ := task.Pids(namespaces.WithNamespace(context.TODO(), testNamespace)); len(pids) != 1 {
+			t.Errorf("task has %d processes after the exec was cancelled, want 1", len(pids))
+		}
+	})
+}
+
+func TestAttachTask(t *testing.T) {
+	type testArguments struct {
+		namespace, containerID string
+	}
+
+	type test struct {
+		name string
+		args testArguments
+	}
+
+	tests := []test{
+		{name: "empty namespace", args: testArguments{namespace: "", containerID: testContainerID}},
+		{name: "weird container ID", args: testArguments{namespace: testNamespace, containerID: weirdString}},
+		{name: "no task", args: testArguments{namespace: testNamespace, containerID: "idle"}},
+	}
+
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	svc := node.NewNode(ctrd.backend, ctrd.logs)
+	defer svc.Close()
+	ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
+
+	for _, id := range []string{testContainerID, "idle"} {
+
+		if _, createErr := ctrd.createContainer(ctx, testImage, id); createErr != nil {
+			t.Fatalf("failed to create seed container with error: %s", createErr.Error())
+		}
+
+		defer ctrd.deleteContainer(ctx, id)
+	}
+
+	// The task is started behind the node's back, like one left running by a previous node process.
+	seedTask, createTaskErr := ctrd.createTask(ctx, testContainerID)
+
+	if createTaskErr != nil {
+		t.Fatalf("failed to create seed task with error: %s", createTaskErr.Error())
+	}
+
+	if startErr := seedTask.(node.RuntimeTask).Start(ctx); startErr != nil {
+		t.Fatalf("failed to start seed task with error: %s", startErr.Error())
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			ctx := namespaces.WithNamespace(context.TODO(), test.args.namespace)
+
+			if _, err := svc.AttachTask(ctx, test.args.containerID, node.TaskIO{}); err == nil {
+				t.Errorf("node.AttachTask succeeded, want error")
+			}
+		})
+	}
+
+	t.Run("reconnect and detach", func(t *testing.T) {
+		lines := make(lineWriter, 16)
+		attachCtx, detach := context.WithCancel(ctx)
+		attached := make(chan error, 1)
+
+		go func() {
+			_, err := svc.AttachTask(attachCtx, testContainerID, node.TaskIO{Stdout: lines})
+			attached <- err
+		}()
+
+		// Output written before the session is attached isn't copied to it, so it's repeated until the session sees it.
+		timeout := time.After(5 * time.Second)
+
+		for received := false; !received; {
+
+			if taskIO, _ := ctrd.backend.TaskIO(ctx, testContainerID); taskIO.Stdout != nil {
+				fmt.Fprintln(taskIO.Stdout, "hello")
+			}
+
+			select {
+			case line := <-lines:
+				received = line == "hello\n"
+			case <-time.After(10 * time.Millisecond):
+			case <-timeout:
+				t.Fatalf("timed out waiting for the task's output")
+			}
+		}
+
+		detach()
+
+		if err := <-attached; !errors.Is(err, context.Canceled) {
+			t.Errorf("node.AttachTask returned %v after detaching, want %s", err, context.Canceled)
+		}
+
+		if task, _ := svc.GetTask(ctx, testContainerID); node.TaskStatus(ctx, task) != "running" {
+			t.Errorf("task is %q after detaching, want running", node.TaskStatus(ctx, task))
+		}
+
+		if records, _ := svc.GetLogs(ctx, testContainerID, node.LogOptions{Tail: 1}); len(records) != 1 || records[0].Line != "hello" {
+			t.Errorf("reconnected task logged %v, want hello", records)
+		}
+	})
+
+	t.Run("exit", func(t *testing.T) {
+		attached := make(chan node.ExitStatus, 1)
+
+		go func() {
+			exitStatus, _ := svc.AttachTask(ctx, testContainerID, node.TaskIO{})
+			attached <- exitStatus
+		}()
+
+		ctrd.killTask(ctx, testContainerID, syscall.SIGKILL)
+
+		select {
+		case exitStatus := <-attached:
+			if exitStatus.ExitCode() != 128+uint32(syscall.SIGKILL) {
+				t.Errorf("node.AttachTask returned exit code %d, want %d", exitStatus.ExitCode(), 128+uint32(syscall.SIGKILL))
+			}
+		case <-time.After(5 * time.Second):
+			t.Errorf("node.AttachTask didn't return after the task exited")
+		}
+	})
+}
+
+func TestPauseResumeTask(t *testing.T) {
+	type testArguments struct {
+		namespace, containerID string
+		resume                 bool
+	}
+
+	type test struct {
+		name       string
+		args       testArguments
+		wantStatus string
+		wantErr    bool
+	}
+
+	tests := []test{
+		{name: "empty namespace", args: testArguments{namespace: "", containerID: testContainerID}, wantErr: true},
+		{name: "weird container ID", args: testArguments{namespace: testNamespace, containerID: weirdString}, wantErr: true},
+		{name: "resume running", args: testArguments{namespace: testNamespace, containerID: testContainerID, resume: true}, wantErr: true},
+		{name: "pause", args: testArguments{namespace: testNamespace, containerID: testContainerID}, wantStatus: "paused"},
+		{name: "pause paused", args: testArguments{namespace: testNamespace, containerID: testContainerID}, wantErr: true},
+		{name: "resume", args: testArguments{namespace: testNamespace, containerID: testContainerID, resume: true}, wantStatus: "running"},
+	}
+
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	svc := node.NewNode(ctrd.backend, ctrd.logs)
+	defer svc.Close()
+	ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
+
+	if _, createErr := ctrd.createContainer(ctx, testImage, testContainerID); createErr != nil {
+		t.Fatalf("failed to create seed container with error: %s", createErr.Error())
+	}
+
+	defer ctrd.deleteContainer(ctx, testContainerID)
+
+	if _, createTaskErr := svc.CreateTask(ctx, testContainerID); createTaskErr != nil {
+		t.Fatalf("node.CreateTask failed with error: %s", createTaskErr.Error())
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				task node.Task
+				err  error
+				ctx  = namespaces.WithNamespace(context.TODO(), test.args.namespace)
+			)
+
+			if test.args.resume {
+				task, err = svc.ResumeTask(ctx, test.args.containerID)
+			} else {
+				task, err = svc.PauseTask(ctx, test.args.containerID)
+			}
+
+			if err != nil && !test.wantErr {
+				t.Fatalf("pausing or resuming failed with error: %s", err.Error())
+			} else if err == nil && test.wantErr {
+				t.Fatalf("pausing or resuming succeeded, want error")
+			}
+
+			if !test.wantErr && node.TaskStatus(ctx, task) != test.wantStatus {
+				t.Errorf("task is %q, want %q", node.TaskStatus(ctx, task), test.wantStatus)
+			}
+		})
+	}
+
+	t.Run("exec in paused task", func(t *testing.T) {
+		svc.PauseTask(ctx, testContainerID)
+		defer svc.ResumeTask(ctx, testContainerID)
+
+		if _, err := svc.ExecTask(ctx, testContainerID, node.ProcessSpec{Args: []string{"true"}}, node.TaskIO{}); err == nil {
+			t.Errorf("node.ExecTask succeeded in a paused task, want error")
+		}
+	})
+
+	t.Run("kill paused task", func(t *testing.T) {
+		svc.PauseTask(ctx, testContainerID)
+
+		if err := svc.KillTask(ctx, testContainerID, "", time.Second); err != nil {
+			t.Errorf("node.KillTask failed on a paused task with error: %s", err.Error())
+		}
+	})
 }
 
-func TestCreateTask(t *testing.T) {
-	type testArguments struct {
-		namespace, containerID string
-	}
+func TestTaskMetrics(t *testing.T) {
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	svc := node.NewNode(ctrd.backend, ctrd.logs)
+	defer svc.Close()
+	ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
 
-	type test struct {
-		name             string
-		args             testArguments
-		wantErr, wantImg bool
+	if _, createErr := ctrd.createContainer(ctx, testImage, testContainerID); createErr != nil {
+		t.Fatalf("failed to create seed container with error: %s", createErr.Error())
 	}
 
-	tests := []test{
-		{name: "empty namespace", args: testArguments{namespace: "", containerID: testContainerID}, wantErr: true},
-		{name: "weird namespace", args: testArguments{namespace: weirdString, containerID: testContainerID}, wantErr: true},
-		{name: "empty container ID", args: testArguments{namespace: testNamespace, containerID: ""}, wantErr: true},
-		{name: "weird container ID", args: testArguments{namespace: testNamespace, containerID: weirdString}, wantErr: true},
-		{name: "valid namespace valid container ID", args: testArguments{namespace: testNamespace, containerID: testContainerID}, wantErr: false},
+	defer ctrd.deleteContainer(ctx, testContainerID)
+
+	if _, updateErr := svc.UpdateContainerResources(ctx, testContainerID, node.Resources{MemoryLimit: 1 << 30, PidsLimit: 64}); updateErr != nil {
+		t.Fatalf("node.UpdateContainerResources failed with error: %s", updateErr.Error())
 	}
 
-	ctrd, ctrdErr := newCtrd(containerdSock)
-	defer ctrd.client.Close()
+	task, createTaskErr := svc.CreateTask(ctx, testContainerID)
 
-	if ctrdErr != nil {
-		t.Errorf("failed to create containerd client with error: %s", ctrdErr.Error())
+	if createTaskErr != nil {
+		t.Fatalf("node.CreateTask failed with error: %s", createTaskErr.Error())
 	}
 
-	node := node.NewNode(ctrd.client)
-
-	ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
+	time.Sleep(10 * time.Millisecond)
+	metrics, metricsErr := task.Metrics(ctx)
 
-	_, pullErr := ctrd.pullImage(ctx, testImage)
+	if metricsErr != nil {
+		t.Fatalf("task.Metrics failed with error: %s", metricsErr.Error())
+	}
 
-	if pullErr != nil {
-		t.Errorf("failed to pull seed image with error: %s", pullErr.Error())
+	if metrics.CPUUsage == 0 || metrics.MemoryLimit != 1<<30 || metrics.PidsCurrent != 1 || metrics.PidsLimit != 64 {
+		t.Errorf("task.Metrics returned %+v, want CPU usage, a 1GiB memory limit and 1 of 64 pids", metrics)
 	}
 
-	defer ctrd.deleteImage(ctx, testImage)
+	t.Run("paused", func(t *testing.T) {
+		svc.PauseTask(ctx, testContainerID)
+		defer svc.ResumeTask(ctx, testContainerID)
 
-	_, createContainerErr := ctrd.createContainer(namespaces.WithNamespace(context.TODO(), testNamespace), testImage, testContainerID)
+		before, _ := task.Metrics(ctx)
+		time.Sleep(10 * time.Millisecond)
+		after, _ := task.Metrics(ctx)
 
-	if createContainerErr != nil {
-		t.Errorf("failed to create seed container with error: %s", createContainerErr.Error())
-	}
+		if after.CPUUsage != before.CPUUsage {
+			t.Errorf("paused task used %dns of CPU, want none", after.CPUUsage-before.CPUUsage)
+		}
+	})
 
-	defer ctrd.deleteContainer(ctx, testContainerID)
+	t.Run("stopped", func(t *testing.T) {
+		if err := svc.KillTask(ctx, testContainerID, "SIGKILL", 0); err != nil {
+			t.Fatalf("node.KillTask failed with error: %s", err.Error())
+		}
 
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			ctx := namespaces.WithNamespace(context.TODO(), test.args.namespace)
+		if _, err := task.Metrics(ctx); err == nil {
+			t.Errorf("task.Metrics succeeded on a stopped task, want error")
+		}
+	})
 
-			if tsk, err := node.CreateTask(ctx, test.args.containerID); err != nil && !test.wantErr {
-				t.Errorf("node.CreateTask failed with error: %s", err.Error())
-			} else if tsk != nil {
-				ctrd.killTask(ctx, tsk.ID())
-				ctrd.deleteTask(ctx, tsk.ID())
-			}
-		})
-	}
+	svc.DeleteTask(ctx, testContainerID)
 }
 
-func TestKillTask(t *testing.T) {
+func TestEvents(t *testing.T) {
 	type testArguments struct {
-		namespace, containerID string
+		namespace string
+		opts      node.EventOptions
 	}
 
 	type test struct {
-		name             string
-		args             testArguments
-		wantErr, wantImg bool
+		name       string
+		args       testArguments
+		wantTopics []string
+		wantErr    bool
 	}
 
-	tests := []test{
-		{name: "empty namespace", args: testArguments{namespace: "", containerID: testContainerID}, wantErr: true},
-		{name: "weird namespace", args: testArguments{namespace: weirdString, containerID: testContainerID}, wantErr: true},
-		{name: "empty container ID", args: testArguments{namespace: testNamespace, containerID: ""}, wantErr: true},
-		{name: "weird container ID", args: testArguments{namespace: testNamespace, containerID: weirdString}, wantErr: true},
-		{name: "valid namespace valid container ID", args: testArguments{namespace: testNamespace, containerID: testContainerID}, wantErr: false},
-	}
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	svc := node.NewNode(ctrd.backend, ctrd.logs)
+	defer svc.Close()
+	ctx, cancel := context.WithCancel(namespaces.WithNamespace(context.TODO(), testNamespace))
+	defer cancel()
 
-	ctrd, ctrdErr := newCtrd(containerdSock)
-	defer ctrd.client.Close()
+	live, eventsErr := svc.Events(ctx, node.EventOptions{ContainerID: testContainerID})
 
-	if ctrdErr != nil {
-		t.Errorf("failed to create containerd client with error: %s", ctrdErr.Error())
+	if eventsErr != nil {
+		t.Fatalf("node.Events failed with error: %s", eventsErr.Error())
 	}
 
-	node := node.NewNode(ctrd.client)
+	if _, pullErr := svc.PullImage(ctx, testImage); pullErr != nil {
+		t.Fatalf("failed to pull seed image with error: %s", pullErr.Error())
+	}
 
-	ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
+	if _, createErr := svc.CreateContainer(ctx, testImage, testContainerID, node.ContainerSpec{}); createErr != nil {
+		t.Fatalf("failed to create seed container with error: %s", createErr.Error())
+	}
 
-	_, pullErr := ctrd.pullImage(ctx, testImage)
+	if _, createTaskErr := svc.CreateTask(ctx, testContainerID); createTaskErr != nil {
+		t.Fatalf("node.CreateTask failed with error: %s", createTaskErr.Error())
+	}
 
-	if pullErr != nil {
-		t.Errorf("failed to pull seed image with error: %s", pullErr.Error())
+	if _, execErr := svc.ExecTask(ctx, testContainerID, node.ProcessSpec{Args: []string{"true"}}, node.TaskIO{}); execErr != nil {
+		t.Fatalf("node.ExecTask failed with error: %s", execErr.Error())
 	}
 
-	defer ctrd.deleteImage(ctx, testImage)
+	svc.KillTask(ctx, testContainerID, "SIGKILL", 0)
+	svc.DeleteTask(ctx, testContainerID)
+	svc.DeleteContainer(ctx, testContainerID)
 
-	_, createContainerErr := ctrd.createContainer(namespaces.WithNamespace(context.TODO(), testNamespace), testImage, testContainerID)
+	all := []string{
+		node.ContainerCreateTopic, node.TaskCreateTopic, node.TaskStartTopic, node.TaskExecAddedTopic, node.TaskExecStartedTopic,
+		node.TaskExitTopic, node.TaskExitTopic, node.TaskDeleteTopic, node.ContainerDeleteTopic,
+	}
 
-	if createContainerErr != nil {
-		t.Errorf("failed to create seed container with error: %s", createContainerErr.Error())
+	for _, want := range all {
+		select {
+		case e := <-live:
+			if e.Topic != want || e.ContainerID != testContainerID {
+				t.Fatalf("node.Events streamed a %s event for %q, want a %s event for %q", e.Topic, e.ContainerID, want, testContainerID)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for a %s event", want)
+		}
 	}
 
-	defer ctrd.deleteContainer(ctx, testContainerID)
+	tests := []test{
+		{name: "empty namespace", args: testArguments{namespace: ""}, wantErr: true},
+		{name: "weird topic", args: testArguments{namespace: testNamespace, opts: node.EventOptions{Topic: weirdString}}, wantErr: true},
+		{name: "backlog", args: testArguments{namespace: testNamespace, opts: node.EventOptions{Since: 1}}, wantTopics: append([]string{node.ImageCreateTopic}, all...)},
+		{name: "topic", args: testArguments{namespace: testNamespace, opts: node.EventOptions{Topic: "/containers", Since: 1}}, wantTopics: []string{node.ContainerCreateTopic, node.ContainerDeleteTopic}},
+		{name: "exact topic", args: testArguments{namespace: testNamespace, opts: node.EventOptions{Topic: node.TaskExitTopic, Since: 1}}, wantTopics: []string{node.TaskExitTopic, node.TaskExitTopic}},
+		{name: "resume", args: testArguments{namespace: testNamespace, opts: node.EventOptions{ContainerID: testContainerID, Since: 9}}, wantTopics: all[len(all)-2:]},
+		{name: "other namespace", args: testArguments{namespace: "clamor-testing-other", opts: node.EventOptions{Since: 1}}},
+	}
 
 	for _, test := range tests {
+
 		t.Run(test.name, func(t *testing.T) {
-			ctrd.createTask(ctx, test.args.containerID)
-			err := node.KillTask(namespaces.WithNamespace(context.TODO(), test.args.namespace), test.args.containerID)
+			ctx, cancel := context.WithCancel(namespaces.WithNamespace(context.TODO(), test.args.namespace))
+			events, err := svc.Events(ctx, test.args.opts)
 
-			if err == nil {
-				ctrd.deleteTask(ctx, test.args.containerID)
-			} else if !test.wantErr {
-				t.Errorf("node.KillTask failed with error: %s", err.Error())
+			if err != nil && !test.wantErr {
+				t.Fatalf("node.Events failed with error: %s", err.Error())
+			} else if err == nil && test.wantErr {
+				t.Fatalf("node.Events succeeded, want error")
+			}
+
+			if test.wantErr {
+				cancel()
+				return
+			}
+
+			// Cancelling ends the stream after the backlog, so the topics received are exactly those replayed.
+			var topics []string
+			time.AfterFunc(100*time.Millisecond, cancel)
+
+			for e := range events {
+				topics = append(topics, e.Topic)
+			}
+
+			if fmt.Sprint(topics) != fmt.Sprint(test.wantTopics) {
+				t.Errorf("node.Events replayed %v, want %v", topics, test.wantTopics)
 			}
 		})
 	}
@@ -308,14 +2979,10 @@ func TestDeleteTask(t *testing.T) {
 		{name: "valid namespace valid container ID", args: testArguments{namespace: testNamespace, containerID: containerID}, wantErr: false},
 	}
 
-	ctrd, ctrdErr := newCtrd(containerdSock)
-	defer ctrd.client.Close()
-
-	if ctrdErr != nil {
-		t.Errorf("failed to create containerd client with error: %s", ctrdErr.Error())
-	}
-
-	node := node.NewNode(ctrd.client)
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	node := node.NewNode(ctrd.backend, ctrd.logs)
+	defer node.Close()
 	ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
 
 	_, createContainerErr := ctrd.createContainer(ctx, testImage, containerID)
@@ -330,7 +2997,7 @@ func TestDeleteTask(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			ctx := namespaces.WithNamespace(context.TODO(), test.args.namespace)
 			ctrd.createTask(ctx, containerID)
-			ctrd.killTask(ctx, containerID)
+			ctrd.killTask(ctx, containerID, syscall.SIGKILL)
 			_, err := node.DeleteTask(ctx, test.args.containerID)
 
 			if err != nil && !test.wantErr {
@@ -359,14 +3026,10 @@ func TestDeleteContainer(t *testing.T) {
 		{name: "valid namespace valid container ID", args: testArguments{namespace: testNamespace, containerID: testContainerID}, wantErr: false},
 	}
 
-	ctrd, ctrdErr := newCtrd(containerdSock)
-	defer ctrd.client.Close()
-
-	if ctrdErr != nil {
-		t.Errorf("failed to create containerd client with error: %s", ctrdErr.Error())
-	}
-
-	node := node.NewNode(ctrd.client)
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	node := node.NewNode(ctrd.backend, ctrd.logs)
+	defer node.Close()
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -376,6 +3039,8 @@ func TestDeleteContainer(t *testing.T) {
 
 			if err != nil && !test.wantErr {
 				t.Errorf("node.DeleteContainer failed with error: %s", err.Error())
+			} else if err == nil && test.wantErr {
+				t.Errorf("node.DeleteContainer succeeded, want error")
 			}
 		})
 	}
@@ -400,14 +3065,10 @@ func TestDeleteImage(t *testing.T) {
 		{name: "valid namespace valid image name", args: testArguments{namespace: testNamespace, imageName: testImage}, wantErr: false},
 	}
 
-	ctrd, ctrdErr := newCtrd(containerdSock)
-	defer ctrd.client.Close()
-
-	if ctrdErr != nil {
-		t.Errorf("failed to create containerd client with error: %s", ctrdErr.Error())
-	}
-
-	node := node.NewNode(ctrd.client)
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	node := node.NewNode(ctrd.backend, ctrd.logs)
+	defer node.Close()
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -418,9 +3079,311 @@ func TestDeleteImage(t *testing.T) {
 
 			if err != nil && !test.wantErr {
 				t.Errorf("node.DeleteImage failed with error: %s", err.Error())
+			} else if err == nil && test.wantErr {
+				t.Errorf("node.DeleteImage succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestTagImage(t *testing.T) {
+	type testArguments struct {
+		namespace, source, target string
+	}
+
+	type test struct {
+		name string
+		args testArguments
+		// wantDigest is the digest of the image the target should point to.
+		wantDigest string
+		wantErr    error
+	}
+
+	var (
+		prod     = "docker.io/library/hello-world:prod"
+		ctrd     = newCtrd(t)
+		n        = node.NewNode(ctrd.backend, ctrd.logs)
+		ctx      = namespaces.WithNamespace(context.TODO(), testNamespace)
+		digestOf = func(name string) string {
+			img, err := n.GetImage(ctx, name)
+
+			if err != nil {
+				t.Fatalf("node.GetImage failed with error: %s", err.Error())
+			}
+
+			return img.Target().Digest.String()
+		}
+	)
+
+	defer ctrd.cleanup()
+	defer n.Close()
+	ctrd.pullImage(ctx, testImage)
+	ctrd.pullImage(ctx, stopSignalImage)
+
+	tests := []test{
+		{name: "empty namespace", args: testArguments{namespace: "", source: testImage, target: prod}, wantErr: errdefs.ErrFailedPrecondition},
+		{name: "missing source", args: testArguments{namespace: testNamespace, source: weirdString, target: prod}, wantErr: errdefs.ErrNotFound},
+		{name: "invalid target", args: testArguments{namespace: testNamespace, source: testImage, target: weirdString}, wantErr: errdefs.ErrInvalidArgument},
+		{name: "new tag", args: testArguments{namespace: testNamespace, source: stopSignalImage, target: prod}, wantDigest: digestOf(stopSignalImage)},
+		{name: "retag", args: testArguments{namespace: testNamespace, source: testImage, target: prod}, wantDigest: digestOf(testImage)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			img, err := n.TagImage(namespaces.WithNamespace(context.TODO(), test.args.namespace), test.args.source, test.args.target)
+
+			if test.wantErr != nil {
+
+				if err == nil {
+					t.Fatalf("node.TagImage succeeded, want error")
+				}
+
+				if !errors.Is(err, test.wantErr) {
+					t.Errorf("node.TagImage failed with error %s, want %s", err.Error(), test.wantErr.Error())
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("node.TagImage failed with error: %s", err.Error())
+			}
+
+			if img.Name() != test.args.target || img.Target().Digest.String() != test.wantDigest {
+				t.Errorf("node.TagImage returned %s at %s, want %s at %s", img.Name(), img.Target().Digest, test.args.target, test.wantDigest)
+			}
+
+			if got := digestOf(test.args.target); got != test.wantDigest {
+				t.Errorf("image %s points to %s, want %s", test.args.target, got, test.wantDigest)
+			}
+		})
+	}
+
+	t.Run("untag", func(t *testing.T) {
+
+		if err := n.UntagImage(ctx, stopSignalImage); !errors.Is(err, errdefs.ErrFailedPrecondition) {
+			t.Errorf("node.UntagImage of an image's last name returned %v, want %s", err, errdefs.ErrFailedPrecondition)
+		}
+
+		if err := n.UntagImage(ctx, testImage); err != nil {
+			t.Fatalf("node.UntagImage failed with error: %s", err.Error())
+		}
+
+		var dne node.ErrNotFound
+
+		if _, err := n.GetImage(ctx, testImage); !errors.As(err, &dne) {
+			t.Errorf("node.GetImage of an untagged name returned %v, want node.ErrNotFound", err)
+		}
+
+		if _, err := n.GetImage(ctx, prod); err != nil {
+			t.Errorf("node.GetImage of the remaining name failed with error: %s", err.Error())
+		}
+	})
+}
+
+func TestMemoryBackendLifecycle(t *testing.T) {
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
+
+	if _, err := ctrd.createContainer(ctx, testImage, testContainerID); err != nil {
+		t.Fatalf("failed to create container with error: %s", err.Error())
+	}
+
+	snapshots, _ := ctrd.backend.Snapshots(ctx)
+
+	if len(snapshots) != 2 || snapshots[0].Name != testContainerID || snapshots[0].Parent != snapshots[1].Name {
+		t.Errorf("expected an image snapshot and a container snapshot on top of it, got %v", snapshots)
+	}
+
+	if _, err := ctrd.createTask(ctx, testContainerID); err != nil {
+		t.Fatalf("failed to create task with error: %s", err.Error())
+	}
+
+	if err := ctrd.deleteContainer(ctx, testContainerID); err == nil {
+		t.Errorf("deleted container with a live task, want error")
+	}
+
+	ctrd.killTask(ctx, testContainerID, syscall.SIGKILL)
+	task, _ := ctrd.getTask(ctx, testContainerID)
+
+	if status, _ := task.Status(ctx, nil); status.ExitStatus != 128+uint32(syscall.SIGKILL) {
+		t.Errorf("killed task exited with %d, want %d", status.ExitStatus, 128+uint32(syscall.SIGKILL))
+	}
+
+	if err := ctrd.deleteTask(ctx, testContainerID); err != nil {
+		t.Errorf("failed to delete task with error: %s", err.Error())
+	}
+
+	if err := ctrd.deleteContainer(ctx, testContainerID); err != nil {
+		t.Errorf("failed to delete container with error: %s", err.Error())
+	}
+
+	if snapshots, _ = ctrd.backend.Snapshots(ctx); len(snapshots) != 1 {
+		t.Errorf("expected container snapshot cleanup, got %v", snapshots)
+	}
+}
+
+func TestGetLogs(t *testing.T) {
+	type testArguments struct {
+		namespace, containerID string
+		opts                   node.LogOptions
+	}
+
+	type test struct {
+		name      string
+		args      testArguments
+		wantLines []string
+		wantErr   bool
+	}
+
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	svc := node.NewNode(ctrd.backend, ctrd.logs)
+	defer svc.Close()
+	ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
+
+	if _, createErr := ctrd.createContainer(ctx, testImage, testContainerID); createErr != nil {
+		t.Fatalf("failed to create seed container with error: %s", createErr.Error())
+	}
+
+	defer ctrd.deleteContainer(ctx, testContainerID)
+
+	if _, createTaskErr := svc.CreateTask(ctx, testContainerID); createTaskErr != nil {
+		t.Fatalf("node.CreateTask failed with error: %s", createTaskErr.Error())
+	}
+
+	taskIO, taskIOErr := ctrd.backend.TaskIO(ctx, testContainerID)
+
+	if taskIOErr != nil {
+		t.Fatalf("failed to get task IO with error: %s", taskIOErr.Error())
+	}
+
+	fmt.Fprint(taskIO.Stdout, "one\ntw")
+	fmt.Fprint(taskIO.Stderr, "oops\r\n")
+	fmt.Fprint(taskIO.Stdout, "o\n")
+	midpoint := time.Now().UTC()
+	fmt.Fprint(taskIO.Stdout, strings.Repeat("x", 20*1024)+"\nunterminated")
+
+	if killErr := ctrd.killTask(ctx, testContainerID, syscall.SIGKILL); killErr != nil {
+		t.Fatalf("failed to kill seed task with error: %s", killErr.Error())
+	}
+
+	if _, deleteErr := svc.DeleteTask(ctx, testContainerID); deleteErr != nil {
+		t.Fatalf("node.DeleteTask failed with error: %s", deleteErr.Error())
+	}
+
+	tests := []test{
+		{name: "empty namespace", args: testArguments{namespace: "", containerID: testContainerID}, wantErr: true},
+		{name: "unknown container", args: testArguments{namespace: testNamespace, containerID: "missing"}, wantErr: true},
+		{name: "unknown stream", args: testArguments{namespace: testNamespace, containerID: testContainerID, opts: node.LogOptions{Stream: "stdin"}}, wantErr: true},
+		{name: "all", args: testArguments{namespace: testNamespace, containerID: testContainerID}, wantLines: []string{"one", "oops", "two", strings.Repeat("x", 16*1024), strings.Repeat("x", 4*1024), "unterminated"}},
+		{name: "stderr", args: testArguments{namespace: testNamespace, containerID: testContainerID, opts: node.LogOptions{Stream: node.StderrStream}}, wantLines: []string{"oops"}},
+		{name: "tail", args: testArguments{namespace: testNamespace, containerID: testContainerID, opts: node.LogOptions{Stream: node.StdoutStream, Tail: 1}}, wantLines: []string{"unterminated"}},
+		{name: "since", args: testArguments{namespace: testNamespace, containerID: testContainerID, opts: node.LogOptions{Since: midpoint, Tail: 2}}, wantLines: []string{strings.Repeat("x", 4*1024), "unterminated"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := namespaces.WithNamespace(context.TODO(), test.args.namespace)
+			records, err := svc.GetLogs(ctx, test.args.containerID, test.args.opts)
+
+			if err != nil && !test.wantErr {
+				t.Errorf("node.GetLogs failed with error: %s", err.Error())
+			} else if err == nil && test.wantErr {
+				t.Errorf("node.GetLogs succeeded, want error")
+			}
+
+			var lines []string
+
+			for _, r := range records {
+				lines = append(lines, r.Line)
+			}
+
+			if !reflect.DeepEqual(lines, test.wantLines) {
+				t.Errorf("node.GetLogs returned %d lines %.40q, want %d lines %.40q", len(lines), lines, len(test.wantLines), test.wantLines)
 			}
 		})
 	}
+
+	if deleteErr := svc.DeleteContainer(ctx, testContainerID); deleteErr != nil {
+		t.Fatalf("node.DeleteContainer failed with error: %s", deleteErr.Error())
+	}
+
+	if _, statErr := os.Stat(filepath.Join(ctrd.logs.Dir, testNamespace, testContainerID+".log")); !os.IsNotExist(statErr) {
+		t.Errorf("expected logs to be removed with the container, got %v", statErr)
+	}
+}
+
+func TestFollowLogs(t *testing.T) {
+	ctrd := newCtrd(t)
+	defer ctrd.cleanup()
+	svc := node.NewNode(ctrd.backend, ctrd.logs)
+	defer svc.Close()
+	ctx := namespaces.WithNamespace(context.TODO(), testNamespace)
+
+	if _, createErr := ctrd.createContainer(ctx, testImage, testContainerID); createErr != nil {
+		t.Fatalf("failed to create seed container with error: %s", createErr.Error())
+	}
+
+	defer ctrd.deleteContainer(ctx, testContainerID)
+
+	if _, followErr := svc.FollowLogs(ctx, weirdString, node.LogOptions{}); followErr == nil {
+		t.Errorf("node.FollowLogs succeeded for an unknown container, want error")
+	}
+
+	if _, createTaskErr := svc.CreateTask(ctx, testContainerID); createTaskErr != nil {
+		t.Fatalf("node.CreateTask failed with error: %s", createTaskErr.Error())
+	}
+
+	taskIO, _ := ctrd.backend.TaskIO(ctx, testContainerID)
+	fmt.Fprintln(taskIO.Stdout, "recorded")
+
+	followCtx, cancel := context.WithCancel(ctx)
+	records, followErr := svc.FollowLogs(followCtx, testContainerID, node.LogOptions{Stream: node.StdoutStream})
+
+	if followErr != nil {
+		t.Fatalf("node.FollowLogs failed with error: %s", followErr.Error())
+	}
+
+	fmt.Fprintln(taskIO.Stderr, "filtered")
+	fmt.Fprintln(taskIO.Stdout, "live")
+
+	for _, want := range []string{"recorded", "live"} {
+		if r := <-records; r.Line != want {
+			t.Errorf("node.FollowLogs returned %q, want %q", r.Line, want)
+		}
+	}
+
+	cancel()
+
+	if _, open := <-records; open {
+		t.Errorf("node.FollowLogs kept streaming after its context was cancelled")
+	}
+
+	if killErr := ctrd.killTask(ctx, testContainerID, syscall.SIGKILL); killErr != nil {
+		t.Fatalf("failed to kill seed task with error: %s", killErr.Error())
+	}
+
+	if _, deleteErr := svc.DeleteTask(ctx, testContainerID); deleteErr != nil {
+		t.Fatalf("node.DeleteTask failed with error: %s", deleteErr.Error())
+	}
+
+	records, followErr = svc.FollowLogs(ctx, testContainerID, node.LogOptions{})
+
+	if followErr != nil {
+		t.Fatalf("node.FollowLogs failed with error: %s", followErr.Error())
+	}
+
+	var lines []string
+
+	for r := range records {
+		lines = append(lines, r.Line)
+	}
+
+	if want := []string{"recorded", "filtered", "live"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("node.FollowLogs without a task returned %v, want %v", lines, want)
+	}
 }
 
 func randString(n int) string {
@@ -436,34 +3399,45 @@ func randString(n int) string {
 
 // ctrd is similar to the containerd interaction provided by node.Service methods, but it's meant to be a simpler implementation that's easier to trust.
 // node.Service tests should use these methods to create SUT dependencies instead of whatever their closest node.Service relative is.
-// e.g. A test for node.CreateTask could use the below ctrd.createContainer method to populate the containerd daemon with the required container prior to task creation.
+// e.g. A test for node.CreateTask could use the below ctrd.createContainer method to populate the backend with the required container prior to task creation.
+// It drives a node.MemoryBackend directly, so tests don't need a containerd daemon.
 
 type ctrd struct {
-	client *containerd.Client
+	backend *node.MemoryBackend
+	logs    *node.LogStore
 }
 
-func newCtrd(containerdPath string) (*ctrd, error) {
-	var (
-		ctr    *containerd.Client
-		ctrErr error
-	)
+func newCtrd(t *testing.T) *ctrd {
+	logDir, tempDirErr := ioutil.TempDir("", "clamor-logs")
 
-	if ctr, ctrErr = containerd.New(containerdPath); ctrErr != nil {
-		return nil, ctrErr
+	if tempDirErr != nil {
+		t.Fatalf("failed to create log directory with error: %s", tempDirErr.Error())
 	}
 
 	return &ctrd{
-		client: ctr,
-	}, nil
+		backend: node.NewMemoryBackend(
+			node.RemoteImage{Ref: testImage},
+			node.RemoteImage{Ref: stopSignalImage, StopSignal: "SIGQUIT"},
+			node.RemoteImage{Ref: stubbornImage, IgnoredSignals: []syscall.Signal{syscall.SIGTERM}},
+			node.RemoteImage{
+				Ref:          multiPlatformImage,
+				Labels:       map[string]string{"io.clamor.test": "true"},
+				Config:       multiPlatformImageConfig,
+				Platforms:    multiPlatforms,
+				Size:         1 << 20,
+				UnpackedSize: 3 << 20,
+			},
+		),
+		logs: node.NewLogStore(logDir),
+	}
 }
 
-func (c *ctrd) pullImage(ctx context.Context, imageName string) (containerd.Image, error) {
-	var (
-		image        containerd.Image
-		pullImageErr error
-	)
+func (c *ctrd) cleanup() {
+	os.RemoveAll(c.logs.Dir)
+}
 
-	image, pullImageErr = c.client.Pull(ctx, imageName, containerd.WithPullUnpack)
+func (c *ctrd) pullImage(ctx context.Context, imageName string) (node.Image, error) {
+	image, pullImageErr := c.backend.Pull(ctx, imageName, node.PullOptions{})
 
 	if pullImageErr != nil {
 		return nil, fmt.Errorf("failed to pull image ref %s with error: %s", imageName, pullImageErr.Error())
@@ -472,70 +3446,45 @@ func (c *ctrd) pullImage(ctx context.Context, imageName string) (containerd.Imag
 	return image, nil
 }
 
-func (c *ctrd) createContainer(ctx context.Context, imageName, id string) (containerd.Container, error) {
+func (c *ctrd) createContainer(ctx context.Context, imageName, id string) (node.Container, error) {
 	var (
-		image   containerd.Image
+		image   node.Image
 		pullErr error
 	)
 
-	if image, pullErr = c.client.Pull(ctx, imageName, containerd.WithPullUnpack); pullErr != nil {
+	if image, pullErr = c.backend.Pull(ctx, imageName, node.PullOptions{}); pullErr != nil {
 		return nil, fmt.Errorf("failed to pull image ref %s with error: %s", imageName, pullErr.Error())
 	}
 
-	return c.client.NewContainer(
-		ctx,
-		id,
-		containerd.WithImage(image),
-		containerd.WithNewSnapshot(id, image),
-		containerd.WithNewSpec(oci.WithImageConfig(image)),
-	)
-}
-
-func (c *ctrd) getContainer(ctx context.Context, id string) (containerd.Container, error) {
-	container, loadContainerErr := c.client.LoadContainer(ctx, id)
-
-	if loadContainerErr != nil {
-		return nil, fmt.Errorf("failed to load container %s with error: %s", id, loadContainerErr.Error())
-	}
-
-	return container, nil
+	return c.backend.NewContainer(ctx, id, image, node.ContainerSpec{})
 }
 
 func (c *ctrd) deleteContainer(ctx context.Context, id string) error {
-	var (
-		container    containerd.Container
-		containerErr error
-	)
-
-	if container, containerErr = c.client.LoadContainer(ctx, id); containerErr != nil {
-		return fmt.Errorf("failed to load container with error: %s", containerErr.Error())
-	}
-
-	return container.Delete(ctx, containerd.WithSnapshotCleanup)
+	return c.backend.DeleteContainer(ctx, id)
 }
 
-func (c *ctrd) createTask(ctx context.Context, containerID string) (containerd.Task, error) {
+func (c *ctrd) createTask(ctx context.Context, containerID string) (node.Task, error) {
 	var (
-		task                         containerd.Task
-		container                    containerd.Container
+		task                         node.RuntimeTask
+		container                    node.RuntimeContainer
 		loadContainerErr, newTaskErr error
 	)
 
-	if container, loadContainerErr = c.client.LoadContainer(ctx, containerID); loadContainerErr != nil {
+	if container, loadContainerErr = c.backend.LoadContainer(ctx, containerID); loadContainerErr != nil {
 		return nil, fmt.Errorf("Node failed to load container %s with error: %s", containerID, loadContainerErr.Error())
 	}
 
-	if task, newTaskErr = container.NewTask(ctx, cio.NewCreator(cio.WithStdio)); newTaskErr != nil {
+	if task, newTaskErr = container.NewTask(ctx, node.TaskIO{}); newTaskErr != nil {
 		return nil, fmt.Errorf("Node failed to create task with error: %s", newTaskErr.Error())
 	}
 
 	return task, nil
 }
 
-func (c *ctrd) killTask(ctx context.Context, containerID string) error {
+func (c *ctrd) killTask(ctx context.Context, containerID string, signal syscall.Signal) error {
 	var (
-		task                             containerd.Task
-		es                               <-chan containerd.ExitStatus
+		task                             node.RuntimeTask
+		es                               <-chan node.ExitStatus
 		getTaskErr, waitErr, killTaskErr error
 	)
 
@@ -547,7 +3496,7 @@ func (c *ctrd) killTask(ctx context.Context, containerID string) error {
 		return fmt.Errorf("failed get task exit status channel with error: %s", waitErr.Error())
 	}
 
-	if killTaskErr = task.Kill(ctx, syscall.SIGKILL); killTaskErr != nil {
+	if killTaskErr = task.Kill(ctx, signal); killTaskErr != nil {
 		return fmt.Errorf("failed to kill task for container %s with error: %s", containerID, killTaskErr.Error())
 	}
 
@@ -558,17 +3507,12 @@ func (c *ctrd) killTask(ctx context.Context, containerID string) error {
 
 func (c *ctrd) deleteTask(ctx context.Context, containerID string) error {
 	var (
-		container                               containerd.Container
-		task                                    containerd.Task
-		getContainerErr, taskErr, deleteTaskErr error
+		task                      node.RuntimeTask
+		getTaskErr, deleteTaskErr error
 	)
 
-	if container, getContainerErr = c.client.LoadContainer(ctx, containerID); getContainerErr != nil {
-		return fmt.Errorf("failed to load task for container %s with error: %s", containerID, getContainerErr.Error())
-	}
-
-	if task, taskErr = container.Task(ctx, nil); taskErr != nil {
-		return fmt.Errorf("failed to load task for container %s with error: %s", containerID, taskErr.Error())
+	if task, getTaskErr = c.getTask(ctx, containerID); getTaskErr != nil {
+		return getTaskErr
 	}
 
 	if _, deleteTaskErr = task.Delete(ctx); deleteTaskErr != nil {
@@ -579,25 +3523,21 @@ func (c *ctrd) deleteTask(ctx context.Context, containerID string) error {
 }
 
 func (c *ctrd) deleteImage(ctx context.Context, name string) error {
-	var (
-		getImageErr, deleteImageErr error
-	)
-
-	if deleteImageErr = c.client.ImageService().Delete(ctx, name); deleteImageErr != nil {
-		return fmt.Errorf("failed to get image with error: %s", getImageErr.Error())
+	if deleteImageErr := c.backend.DeleteImage(ctx, name); deleteImageErr != nil {
+		return fmt.Errorf("failed to delete image with error: %s", deleteImageErr.Error())
 	}
 
 	return nil
 }
 
-func (c *ctrd) getTask(ctx context.Context, containerID string) (containerd.Task, error) {
-	container, getContainerErr := c.getContainer(ctx, containerID)
+func (c *ctrd) getTask(ctx context.Context, containerID string) (node.RuntimeTask, error) {
+	container, getContainerErr := c.backend.LoadContainer(ctx, containerID)
 
 	if getContainerErr != nil {
 		return nil, fmt.Errorf("failed to load container for task %s: %w", containerID, getContainerErr)
 	}
 
-	task, taskErr := container.Task(ctx, nil)
+	task, taskErr := container.LoadTask(ctx)
 
 	if taskErr != nil {
 		return nil, fmt.Errorf("failed to load task for container %s: %w", containerID, taskErr)
@@ -605,3 +3545,11 @@ func (c *ctrd) getTask(ctx context.Context, containerID string) (containerd.Task
 
 	return task, nil
 }
+
+// lineWriter hands every write to a channel.
+type lineWriter chan string
+
+func (w lineWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
@@ -0,0 +1,233 @@
+package node
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/namespaces"
+)
+
+// Operation kinds, named after the mutations they run.
+const (
+	PullImageOperation = "pullImage"
+	KillTaskOperation  = "killTask"
+)
+
+// Operation statuses. An operation is running until it succeeds, fails or is cancelled.
+const (
+	OperationRunning   = "running"
+	OperationSucceeded = "succeeded"
+	OperationFailed    = "failed"
+	OperationCancelled = "cancelled"
+)
+
+// operationHistory is how many finished operations a namespace keeps around to be queried.
+const operationHistory = 256
+
+// Operation is a call to the Node that runs in the background, so it outlives the request that started it.
+type Operation struct {
+	ID   string
+	Kind string
+	// Target is what the operation acts on: the image ref of a pull, the container ID of a kill.
+	Target string
+	Status string
+	// Result is what a successful operation produced: the Image of a pull. Kills produce nothing.
+	Result     interface{}
+	Error      string
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// Finished reports whether the operation is over.
+func (o Operation) Finished() bool {
+	return o.Status != OperationRunning
+}
+
+// operationHub runs the operations of a Node and keeps them around once they're finished.
+type operationHub struct {
+	mu  sync.Mutex
+	ops map[string]*operation
+}
+
+type operation struct {
+	Operation
+	namespace string
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+func newOperationHub() *operationHub {
+	return &operationHub{ops: make(map[string]*operation)}
+}
+
+// newOperationID returns a random ID for an operation.
+func newOperationID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+
+	return "op-" + hex.EncodeToString(b)
+}
+
+// start runs fn in the background with a context that carries ctx's namespace, but is only done when the operation is cancelled.
+func (h *operationHub) start(ctx context.Context, kind, target string, fn func(ctx context.Context) (interface{}, error)) (Operation, error) {
+	namespace, err := namespaces.NamespaceRequired(ctx)
+
+	if err != nil {
+		return Operation{}, err
+	}
+
+	opCtx, cancel := context.WithCancel(namespaces.WithNamespace(context.Background(), namespace))
+	op := &operation{
+		Operation: Operation{
+			ID:        newOperationID(),
+			Kind:      kind,
+			Target:    target,
+			Status:    OperationRunning,
+			StartedAt: time.Now().UTC(),
+		},
+		namespace: namespace,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	h.ops[op.ID] = op
+	started := op.Operation
+	h.mu.Unlock()
+
+	go func() {
+		result, runErr := fn(opCtx)
+		cancelled := opCtx.Err() != nil
+		cancel()
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		switch {
+		case runErr == nil:
+			op.Status, op.Result = OperationSucceeded, result
+		case cancelled:
+			op.Status, op.Error = OperationCancelled, runErr.Error()
+		default:
+			op.Status, op.Error = OperationFailed, runErr.Error()
+		}
+
+		op.FinishedAt = time.Now().UTC()
+		close(op.done)
+		h.prune(namespace)
+	}()
+
+	return started, nil
+}
+
+// prune forgets the oldest finished operations of the namespace beyond operationHistory. Callers must hold h.mu.
+func (h *operationHub) prune(namespace string) {
+	var finished []*operation
+
+	for _, op := range h.ops {
+
+		if op.namespace == namespace && op.Finished() {
+			finished = append(finished, op)
+		}
+	}
+
+	if len(finished) <= operationHistory {
+		return
+	}
+
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].FinishedAt.Before(finished[j].FinishedAt)
+	})
+
+	for _, op := range finished[:len(finished)-operationHistory] {
+		delete(h.ops, op.ID)
+	}
+}
+
+// lookup returns the operation with the given ID in ctx's namespace. Callers must hold h.mu.
+func (h *operationHub) lookup(ctx context.Context, id string) (*operation, error) {
+	namespace, err := namespaces.NamespaceRequired(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	op, exists := h.ops[id]
+
+	if !exists || op.namespace != namespace {
+		return nil, fmt.Errorf("operation %q: %w", id, errdefs.ErrNotFound)
+	}
+
+	return op, nil
+}
+
+func (h *operationHub) get(ctx context.Context, id string) (Operation, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	op, err := h.lookup(ctx, id)
+
+	if err != nil {
+		return Operation{}, err
+	}
+
+	return op.Operation, nil
+}
+
+// list returns the operations of ctx's namespace, oldest first.
+func (h *operationHub) list(ctx context.Context) ([]Operation, error) {
+	namespace, err := namespaces.NamespaceRequired(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	var ops []Operation
+
+	for _, op := range h.ops {
+
+		if op.namespace == namespace {
+			ops = append(ops, op.Operation)
+		}
+	}
+
+	h.mu.Unlock()
+
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].StartedAt.Before(ops[j].StartedAt)
+	})
+
+	return ops, nil
+}
+
+// cancel cancels the context of a running operation and waits for it to finish, or for ctx to be done.
+// Finished operations are left as they are.
+func (h *operationHub) cancel(ctx context.Context, id string) (Operation, error) {
+	h.mu.Lock()
+	op, err := h.lookup(ctx, id)
+	h.mu.Unlock()
+
+	if err != nil {
+		return Operation{}, err
+	}
+
+	op.cancel()
+
+	select {
+	case <-op.done:
+	case <-ctx.Done():
+		return Operation{}, ctx.Err()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return op.Operation, nil
+}
@@ -0,0 +1,148 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/identifiers"
+	"github.com/containerd/containerd/reference"
+)
+
+// Container labels that tie the containers of a pod together.
+const (
+	// PodLabel holds the ID of the pod a container belongs to. A pod's sandbox container has the pod's ID.
+	PodLabel = "io.clamor.pod"
+	// PodMemberLabel holds the name of a member container within its pod. Sandbox containers don't have it.
+	PodMemberLabel = "io.clamor.pod.member"
+)
+
+// DefaultSandboxImage runs the sandbox container of pods that don't name another image, unless the node is configured with another one, see WithSandboxImage.
+const DefaultSandboxImage = "registry.k8s.io/pause:3.2"
+
+// Pod statuses, aggregated from the tasks of a pod's sandbox and members.
+const (
+	// PodRunning is the status of a pod whose tasks all run.
+	PodRunning = "running"
+	// PodPaused is the status of a pod whose tasks are all paused.
+	PodPaused = "paused"
+	// PodStopped is the status of a pod none of whose tasks run, or that has no tasks.
+	PodStopped = "stopped"
+	// PodDegraded is the status of a pod with some tasks running and others not, e.g. because a member exited.
+	PodDegraded = "degraded"
+)
+
+// PodSpec describes a pod: a sandbox container that holds the network, IPC and PID namespaces, and the member containers that join them.
+type PodSpec struct {
+	// SandboxImage runs the sandbox container. Defaults to the node's sandbox image, see WithSandboxImage, which is pulled if it isn't present.
+	SandboxImage string      `json:"sandbox_image,omitempty"`
+	Members      []PodMember `json:"members"`
+}
+
+// PodMember describes a member container of a pod.
+type PodMember struct {
+	// Name identifies the member within its pod. The member's container ID is PodContainerID of the pod's ID and Name.
+	Name  string        `json:"name"`
+	Image string        `json:"image"`
+	Spec  ContainerSpec `json:"spec"`
+}
+
+// Validate reports pod specs without members, with members whose names aren't unique, or with malformed members.
+// Members can't name a sandbox of their own.
+func (s PodSpec) Validate() error {
+	if s.SandboxImage != "" {
+
+		if _, err := reference.Parse(s.SandboxImage); err != nil {
+			return fmt.Errorf("sandbox image %q: %s: %w", s.SandboxImage, err, errdefs.ErrInvalidArgument)
+		}
+	}
+
+	if len(s.Members) == 0 {
+		return fmt.Errorf("pod must have a member: %w", errdefs.ErrInvalidArgument)
+	}
+
+	names := make(map[string]bool)
+
+	for _, m := range s.Members {
+
+		if err := identifiers.Validate(m.Name); err != nil {
+			return fmt.Errorf("pod member name %q: %w", m.Name, err)
+		}
+
+		if names[m.Name] {
+			return fmt.Errorf("pod member name %q is used twice: %w", m.Name, errdefs.ErrInvalidArgument)
+		}
+
+		names[m.Name] = true
+
+		if m.Spec.Sandbox != "" {
+			return fmt.Errorf("pod member %s can't name a sandbox: %w", m.Name, errdefs.ErrInvalidArgument)
+		}
+
+		if err := m.Spec.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PodContainerID returns the ID of the container of the given pod member.
+func PodContainerID(podID, member string) string {
+	return podID + "." + member
+}
+
+// Pod is a sandbox container and the member containers that share its namespaces.
+type Pod struct {
+	ID      string
+	Sandbox Container
+	// Members are ordered by container ID.
+	Members []Container
+	// Status is PodRunning, PodPaused, PodStopped or PodDegraded.
+	Status string
+}
+
+// podStatus aggregates the statuses of the tasks of the given containers.
+func podStatus(ctx context.Context, cs []Container) string {
+	var running, paused int
+
+	for _, c := range cs {
+		task, err := c.Task(ctx, nil)
+
+		if err != nil {
+			continue
+		}
+
+		switch TaskStatus(ctx, task) {
+		case string(containerd.Running):
+			running++
+		case string(containerd.Paused):
+			paused++
+		}
+	}
+
+	switch {
+	case running == len(cs):
+		return PodRunning
+	case paused == len(cs):
+		return PodPaused
+	case running+paused == 0:
+		return PodStopped
+	default:
+		return PodDegraded
+	}
+}
+
+// newPod assembles the pod with the given ID out of its sandbox and member containers.
+func newPod(ctx context.Context, id string, sandbox Container, members []Container) Pod {
+	sort.Slice(members, func(i, j int) bool { return members[i].ID() < members[j].ID() })
+
+	return Pod{
+		ID:      id,
+		Sandbox: sandbox,
+		Members: members,
+		Status:  podStatus(ctx, append([]Container{sandbox}, members...)),
+	}
+}
@@ -0,0 +1,109 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/namespaces"
+)
+
+// ProcessSpec describes an additional process executed inside a running task. Zero values fall back to the task's own process.
+type ProcessSpec struct {
+	// Args holds the command and its arguments. It's required.
+	Args []string `json:"args"`
+	// Env holds KEY=VALUE pairs that are added to, or override, the task environment.
+	Env        []string `json:"env,omitempty"`
+	WorkingDir string   `json:"working_dir,omitempty"`
+	// User is a user name or uid, optionally followed by :group or :gid. Like ContainerSpec.User, it's resolved against the container's
+	// /etc/passwd and /etc/group, so a lone uid gets its primary group from there.
+	User string `json:"user,omitempty"`
+	// Terminal allocates a pseudo terminal for the process. Its stderr is then merged into stdout.
+	Terminal bool `json:"terminal,omitempty"`
+}
+
+// Validate reports malformed spec values.
+func (s ProcessSpec) Validate() error {
+	if len(s.Args) == 0 || s.Args[0] == "" {
+		return fmt.Errorf("process args must name a command: %w", errdefs.ErrInvalidArgument)
+	}
+
+	for _, e := range s.Env {
+		if strings.Index(e, "=") < 1 {
+			return fmt.Errorf("env entry %q must have the form KEY=VALUE: %w", e, errdefs.ErrInvalidArgument)
+		}
+	}
+
+	if strings.Count(s.User, ":") > 1 {
+		return fmt.Errorf("user %q must have the form user[:group]: %w", s.User, errdefs.ErrInvalidArgument)
+	}
+
+	return nil
+}
+
+// newExecID returns a random ID for an exec'd process.
+// CappedBuffer collects process output, keeping the first bytes written to it up to its limit and dropping the rest.
+// Writes never fail, so the process isn't held up by a full buffer, and stdout and stderr may share one.
+type CappedBuffer struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// NewCappedBuffer returns an empty CappedBuffer that keeps up to limit bytes.
+func NewCappedBuffer(limit int) *CappedBuffer {
+	return &CappedBuffer{limit: limit}
+}
+
+func (b *CappedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if room := b.limit - b.buf.Len(); len(p) > room {
+		b.truncated = true
+		b.buf.Write(p[:room])
+	} else {
+		b.buf.Write(p)
+	}
+
+	return len(p), nil
+}
+
+// String returns the output kept.
+func (b *CappedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+// Truncated reports whether any output was dropped.
+func (b *CappedBuffer) Truncated() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.truncated
+}
+
+func newExecID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+
+	return "exec-" + hex.EncodeToString(b)
+}
+
+// detachedContext returns a context carrying ctx's namespace that's never cancelled.
+// It's used to clean up after operations whose own context is done.
+func detachedContext(ctx context.Context) context.Context {
+	if namespace, isSet := namespaces.Namespace(ctx); isSet {
+		return namespaces.WithNamespace(context.Background(), namespace)
+	}
+
+	return context.Background()
+}
@@ -0,0 +1,386 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/namespaces"
+	"github.com/containerd/containerd/platforms"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Pull job statuses. A job goes through them in order, ending up done or failed.
+const (
+	PullResolving   = "resolving"
+	PullDownloading = "downloading"
+	PullUnpacking   = "unpacking"
+	PullDone        = "done"
+	PullFailed      = "failed"
+)
+
+// Layer statuses.
+const (
+	LayerWaiting     = "waiting"
+	LayerDownloading = "downloading"
+	LayerDone        = "done"
+)
+
+// pullHistory is how many finished pull jobs a namespace keeps around to be queried.
+const pullHistory = 64
+
+// AllPlatforms asks PullImage for the content of every platform an image is built for.
+const AllPlatforms = "all"
+
+// PullOptions holds what a Backend needs to know about a pull besides its ref.
+type PullOptions struct {
+	// Progress is called whenever the pull makes progress. Calls are never concurrent and stop before Pull returns.
+	Progress func(PullProgress)
+	// Platforms lists the platforms whose content is pulled, the node's platform if there are none.
+	// Images are only unpacked for the node's platform, the others' content is kept for exporting or retagging.
+	// A pull fails with errdefs.ErrNotFound if the image isn't built for one of them.
+	Platforms []ocispec.Platform
+	// AllPlatforms pulls the content of every platform the image is built for. Platforms is ignored.
+	AllPlatforms bool
+}
+
+// parsePullPlatforms turns the platforms given to PullImage, e.g. linux/arm64/v8 or AllPlatforms, into PullOptions.
+func parsePullPlatforms(specs []string) (opts PullOptions, err error) {
+	for _, spec := range specs {
+
+		if spec == AllPlatforms {
+			opts.AllPlatforms = true
+			continue
+		}
+
+		p, parseErr := platforms.Parse(spec)
+
+		if parseErr != nil {
+			return PullOptions{}, fmt.Errorf("invalid platform %q: %v: %w", spec, parseErr, errdefs.ErrInvalidArgument)
+		}
+
+		opts.Platforms = append(opts.Platforms, platforms.Normalize(p))
+	}
+
+	if opts.AllPlatforms {
+		opts.Platforms = nil
+	}
+
+	return opts, nil
+}
+
+// matcher matches the platforms the pull is for.
+func (o PullOptions) matcher() platforms.MatchComparer {
+	switch {
+	case o.AllPlatforms:
+		return platforms.All
+	case len(o.Platforms) > 0:
+		return platforms.Any(o.Platforms...)
+	}
+
+	return platforms.Default()
+}
+
+// PullProgress is a Backend's report on a pull that's underway.
+type PullProgress struct {
+	// Status is PullResolving, PullDownloading or PullUnpacking.
+	Status string
+	// Layers lists the image's layers for the platforms being pulled once they're known.
+	Layers []LayerProgress
+}
+
+// LayerProgress tracks the download of an image layer.
+type LayerProgress struct {
+	Digest    string
+	MediaType string
+	Status    string
+	// Offset is how much of the layer's Total bytes has been fetched.
+	Offset int64
+	Total  int64
+}
+
+// PullJob is a pull of an image ref, running or finished. A namespace has at most one job per ref: a new pull replaces the previous job.
+type PullJob struct {
+	Ref    string
+	Status string
+	Layers []LayerProgress
+	// Offset and Total add up the layers' so progress can be shown as a whole.
+	Offset    int64
+	Total     int64
+	StartedAt time.Time
+	// UpdatedAt is when the job last made progress. A running job that hasn't been updated in a while is stuck.
+	UpdatedAt  time.Time
+	FinishedAt time.Time
+	// Error says why the pull failed.
+	Error string
+}
+
+// Finished reports whether the job is done or failed.
+func (j PullJob) Finished() bool {
+	return j.Status == PullDone || j.Status == PullFailed
+}
+
+// snapshot copies j so it can be handed out while the job goes on.
+func (j *PullJob) snapshot() PullJob {
+	s := *j
+	s.Layers = append([]LayerProgress(nil), j.Layers...)
+
+	return s
+}
+
+type pullKey struct {
+	namespace, ref string
+}
+
+// pullHub keeps track of the pull jobs of a Node and of the subscribers following them.
+type pullHub struct {
+	mu       sync.Mutex
+	jobs     map[pullKey]*PullJob
+	watchers map[pullKey]map[*pullWatcher]struct{}
+}
+
+// pullWatcher follows the job it's attached to. Watchers that aren't attached yet wait for the next job of their ref to start.
+type pullWatcher struct {
+	job *PullJob
+	// updates holds the latest snapshot the watcher hasn't received yet. Older ones are dropped, a job's progress only matters as of now.
+	updates chan PullJob
+}
+
+func newPullHub() *pullHub {
+	return &pullHub{
+		jobs:     make(map[pullKey]*PullJob),
+		watchers: make(map[pullKey]map[*pullWatcher]struct{}),
+	}
+}
+
+// pullTracker records the progress of a single pull into its job.
+type pullTracker struct {
+	hub *pullHub
+	key pullKey
+	job *PullJob
+}
+
+// start creates the job of a pull of ref in ctx's namespace.
+func (h *pullHub) start(ctx context.Context, ref string) (*pullTracker, error) {
+	namespace, err := namespaces.NamespaceRequired(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	t := &pullTracker{
+		hub: h,
+		key: pullKey{namespace: namespace, ref: ref},
+		job: &PullJob{Ref: ref, Status: PullResolving, StartedAt: now, UpdatedAt: now},
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.jobs[t.key] = t.job
+	h.notify(t.key, t.job)
+
+	return t, nil
+}
+
+// update is a PullOptions.Progress function.
+func (t *pullTracker) update(p PullProgress) {
+	t.hub.mu.Lock()
+	defer t.hub.mu.Unlock()
+
+	t.job.Status, t.job.Layers = p.Status, p.Layers
+	t.job.Offset, t.job.Total = 0, 0
+
+	for _, l := range p.Layers {
+		t.job.Offset += l.Offset
+		t.job.Total += l.Total
+	}
+
+	t.job.UpdatedAt = time.Now().UTC()
+	t.hub.notify(t.key, t.job)
+}
+
+// finish ends the job with the outcome of the pull.
+func (t *pullTracker) finish(err error) {
+	t.hub.mu.Lock()
+	defer t.hub.mu.Unlock()
+
+	t.job.Status = PullDone
+
+	if err != nil {
+		t.job.Status, t.job.Error = PullFailed, err.Error()
+	}
+
+	t.job.UpdatedAt = time.Now().UTC()
+	t.job.FinishedAt = t.job.UpdatedAt
+	t.hub.notify(t.key, t.job)
+	t.hub.prune(t.key.namespace)
+}
+
+// notify hands a snapshot of job to the watchers following it, and to the ones waiting for a job of its ref.
+// Watchers of a finished job are closed after its final snapshot. Callers must hold h.mu.
+func (h *pullHub) notify(key pullKey, job *PullJob) {
+	for w := range h.watchers[key] {
+
+		if w.job == nil && !job.Finished() {
+			w.job = job
+		}
+
+		if w.job != job {
+			continue
+		}
+
+		select {
+		case <-w.updates:
+		default:
+		}
+
+		w.updates <- job.snapshot()
+
+		if job.Finished() {
+			delete(h.watchers[key], w)
+			close(w.updates)
+		}
+	}
+}
+
+// prune forgets the oldest finished jobs of the namespace beyond pullHistory. Callers must hold h.mu.
+func (h *pullHub) prune(namespace string) {
+	var finished []pullKey
+
+	for key, job := range h.jobs {
+
+		if key.namespace == namespace && job.Finished() {
+			finished = append(finished, key)
+		}
+	}
+
+	if len(finished) <= pullHistory {
+		return
+	}
+
+	sort.Slice(finished, func(i, j int) bool {
+		return h.jobs[finished[i]].FinishedAt.Before(h.jobs[finished[j]].FinishedAt)
+	})
+
+	for _, key := range finished[:len(finished)-pullHistory] {
+		delete(h.jobs, key)
+	}
+}
+
+// get returns the job of ref in ctx's namespace.
+func (h *pullHub) get(ctx context.Context, ref string) (PullJob, error) {
+	namespace, err := namespaces.NamespaceRequired(ctx)
+
+	if err != nil {
+		return PullJob{}, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	job, exists := h.jobs[pullKey{namespace: namespace, ref: ref}]
+
+	if !exists {
+		return PullJob{}, fmt.Errorf("pull job %q: %w", ref, errdefs.ErrNotFound)
+	}
+
+	return job.snapshot(), nil
+}
+
+// list returns the jobs of ctx's namespace, oldest first.
+func (h *pullHub) list(ctx context.Context) ([]PullJob, error) {
+	namespace, err := namespaces.NamespaceRequired(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	var jobs []PullJob
+
+	for key, job := range h.jobs {
+
+		if key.namespace == namespace {
+			jobs = append(jobs, job.snapshot())
+		}
+	}
+
+	h.mu.Unlock()
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].StartedAt.Before(jobs[j].StartedAt)
+	})
+
+	return jobs, nil
+}
+
+// follow streams snapshots of the running job of ref in ctx's namespace, or of the next one to start, until it finishes or ctx is done.
+func (h *pullHub) follow(ctx context.Context, ref string) (<-chan PullJob, error) {
+	namespace, err := namespaces.NamespaceRequired(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	key := pullKey{namespace: namespace, ref: ref}
+	w := &pullWatcher{updates: make(chan PullJob, 1)}
+
+	h.mu.Lock()
+
+	if h.watchers[key] == nil {
+		h.watchers[key] = make(map[*pullWatcher]struct{})
+	}
+
+	h.watchers[key][w] = struct{}{}
+
+	if job, exists := h.jobs[key]; exists && !job.Finished() {
+		w.job = job
+		w.updates <- job.snapshot()
+	}
+
+	h.mu.Unlock()
+
+	jobs := make(chan PullJob)
+
+	go func() {
+		defer close(jobs)
+		defer h.unfollow(key, w)
+
+		for {
+			select {
+			case job, open := <-w.updates:
+				if !open {
+					return
+				}
+
+				select {
+				case jobs <- job:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return jobs, nil
+}
+
+func (h *pullHub) unfollow(key pullKey, w *pullWatcher) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, following := h.watchers[key][w]; following {
+		delete(h.watchers[key], w)
+	}
+
+	if len(h.watchers[key]) == 0 {
+		delete(h.watchers, key)
+	}
+}
@@ -0,0 +1,364 @@
+package node
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/containerd/containerd/remotes/docker"
+)
+
+// dockerHubHost is the name Registries use for Docker Hub, whichever of its hosts a pull talks to.
+const dockerHubHost = "docker.io"
+
+// dockerHubEndpoint is the host pulls from Docker Hub talk to.
+const dockerHubEndpoint = "registry-1.docker.io"
+
+// RegistryConfig holds the credentials used to pull from a registry, the mirrors pulls go through and how to connect to it.
+// Credentials are either given inline, as a username and password or as a token, or read from a docker-style config.json.
+// Mirrors are registries too: their credentials and connection settings are the ones of their own entry.
+type RegistryConfig struct {
+	// Username and Password authenticate with basic auth, or with the registry's token service when it hands out bearer tokens.
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// Token is an identity token, exchanged with the registry's token service for bearer tokens. It's used when there's no Username.
+	Token string `json:"token"`
+	// DockerConfig is the path to a docker-style config.json whose auths entry for the registry holds the credentials.
+	// It's read on every pull, so credentials can be rotated without restarting the node. Credential helpers and stores aren't supported.
+	DockerConfig string `json:"docker_config"`
+
+	// Mirrors lists the endpoints that are tried before the registry, in priority order, e.g. "https://mirror.example.com" or "http://localhost:5000".
+	// A mirror that fails, refuses access or doesn't have what's pulled is skipped, the registry itself is tried last.
+	Mirrors []string `json:"mirrors"`
+	// PlainHTTP allows talking to the registry over HTTP. Localhost registries are talked to over HTTP unless their config has TLS settings.
+	PlainHTTP bool `json:"plain_http"`
+	// InsecureSkipVerify turns off verification of the registry's TLS certificate.
+	InsecureSkipVerify bool `json:"insecure_skip_verify"`
+	// CAFile is a PEM bundle of CAs the registry's certificate is verified against, in addition to the system's.
+	CAFile string `json:"ca_file"`
+	// CertFile and KeyFile are the PEM client certificate and key presented to registries that ask for one.
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
+}
+
+// hasTLS reports whether c sets up TLS connections to the registry.
+func (c RegistryConfig) hasTLS() bool {
+	return c.InsecureSkipVerify || c.CAFile != "" || c.CertFile != "" || c.KeyFile != ""
+}
+
+// tlsConfig returns the TLS settings connections to the registry use, nil for the defaults.
+func (c RegistryConfig) tlsConfig() (*tls.Config, error) {
+	if !c.hasTLS() {
+		return nil, nil
+	}
+
+	config := &tls.Config{InsecureSkipVerify: c.InsecureSkipVerify}
+
+	if c.CAFile != "" {
+		pem, err := ioutil.ReadFile(c.CAFile)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle %s: %w", c.CAFile, err)
+		}
+
+		if config.RootCAs, err = x509.SystemCertPool(); err != nil {
+			config.RootCAs = x509.NewCertPool()
+		}
+
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("failed to read CA bundle %s: no PEM certificates", c.CAFile)
+		}
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate %s: %w", c.CertFile, err)
+		}
+
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// String describes where c's credentials come from without giving them away, so configs can't leak credentials into logs.
+func (c RegistryConfig) String() string {
+	switch {
+	case c.Username != "":
+		return fmt.Sprintf("{username: %s, password: [redacted]}", c.Username)
+	case c.Token != "":
+		return "{token: [redacted]}"
+	case c.DockerConfig != "":
+		return fmt.Sprintf("{docker_config: %s}", c.DockerConfig)
+	}
+
+	return "{}"
+}
+
+// GoString redacts credentials from %#v as String does from %v.
+func (c RegistryConfig) GoString() string {
+	return c.String()
+}
+
+// Registries maps registry hosts, e.g. "docker.io" or "registry.example.com:5000", to their configs.
+type Registries map[string]RegistryConfig
+
+// Credentials returns the username and secret to authenticate with the given registry host. A secret without a username is an identity token.
+// Hosts without credentials get empty strings, so pulls from them stay anonymous.
+func (r Registries) Credentials(host string) (username, secret string, err error) {
+	config := r.lookup(host)
+
+	switch {
+	case config.Username != "":
+		return config.Username, config.Password, nil
+	case config.Token != "":
+		return "", config.Token, nil
+	case config.DockerConfig != "":
+		return dockerConfigCredentials(config.DockerConfig, registryHost(host))
+	}
+
+	return "", "", nil
+}
+
+// lookup returns the config of the given registry host, the zero RegistryConfig if it has none.
+func (r Registries) lookup(host string) RegistryConfig {
+	host = registryHost(host)
+
+	for key, config := range r {
+
+		if registryHost(key) == host {
+			return config
+		}
+	}
+
+	return RegistryConfig{}
+}
+
+// Hosts returns the endpoints pulls from the given registry host go through: its mirrors, in order, and then the registry itself.
+// It's the docker.RegistryHosts of the resolvers pulls use.
+func (r Registries) Hosts(host string) (hosts []docker.RegistryHost, err error) {
+	for _, mirror := range r.lookup(host).Mirrors {
+		endpoint, endpointErr := r.endpoint(mirror)
+
+		if endpointErr != nil {
+			return nil, fmt.Errorf("invalid mirror %s of registry %s: %w", mirror, host, endpointErr)
+		}
+
+		endpoint.Capabilities = docker.HostCapabilityPull | docker.HostCapabilityResolve
+		endpoint.Client.Transport = mirrorTransport{next: endpoint.Client.Transport, credentials: r.Credentials}
+		hosts = append(hosts, endpoint)
+	}
+
+	name := host
+
+	if registryHost(host) == dockerHubHost {
+		name = dockerHubEndpoint
+	}
+
+	endpoint, err := r.endpoint(name)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid registry %s: %w", host, err)
+	}
+
+	endpoint.Capabilities = docker.HostCapabilityPull | docker.HostCapabilityResolve | docker.HostCapabilityPush
+
+	return append(hosts, endpoint), nil
+}
+
+// endpoint returns the connection to the registry at the given endpoint, a host or a URL whose scheme overrides the host's config.
+func (r Registries) endpoint(name string) (docker.RegistryHost, error) {
+	if !strings.Contains(name, "://") {
+		name = "//" + name
+	}
+
+	u, err := url.Parse(name)
+
+	if err != nil {
+		return docker.RegistryHost{}, err
+	}
+
+	config := r.lookup(u.Host)
+	tlsConfig, err := config.tlsConfig()
+
+	if err != nil {
+		return docker.RegistryHost{}, err
+	}
+
+	if u.Scheme == "" {
+		u.Scheme = "https"
+
+		if local, _ := docker.MatchLocalhost(u.Host); config.PlainHTTP || (local && !config.hasTLS()) {
+			u.Scheme = "http"
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	// The authorizer talks to the registry's token service, whose failures are reported as they are even for mirrors, see mirrorTransport.
+	client, authClient := &http.Client{Transport: transport}, &http.Client{Transport: transport}
+	path := strings.TrimSuffix(u.Path, "/")
+
+	if !strings.HasSuffix(path, "/v2") {
+		path += "/v2"
+	}
+
+	return docker.RegistryHost{
+		Client:     client,
+		Authorizer: docker.NewDockerAuthorizer(docker.WithAuthClient(authClient), docker.WithAuthCreds(r.Credentials)),
+		Host:       u.Host,
+		Scheme:     u.Scheme,
+		Path:       path,
+	}, nil
+}
+
+// mirrorTransport reports a mirror that can't be reached, fails or turns requests away as not having what was asked for.
+// Resolvers only move on to the next host when one doesn't have something, this is what makes them fall back to the registry.
+// A mirror refusing access is turned away too, unless its 401 is a challenge the authorizer takes up and hasn't answered yet.
+type mirrorTransport struct {
+	next http.RoundTripper
+	// credentials are the ones the mirror's authorizer answers basic challenges with.
+	credentials func(host string) (username, secret string, err error)
+}
+
+func (t mirrorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+
+	if req.Context().Err() != nil {
+		return resp, err
+	}
+
+	if err == nil && !t.miss(req, resp) {
+		return resp, nil
+	}
+
+	if err == nil {
+		resp.Body.Close()
+	}
+
+	return &http.Response{
+		Status:     "404 Not Found",
+		StatusCode: http.StatusNotFound,
+		Proto:      req.Proto,
+		ProtoMajor: req.ProtoMajor,
+		ProtoMinor: req.ProtoMinor,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+		Request:    req,
+	}, nil
+}
+
+// miss reports whether the mirror's response to req means it can't serve what was asked for.
+func (t mirrorTransport) miss(req *http.Request, resp *http.Response) bool {
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError, resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode == http.StatusForbidden:
+		return true
+	case resp.StatusCode == http.StatusUnauthorized:
+		return req.Header.Get("Authorization") != "" || !t.challenged(req, resp)
+	}
+
+	return false
+}
+
+// challenged reports whether resp challenges req in a way the authorizer answers: bearer challenges, which anonymous pulls get tokens for too,
+// and basic challenges of mirrors that have credentials.
+func (t mirrorTransport) challenged(req *http.Request, resp *http.Response) bool {
+	for _, challenge := range resp.Header.Values("WWW-Authenticate") {
+		scheme := strings.ToLower(strings.SplitN(strings.TrimSpace(challenge), " ", 2)[0])
+
+		switch scheme {
+		case "bearer":
+			return true
+		case "basic":
+
+			if username, secret, err := t.credentials(req.URL.Host); err == nil && username != "" && secret != "" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// dockerAuth is an entry of the auths object of a docker config.json.
+type dockerAuth struct {
+	// Auth is the base64 encoding of username:password.
+	Auth          string `json:"auth"`
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+	IdentityToken string `json:"identitytoken"`
+}
+
+// dockerConfigCredentials looks the given registry host up in the auths of the docker config.json at path.
+func dockerConfigCredentials(path, host string) (username, secret string, err error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read docker config %s: %w", path, err)
+	}
+
+	var config struct {
+		Auths map[string]dockerAuth `json:"auths"`
+	}
+
+	if err = json.Unmarshal(data, &config); err != nil {
+		return "", "", fmt.Errorf("failed to decode docker config %s: %w", path, err)
+	}
+
+	for key, auth := range config.Auths {
+
+		if registryHost(key) != host {
+			continue
+		}
+
+		if auth.IdentityToken != "" {
+			return "", auth.IdentityToken, nil
+		}
+
+		if auth.Auth == "" {
+			return auth.Username, auth.Password, nil
+		}
+
+		decoded, decodeErr := base64.StdEncoding.DecodeString(auth.Auth)
+
+		if decodeErr != nil {
+			return "", "", fmt.Errorf("failed to decode docker config %s auth for %s: %w", path, key, decodeErr)
+		}
+
+		credentials := strings.SplitN(string(decoded), ":", 2)
+
+		if len(credentials) != 2 {
+			return "", "", fmt.Errorf("failed to decode docker config %s auth for %s: want username:password", path, key)
+		}
+
+		return credentials[0], credentials[1], nil
+	}
+
+	return "", "", nil
+}
+
+// registryHost reduces the ways a registry is named, e.g. https://index.docker.io/v1/ in docker configs, to its host.
+// Docker Hub's hosts all come out as docker.io.
+func registryHost(name string) string {
+	name = strings.TrimPrefix(strings.TrimPrefix(name, "https://"), "http://")
+
+	if slash := strings.Index(name, "/"); slash >= 0 {
+		name = name[:slash]
+	}
+
+	switch name {
+	case "index.docker.io", "registry-1.docker.io":
+		return dockerHubHost
+	}
+
+	return name
+}
@@ -0,0 +1,170 @@
+package node
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/oci"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// defaultCPUPeriod is the CFS period, in microseconds, used when a CPU quota is given without one.
+const defaultCPUPeriod = 100000
+
+// defaultCPUShares is the kernel's CPU weight for a cgroup, which a task gets back when its CPU shares are cleared.
+const defaultCPUShares = uint64(1024)
+
+// Resources holds the cgroup limits applied to a container's task. Zero values leave the corresponding limit unset.
+type Resources struct {
+	// CPUShares is the relative CPU weight against other containers.
+	CPUShares uint64 `json:"cpu_shares,omitempty"`
+	// CPUQuota is the CPU time, in microseconds, the task may use per CPUPeriod.
+	CPUQuota  int64  `json:"cpu_quota,omitempty"`
+	CPUPeriod uint64 `json:"cpu_period,omitempty"`
+	// MemoryLimit is the hard memory limit in bytes.
+	MemoryLimit int64 `json:"memory_limit,omitempty"`
+	// MemoryReservation is the soft memory limit in bytes.
+	MemoryReservation int64 `json:"memory_reservation,omitempty"`
+	// MemorySwap is the memory plus swap limit in bytes, or -1 for unlimited swap.
+	MemorySwap int64 `json:"memory_swap,omitempty"`
+	// PidsLimit is the maximum number of processes in the task, or -1 for unlimited.
+	PidsLimit int64 `json:"pids_limit,omitempty"`
+}
+
+// Validate reports limits that the kernel would reject or that contradict each other.
+func (r Resources) Validate() error {
+	switch {
+	case r.CPUQuota < 0:
+		return fmt.Errorf("cpu quota %d must not be negative: %w", r.CPUQuota, errdefs.ErrInvalidArgument)
+	case r.CPUQuota > 0 && r.CPUQuota < 1000:
+		return fmt.Errorf("cpu quota %d must be at least 1000us: %w", r.CPUQuota, errdefs.ErrInvalidArgument)
+	case r.CPUPeriod != 0 && (r.CPUPeriod < 1000 || r.CPUPeriod > 1000000):
+		return fmt.Errorf("cpu period %d must be between 1000us and 1s: %w", r.CPUPeriod, errdefs.ErrInvalidArgument)
+	case r.MemoryLimit < 0, r.MemoryReservation < 0:
+		return fmt.Errorf("memory limits must not be negative: %w", errdefs.ErrInvalidArgument)
+	case r.MemoryLimit > 0 && r.MemoryReservation > r.MemoryLimit:
+		return fmt.Errorf("memory reservation %d exceeds memory limit %d: %w", r.MemoryReservation, r.MemoryLimit, errdefs.ErrInvalidArgument)
+	case r.MemorySwap < -1:
+		return fmt.Errorf("memory swap %d must be -1 or positive: %w", r.MemorySwap, errdefs.ErrInvalidArgument)
+	case r.MemorySwap > 0 && r.MemoryLimit == 0:
+		return fmt.Errorf("memory swap requires a memory limit: %w", errdefs.ErrInvalidArgument)
+	case r.MemorySwap > 0 && r.MemorySwap < r.MemoryLimit:
+		return fmt.Errorf("memory swap %d must not be lower than memory limit %d: %w", r.MemorySwap, r.MemoryLimit, errdefs.ErrInvalidArgument)
+	case r.PidsLimit < -1:
+		return fmt.Errorf("pids limit %d must be -1 or positive: %w", r.PidsLimit, errdefs.ErrInvalidArgument)
+	}
+
+	return nil
+}
+
+// linuxResources maps r onto the OCI resources runc enforces through cgroups.
+func (r Resources) linuxResources() *specs.LinuxResources {
+	lr := &specs.LinuxResources{}
+
+	if r.CPUShares > 0 || r.CPUQuota > 0 || r.CPUPeriod > 0 {
+		lr.CPU = &specs.LinuxCPU{}
+
+		if r.CPUShares > 0 {
+			shares := r.CPUShares
+			lr.CPU.Shares = &shares
+		}
+
+		if r.CPUQuota > 0 {
+			quota, period := r.CPUQuota, r.CPUPeriod
+
+			if period == 0 {
+				period = defaultCPUPeriod
+			}
+
+			lr.CPU.Quota, lr.CPU.Period = &quota, &period
+		} else if r.CPUPeriod > 0 {
+			period := r.CPUPeriod
+			lr.CPU.Period = &period
+		}
+	}
+
+	if r.MemoryLimit > 0 || r.MemoryReservation > 0 || r.MemorySwap != 0 {
+		lr.Memory = &specs.LinuxMemory{}
+
+		if r.MemoryLimit > 0 {
+			limit := r.MemoryLimit
+			lr.Memory.Limit = &limit
+		}
+
+		if r.MemoryReservation > 0 {
+			reservation := r.MemoryReservation
+			lr.Memory.Reservation = &reservation
+		}
+
+		if r.MemorySwap != 0 {
+			swap := r.MemorySwap
+			lr.Memory.Swap = &swap
+		}
+	}
+
+	if r.PidsLimit != 0 {
+		lr.Pids = &specs.LinuxPids{Limit: r.PidsLimit}
+	}
+
+	return lr
+}
+
+// updateResources maps r onto the OCI resources sent to a live task. runc update leaves the limits it gets nil for as they are,
+// so limits that r clears are sent as the values that lift them instead.
+func (r Resources) updateResources() *specs.LinuxResources {
+	lr := r.linuxResources()
+	unlimited := int64(-1)
+
+	if lr.CPU == nil {
+		lr.CPU = &specs.LinuxCPU{}
+	}
+
+	if lr.CPU.Shares == nil {
+		shares := defaultCPUShares
+		lr.CPU.Shares = &shares
+	}
+
+	if lr.CPU.Quota == nil {
+		quota := unlimited
+		lr.CPU.Quota = &quota
+	}
+
+	if lr.Memory == nil {
+		lr.Memory = &specs.LinuxMemory{}
+	}
+
+	for _, limit := range []**int64{&lr.Memory.Limit, &lr.Memory.Reservation, &lr.Memory.Swap} {
+
+		if *limit == nil {
+			value := unlimited
+			*limit = &value
+		}
+	}
+
+	if lr.Pids == nil {
+		lr.Pids = &specs.LinuxPids{Limit: unlimited}
+	}
+
+	return lr
+}
+
+// withResources is an oci.SpecOpts that replaces the spec's cgroup limits with r, keeping its device rules.
+func withResources(r Resources) oci.SpecOpts {
+	return func(_ context.Context, _ oci.Client, _ *containers.Container, s *oci.Spec) error {
+		if s.Linux == nil {
+			s.Linux = &specs.Linux{}
+		}
+
+		lr := r.linuxResources()
+
+		if s.Linux.Resources != nil {
+			lr.Devices = s.Linux.Resources.Devices
+		}
+
+		s.Linux.Resources = lr
+
+		return nil
+	}
+}
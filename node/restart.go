@@ -0,0 +1,368 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/namespaces"
+)
+
+// Restart policies. A container's policy is the value of its RestartPolicyLabel, e.g. on-failure:3.
+const (
+	// RestartNo never restarts the container's task.
+	RestartNo = "no"
+	// RestartOnFailure restarts the task when it exits with a non-zero status, at most MaxRetries times if that's set.
+	RestartOnFailure = "on-failure"
+	// RestartAlways restarts the task whenever it exits, unless it was stopped by hand.
+	// A task stopped by hand is restarted anyway when the node starts.
+	RestartAlways = "always"
+	// RestartUnlessStopped restarts the task whenever it exits, unless it was stopped by hand, including when the node starts.
+	RestartUnlessStopped = "unless-stopped"
+)
+
+// Container labels holding the restart policy of a container and the restart supervisor's state, so both survive a node restart.
+const (
+	RestartPolicyLabel = "io.clamor.restart.policy"
+	// RestartCountLabel counts the restarts since the task was last started by hand.
+	RestartCountLabel = "io.clamor.restart.count"
+	// RestartStoppedLabel is "true" once the task is stopped by hand and "false" once it's started by hand.
+	RestartStoppedLabel = "io.clamor.restart.stopped"
+	LastExitStatusLabel = "io.clamor.restart.exit-status"
+	LastExitAtLabel     = "io.clamor.restart.exited-at"
+)
+
+// Restart backoff defaults. The delay before a restart doubles with every restart since the task was last started by hand.
+const (
+	DefaultRestartBackoff    = 100 * time.Millisecond
+	DefaultMaxRestartBackoff = time.Minute
+)
+
+// RestartPolicy says when the restart supervisor restarts a container's task after it exits.
+type RestartPolicy struct {
+	// Name is RestartNo, RestartOnFailure, RestartAlways or RestartUnlessStopped.
+	Name string
+	// MaxRetries limits the restarts of RestartOnFailure. Zero doesn't limit them.
+	MaxRetries int
+}
+
+// ParseRestartPolicy parses a policy in the form docker run --restart takes, e.g. always or on-failure:3. The empty string is RestartNo.
+func ParseRestartPolicy(s string) (p RestartPolicy, err error) {
+	p.Name = s
+
+	if s == "" {
+		p.Name = RestartNo
+	}
+
+	if i := strings.Index(s, ":"); i >= 0 {
+		p.Name = s[:i]
+
+		if p.MaxRetries, err = strconv.Atoi(s[i+1:]); err != nil || p.MaxRetries < 0 {
+			return RestartPolicy{}, fmt.Errorf("restart policy %q must have a non-negative retry count: %w", s, errdefs.ErrInvalidArgument)
+		}
+
+		if p.Name != RestartOnFailure {
+			return RestartPolicy{}, fmt.Errorf("restart policy %q can't have a retry count: %w", s, errdefs.ErrInvalidArgument)
+		}
+	}
+
+	switch p.Name {
+	case RestartNo, RestartOnFailure, RestartAlways, RestartUnlessStopped:
+		return p, nil
+	}
+
+	return RestartPolicy{}, fmt.Errorf("unknown restart policy %q: %w", s, errdefs.ErrInvalidArgument)
+}
+
+func (p RestartPolicy) String() string {
+	if p.Name == RestartOnFailure && p.MaxRetries > 0 {
+		return p.Name + ":" + strconv.Itoa(p.MaxRetries)
+	}
+
+	return p.Name
+}
+
+// RestartStatus is a container's restart policy and what the restart supervisor recorded about it.
+type RestartStatus struct {
+	Policy RestartPolicy
+	// Count is how many times the task was restarted since it was last started by hand.
+	Count int
+	// Stopped is set once the task is stopped by hand, with its stop signal or SIGKILL, until it's started by hand again.
+	Stopped bool
+	// LastExitStatus and LastExitAt describe the task's last exit. LastExitAt is zero until it first exits.
+	LastExitStatus uint32
+	LastExitAt     time.Time
+}
+
+// ParseRestartStatus reads the restart status off a container's labels. Containers without a RestartPolicyLabel aren't supervised:
+// supervised reports whether the labels have one.
+func ParseRestartStatus(labels map[string]string) (status RestartStatus, supervised bool, err error) {
+	policy, supervised := labels[RestartPolicyLabel]
+
+	if !supervised {
+		return RestartStatus{}, false, nil
+	}
+
+	if status.Policy, err = ParseRestartPolicy(policy); err != nil {
+		return RestartStatus{}, true, err
+	}
+
+	// The supervisor's own labels are only ever written by it, so malformed values are read as zero.
+	status.Count, _ = strconv.Atoi(labels[RestartCountLabel])
+	status.Stopped = labels[RestartStoppedLabel] == "true"
+
+	if exitStatus, parseErr := strconv.ParseUint(labels[LastExitStatusLabel], 10, 32); parseErr == nil {
+		status.LastExitStatus = uint32(exitStatus)
+	}
+
+	status.LastExitAt, _ = time.Parse(time.RFC3339Nano, labels[LastExitAtLabel])
+
+	return status, true, nil
+}
+
+// restartSupervisor restarts the tasks of supervised containers under their restart policy when they exit.
+type restartSupervisor struct {
+	node *Node
+	// backoff is the delay before the first restart, it doubles up to maxBackoff with every further one.
+	backoff, maxBackoff time.Duration
+
+	mu sync.Mutex
+	// pending holds the containers whose restart is scheduled, by namespace and ID.
+	pending map[string]bool
+}
+
+// run watches task exits until ctx is done, resubscribing whenever the Backend's event stream fails. subscribed is closed after the first subscription.
+// Every subscription is followed by a scan of the supervised containers, for the exits the supervisor didn't see.
+func (s *restartSupervisor) run(ctx context.Context, subscribed chan<- struct{}) {
+	for startup := true; ; startup = false {
+		subCtx, cancel := context.WithCancel(ctx)
+		events, errs := s.node.Backend.Subscribe(subCtx, `topic=="`+TaskExitTopic+`"`)
+
+		if subscribed != nil {
+			close(subscribed)
+			subscribed = nil
+		}
+
+		s.scan(ctx, startup)
+		s.watch(ctx, events, errs)
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(eventRetry):
+		}
+	}
+}
+
+// watch handles the exits of tasks' own processes until the stream fails or ctx is done.
+func (s *restartSupervisor) watch(ctx context.Context, events <-chan Event, errs <-chan error) {
+	for {
+		select {
+		case e, open := <-events:
+			if !open {
+				return
+			}
+
+			if e.ExecID == "" {
+				s.exited(namespaces.WithNamespace(ctx, e.Namespace), e.ContainerID, e.ExitStatus, e.ExitedAt)
+			}
+		case <-errs:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// scan handles the supervised containers whose task is stopped, or gone, e.g. because containerd restarted.
+// Tasks that were never started are left alone. On startup, tasks of RestartAlways containers are restarted even if they were stopped by hand.
+func (s *restartSupervisor) scan(ctx context.Context, startup bool) {
+	nss, err := s.node.Backend.Namespaces(ctx)
+
+	if err != nil {
+		return
+	}
+
+	for _, ns := range nss {
+		ctx := namespaces.WithNamespace(ctx, ns)
+		cs, listErr := s.node.Backend.Containers(ctx, `labels."`+RestartPolicyLabel+`"`)
+
+		if listErr != nil {
+			continue
+		}
+
+		for _, c := range cs {
+			labels, labelsErr := c.Labels(ctx)
+			status, _, statusErr := ParseRestartStatus(labels)
+			_, started := labels[RestartStoppedLabel]
+
+			if labelsErr != nil || statusErr != nil {
+				continue
+			}
+
+			forced := startup && status.Policy.Name == RestartAlways && status.Stopped
+			task, loadErr := c.LoadTask(ctx)
+
+			if loadErr == nil {
+				taskStatus, taskStatusErr := task.Status(ctx, nil)
+
+				switch {
+				case taskStatusErr != nil || taskStatus.Status != containerd.Stopped:
+				case forced:
+					s.schedule(ctx, c.ID(), status.Count, true)
+				default:
+					s.exited(ctx, c.ID(), taskStatus.ExitStatus, taskStatus.ExitTime)
+				}
+
+				continue
+			} else if !errors.Is(loadErr, errdefs.ErrNotFound) || !started {
+				continue
+			}
+
+			switch {
+			case forced:
+				s.schedule(ctx, c.ID(), status.Count, true)
+			case (status.Policy.Name == RestartAlways || status.Policy.Name == RestartUnlessStopped) && !status.Stopped:
+				s.schedule(ctx, c.ID(), status.Count, false)
+			}
+		}
+	}
+}
+
+// exited records the exit of a container's task and schedules its restart if the container's policy asks for one.
+func (s *restartSupervisor) exited(ctx context.Context, id string, exitStatus uint32, exitedAt time.Time) {
+	c, err := s.node.Backend.LoadContainer(ctx, id)
+
+	if err != nil {
+		return
+	}
+
+	labels, err := c.Labels(ctx)
+
+	if err != nil {
+		return
+	}
+
+	status, supervised, err := ParseRestartStatus(labels)
+
+	if !supervised || err != nil {
+		return
+	}
+
+	setErr := c.SetLabels(ctx, map[string]string{
+		LastExitStatusLabel: strconv.FormatUint(uint64(exitStatus), 10),
+		LastExitAtLabel:     exitedAt.UTC().Format(time.RFC3339Nano),
+	})
+
+	if setErr != nil || status.Stopped {
+		return
+	}
+
+	switch status.Policy.Name {
+	case RestartOnFailure:
+
+		if exitStatus == 0 || (status.Policy.MaxRetries > 0 && status.Count >= status.Policy.MaxRetries) {
+			return
+		}
+	case RestartAlways, RestartUnlessStopped:
+	default:
+		return
+	}
+
+	s.schedule(ctx, id, status.Count, false)
+}
+
+// schedule restarts the container's task after the backoff of its restart count, unless a restart is already scheduled. Restarts that come due after ctx is done are dropped.
+// A forced restart ignores the task being stopped by hand.
+func (s *restartSupervisor) schedule(ctx context.Context, id string, count int, force bool) {
+	namespace, _ := namespaces.Namespace(ctx)
+	key := namespace + "/" + id
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending[key] {
+		return
+	}
+
+	s.pending[key] = true
+	delay := s.backoff
+
+	for i := 0; i < count && delay < s.maxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > s.maxBackoff {
+		delay = s.maxBackoff
+	}
+
+	time.AfterFunc(delay, func() {
+
+		if ctx.Err() == nil {
+			s.restart(ctx, id, force)
+		}
+
+		s.mu.Lock()
+		delete(s.pending, key)
+		s.mu.Unlock()
+	})
+}
+
+// restart replaces the container's stopped task with a new one, unless it was stopped by hand or started again in the meantime.
+func (s *restartSupervisor) restart(ctx context.Context, id string, force bool) {
+	c, err := s.node.Backend.LoadContainer(ctx, id)
+
+	if err != nil {
+		return
+	}
+
+	labels, err := c.Labels(ctx)
+
+	if err != nil {
+		return
+	}
+
+	status, supervised, err := ParseRestartStatus(labels)
+
+	if !supervised || err != nil || (status.Stopped && !force) {
+		return
+	}
+
+	if task, loadErr := c.LoadTask(ctx); loadErr == nil {
+		taskStatus, statusErr := task.Status(ctx, nil)
+
+		if statusErr != nil || taskStatus.Status != containerd.Stopped {
+			return
+		}
+
+		if _, deleteErr := s.node.DeleteTask(ctx, id); deleteErr != nil {
+			return
+		}
+	} else if !errors.Is(loadErr, errdefs.ErrNotFound) {
+		return
+	}
+
+	s.node.createTask(ctx, id, map[string]string{RestartCountLabel: strconv.Itoa(status.Count + 1), RestartStoppedLabel: "false"})
+}
+
+// setRestartLabels sets the given labels on the container if it's supervised. Unsupervised containers are left as they are.
+func setRestartLabels(ctx context.Context, c RuntimeContainer, labels map[string]string) error {
+	current, err := c.Labels(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if _, supervised := current[RestartPolicyLabel]; !supervised {
+		return nil
+	}
+
+	return c.SetLabels(ctx, labels)
+}
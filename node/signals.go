@@ -0,0 +1,40 @@
+package node
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/errdefs"
+)
+
+// DefaultStopTimeout is how long a graceful stop waits for a task to exit before it's killed.
+const DefaultStopTimeout = 10 * time.Second
+
+// ParseSignal parses a signal number or name. Names are case insensitive and the SIG prefix is optional, so "15", "SIGTERM" and "term" are equivalent.
+func ParseSignal(raw string) (syscall.Signal, error) {
+	name := strings.ToUpper(raw)
+
+	if name != "" && (name[0] < '0' || name[0] > '9') && !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+
+	signal, err := containerd.ParseSignal(name)
+
+	if err != nil {
+		return 0, fmt.Errorf("invalid signal %q: %v: %w", raw, err, errdefs.ErrInvalidArgument)
+	}
+
+	return signal, nil
+}
+
+// stopSignal returns the stop signal recorded in the given container labels, SIGTERM if there's none.
+func stopSignal(labels map[string]string) (syscall.Signal, error) {
+	if raw, exists := labels[containerd.StopSignalLabel]; exists {
+		return ParseSignal(raw)
+	}
+
+	return syscall.SIGTERM, nil
+}
@@ -2,14 +2,27 @@ package node
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"syscall"
 
 	"github.com/containerd/containerd"
 	"github.com/containerd/containerd/cio"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/oci"
+	"golang.org/x/sys/unix"
 )
 
 // ExitStatus wraps containerd.ExitStatus
 type ExitStatus containerd.ExitStatus
 
+// ExitCode returns the process's exit code, containerd.UnknownExitStatus if it's unknown.
+func (es ExitStatus) ExitCode() uint32 {
+	return containerd.ExitStatus(es).ExitCode()
+}
+
 // Status wraps containerd.Status
 type Status containerd.Status
 
@@ -22,16 +35,21 @@ type Task interface {
 	Pid() uint32
 	Status(ctx context.Context, attach cio.Attach) (Status, error)
 	Pids(ctx context.Context) ([]ProcessInfo, error)
+	Metrics(ctx context.Context) (Metrics, error)
 }
 
-func newTask(c containerd.Task) Task {
+func newTask(client *containerd.Client, c containerd.Container, t containerd.Task) RuntimeTask {
 	return &task{
-		ctrTask: c,
+		client:       client,
+		ctrContainer: c,
+		ctrTask:      t,
 	}
 }
 
 type task struct {
-	ctrTask containerd.Task
+	client       *containerd.Client
+	ctrContainer containerd.Container
+	ctrTask      containerd.Task
 }
 
 func (t *task) ID() string {
@@ -56,3 +74,207 @@ func (t *task) Pids(ctx context.Context) (pis []ProcessInfo, err error) {
 
 	return pis, err
 }
+
+func (t *task) Metrics(ctx context.Context) (Metrics, error) {
+	m, err := t.ctrTask.Metrics(ctx)
+
+	if err != nil {
+		return Metrics{}, err
+	}
+
+	return decodeMetrics(m)
+}
+
+func (t *task) Start(ctx context.Context) error {
+	return t.ctrTask.Start(ctx)
+}
+
+func (t *task) Wait(ctx context.Context) (<-chan ExitStatus, error) {
+	ctrES, err := t.ctrTask.Wait(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return exitStatusChannel(ctrES), nil
+}
+
+func (t *task) Kill(ctx context.Context, signal syscall.Signal) error {
+	return t.ctrTask.Kill(ctx, signal)
+}
+
+func (t *task) Update(ctx context.Context, r Resources) error {
+	return t.ctrTask.Update(ctx, containerd.WithResources(r.updateResources()))
+}
+
+func (t *task) Pause(ctx context.Context) error {
+	return t.ctrTask.Pause(ctx)
+}
+
+func (t *task) Resume(ctx context.Context) error {
+	return t.ctrTask.Resume(ctx)
+}
+
+func (t *task) Resize(ctx context.Context, width, height uint32) error {
+	return t.ctrTask.Resize(ctx, width, height)
+}
+
+// Dial opens the socket on a thread that joins the network namespace of the task's process.
+// The thread is never unlocked, so it exits with the dialing goroutine instead of going back to the scheduler in the task's namespace.
+func (t *task) Dial(ctx context.Context, network, address string) (net.Conn, error) {
+	netns, err := os.Open(fmt.Sprintf("/proc/%d/ns/net", t.Pid()))
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to open network namespace of task %s: %w", t.ID(), err)
+	}
+
+	defer netns.Close()
+
+	type dialed struct {
+		conn net.Conn
+		err  error
+	}
+
+	result := make(chan dialed, 1)
+
+	go func() {
+		runtime.LockOSThread()
+
+		if setnsErr := unix.Setns(int(netns.Fd()), unix.CLONE_NEWNET); setnsErr != nil {
+			result <- dialed{err: fmt.Errorf("failed to join network namespace of task %s: %w", t.ID(), setnsErr)}
+			return
+		}
+
+		var dialer net.Dialer
+		conn, dialErr := dialer.DialContext(ctx, network, address)
+		result <- dialed{conn: conn, err: dialErr}
+	}()
+
+	d := <-result
+
+	return d.conn, d.err
+}
+
+func (t *task) Delete(ctx context.Context) (ExitStatus, error) {
+	es, err := t.ctrTask.Delete(ctx)
+
+	if err != nil {
+		return ExitStatus{}, err
+	}
+
+	return ExitStatus(*es), nil
+}
+
+func (t *task) Exec(ctx context.Context, id string, spec ProcessSpec, io TaskIO) (RuntimeProcess, error) {
+	s, err := t.ctrContainer.Spec(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Process == nil {
+		return nil, fmt.Errorf("spec of container %s has no process to exec from: %w", t.ctrContainer.ID(), errdefs.ErrFailedPrecondition)
+	}
+
+	// The task's own process is the template, so the exec'd one shares its capabilities, rlimits and environment.
+	pspec := *s.Process
+	pspec.Args = spec.Args
+	pspec.Env = append([]string{}, pspec.Env...)
+	pspec.Terminal = spec.Terminal
+	execSpec := &oci.Spec{Process: &pspec, Root: s.Root}
+	var opts []oci.SpecOpts
+
+	if len(spec.Env) > 0 {
+		opts = append(opts, oci.WithEnv(spec.Env))
+	}
+
+	if spec.WorkingDir != "" {
+		opts = append(opts, oci.WithProcessCwd(spec.WorkingDir))
+	}
+
+	// Users are resolved against the container's rootfs, the same way ContainerSpec.User is when the container is created.
+	if spec.User != "" {
+		opts = append(opts, oci.WithUser(spec.User))
+	}
+
+	info, err := t.ctrContainer.Info(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, opt := range opts {
+		if err = opt(ctx, t.client, &info, execSpec); err != nil {
+			return nil, err
+		}
+	}
+
+	ioOpts := []cio.Opt{cio.WithStreams(io.Stdin, io.Stdout, io.Stderr)}
+
+	if spec.Terminal {
+		ioOpts = append(ioOpts, cio.WithTerminal)
+	}
+
+	p, err := t.ctrTask.Exec(ctx, id, &pspec, cio.NewCreator(ioOpts...))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &process{ctrProcess: p}, nil
+}
+
+type process struct {
+	ctrProcess containerd.Process
+}
+
+func (p *process) ID() string {
+	return p.ctrProcess.ID()
+}
+
+func (p *process) Pid() uint32 {
+	return p.ctrProcess.Pid()
+}
+
+func (p *process) Start(ctx context.Context) error {
+	return p.ctrProcess.Start(ctx)
+}
+
+func (p *process) Wait(ctx context.Context) (<-chan ExitStatus, error) {
+	ctrES, err := p.ctrProcess.Wait(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return exitStatusChannel(ctrES), nil
+}
+
+func (p *process) Kill(ctx context.Context, signal syscall.Signal) error {
+	return p.ctrProcess.Kill(ctx, signal)
+}
+
+func (p *process) Resize(ctx context.Context, width, height uint32) error {
+	return p.ctrProcess.Resize(ctx, width, height)
+}
+
+func (p *process) Delete(ctx context.Context) (ExitStatus, error) {
+	es, err := p.ctrProcess.Delete(ctx)
+
+	if err != nil {
+		return ExitStatus{}, err
+	}
+
+	return ExitStatus(*es), nil
+}
+
+func exitStatusChannel(ctrES <-chan containerd.ExitStatus) <-chan ExitStatus {
+	es := make(chan ExitStatus, 1)
+
+	go func() {
+		es <- ExitStatus(<-ctrES)
+		close(es)
+	}()
+
+	return es
+}
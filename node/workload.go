@@ -0,0 +1,623 @@
+package node
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/identifiers"
+	"github.com/containerd/containerd/namespaces"
+	"github.com/containerd/containerd/reference"
+)
+
+// Desired states of a workload's task.
+const (
+	// WorkloadRunning keeps the task running. It's the default.
+	WorkloadRunning = "running"
+	// WorkloadStopped keeps the container but no running task.
+	WorkloadStopped = "stopped"
+)
+
+// Container labels that tie a container to the workload it runs, so workloads are picked up again when the node restarts.
+const (
+	WorkloadLabel = "io.clamor.workload"
+	// WorkloadHashLabel is a digest of the image and spec the container was created from. A workload whose digest differs gets a new container.
+	WorkloadHashLabel  = "io.clamor.workload.hash"
+	WorkloadStateLabel = "io.clamor.workload.state"
+)
+
+// DefaultReconcileInterval is how often workloads are checked for drift when no other interval is set, see WithReconcileInterval.
+const DefaultReconcileInterval = 10 * time.Second
+
+// Workload is a container the node keeps in a desired state: created from Image with Spec, and with its task running or not.
+type Workload struct {
+	// Name is the ID of the workload's container.
+	Name  string        `json:"name"`
+	Image string        `json:"image"`
+	Spec  ContainerSpec `json:"spec"`
+	// State is WorkloadRunning or WorkloadStopped. The empty string is WorkloadRunning.
+	State string `json:"state,omitempty"`
+}
+
+// Validate reports malformed workloads.
+func (w Workload) Validate() error {
+	if err := identifiers.Validate(w.Name); err != nil {
+		return fmt.Errorf("workload name %q: %w", w.Name, err)
+	}
+
+	if _, err := reference.Parse(w.Image); err != nil {
+		return fmt.Errorf("workload image %q: %s: %w", w.Image, err, errdefs.ErrInvalidArgument)
+	}
+
+	switch w.State {
+	case "", WorkloadRunning, WorkloadStopped:
+	default:
+		return fmt.Errorf("workload state %q must be %s or %s: %w", w.State, WorkloadRunning, WorkloadStopped, errdefs.ErrInvalidArgument)
+	}
+
+	return w.Spec.Validate()
+}
+
+// hash digests the image and spec of w, the parts of a workload its container is created from.
+func (w Workload) hash() string {
+	data, _ := json.Marshal(struct {
+		Image string        `json:"image"`
+		Spec  ContainerSpec `json:"spec"`
+	}{w.Image, w.Spec})
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// WorkloadStatus is a workload as its last reconcile found it.
+type WorkloadStatus struct {
+	Workload Workload
+	// Synced reports whether the last reconcile left the runtime matching the workload.
+	Synced bool
+	// Drift lists what the last reconcile found out of place and set right, e.g. "task missing".
+	Drift []string
+	// Error is why the last reconcile failed. It's retried on the next one.
+	Error string
+	// TaskStatus is the status of the workload's task after the last reconcile, empty if it has none.
+	TaskStatus   string
+	ReconciledAt time.Time
+}
+
+// Manifest is a file of workloads, see LoadManifest.
+type Manifest struct {
+	// Namespace the workloads are applied in. Defaults to containerd's default namespace.
+	Namespace string     `json:"namespace"`
+	Workloads []Workload `json:"workloads"`
+}
+
+// LoadManifest reads the given .json manifest file, and checks its workloads are well formed.
+func LoadManifest(path string) (m Manifest, err error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return Manifest{}, fmt.Errorf("failed to read manifest %s: %w", path, err)
+	}
+
+	return parseManifest(path, data)
+}
+
+// parseManifest decodes the manifest read from the given file, see LoadManifest.
+func parseManifest(path string, data []byte) (m Manifest, err error) {
+	if err = json.Unmarshal(data, &m); err != nil {
+		return Manifest{}, fmt.Errorf("failed to decode manifest %s: %w", path, err)
+	}
+
+	if m.Namespace == "" {
+		m.Namespace = namespaces.Default
+	}
+
+	if err = namespaces.Validate(m.Namespace); err != nil {
+		return Manifest{}, fmt.Errorf("invalid namespace in manifest %s: %w", path, err)
+	}
+
+	for _, w := range m.Workloads {
+
+		if err = w.Validate(); err != nil {
+			return Manifest{}, fmt.Errorf("invalid workload %s in manifest %s: %w", w.Name, path, err)
+		}
+	}
+
+	return m, nil
+}
+
+// ApplyManifest applies the workloads of the manifest in its namespace, in order. It stops at the first workload svc rejects,
+// and returns the statuses of the workloads it applied before along with the error.
+func ApplyManifest(svc WorkloadService, m Manifest) (statuses []WorkloadStatus, err error) {
+	ctx := namespaces.WithNamespace(context.Background(), m.Namespace)
+
+	for _, w := range m.Workloads {
+		status, applyErr := svc.ApplyWorkload(ctx, w)
+
+		if applyErr != nil {
+			return statuses, fmt.Errorf("failed to apply workload %s: %w", w.Name, applyErr)
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// reconciler keeps the containers and tasks of the applied workloads in the state their workloads describe.
+type reconciler struct {
+	node     *Node
+	interval time.Duration
+
+	mu        sync.Mutex
+	workloads map[string]map[string]*workloadRecord
+	// passes holds the locks of the workloads being reconciled or deleted, by namespace and name.
+	passes map[string]*workloadPass
+}
+
+// workloadPass is held while a workload is reconciled or deleted, so it's never converged twice at once.
+// Other workloads go on, so a slow pull only holds up the workloads of its image.
+type workloadPass struct {
+	sync.Mutex
+	// refs counts the holder and the waiters of the pass, which is dropped once none are left. It's guarded by the reconciler's lock.
+	refs int
+}
+
+// workloadRecord is an applied workload, with the digest its container has to carry and the outcome of its last reconcile.
+type workloadRecord struct {
+	workload Workload
+	hash     string
+	status   WorkloadStatus
+}
+
+func newReconciler(n *Node) *reconciler {
+	return &reconciler{
+		node:      n,
+		interval:  DefaultReconcileInterval,
+		workloads: make(map[string]map[string]*workloadRecord),
+		passes:    make(map[string]*workloadPass),
+	}
+}
+
+// lock takes the pass of the given workload and returns the func that releases it.
+func (r *reconciler) lock(ns, name string) (unlock func()) {
+	key := ns + "/" + name
+
+	r.mu.Lock()
+	pass, exists := r.passes[key]
+
+	if !exists {
+		pass = &workloadPass{}
+		r.passes[key] = pass
+	}
+
+	pass.refs++
+	r.mu.Unlock()
+
+	pass.Lock()
+
+	return func() {
+		pass.Unlock()
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if pass.refs--; pass.refs == 0 {
+			delete(r.passes, key)
+		}
+	}
+}
+
+// run reconciles every workload each interval until ctx is done, starting right away if adopt picked up any.
+func (r *reconciler) run(ctx context.Context, adopted bool) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	if adopted {
+		r.reconcileAll(ctx)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.reconcileAll(ctx)
+		}
+	}
+}
+
+// adopt rebuilds the workloads of the containers created before the node started, from the containers that carry a WorkloadLabel.
+// It reports whether it found any.
+func (r *reconciler) adopt() (adopted bool) {
+	nss, err := r.node.Backend.Namespaces(context.Background())
+
+	if err != nil {
+		return false
+	}
+
+	for _, ns := range nss {
+		ctx := namespaces.WithNamespace(context.Background(), ns)
+		cs, listErr := r.node.Backend.Containers(ctx, `labels."`+WorkloadLabel+`"`)
+
+		if listErr != nil {
+			continue
+		}
+
+		for _, c := range cs {
+			labels, labelsErr := c.Labels(ctx)
+			spec, specErr := c.Spec(ctx)
+			image, imageErr := c.Image(ctx)
+
+			if labelsErr != nil || specErr != nil || imageErr != nil || labels[WorkloadLabel] != c.ID() {
+				continue
+			}
+
+			// The labels Node adds on creation aren't part of the workload.
+			specLabels := make(map[string]string)
+
+			for k, v := range spec.Labels {
+				specLabels[k] = v
+			}
+
+			delete(specLabels, WorkloadLabel)
+			delete(specLabels, WorkloadHashLabel)
+			delete(specLabels, WorkloadStateLabel)
+
+			if spec.RestartPolicy != "" {
+				delete(specLabels, RestartPolicyLabel)
+			}
+
+			if spec.Labels = specLabels; len(specLabels) == 0 {
+				spec.Labels = nil
+			}
+
+			w := Workload{Name: c.ID(), Image: image.Name(), Spec: spec, State: labels[WorkloadStateLabel]}
+
+			if w.State == "" {
+				w.State = WorkloadRunning
+			}
+
+			r.mu.Lock()
+
+			if _, applied := r.namespace(ns)[w.Name]; !applied {
+				r.namespace(ns)[w.Name] = &workloadRecord{workload: w, hash: labels[WorkloadHashLabel], status: WorkloadStatus{Workload: w}}
+				adopted = true
+			}
+
+			r.mu.Unlock()
+		}
+	}
+
+	return adopted
+}
+
+// namespace returns the workloads applied in the given namespace. Callers must hold r.mu.
+func (r *reconciler) namespace(ns string) map[string]*workloadRecord {
+	workloads, exists := r.workloads[ns]
+
+	if !exists {
+		workloads = make(map[string]*workloadRecord)
+		r.workloads[ns] = workloads
+	}
+
+	return workloads
+}
+
+// reconcileAll reconciles every applied workload, until ctx is done.
+func (r *reconciler) reconcileAll(ctx context.Context) {
+	type target struct {
+		ns     string
+		record *workloadRecord
+	}
+
+	var targets []target
+
+	r.mu.Lock()
+
+	for ns, workloads := range r.workloads {
+
+		for _, record := range workloads {
+			targets = append(targets, target{ns: ns, record: record})
+		}
+	}
+
+	r.mu.Unlock()
+
+	for _, t := range targets {
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		r.reconcile(namespaces.WithNamespace(ctx, t.ns), t.record)
+	}
+}
+
+// apply stores the workload as the desired state of its container, replacing the previous one, and reconciles it.
+func (r *reconciler) apply(ctx context.Context, w Workload) (WorkloadStatus, error) {
+	ns, err := namespaces.NamespaceRequired(ctx)
+
+	if err != nil {
+		return WorkloadStatus{}, err
+	}
+
+	if err = w.Validate(); err != nil {
+		return WorkloadStatus{}, err
+	}
+
+	if w.State == "" {
+		w.State = WorkloadRunning
+	}
+
+	record := &workloadRecord{workload: w, hash: w.hash(), status: WorkloadStatus{Workload: w}}
+
+	r.mu.Lock()
+	r.namespace(ns)[w.Name] = record
+	r.mu.Unlock()
+
+	return r.reconcile(ctx, record), nil
+}
+
+// reconcile converges the runtime to the record's workload, unless the workload was deleted or replaced, and records the outcome.
+func (r *reconciler) reconcile(ctx context.Context, record *workloadRecord) WorkloadStatus {
+	ns, _ := namespaces.Namespace(ctx)
+	w := record.workload
+
+	unlock := r.lock(ns, w.Name)
+	defer unlock()
+
+	r.mu.Lock()
+	current := r.workloads[ns][w.Name] == record
+	r.mu.Unlock()
+
+	if !current {
+		return r.status(record)
+	}
+
+	drift, err := r.converge(ctx, w, record.hash)
+	status := WorkloadStatus{Workload: w, Synced: err == nil, Drift: drift, ReconciledAt: time.Now().UTC()}
+
+	if err != nil {
+		status.Error = err.Error()
+	}
+
+	if task, taskErr := r.node.getTask(ctx, w.Name); taskErr == nil {
+		status.TaskStatus = TaskStatus(ctx, task)
+	}
+
+	r.mu.Lock()
+	record.status = status
+	r.mu.Unlock()
+
+	return status
+}
+
+func (r *reconciler) status(record *workloadRecord) WorkloadStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := record.status
+	status.Drift = append([]string(nil), record.status.Drift...)
+
+	return status
+}
+
+// converge makes the runtime match the workload: its image pulled, its container created from the workload's image and spec,
+// and its task running or not. A task that exited is started again unless the workload's spec has a restart policy, which then decides.
+// It returns what it found out of place.
+func (r *reconciler) converge(ctx context.Context, w Workload, hash string) (drift []string, err error) {
+	n := r.node
+
+	if _, err = n.Backend.GetImage(ctx, w.Image); errors.Is(err, errdefs.ErrNotFound) {
+		drift = append(drift, "image missing")
+
+		if _, err = n.PullImage(ctx, w.Image); err != nil {
+			return drift, err
+		}
+	} else if err != nil {
+		return drift, fmt.Errorf("failed to get image %s: %w", w.Image, err)
+	}
+
+	container, err := n.Backend.LoadContainer(ctx, w.Name)
+
+	switch {
+	case errors.Is(err, errdefs.ErrNotFound):
+		drift = append(drift, "container missing")
+		container = nil
+	case err != nil:
+		return drift, fmt.Errorf("failed to load container %s: %w", w.Name, err)
+	default:
+		labels, labelsErr := container.Labels(ctx)
+
+		if labelsErr != nil {
+			return drift, fmt.Errorf("failed to get labels of container %s: %w", w.Name, labelsErr)
+		}
+
+		if labels[WorkloadLabel] != w.Name {
+			return drift, fmt.Errorf("container %s isn't managed by a workload: %w", w.Name, errdefs.ErrAlreadyExists)
+		}
+
+		if labels[WorkloadHashLabel] != hash {
+			drift = append(drift, "container outdated")
+
+			if err = r.node.removeContainer(ctx, w.Name); err != nil {
+				return drift, err
+			}
+
+			container = nil
+		} else if labels[WorkloadStateLabel] != w.State {
+
+			if err = container.SetLabels(ctx, map[string]string{WorkloadStateLabel: w.State}); err != nil {
+				return drift, fmt.Errorf("failed to set state of container %s: %w", w.Name, err)
+			}
+		}
+	}
+
+	if container == nil {
+		spec := w.Spec
+		spec.Labels = map[string]string{WorkloadLabel: w.Name, WorkloadHashLabel: hash, WorkloadStateLabel: w.State}
+
+		for k, v := range w.Spec.Labels {
+			spec.Labels[k] = v
+		}
+
+		if _, err = n.CreateContainer(ctx, w.Image, w.Name, spec); err != nil {
+			return drift, err
+		}
+
+		if container, err = n.Backend.LoadContainer(ctx, w.Name); err != nil {
+			return drift, fmt.Errorf("failed to load container %s: %w", w.Name, err)
+		}
+	}
+
+	task, err := container.LoadTask(ctx)
+
+	if err != nil && !errors.Is(err, errdefs.ErrNotFound) {
+		return drift, fmt.Errorf("failed to load task for container %s: %w", w.Name, err)
+	}
+
+	if w.State == WorkloadStopped {
+
+		if task == nil {
+			return drift, nil
+		}
+
+		status, statusErr := task.Status(ctx, nil)
+
+		if statusErr != nil {
+			return drift, fmt.Errorf("failed to get status of task for container %s: %w", w.Name, statusErr)
+		} else if status.Status == containerd.Stopped || status.Status == containerd.Created {
+			return drift, nil
+		}
+
+		drift = append(drift, "task running")
+
+		if err = n.KillTask(ctx, w.Name, "", 0); err != nil {
+			return drift, err
+		}
+
+		_, err = n.DeleteTask(ctx, w.Name)
+
+		return drift, err
+	}
+
+	if task == nil {
+		drift = append(drift, "task missing")
+		_, err = n.CreateTask(ctx, w.Name)
+
+		return drift, err
+	}
+
+	status, err := task.Status(ctx, nil)
+
+	if err != nil {
+		return drift, fmt.Errorf("failed to get status of task for container %s: %w", w.Name, err)
+	}
+
+	switch status.Status {
+	case containerd.Running:
+	case containerd.Paused:
+		drift = append(drift, "task paused")
+		_, err = n.ResumeTask(ctx, w.Name)
+	case containerd.Stopped:
+
+		if w.Spec.RestartPolicy != "" {
+			return drift, nil
+		}
+
+		drift = append(drift, "task exited")
+
+		if _, err = n.DeleteTask(ctx, w.Name); err == nil {
+			_, err = n.CreateTask(ctx, w.Name)
+		}
+	default:
+		drift = append(drift, "task "+string(status.Status))
+		err = fmt.Errorf("task for container %s is %s: %w", w.Name, status.Status, errdefs.ErrFailedPrecondition)
+	}
+
+	return drift, err
+}
+
+// get returns the status of the given applied workload.
+func (r *reconciler) get(ctx context.Context, name string) (WorkloadStatus, error) {
+	ns, err := namespaces.NamespaceRequired(ctx)
+
+	if err != nil {
+		return WorkloadStatus{}, err
+	}
+
+	r.mu.Lock()
+	record, exists := r.workloads[ns][name]
+	r.mu.Unlock()
+
+	if !exists {
+		return WorkloadStatus{}, fmt.Errorf("workload %q: %w", name, errdefs.ErrNotFound)
+	}
+
+	return r.status(record), nil
+}
+
+// list returns the statuses of the workloads applied in ctx's namespace, by name.
+func (r *reconciler) list(ctx context.Context) ([]WorkloadStatus, error) {
+	ns, err := namespaces.NamespaceRequired(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var records []*workloadRecord
+
+	r.mu.Lock()
+
+	for _, record := range r.workloads[ns] {
+		records = append(records, record)
+	}
+
+	r.mu.Unlock()
+
+	statuses := make([]WorkloadStatus, 0, len(records))
+
+	for _, record := range records {
+		statuses = append(statuses, r.status(record))
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Workload.Name < statuses[j].Workload.Name })
+
+	return statuses, nil
+}
+
+// delete forgets the given workload and removes its container and task.
+func (r *reconciler) delete(ctx context.Context, name string) error {
+	ns, err := namespaces.NamespaceRequired(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	unlock := r.lock(ns, name)
+	defer unlock()
+
+	r.mu.Lock()
+	_, exists := r.workloads[ns][name]
+	delete(r.workloads[ns], name)
+	r.mu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("workload %q: %w", name, errdefs.ErrNotFound)
+	}
+
+	if err = r.node.removeContainer(ctx, name); err != nil && !errors.Is(err, errdefs.ErrNotFound) {
+		return err
+	}
+
+	return nil
+}